@@ -4,9 +4,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/brianbroderick/lantern/internal/postgresql/logs"
 	"github.com/brianbroderick/lantern/pkg/repo"
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
+	"github.com/brianbroderick/lantern/pkg/sql/normalize"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
 )
 
 func main() {
@@ -37,14 +41,37 @@ func main() {
 			os.Exit(0)
 		}
 
+		rules, err := normalize.Parse(*strArgs["normalize"])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		opts := logs.Options{Normalize: rules, JumbleQueryIDs: *boolArgs["jumble"]}
+
+		if *strArgs["catalog"] != "" {
+			opts.Catalog, err = catalog.LoadFiles(strings.Split(*strArgs["catalog"], ","), token.Postgres)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+
+		if *strArgs["searchPaths"] != "" {
+			opts.SearchPaths, err = repo.LoadSearchPathConfig(*strArgs["searchPaths"])
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+
 		if boolArgs["rebuildJson"] != nil && *boolArgs["rebuildJson"] {
 			log := logs.LoadLogFile(*strArgs["file"])
-			logs.AggregateLogs(*strArgs["file"], log, "queries.json", "databases.json")
+			logs.AggregateLogs(*strArgs["file"], log, "queries.json", "databases.json", opts)
 		}
 
 		logs.UpsertQueries()
 		logs.UpsertDatabases()
-		logs.ExtractAndUpsertQueryMetadata()
+		logs.ExtractAndUpsertQueryMetadata(opts)
 
 		// Log that this file has been processed
 		pf.Processed(db)
@@ -63,7 +90,11 @@ func processCli(args []string) (map[string]*string, map[string]*int, map[string]
 	boolArgs := make(map[string]*bool)
 
 	boolArgs["rebuildJson"] = processCmd.Bool("rebuild_json", true, "Rebuild the json files from the logs")
+	boolArgs["jumble"] = processCmd.Bool("jumble", false, "Compute a query_id for queries logged without %Q in log_line_prefix")
 	strArgs["file"] = processCmd.String("file", "", "File to be processed")
+	strArgs["catalog"] = processCmd.String("catalog", "", "Comma separated .sql files of DDL or .json dumps of information_schema.columns, to resolve columns to tables")
+	strArgs["searchPaths"] = processCmd.String("search_paths", "", "JSON file of the default search_path by database and role, to resolve unqualified tables to schemas")
+	strArgs["normalize"] = processCmd.String("normalize", "", "Normalization rules applied before fingerprinting: lists, aliases, identifiers, commutative, or all")
 
 	processCmd.Parse(args[2:])
 
@@ -78,6 +109,10 @@ func printHelp() {
 	lantern-logs process          - Process a log file
 		--rebuild_json=false        - Rebuild the json files from the logs
 		--file=                     - File to be processed
+		--normalize=                - Normalization rules: lists,aliases,identifiers,commutative or all
+		--jumble=true               - Compute a query_id for queries logged without one
+		--catalog=                  - Comma separated .sql DDL files or .json dumps of information_schema.columns
+		--search_paths=             - JSON file of the default search_path by database and role
 	`
 
 	fmt.Println(helpText)
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/diff"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// diffCmd prints what changed between the queries in two SQL files. Like diff, it exits with 0 when
+// they're equivalent, 1 when they aren't, and 2 when a file can't be read or parsed.
+func diffCmd(args []string) int {
+	diffFlags := flag.NewFlagSet("diff", flag.ExitOnError)
+	quiet := diffFlags.Bool("q", false, "Only report whether the files are equivalent")
+	dialectName := diffFlags.String("dialect", "postgres", "SQL dialect: postgres, mysql, or sqlite")
+
+	diffFlags.Parse(args)
+
+	dialect, err := token.ParseDialect(*dialectName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if diffFlags.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: lantern diff [-q] [-dialect=postgres] a.sql b.sql")
+		return 2
+	}
+
+	a, ok := parseFile(diffFlags.Arg(0), dialect)
+	if !ok {
+		return 2
+	}
+	b, ok := parseFile(diffFlags.Arg(1), dialect)
+	if !ok {
+		return 2
+	}
+
+	changes := diff.Programs(a, b)
+	if len(changes) == 0 {
+		return 0
+	}
+
+	if *quiet {
+		fmt.Printf("%s and %s differ\n", diffFlags.Arg(0), diffFlags.Arg(1))
+		return 1
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return 1
+}
+
+// parseFile parses a SQL file, printing any errors
+func parseFile(name string, dialect token.Dialect) (*ast.Program, bool) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, false
+	}
+
+	p := parser.New(lexer.New(string(src), dialect))
+	program := p.ParseProgram()
+
+	for _, e := range p.ParseErrors() {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", name, e.Pos.Line, e.Pos.Char, e.Msg)
+	}
+	return program, len(p.ParseErrors()) == 0
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brianbroderick/lantern/pkg/sql/format"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// fmtCmd formats SQL files and returns the exit code
+func fmtCmd(args []string) int {
+	fmtFlags := flag.NewFlagSet("fmt", flag.ExitOnError)
+
+	write := fmtFlags.Bool("w", false, "Write the result to the file instead of stdout")
+	check := fmtFlags.Bool("check", false, "List the files that aren't formatted and exit with 1 if there are any")
+	keywordCase := fmtFlags.String("keyword-case", "upper", "Keyword case: upper or lower")
+	indent := fmtFlags.Int("indent", 2, "Spaces per level of indentation")
+	comma := fmtFlags.String("comma", "trailing", "Comma style: trailing or leading")
+	width := fmtFlags.Int("width", 80, "Line width")
+	dialect := fmtFlags.String("dialect", "postgres", "SQL dialect: postgres, mysql, or sqlite")
+
+	fmtFlags.Parse(args)
+
+	opts := format.DefaultOptions()
+	opts.Indent = *indent
+	opts.LineWidth = *width
+
+	var err error
+	if opts.Dialect, err = token.ParseDialect(*dialect); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	switch *keywordCase {
+	case "upper":
+		opts.KeywordCase = format.KeywordUpper
+	case "lower":
+		opts.KeywordCase = format.KeywordLower
+	default:
+		fmt.Fprintf(os.Stderr, "unknown keyword case %q, expected upper or lower\n", *keywordCase)
+		return 2
+	}
+
+	switch *comma {
+	case "trailing":
+		opts.CommaStyle = format.CommaTrailing
+	case "leading":
+		opts.CommaStyle = format.CommaLeading
+	default:
+		fmt.Fprintf(os.Stderr, "unknown comma style %q, expected trailing or leading\n", *comma)
+		return 2
+	}
+
+	files := fmtFlags.Args()
+	if len(files) == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		formatted, err := format.Source(string(src), opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "<stdin>: %s\n", err)
+			return 2
+		}
+
+		if *check {
+			if formatted != string(src) {
+				fmt.Println("<stdin>")
+				return 1
+			}
+			return 0
+		}
+
+		fmt.Print(formatted)
+		return 0
+	}
+
+	code := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 2
+			continue
+		}
+
+		formatted, err := format.Source(string(src), opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			code = 2
+			continue
+		}
+
+		switch {
+		case *check:
+			if formatted != string(src) {
+				fmt.Println(file)
+				if code == 0 {
+					code = 1
+				}
+			}
+		case *write:
+			if formatted != string(src) {
+				if err := os.WriteFile(file, []byte(formatted), 0644); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					code = 2
+				}
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+
+	return code
+}
@@ -9,6 +9,23 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(fmtCmd(os.Args[2:]))
+		case "parse":
+			os.Exit(parseCmd(os.Args[2:]))
+		case "diff":
+			os.Exit(diffCmd(os.Args[2:]))
+		case "help":
+			printHelp()
+			os.Exit(0)
+		default:
+			printHelp()
+			os.Exit(1)
+		}
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -20,3 +37,29 @@ func main() {
 	fmt.Println("")
 	repl.StartParser(os.Stdin, os.Stdout)
 }
+
+func printHelp() {
+	helpText := `
+  Usage: lantern [command] [arguments]
+
+  lantern                       - Start the REPL
+  lantern help                  - Print this help message
+  lantern fmt [files]           - Format SQL files, or stdin when there are no files
+    -w                          - Write the result to the file instead of stdout
+    -check                      - List the files that aren't formatted and exit with 1 if there are any
+    -keyword-case=upper         - upper or lower
+    -indent=2                   - Spaces per level of indentation
+    -comma=trailing             - trailing or leading
+    -width=80                   - Line width
+    -dialect=postgres           - postgres, mysql, or sqlite
+  lantern parse [files]         - Parse SQL files, or stdin when there are no files, and print each statement
+    -json                       - Print the AST as JSON instead
+    -mask                       - Replace values with ? in the statements
+    -dialect=postgres           - postgres, mysql, or sqlite
+  lantern diff a.sql b.sql      - Print the tables, columns, predicates, and clauses that changed between two queries
+    -q                          - Only report whether they're equivalent
+    -dialect=postgres           - postgres, mysql, or sqlite
+	`
+
+	fmt.Println(helpText)
+}
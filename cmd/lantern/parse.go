@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// parseCmd parses SQL files and prints their statements, or their AST as JSON, and returns the exit code
+func parseCmd(args []string) int {
+	parseFlags := flag.NewFlagSet("parse", flag.ExitOnError)
+
+	asJSON := parseFlags.Bool("json", false, "Print the AST as JSON")
+	mask := parseFlags.Bool("mask", false, "Replace values with ? when printing statements")
+	dialectName := parseFlags.String("dialect", "postgres", "SQL dialect: postgres, mysql, or sqlite")
+
+	parseFlags.Parse(args)
+
+	dialect, err := token.ParseDialect(*dialectName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	files := parseFlags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	code := 0
+	for _, file := range files {
+		var (
+			src  []byte
+			err  error
+			name = file
+		)
+		if file == "-" {
+			src, err = io.ReadAll(os.Stdin)
+			name = "<stdin>"
+		} else {
+			src, err = os.ReadFile(file)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 2
+			continue
+		}
+
+		l := lexer.New(string(src), dialect)
+		p := parser.New(l)
+		program := p.ParseProgram()
+
+		for _, e := range p.ParseErrors() {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", name, e.Pos.Line, e.Pos.Char, e.Msg)
+			code = 1
+		}
+
+		if *asJSON {
+			data, err := ast.MarshalIndent(program, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+				code = 2
+				continue
+			}
+			fmt.Println(string(data))
+			continue
+		}
+
+		for _, stmt := range program.Statements {
+			fmt.Println(stmt.String(*mask))
+		}
+	}
+
+	return code
+}
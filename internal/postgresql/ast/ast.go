@@ -58,6 +58,7 @@ type LogStatement struct {
 	User            string
 	Database        string
 	Pid             int
+	QueryID         int64 // Postgres' query_id from %Q in log_line_prefix, or 0 when it isn't logged
 	Severity        string
 	DurationLit     string
 	DurationMeasure string
@@ -74,8 +75,12 @@ func (ls *LogStatement) String() string {
 	var out bytes.Buffer
 
 	// Prefix
-	out.WriteString(fmt.Sprintf("%s %s %s:%s(%d):%s@%s:[%d]:%s:",
-		ls.Date, ls.Time, ls.Timezone, ls.RemoteHost, ls.RemotePort, ls.User, ls.Database, ls.Pid, ls.Severity))
+	out.WriteString(fmt.Sprintf("%s %s %s:%s(%d):%s@%s:[%d]:",
+		ls.Date, ls.Time, ls.Timezone, ls.RemoteHost, ls.RemotePort, ls.User, ls.Database, ls.Pid))
+	if ls.QueryID != 0 {
+		out.WriteString(fmt.Sprintf("%d:", ls.QueryID))
+	}
+	out.WriteString(fmt.Sprintf("%s:", ls.Severity))
 
 	// Duration
 	if ls.DurationLit != "" {
@@ -13,7 +13,9 @@ import (
 	"github.com/brianbroderick/lantern/internal/postgresql/parser"
 	"github.com/brianbroderick/lantern/internal/postgresql/projectpath"
 	"github.com/brianbroderick/lantern/pkg/repo"
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
 	"github.com/brianbroderick/lantern/pkg/sql/logit"
+	"github.com/brianbroderick/lantern/pkg/sql/normalize"
 )
 
 func LoadLogFile(f string) string {
@@ -26,11 +28,23 @@ func LoadLogFile(f string) string {
 	return string(file)
 }
 
-func AggregateLogs(fileName, log, queriesFile, databasesFile string) (*repo.Databases, *repo.Queries) {
+// Options change how the queries in a log are analyzed
+type Options struct {
+	Normalize      normalize.Options      // the normalization rules applied before a query is fingerprinted
+	JumbleQueryIDs bool                   // compute a query_id for the queries logged without one
+	Catalog        *catalog.Catalog       // the tables and columns that unqualified columns are resolved to, if they're known
+	SearchPaths    *repo.SearchPathConfig // the search_path sessions start with, by database and role
+}
+
+// AggregateLogs analyzes each query in the log and caches the results in the queries and databases files
+func AggregateLogs(fileName, log, queriesFile, databasesFile string, opts Options) (*repo.Databases, *repo.Queries) {
 	logit.Clear("queries-process-error")
 
 	databases := repo.NewDatabases(fileName)
 	statements := repo.NewQueries(fileName)
+	statements.Normalize = opts.Normalize
+	statements.JumbleQueryIDs = opts.JumbleQueryIDs
+	statements.SearchPaths = opts.SearchPaths
 
 	l := lexer.New(log)
 	p := parser.New(l)
@@ -62,6 +76,8 @@ loop:
 			Database:        query.Database,
 			Input:           query.Query,
 			UserName:        query.User,
+			Session:         strconv.Itoa(query.Pid),
+			QueryID:         query.QueryID,
 			DurationUs:      convertTime(query.DurationLit, query.DurationMeasure),
 			MustExtract:     false, // We're passing in false into mustExtract because that'll happen at a later step
 		}
@@ -31,7 +31,8 @@ func UpsertDatabases() {
 	databases.Upsert(db)
 }
 
-func ExtractAndUpsertQueryMetadata() {
+// ExtractAndUpsertQueryMetadata extracts the tables and columns of the cached queries. Only the catalog of the options is used.
+func ExtractAndUpsertQueryMetadata(opts Options) {
 	data, err := os.ReadFile(filepath.Join(projectpath.Root, "processed", "queries.json"))
 	if HasErr("Error reading file", err) {
 		return
@@ -43,6 +44,7 @@ func ExtractAndUpsertQueryMetadata() {
 	jsonQueries := repo.Queries{}
 	repo.UnmarshalJSON(data, &jsonQueries)
 	queries.Queries = jsonQueries.Queries
+	queries.Catalog = opts.Catalog
 
 	if len(queries.Queries) == 0 {
 		return
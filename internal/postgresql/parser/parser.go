@@ -120,6 +120,9 @@ func (p *Parser) parseStatement() ast.Statement {
 		if prevStmt, ok := p.incompleteStatement[key]; ok {
 			prevStmt.DurationLit = stmt.DurationLit
 			prevStmt.DurationMeasure = stmt.DurationMeasure
+			if prevStmt.QueryID == 0 {
+				prevStmt.QueryID = stmt.QueryID
+			}
 
 			p.incompleteStatement[key] = nil
 			delete(p.incompleteStatement, key)
@@ -230,6 +233,16 @@ loop:
 		p.nextToken()
 	}
 
+	// Query ID, when log_line_prefix has %Q after the pid, i.e. [%p]:%Q: or [%p]:query_id=%Q:
+	if id, ok := p.parseQueryID(); ok {
+		s.QueryID = id
+		p.nextToken()
+
+		if p.curTokenIs(token.COLON) {
+			p.nextToken()
+		}
+	}
+
 	// Severity
 	if p.curTokenIs(token.IDENT) {
 		s.Severity = p.curToken.Lit
@@ -338,6 +351,26 @@ loop:
 	return s, nil
 }
 
+// parseQueryID reads a query id from the prefix. The lexer reads a positive id along with the colon after it,
+// as it would for a time, while a negative one, or one written as query_id=, is read as an IDENT followed by a colon.
+func (p *Parser) parseQueryID() (int64, bool) {
+	lit := p.curToken.Lit
+	switch {
+	case p.curTokenIs(token.INT) && strings.HasSuffix(lit, ":"):
+		lit = strings.TrimSuffix(lit, ":")
+	case (p.curTokenIs(token.INT) || p.curTokenIs(token.IDENT)) && p.peekTokenIs(token.COLON):
+		lit = strings.TrimPrefix(lit, "query_id=")
+	default:
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(lit, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
 func (p *Parser) parseErr(iter int, expected token.TokenType, tok token.Token) error {
 	return fmt.Errorf("line %d char %d: %d: expected %s, got %s. Lit: %s", p.l.Pos.Line, p.l.Pos.Char, iter, expected, tok.Type, tok.Lit)
 }
@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/brianbroderick/lantern/internal/postgresql/ast"
 	"github.com/brianbroderick/lantern/internal/postgresql/lexer"
 	"github.com/stretchr/testify/assert"
 )
@@ -151,6 +153,12 @@ func TestParserStatements(t *testing.T) {
 			result:        `2024-07-10 17:48:42 UTC:10.1.1.1(38502):postgres@lantern:[34633]:LOG:  duration: 0.398 ms  statement: SET STATEMENT_TIMEOUT = '360s'; /*{"id":15514,"team_name":"my_team"}*/DROP TABLE IF EXISTS my_tmp_tbl;`,
 			lenStatements: 1,
 		},
+		// With %Q in log_line_prefix
+		{
+			str:           "2024-07-10 17:48:11 UTC:10.1.1.1(48684):pp@mydb:[40113]:-3962393542931145239:LOG:  duration: 1.410 ms  statement: select * from users where id = 1",
+			result:        "2024-07-10 17:48:11 UTC:10.1.1.1(48684):pp@mydb:[40113]:-3962393542931145239:LOG:  duration: 1.410 ms  statement: select * from users where id = 1",
+			lenStatements: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -164,6 +172,35 @@ func TestParserStatements(t *testing.T) {
 
 }
 
+func TestParserQueryID(t *testing.T) {
+	var tests = []struct {
+		str     string
+		queryID int64
+	}{
+		{"2024-07-10 17:48:11 UTC:10.1.1.1(48684):pp@mydb:[40113]:LOG:  duration: 1.410 ms  statement: select 1", 0},
+		{"2024-07-10 17:48:11 UTC:10.1.1.1(48684):pp@mydb:[40113]:1147616880456321454:LOG:  duration: 1.410 ms  statement: select 1", 1147616880456321454},
+		{"2024-07-10 17:48:11 UTC:10.1.1.1(48684):pp@mydb:[40113]:-3962393542931145239:LOG:  duration: 1.410 ms  statement: select 1", -3962393542931145239},
+		{"2024-07-10 17:48:11 UTC:10.1.1.1(48684):pp@mydb:[40113]:query_id=42:LOG:  duration: 1.410 ms  statement: select 1", 42},
+		// The statement and its duration are logged separately
+		{`2024-07-10 17:48:11 UTC:10.1.1.1(48684):pp@mydb:[40113]:42:LOG:  statement: select 1
+		2024-07-10 17:48:11 UTC:10.1.1.1(48684):pp@mydb:[40113]:42:LOG:  duration: 1.410 ms`, 42},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.str)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if assert.Equal(t, 1, len(program.Statements), tt.str) {
+			stmt := program.Statements[0].(*ast.LogStatement)
+			assert.Equal(t, tt.queryID, stmt.QueryID, tt.str)
+			assert.Equal(t, "LOG", stmt.Severity, tt.str)
+			assert.Equal(t, "select 1", strings.TrimSpace(stmt.Query), tt.str)
+			assert.Equal(t, "1.410", stmt.DurationLit, tt.str)
+		}
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
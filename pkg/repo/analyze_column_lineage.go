@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+)
+
+// addColumnLineage adds the columns that a query writes, along with the columns they're computed from
+func (q *Queries) addColumnLineage(qu *Query, ext *extractor.Extractor) {
+	for _, l := range ext.ColumnLineage {
+		uid := UuidV5(fmt.Sprintf("%s|%s.%s.%s|%s.%s.%s", qu.UID, l.TargetSchema, l.TargetTable, l.TargetColumn, l.SourceSchema, l.SourceTable, l.SourceColumn))
+		uidStr := uid.String()
+		if _, ok := q.ColumnLineage[uidStr]; !ok {
+			q.ColumnLineage[uidStr] = &extractor.ColumnLineage{
+				UID:          uid,
+				QueryUID:     qu.UID,
+				Command:      l.Command,
+				TargetSchema: l.TargetSchema,
+				TargetTable:  l.TargetTable,
+				TargetColumn: l.TargetColumn,
+				SourceSchema: l.SourceSchema,
+				SourceTable:  l.SourceTable,
+				SourceColumn: l.SourceColumn,
+				Expression:   l.Expression,
+				Functions:    l.Functions,
+			}
+		}
+	}
+}
+
+func (q *Queries) UpsertColumnLineage() {
+	if len(q.ColumnLineage) == 0 {
+		return
+	}
+
+	rows := q.insValuesColumnLineage()
+	query := fmt.Sprintf(q.insColumnLineage(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+func (q *Queries) insColumnLineage() string {
+	return `INSERT INTO column_lineage (uid, query_uid, command, target_schema, target_table, target_column, source_schema, source_table, source_column, expression, functions)
+	VALUES %s 
+	ON CONFLICT (uid) DO UPDATE 
+	SET command = EXCLUDED.command, expression = EXCLUDED.expression, functions = EXCLUDED.functions;`
+}
+
+func (q *Queries) insValuesColumnLineage() []string {
+	var rows []string
+
+	for uid, l := range q.ColumnLineage {
+		expression := strings.ReplaceAll(l.Expression, "'", "''")
+		functions := strings.ReplaceAll(strings.Join(l.Functions, ","), "'", "''")
+		rows = append(rows,
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s')",
+				uid, l.QueryUID, l.Command, l.TargetSchema, l.TargetTable, l.TargetColumn, l.SourceSchema, l.SourceTable, l.SourceColumn, expression, functions))
+	}
+	return rows
+}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+)
+
+// addColumnTypes adds the evidence of the columns' types in a query. The same column in different queries
+// is one column, so its evidence is added together. Columns that couldn't be resolved to a table are skipped.
+func (q *Queries) addColumnTypes(qu *Query, ext *extractor.Extractor) {
+	for _, t := range ext.ColumnTypes {
+		if t.Table == "UNKNOWN" {
+			continue
+		}
+		uidStr := t.UID.String()
+		if existing, ok := q.ColumnTypes[uidStr]; ok {
+			existing.Add(t)
+			continue
+		}
+		c := &extractor.ColumnTypes{
+			UID:      t.UID,
+			TableUID: t.TableUID,
+			Schema:   t.Schema,
+			Table:    t.Table,
+			Column:   t.Column,
+		}
+		c.Add(t)
+		q.ColumnTypes[uidStr] = c
+	}
+}
+
+func (q *Queries) UpsertColumnTypes() {
+	if len(q.ColumnTypes) == 0 {
+		return
+	}
+
+	rows := q.insValuesColumnTypes()
+	query := fmt.Sprintf(q.insColumnTypes(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+// The type with the most confidence is kept, and a column is inconsistent once it's been used as more than one kind of type
+func (q *Queries) insColumnTypes() string {
+	return `INSERT INTO columns (uid, table_uid, schema_name, table_name, column_name, inferred_type, type_confidence, used_as, inconsistent)
+	VALUES %s
+	ON CONFLICT (uid) DO UPDATE 
+	SET inferred_type = CASE WHEN EXCLUDED.type_confidence >= COALESCE(columns.type_confidence, 0) THEN EXCLUDED.inferred_type ELSE columns.inferred_type END,
+		type_confidence = GREATEST(EXCLUDED.type_confidence, columns.type_confidence),
+		used_as = ARRAY(SELECT DISTINCT unnest(columns.used_as || EXCLUDED.used_as) ORDER BY 1),
+		inconsistent = cardinality(ARRAY(SELECT DISTINCT unnest(columns.used_as || EXCLUDED.used_as))) > 1;`
+}
+
+func (q *Queries) insValuesColumnTypes() []string {
+	var rows []string
+
+	for uid, c := range q.ColumnTypes {
+		rows = append(rows,
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', %g, '{%s}', %t)",
+				uid, c.TableUID, c.Schema, c.Table, c.Column, c.Type, c.Confidence, strings.Join(c.UsedAs, ","), c.Inconsistent))
+	}
+	return rows
+}
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// functions are added in addFunctionsInQueries() function
+
+func (q *Queries) UpsertFunctions() {
+	if len(q.Functions) == 0 {
+		return
+	}
+
+	rows := q.insValuesFunctions()
+	query := fmt.Sprintf(q.insFunctions(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+// A user-defined function called with OVER or FILTER is known to be a window function or an aggregate,
+// so the kind is only updated from scalar.
+func (q *Queries) insFunctions() string {
+	return `INSERT INTO functions (uid, schema_name, function_name, argument_count, kind, volatility, builtin)
+	VALUES %s
+	ON CONFLICT (uid) DO UPDATE 
+	SET kind = CASE WHEN functions.kind = 'scalar' THEN EXCLUDED.kind ELSE functions.kind END,
+		volatility = EXCLUDED.volatility, builtin = EXCLUDED.builtin;`
+}
+
+func (q *Queries) insValuesFunctions() []string {
+	var rows []string
+
+	for uid, function := range q.Functions {
+		name := strings.ReplaceAll(function.Name, "'", "''")
+		rows = append(rows,
+			fmt.Sprintf("('%s', '%s', '%s', %d, '%s', '%s', %t)",
+				uid, function.Schema, name, function.Arguments, function.Kind, function.Volatility, function.Builtin))
+	}
+	return rows
+}
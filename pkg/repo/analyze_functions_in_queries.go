@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+)
+
+// addFunctionsInQueries adds the functions a query calls to the Queries struct
+func (q *Queries) addFunctionsInQueries(qu *Query, ext *extractor.Extractor) {
+	for _, function := range ext.FunctionsInQueries {
+		uid := UuidV5(fmt.Sprintf("%s|%s", qu.UID, function.FunctionUID))
+		uidStr := uid.String()
+		if _, ok := q.FunctionsInQueries[uidStr]; !ok {
+			q.FunctionsInQueries[uidStr] = &extractor.FunctionsInQueries{
+				UID:         uid,
+				FunctionUID: function.FunctionUID,
+				QueryUID:    qu.UID,
+				Schema:      function.Schema,
+				Name:        function.Name,
+				Arguments:   function.Arguments,
+				Kind:        function.Kind,
+				Volatility:  function.Volatility,
+				Builtin:     function.Builtin,
+				Windowed:    function.Windowed,
+				Calls:       function.Calls,
+			}
+		}
+
+		functionUIDStr := function.FunctionUID.String()
+		if _, ok := q.Functions[functionUIDStr]; !ok {
+			q.Functions[functionUIDStr] = &extractor.Functions{
+				UID:        function.FunctionUID,
+				Schema:     function.Schema,
+				Name:       function.Name,
+				Arguments:  function.Arguments,
+				Kind:       function.Kind,
+				Volatility: function.Volatility,
+				Builtin:    function.Builtin,
+			}
+		}
+	}
+}
+
+func (q *Queries) UpsertFunctionsInQueries() {
+	if len(q.FunctionsInQueries) == 0 {
+		return
+	}
+
+	rows := q.insValuesFunctionsInQueries()
+	query := fmt.Sprintf(q.insFunctionsInQueries(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+func (q *Queries) insFunctionsInQueries() string {
+	return `INSERT INTO functions_in_queries (uid, function_uid, query_uid, schema_name, function_name, argument_count, kind, windowed, calls)
+	VALUES %s 
+	ON CONFLICT (uid) DO UPDATE 
+	SET kind = EXCLUDED.kind, windowed = EXCLUDED.windowed, calls = EXCLUDED.calls;`
+}
+
+func (q *Queries) insValuesFunctionsInQueries() []string {
+	var rows []string
+
+	for uid, function := range q.FunctionsInQueries {
+		name := strings.ReplaceAll(function.Name, "'", "''")
+		rows = append(rows,
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', %d, '%s', %t, %d)",
+				uid, function.FunctionUID, function.QueryUID, function.Schema, name, function.Arguments, function.Kind, function.Windowed, function.Calls))
+	}
+	return rows
+}
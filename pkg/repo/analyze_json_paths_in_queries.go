@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+)
+
+// addJSONPathsInQueries adds the keys of JSON columns that a query uses
+func (q *Queries) addJSONPathsInQueries(qu *Query, ext *extractor.Extractor) {
+	for _, p := range ext.JSONPathsInQueries {
+		uid := UuidV5(fmt.Sprintf("%s|%s|%s.%s.%s|%s|%s", qu.UID, p.Clause.String(), p.Schema, p.Table, p.Column, p.Path, p.Operator))
+		uidStr := uid.String()
+		if _, ok := q.JSONPathsInQueries[uidStr]; !ok {
+			q.JSONPathsInQueries[uidStr] = &extractor.JSONPathsInQueries{
+				UID:      uid,
+				QueryUID: qu.UID,
+				Schema:   p.Schema,
+				Table:    p.Table,
+				Column:   p.Column,
+				Path:     p.Path,
+				Operator: p.Operator,
+				Clause:   p.Clause,
+				Count:    p.Count,
+			}
+		}
+	}
+}
+
+func (q *Queries) UpsertJSONPathsInQueries() {
+	if len(q.JSONPathsInQueries) == 0 {
+		return
+	}
+
+	rows := q.insValuesJSONPathsInQueries()
+	query := fmt.Sprintf(q.insJSONPathsInQueries(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+func (q *Queries) insJSONPathsInQueries() string {
+	return `INSERT INTO json_paths_in_queries (uid, query_uid, schema_name, table_name, column_name, path, operator, clause, count)
+	VALUES %s 
+	ON CONFLICT (uid) DO UPDATE 
+	SET count = EXCLUDED.count;`
+}
+
+func (q *Queries) insValuesJSONPathsInQueries() []string {
+	var rows []string
+
+	for uid, p := range q.JSONPathsInQueries {
+		path := strings.ReplaceAll(p.Path, "'", "''")
+		rows = append(rows,
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', %d)",
+				uid, p.QueryUID, p.Schema, p.Table, p.Column, path, p.Operator, p.Clause, p.Count))
+	}
+	return rows
+}
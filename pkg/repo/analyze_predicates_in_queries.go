@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+)
+
+// addPredicatesInQueries adds how a query filters its columns
+func (q *Queries) addPredicatesInQueries(qu *Query, ext *extractor.Extractor) {
+	for _, p := range ext.PredicatesInQueries {
+		uid := UuidV5(fmt.Sprintf("%s|%s|%s.%s.%s|%s|%s|%s|%s|%d", qu.UID, p.Clause.String(), p.Schema, p.Table, p.Column, p.Expression, p.Operator, p.ValueKind, p.Class, p.InListSize))
+		uidStr := uid.String()
+		if _, ok := q.PredicatesInQueries[uidStr]; !ok {
+			q.PredicatesInQueries[uidStr] = &extractor.PredicatesInQueries{
+				UID:        uid,
+				QueryUID:   qu.UID,
+				Schema:     p.Schema,
+				Table:      p.Table,
+				Column:     p.Column,
+				Clause:     p.Clause,
+				Operator:   p.Operator,
+				ValueKind:  p.ValueKind,
+				Class:      p.Class,
+				Function:   p.Function,
+				Expression: p.Expression,
+				InListSize: p.InListSize,
+			}
+		}
+	}
+}
+
+func (q *Queries) UpsertPredicatesInQueries() {
+	if len(q.PredicatesInQueries) == 0 {
+		return
+	}
+
+	rows := q.insValuesPredicatesInQueries()
+	query := fmt.Sprintf(q.insPredicatesInQueries(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+func (q *Queries) insPredicatesInQueries() string {
+	return `INSERT INTO predicates_in_queries (uid, query_uid, schema_name, table_name, column_name, clause, operator, value_kind, selectivity_class, function_name, expression, in_list_size)
+	VALUES %s 
+	ON CONFLICT (uid) DO UPDATE 
+	SET function_name = EXCLUDED.function_name, expression = EXCLUDED.expression;`
+}
+
+func (q *Queries) insValuesPredicatesInQueries() []string {
+	var rows []string
+
+	for uid, p := range q.PredicatesInQueries {
+		function := strings.ReplaceAll(p.Function, "'", "''")
+		expression := strings.ReplaceAll(p.Expression, "'", "''")
+		rows = append(rows,
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', %d)",
+				uid, p.QueryUID, p.Schema, p.Table, p.Column, p.Clause, p.Operator, p.ValueKind, p.Class, function, expression, p.InListSize))
+	}
+	return rows
+}
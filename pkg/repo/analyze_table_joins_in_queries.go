@@ -11,7 +11,7 @@ import (
 func (q *Queries) addTableJoinsInQueries(qu *Query, ext *extractor.Extractor) {
 
 	for _, table := range ext.TableJoinsInQueries {
-		uid := UuidV5(fmt.Sprintf("%s|%s|%s|%s", qu.UID, table.TableUIDa, table.TableUIDb, table.OnCondition))
+		uid := UuidV5(fmt.Sprintf("%s|%s|%s|%s|%s|%s", qu.UID, table.TableUIDa, table.TableUIDb, table.ColumnA, table.ColumnB, table.OnCondition))
 		uidStr := uid.String()
 		if _, ok := q.TableJoinsInQueries[uidStr]; !ok {
 			q.TableJoinsInQueries[uidStr] = &extractor.TableJoinsInQueries{
@@ -23,8 +23,10 @@ func (q *Queries) addTableJoinsInQueries(qu *Query, ext *extractor.Extractor) {
 				OnCondition:   table.OnCondition,
 				SchemaA:       table.SchemaA,
 				TableA:        table.TableA,
+				ColumnA:       table.ColumnA,
 				SchemaB:       table.SchemaB,
 				TableB:        table.TableB,
+				ColumnB:       table.ColumnB,
 			}
 		}
 	}
@@ -44,20 +46,22 @@ func (q *Queries) UpsertTableJoinsInQueries() {
 }
 
 func (q *Queries) insTableJoinsInQueries() string {
-	return `INSERT INTO table_joins_in_queries (uid, query_uid, table_uid_a, table_uid_b, join_condition, on_condition, schema_a, table_a, schema_b, table_b)
+	return `INSERT INTO table_joins_in_queries (uid, query_uid, table_uid_a, table_uid_b, join_condition, on_condition, schema_a, table_a, column_a, schema_b, table_b, column_b)
 	VALUES %s 
 	ON CONFLICT (uid) DO UPDATE 
 	SET query_uid = EXCLUDED.query_uid, table_uid_a = EXCLUDED.table_uid_a, table_uid_b = EXCLUDED.table_uid_b, 
-	  join_condition = EXCLUDED.join_condition, schema_a = EXCLUDED.schema_a, table_a = EXCLUDED.table_a, schema_b = EXCLUDED.schema_b, table_b = EXCLUDED.table_b;`
+	  join_condition = EXCLUDED.join_condition, schema_a = EXCLUDED.schema_a, table_a = EXCLUDED.table_a, column_a = EXCLUDED.column_a,
+	  schema_b = EXCLUDED.schema_b, table_b = EXCLUDED.table_b, column_b = EXCLUDED.column_b;`
 }
 
 func (q *Queries) insValuesTableJoinsInQueries() []string {
 	var rows []string
 
 	for uid, query := range q.TableJoinsInQueries {
+		onCondition := strings.ReplaceAll(query.OnCondition, "'", "''")
 		rows = append(rows,
-			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s')",
-				uid, query.QueryUID, query.TableUIDa, query.TableUIDb, query.JoinCondition, query.OnCondition, query.SchemaA, query.TableA, query.SchemaB, query.TableB))
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s')",
+				uid, query.QueryUID, query.TableUIDa, query.TableUIDb, query.JoinCondition, onCondition, query.SchemaA, query.TableA, query.ColumnA, query.SchemaB, query.TableB, query.ColumnB))
 	}
 	return rows
 }
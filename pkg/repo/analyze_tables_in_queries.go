@@ -22,6 +22,10 @@ func (q *Queries) addTablesInQueries(qu *Query, ext *extractor.Extractor) {
 				Schema:   table.Schema,
 				Name:     table.Name,
 				Command:  table.Command,
+				Reads:    table.Reads,
+				Writes:   table.Writes,
+				Locks:    table.Locks,
+				LockMode: table.LockMode,
 			}
 		}
 
@@ -50,14 +54,18 @@ func (q *Queries) UpsertTablesInQueries() {
 }
 
 func (q *Queries) insTablesInQueries() string {
-	return `INSERT INTO tables_in_queries (uid, table_uid, query_uid, schema_name, table_name, command) 
+	return `INSERT INTO tables_in_queries (uid, table_uid, query_uid, schema_name, table_name, command, reads, writes, locks, lock_mode) 
 	VALUES %s 
 	ON CONFLICT (uid) DO UPDATE 
 	SET table_uid = EXCLUDED.table_uid, 
 		query_uid = EXCLUDED.query_uid, 
 		schema_name = EXCLUDED.schema_name, 
 		table_name = EXCLUDED.table_name,
-		command = EXCLUDED.command;`
+		command = EXCLUDED.command,
+		reads = EXCLUDED.reads,
+		writes = EXCLUDED.writes,
+		locks = EXCLUDED.locks,
+		lock_mode = EXCLUDED.lock_mode;`
 }
 
 func (q *Queries) insValuesTablesInQueries() []string {
@@ -65,8 +73,8 @@ func (q *Queries) insValuesTablesInQueries() []string {
 
 	for uid, query := range q.TablesInQueries {
 		rows = append(rows,
-			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s')",
-				uid, query.TableUID, query.QueryUID, query.Schema, query.Name, query.Command))
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', %t, %t, %t, '%s')",
+				uid, query.TableUID, query.QueryUID, query.Schema, query.Name, query.Command, query.Reads, query.Writes, query.Locks, query.LockMode))
 	}
 	return rows
 }
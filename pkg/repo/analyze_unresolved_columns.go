@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// catalogTables adds the tables created by CREATE TABLE queries to the catalog, so queries on them are resolved too.
+// It's a no-op without a catalog.
+func (q *Queries) catalogTables() {
+	if q.Catalog == nil {
+		return
+	}
+
+	// The order of the queries isn't known, so the tables are added in the order of their SHAs to always get the same catalog
+	shas := []string{}
+	for sha, query := range q.Queries {
+		if query.Command == token.CREATE {
+			shas = append(shas, sha)
+		}
+	}
+	sort.Strings(shas)
+
+	for _, sha := range shas {
+		p := parser.New(lexer.New(q.Queries[sha].UnmaskedQuery, q.Dialect))
+		program := p.ParseProgram()
+		for _, stmt := range program.Statements {
+			q.Catalog.AddStatement(stmt)
+		}
+	}
+}
+
+// addUnresolvedColumns adds the columns that the catalog couldn't resolve to a table
+func (q *Queries) addUnresolvedColumns(qu *Query, ext *extractor.Extractor) {
+	for _, column := range ext.UnresolvedColumns {
+		uid := UuidV5(fmt.Sprintf("%s|%s|%s.%s.%s", qu.UID, column.Clause.String(), column.Schema, column.Table, column.Name))
+		uidStr := uid.String()
+		if _, ok := q.UnresolvedColumns[uidStr]; !ok {
+			q.UnresolvedColumns[uidStr] = &extractor.UnresolvedColumns{
+				UID:        uid,
+				QueryUID:   qu.UID,
+				Schema:     column.Schema,
+				Table:      column.Table,
+				Name:       column.Name,
+				Clause:     column.Clause,
+				Reason:     column.Reason,
+				Candidates: column.Candidates,
+			}
+		}
+	}
+}
+
+func (q *Queries) UpsertUnresolvedColumns() {
+	if len(q.UnresolvedColumns) == 0 {
+		return
+	}
+
+	rows := q.insValuesUnresolvedColumns()
+	query := fmt.Sprintf(q.insUnresolvedColumns(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+func (q *Queries) insUnresolvedColumns() string {
+	return `INSERT INTO unresolved_columns_in_queries (uid, query_uid, schema_name, table_name, column_name, clause, reason, candidates)
+	VALUES %s 
+	ON CONFLICT (uid) DO UPDATE 
+	SET reason = EXCLUDED.reason, candidates = EXCLUDED.candidates;`
+}
+
+func (q *Queries) insValuesUnresolvedColumns() []string {
+	var rows []string
+
+	for uid, column := range q.UnresolvedColumns {
+		rows = append(rows,
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s')",
+				uid, column.QueryUID, column.Schema, column.Table, column.Name, column.Clause, column.Reason, strings.Join(column.Candidates, ",")))
+	}
+	return rows
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS query_tags;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS query_tags (
+   uid UUID PRIMARY KEY NOT NULL,
+   queries_by_hour_uid UUID NOT NULL, -- foreign key to queries_by_hour table. 
+   key TEXT NOT NULL, -- from the query's comments, e.g. controller in /*controller='users'*/
+   value TEXT NOT NULL,
+   total_count BIGINT NOT NULL DEFAULT 0,
+   total_duration_us BIGINT NOT NULL DEFAULT 0 -- in microseconds
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_query_tags_uniq ON query_tags (queries_by_hour_uid, key, value);
+CREATE INDEX IF NOT EXISTS idx_query_tags_key_value ON query_tags (key, value);
@@ -0,0 +1,2 @@
+-- the normalization rules applied before the query was fingerprinted, e.g. lists,identifiers. Empty means none.
+ALTER TABLE queries ADD COLUMN IF NOT EXISTS normalization TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS postgres_query_ids;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS postgres_query_ids (
+   uid UUID PRIMARY KEY NOT NULL,
+   query_uid UUID NOT NULL, -- foreign key to queries table
+   query_id BIGINT NOT NULL, -- the queryid in pg_stat_statements
+   computed BOOLEAN NOT NULL DEFAULT false -- computed by Lantern because the log didn't include %Q
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_postgres_query_ids_uniq ON postgres_query_ids (query_uid, query_id);
+CREATE INDEX IF NOT EXISTS idx_postgres_query_ids_query_id ON postgres_query_ids (query_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS unresolved_columns_in_queries (
+   uid UUID PRIMARY KEY NOT NULL,
+   query_uid UUID NOT NULL, -- foreign key to queries table
+   schema_name TEXT NOT NULL DEFAULT 'public',
+   table_name TEXT NOT NULL, -- UNKNOWN when the column is ambiguous or missing from all of the query's tables
+   column_name TEXT NOT NULL,
+   clause TEXT NOT NULL,
+   reason TEXT NOT NULL, -- ambiguous or missing
+   candidates TEXT NOT NULL DEFAULT '' -- the tables that have an ambiguous column, separated by commas
+);
+
+CREATE INDEX IF NOT EXISTS idx_unresolved_columns_in_queries_query_uid ON unresolved_columns_in_queries (query_uid);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS column_lineage;
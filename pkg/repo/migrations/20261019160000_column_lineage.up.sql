@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS column_lineage (
+   uid UUID PRIMARY KEY NOT NULL, -- the uid is calculated from the query, target and source
+   query_uid UUID NOT NULL, -- foreign key to queries table
+   command TEXT NOT NULL, -- INSERT, UPDATE or CREATE
+   target_schema TEXT NOT NULL DEFAULT 'public',
+   target_table TEXT NOT NULL, -- the table that's written to
+   target_column TEXT NOT NULL,
+   source_schema TEXT NOT NULL DEFAULT '', -- the source columns are empty when the value isn't computed from a column
+   source_table TEXT NOT NULL DEFAULT '',
+   source_column TEXT NOT NULL DEFAULT '',
+   expression TEXT NOT NULL DEFAULT '', -- the masked expression, when the value isn't a column as is
+   functions TEXT NOT NULL DEFAULT '' -- the functions called in the expression, separated by commas
+);
+
+CREATE INDEX IF NOT EXISTS idx_column_lineage_query_uid ON column_lineage (query_uid);
+CREATE INDEX IF NOT EXISTS idx_column_lineage_target ON column_lineage (target_schema, target_table, target_column);
+CREATE INDEX IF NOT EXISTS idx_column_lineage_source ON column_lineage (source_schema, source_table, source_column);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_table_joins_in_queries_column_b;
+DROP INDEX IF EXISTS idx_table_joins_in_queries_column_a;
+
+ALTER TABLE table_joins_in_queries DROP COLUMN IF EXISTS column_b;
+ALTER TABLE table_joins_in_queries DROP COLUMN IF EXISTS column_a;
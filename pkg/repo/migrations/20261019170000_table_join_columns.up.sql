@@ -0,0 +1,7 @@
+-- Each pair of columns in a join is its own row, so composite keys have a row for each column.
+-- The columns are empty for a NATURAL join when the tables' columns aren't known.
+ALTER TABLE table_joins_in_queries ADD COLUMN IF NOT EXISTS column_a TEXT NOT NULL DEFAULT '';
+ALTER TABLE table_joins_in_queries ADD COLUMN IF NOT EXISTS column_b TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_table_joins_in_queries_column_a ON table_joins_in_queries (schema_a, table_a, column_a);
+CREATE INDEX IF NOT EXISTS idx_table_joins_in_queries_column_b ON table_joins_in_queries (schema_b, table_b, column_b);
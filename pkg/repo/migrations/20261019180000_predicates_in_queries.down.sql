@@ -0,0 +1 @@
+DROP TABLE IF EXISTS predicates_in_queries;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS predicates_in_queries (
+   uid UUID PRIMARY KEY NOT NULL, -- the uid is calculated from the query and the predicate
+   query_uid UUID NOT NULL, -- foreign key to queries table
+   schema_name TEXT NOT NULL DEFAULT 'public',
+   table_name TEXT NOT NULL,
+   column_name TEXT NOT NULL,
+   clause TEXT NOT NULL, -- WHERE, HAVING or ON
+   operator TEXT NOT NULL, -- =, <, BETWEEN, IN, LIKE, IS NULL, @>, etc
+   value_kind TEXT NOT NULL, -- parameter, constant, column, subquery, function, null, list or expression
+   selectivity_class TEXT NOT NULL, -- equality, range, in_list, prefix, leading_wildcard, pattern, null, containment, negation or other
+   function_name TEXT NOT NULL DEFAULT '', -- the function the column is passed to, like lower
+   expression TEXT NOT NULL DEFAULT '', -- the masked expression, when the column isn't compared as is
+   in_list_size INT NOT NULL DEFAULT 0 -- the number of values in IN ( ... )
+);
+
+CREATE INDEX IF NOT EXISTS idx_predicates_in_queries_query_uid ON predicates_in_queries (query_uid);
+CREATE INDEX IF NOT EXISTS idx_predicates_in_queries_column ON predicates_in_queries (schema_name, table_name, column_name);
+CREATE INDEX IF NOT EXISTS idx_predicates_in_queries_class ON predicates_in_queries (selectivity_class);
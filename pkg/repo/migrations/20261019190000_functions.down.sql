@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS functions_in_queries;
+DROP TABLE IF EXISTS functions;
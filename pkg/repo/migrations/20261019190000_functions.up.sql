@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS functions (
+   uid UUID PRIMARY KEY NOT NULL, -- the uid is calculated from the schema, name and number of arguments
+   schema_name TEXT NOT NULL DEFAULT 'public', -- pg_catalog for builtin functions
+   function_name TEXT NOT NULL,
+   argument_count INT NOT NULL DEFAULT 0,
+   kind TEXT NOT NULL DEFAULT 'scalar', -- scalar, aggregate or window
+   volatility TEXT NOT NULL DEFAULT '', -- immutable, stable or volatile, when it's known
+   builtin BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_functions_name ON functions (schema_name, function_name, argument_count);
+
+CREATE TABLE IF NOT EXISTS functions_in_queries (
+   uid UUID PRIMARY KEY NOT NULL,
+   function_uid UUID NOT NULL, -- foreign key to functions table
+   query_uid UUID NOT NULL, -- foreign key to queries table
+   schema_name TEXT NOT NULL DEFAULT 'public',
+   function_name TEXT NOT NULL,
+   argument_count INT NOT NULL DEFAULT 0,
+   kind TEXT NOT NULL DEFAULT 'scalar',
+   windowed BOOLEAN NOT NULL DEFAULT FALSE, -- called with OVER ( ... )
+   calls INT NOT NULL DEFAULT 1 -- the number of times the query calls it
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_functions_in_queries_join ON functions_in_queries (query_uid, function_uid);
+CREATE INDEX IF NOT EXISTS idx_functions_in_queries_function_uid ON functions_in_queries (function_uid);
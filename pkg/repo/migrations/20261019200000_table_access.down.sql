@@ -0,0 +1,6 @@
+DROP VIEW IF EXISTS table_access_by_hours;
+
+ALTER TABLE tables_in_queries DROP COLUMN IF EXISTS lock_mode;
+ALTER TABLE tables_in_queries DROP COLUMN IF EXISTS locks;
+ALTER TABLE tables_in_queries DROP COLUMN IF EXISTS writes;
+ALTER TABLE tables_in_queries DROP COLUMN IF EXISTS reads;
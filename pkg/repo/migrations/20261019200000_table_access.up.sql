@@ -0,0 +1,21 @@
+ALTER TABLE tables_in_queries ADD COLUMN IF NOT EXISTS reads BOOLEAN NOT NULL DEFAULT FALSE; -- selected from, joined, or in a subquery
+ALTER TABLE tables_in_queries ADD COLUMN IF NOT EXISTS writes BOOLEAN NOT NULL DEFAULT FALSE; -- inserted into, updated, deleted from, truncated, etc
+ALTER TABLE tables_in_queries ADD COLUMN IF NOT EXISTS locks BOOLEAN NOT NULL DEFAULT FALSE; -- locked with FOR UPDATE or FOR SHARE
+ALTER TABLE tables_in_queries ADD COLUMN IF NOT EXISTS lock_mode TEXT NOT NULL DEFAULT ''; -- UPDATE, NO KEY UPDATE, SHARE or KEY SHARE
+
+COMMENT ON COLUMN tables_in_queries.command IS 'the command of the statement, i.e. SELECT, INSERT, UPDATE, DELETE. The reads, writes and locks columns are how the table is accessed';
+
+-- How each table is accessed, by hour. A query that reads and writes a table counts toward both.
+-- The duration shares are the parts of the time spent in queries on the table that were spent reading, writing and locking it.
+CREATE OR REPLACE VIEW table_access_by_hours AS
+SELECT t.table_uid, t.schema_name, t.table_name, h.queried_date, h.queried_hour,
+  SUM(h.total_count) FILTER (WHERE t.reads) AS reads,
+  SUM(h.total_count) FILTER (WHERE t.writes) AS writes,
+  SUM(h.total_count) FILTER (WHERE t.locks) AS locks,
+  SUM(h.total_duration_us) AS total_duration_us,
+  ROUND(COALESCE(SUM(h.total_duration_us) FILTER (WHERE t.reads), 0)::NUMERIC / NULLIF(SUM(h.total_duration_us), 0), 3) AS read_duration_share,
+  ROUND(COALESCE(SUM(h.total_duration_us) FILTER (WHERE t.writes), 0)::NUMERIC / NULLIF(SUM(h.total_duration_us), 0), 3) AS write_duration_share,
+  ROUND(COALESCE(SUM(h.total_duration_us) FILTER (WHERE t.locks), 0)::NUMERIC / NULLIF(SUM(h.total_duration_us), 0), 3) AS lock_duration_share
+FROM tables_in_queries t
+JOIN queries_by_hours h ON h.query_uid = t.query_uid
+GROUP BY t.table_uid, t.schema_name, t.table_name, h.queried_date, h.queried_hour;
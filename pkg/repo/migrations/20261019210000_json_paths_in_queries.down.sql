@@ -0,0 +1 @@
+DROP TABLE IF EXISTS json_paths_in_queries;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS json_paths_in_queries (
+   uid UUID PRIMARY KEY NOT NULL, -- the uid is calculated from the query, clause, column, path and operator
+   query_uid UUID NOT NULL, -- foreign key to queries table
+   schema_name TEXT NOT NULL DEFAULT 'public',
+   table_name TEXT NOT NULL,
+   column_name TEXT NOT NULL,
+   path TEXT NOT NULL, -- the keys, like {address,city}. A key that's a parameter is ?
+   operator TEXT NOT NULL, -- ->, ->>, #>, #>>, ?, ?|, ?&, @> or <@
+   clause TEXT NOT NULL, -- SELECT, WHERE, ORDER, etc
+   count INT NOT NULL DEFAULT 1 -- the number of times the query uses it
+);
+
+CREATE INDEX IF NOT EXISTS idx_json_paths_in_queries_query_uid ON json_paths_in_queries (query_uid);
+CREATE INDEX IF NOT EXISTS idx_json_paths_in_queries_column ON json_paths_in_queries (schema_name, table_name, column_name);
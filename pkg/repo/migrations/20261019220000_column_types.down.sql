@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_columns_inconsistent;
+
+ALTER TABLE columns DROP COLUMN IF EXISTS inconsistent;
+ALTER TABLE columns DROP COLUMN IF EXISTS used_as;
+ALTER TABLE columns DROP COLUMN IF EXISTS type_confidence;
+ALTER TABLE columns DROP COLUMN IF EXISTS inferred_type;
@@ -0,0 +1,6 @@
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS inferred_type TEXT; -- the probable type, from how queries use the column
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS type_confidence REAL; -- from 0 to 1
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS used_as TEXT[] NOT NULL DEFAULT '{}'; -- the kinds of types it's used as, like text, numeric, temporal, json or array
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS inconsistent BOOLEAN NOT NULL DEFAULT FALSE; -- used as more than one kind, like compared to both text and integers
+
+CREATE INDEX IF NOT EXISTS idx_columns_inconsistent ON columns (inconsistent) WHERE inconsistent;
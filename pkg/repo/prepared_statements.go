@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// PreparedQuery is the query behind a named prepared statement or cursor within a session.
+// It's used to link EXECUTE and FETCH back to the query they run.
+type PreparedQuery struct {
+	Masked   string
+	Unmasked string
+	Command  token.TokenType
+}
+
+// linkSessionStatement fingerprints PREPARE and DECLARE CURSOR as the query they wrap and remembers that
+// query for the session. EXECUTE and FETCH are then fingerprinted as the query they were prepared with.
+// DEALLOCATE and CLOSE forget the names. Anything that isn't linked is left alone.
+func (q *Queries) linkSessionStatement(stmt ast.Statement, w *QueryWorker) {
+	switch stmt := stmt.(type) {
+	case *ast.PrepareStatement:
+		q.rememberPrepared(preparedKey("prepare", w.Session, stmt.Name), stmt.Query, w)
+	case *ast.DeclareCursorStatement:
+		q.rememberPrepared(preparedKey("cursor", w.Session, stmt.Name), stmt.Query, w)
+	case *ast.ExecuteStatement:
+		q.usePrepared(preparedKey("prepare", w.Session, stmt.Name), w)
+	case *ast.FetchStatement:
+		q.usePrepared(preparedKey("cursor", w.Session, stmt.Cursor), w)
+	case *ast.DeallocateStatement:
+		q.forgetPrepared("prepare", w.Session, stmt.Name, stmt.All)
+	case *ast.CloseStatement:
+		q.forgetPrepared("cursor", w.Session, stmt.Cursor, stmt.All)
+	}
+}
+
+func (q *Queries) rememberPrepared(key string, inner ast.Statement, w *QueryWorker) {
+	if inner == nil {
+		return
+	}
+
+	w.Masked = q.masked(inner)
+	w.Unmasked = inner.String(false)
+	w.Command = inner.Command()
+
+	if q.Prepared == nil {
+		q.Prepared = make(map[string]*PreparedQuery)
+	}
+	q.Prepared[key] = &PreparedQuery{Masked: w.Masked, Unmasked: w.Unmasked, Command: w.Command}
+}
+
+func (q *Queries) usePrepared(key string, w *QueryWorker) {
+	if prepared, ok := q.Prepared[key]; ok {
+		w.Masked = prepared.Masked
+		w.Unmasked = prepared.Unmasked
+		w.Command = prepared.Command
+	}
+}
+
+func (q *Queries) forgetPrepared(kind, session, name string, all bool) {
+	if !all {
+		delete(q.Prepared, preparedKey(kind, session, name))
+		return
+	}
+
+	prefix := preparedKey(kind, session, "")
+	for key := range q.Prepared {
+		if strings.HasPrefix(key, prefix) {
+			delete(q.Prepared, key)
+		}
+	}
+}
+
+// preparedKey scopes a prepared statement or cursor name to its session. Names are case insensitive like other PG identifiers.
+func preparedKey(kind, session, name string) string {
+	return fmt.Sprintf("%s|%s|%s", kind, session, strings.ToLower(name))
+}
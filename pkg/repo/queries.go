@@ -6,9 +6,13 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
 	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+	"github.com/brianbroderick/lantern/pkg/sql/jumble"
 	"github.com/brianbroderick/lantern/pkg/sql/lexer"
 	"github.com/brianbroderick/lantern/pkg/sql/logit"
+	"github.com/brianbroderick/lantern/pkg/sql/normalize"
 	"github.com/brianbroderick/lantern/pkg/sql/parser"
 	"github.com/brianbroderick/lantern/pkg/sql/token"
 	"github.com/google/uuid"
@@ -22,10 +26,38 @@ type Queries struct {
 	TablesInQueries           map[string]*extractor.TablesInQueries     `json:"tables_in_queries,omitempty"`
 	TableJoinsInQueries       map[string]*extractor.TableJoinsInQueries `json:"table_joins_in_queries,omitempty"`
 	Tables                    map[string]*extractor.Tables              `json:"tables,omitempty"`
+	Functions                 map[string]*extractor.Functions           `json:"functions,omitempty"`
 	CreateStatementsInQueries map[string]*CreateStatementsInQueries     `json:"create_statements_in_queries,omitempty"`
 	CreateStatements          map[string]*CreateStatement               `json:"create_statements,omitempty"`
+	UnresolvedColumns         map[string]*extractor.UnresolvedColumns   `json:"unresolved_columns,omitempty"`
+	ColumnLineage             map[string]*extractor.ColumnLineage       `json:"column_lineage,omitempty"`
+	PredicatesInQueries       map[string]*extractor.PredicatesInQueries `json:"predicates_in_queries,omitempty"`
+	JSONPathsInQueries        map[string]*extractor.JSONPathsInQueries  `json:"json_paths_in_queries,omitempty"`
+	ColumnTypes               map[string]*extractor.ColumnTypes         `json:"column_types,omitempty"` // by column uid
 
-	Errors map[string]int `json:"errors,omitempty"`
+	Errors map[string]int `json:"errors,omitempty"` // statements that failed to parse, by parser.ErrorCode
+
+	// Normalization rules applied before a query is fingerprinted. Changing them changes the fingerprints.
+	Normalize normalize.Options `json:"normalize,omitempty"`
+
+	// Compute a query_id for statements whose log didn't include one
+	JumbleQueryIDs bool `json:"jumble_query_ids,omitempty"`
+
+	// The dialect queries are parsed as. The zero value is Postgres.
+	Dialect token.Dialect `json:"dialect,omitempty"`
+
+	// The tables and columns of the database, which resolve columns that aren't qualified with a table.
+	// The tables created by the queries themselves are added to it when they're processed.
+	Catalog *catalog.Catalog `json:"-"`
+
+	// The search_path that sessions start with, by database and role
+	SearchPaths *SearchPathConfig `json:"-"`
+
+	// Prepared statements and cursors by session, so EXECUTE and FETCH can be linked to their query
+	Prepared map[string]*PreparedQuery `json:"-"`
+
+	// The search_path set in each session, so unqualified tables are resolved to the right schema
+	SessionSearchPaths map[string][]string `json:"-"`
 }
 
 // NewQueries creates a new Queries struct
@@ -38,14 +70,24 @@ func NewQueries(source string) *Queries {
 		TablesInQueries:           make(map[string]*extractor.TablesInQueries),
 		TableJoinsInQueries:       make(map[string]*extractor.TableJoinsInQueries),
 		Tables:                    make(map[string]*extractor.Tables),
+		Functions:                 make(map[string]*extractor.Functions),
 		CreateStatementsInQueries: make(map[string]*CreateStatementsInQueries),
 		CreateStatements:          make(map[string]*CreateStatement),
-
-		Errors: make(map[string]int),
+		UnresolvedColumns:         make(map[string]*extractor.UnresolvedColumns),
+		ColumnLineage:             make(map[string]*extractor.ColumnLineage),
+		PredicatesInQueries:       make(map[string]*extractor.PredicatesInQueries),
+		JSONPathsInQueries:        make(map[string]*extractor.JSONPathsInQueries),
+		ColumnTypes:               make(map[string]*extractor.ColumnTypes),
+
+		Errors:             make(map[string]int),
+		Prepared:           make(map[string]*PreparedQuery),
+		SessionSearchPaths: make(map[string][]string),
 	}
 }
 
 func (q *Queries) Process() bool {
+	q.catalogTables()
+
 	for _, query := range q.Queries {
 		w := QueryWorker{
 			MustExtract: true,
@@ -57,10 +99,19 @@ func (q *Queries) Process() bool {
 	q.ExtractStats()
 	q.UpsertQueryByHours()
 	q.UpsertQueryUsers()
+	q.UpsertQueryTags()
+	q.UpsertQueryIDs()
 	q.UpsertTablesInQueries()
 	q.UpsertColumnsInQueries()
+	q.UpsertUnresolvedColumns()
 	q.UpsertTableJoinsInQueries()
+	q.UpsertColumnLineage()
+	q.UpsertPredicatesInQueries()
+	q.UpsertJSONPathsInQueries()
+	q.UpsertColumnTypes()
+	q.UpsertFunctionsInQueries()
 	q.UpsertTables() // must run after UpsertTablesInQueries to populate the tables map
+	q.UpsertFunctions()
 	q.UpsertCreateStatements()
 	q.UpsertCreateStatementsInQueries()
 
@@ -70,17 +121,14 @@ func (q *Queries) Process() bool {
 // Analyze processes a query and returns a bool whether or not the query was parsed successfully
 // This ends up calling addQuery which adds the query to the Queries struct
 // Then the Queries struct is cached as a JSON file
+// Statements that fail to parse are counted and logged, but the other statements in the input are still added
 func (q *Queries) Analyze(w QueryWorker) bool {
-	l := lexer.New(w.Input)
+	l := lexer.New(w.Input, q.Dialect)
 	p := parser.New(l)
 	program := p.ParseProgram()
 
 	if len(p.Errors()) > 0 {
-		if val, ok := q.Errors[p.Errors()[0]]; ok {
-			q.Errors[p.Errors()[0]] = val + 1
-		} else {
-			q.Errors[p.Errors()[0]] = 1
-		}
+		q.countParseErrors(p.ParseErrors())
 
 		sqlLen := len(w.Input)
 		truncated := ""
@@ -91,10 +139,9 @@ func (q *Queries) Analyze(w QueryWorker) bool {
 
 		logit.Append("queries-process-error", fmt.Sprintf("Next Errors from: %s%s\n---\n", w.Input[0:sqlLen], truncated))
 
-		for _, msg := range p.Errors() {
-			logit.Append("queries-process-error", msg)
+		for _, e := range p.ParseErrors() {
+			logit.Append("queries-process-error", fmt.Sprintf("%s in statement %d at %s: %s", e.Code, e.Statement, e.Pos, e.Msg))
 		}
-		return false
 	}
 
 	// Determine how many queries are in the statement since we can send multiple queries in a single statement
@@ -110,18 +157,58 @@ loop:
 	}
 	w.TransactionQueryCount = queryCount
 
-	for _, stmt := range program.Statements {
+	// Postgres logs one query_id for the whole input, so it's only known to belong to a statement when there's one.
+	// Statements that failed to parse were still in the input, so then it isn't known either.
+	logQueryID := w.QueryID
+	if len(program.Statements) != 1 || len(p.Errors()) > 0 {
+		logQueryID = 0
+	}
+
+	for i, stmt := range program.Statements {
+		w.QueryID, w.ComputedQueryID = logQueryID, false
+		if logQueryID == 0 && q.JumbleQueryIDs {
+			w.QueryID, w.ComputedQueryID = jumble.QueryID(stmt), true
+		}
+
+		w.SearchPath = q.searchPath(&w)
+
 		r := extractor.NewExtractor(&stmt, w.MustExtract)
+		r.SearchPath, r.User = w.SearchPath, w.UserName
 		r.Execute(*r.Ast)
 
-		w.Masked = stmt.String(true)    // maskParams = true, i.e. replace all values with ?
+		w.Masked = q.masked(stmt)       // replace all values with ? and apply the normalization rules
 		w.Unmasked = stmt.String(false) // maskParams = false, i.e. leave params alone
 		w.Command = stmt.Command()
+		w.Tags = program.Tags(i) // comments aren't in the fingerprint, so tagged and untagged queries are the same query
+		q.linkSessionStatement(stmt, &w)
+		q.trackSearchPath(stmt, &w)
 
 		q.addQuery(w)
 	}
 
-	return true
+	return len(p.Errors()) == 0
+}
+
+// masked returns the string of the statement that's fingerprinted
+func (q *Queries) masked(stmt ast.Statement) string {
+	return normalize.Masked(stmt, q.Normalize)
+}
+
+// countParseErrors counts each statement that failed to parse by the code of its first error.
+// Any later errors in the same statement tend to be fallout from the first one.
+func (q *Queries) countParseErrors(errs []*parser.ParseError) {
+	if q.Errors == nil {
+		q.Errors = make(map[string]int)
+	}
+
+	counted := make(map[int]bool)
+	for _, e := range errs {
+		if counted[e.Statement] {
+			continue
+		}
+		counted[e.Statement] = true
+		q.Errors[string(e.Code)]++
+	}
 }
 
 // addQuery adds a query to the Queries struct
@@ -173,6 +260,7 @@ func (q *Queries) addQuery(w QueryWorker) {
 			TotalQueriesInTransaction: transactionQueryCount,
 			Users:                     users,
 		}
+		addQueryTags(queryByHours[ts], w.Tags, durationUs)
 
 		q.Queries[uidStr] = &Query{
 			UID:           uid,
@@ -182,12 +270,18 @@ func (q *Queries) addQuery(w QueryWorker) {
 			MaskedQuery:   w.Masked,
 			UnmaskedQuery: w.Unmasked,
 			Command:       w.Command,
+			Normalization: q.Normalize.String(),
 			QueryByHours:  queryByHours,
+			SearchPath:    w.SearchPath,
+			UserName:      w.UserName,
 		}
+		addQueryID(q.Queries[uidStr], w)
 	} else {
 		q.Queries[uidStr].QueryByHours[ts].TotalCount++
 		q.Queries[uidStr].QueryByHours[ts].TotalDurationUs += durationUs
 		q.Queries[uidStr].QueryByHours[ts].TotalQueriesInTransaction += transactionQueryCount
+		addQueryTags(q.Queries[uidStr].QueryByHours[ts], w.Tags, durationUs)
+		addQueryID(q.Queries[uidStr], w)
 
 		if _, ok := q.Queries[uidStr].QueryByHours[ts].Users[w.UserName]; !ok {
 			q.Queries[uidStr].QueryByHours[ts].Users[w.UserName] = &QueryUser{UID: UuidV5(fmt.Sprintf("%s|%s", w.UserName, uidStr)), QueriesByHourUID: qbhUID, UserName: w.UserName, TotalCount: 1, TotalDurationUs: durationUs}
@@ -225,7 +319,7 @@ func (q *Queries) Upsert() {
 func (q *Queries) ins() string {
 	return `INSERT INTO queries (
 	uid, database_uid, source_uid, command, 	
-	masked_query, unmasked_query, source_query) 
+	masked_query, unmasked_query, source_query, normalization) 
 	VALUES %s 
 	ON CONFLICT (uid) DO NOTHING;`
 }
@@ -239,11 +333,11 @@ func (q *Queries) insValues() []string {
 		original := strings.ReplaceAll(query.SourceQuery, "'", "''")
 
 		rows = append(rows,
-			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', '%s')",
+			fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s')",
 				uid, query.DatabaseUID, query.SourceUID, query.Command.String(),
 				// query.TotalCount, query.TotalDurationUs, query.TotalQueriesInTransaction,
 				// int64(math.Round(float64(query.TotalDurationUs)/float64(query.TotalCount))), float64(query.TotalQueriesInTransaction)/float64(query.TotalCount),
-				masked, unmasked, original))
+				masked, unmasked, original, query.Normalization))
 	}
 	return rows
 }
@@ -255,6 +349,7 @@ func (q *Queries) ExtractStats() {
 	fmt.Printf("TableJoinsInQueries Len: %d\n", len(q.TableJoinsInQueries))
 }
 
+// LogAggregateOfErrors logs the number of statements that failed to parse, grouped by parser.ErrorCode
 func (q *Queries) LogAggregateOfErrors() {
 	errCnt := 0
 	errs := make([]string, 0, len(q.Errors))
@@ -268,6 +363,7 @@ func (q *Queries) LogAggregateOfErrors() {
 		return q.Errors[errs[i]] > q.Errors[errs[j]]
 	})
 
+	logit.Append("queries-process-error-aggregate", fmt.Sprintf("Statements with errors: %d", errCnt))
 	for _, key := range errs {
 		msg := fmt.Sprintf("  %s: %d", key, q.Errors[key])
 		logit.Append("queries-process-error-aggregate", msg)
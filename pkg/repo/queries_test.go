@@ -2,10 +2,13 @@ package repo
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
 	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+	"github.com/brianbroderick/lantern/pkg/sql/normalize"
 	"github.com/brianbroderick/lantern/pkg/sql/token"
 	"github.com/stretchr/testify/assert"
 )
@@ -174,3 +177,440 @@ func TestQueriesAnalyze(t *testing.T) {
 	avg := timeDiff / time.Duration(len(tests))
 	fmt.Printf("TestQueriesAnalyze, Elapsed Time: %s, Avg per query: %s\n", timeDiff, avg)
 }
+
+func TestQueriesAnalyzeSessions(t *testing.T) {
+	databases := NewDatabases("TestQueriesAnalyzeSessions")
+	queries := NewQueries("TestQueriesAnalyzeSessions")
+	source := NewSource("testDB", "testDB")
+
+	selectUID := UuidV5("(SELECT * FROM users WHERE (id = ?));").String()
+
+	tests := []struct {
+		session string
+		input   string
+		uid     string
+		command token.TokenType
+	}{
+		// A plain query and a prepared one share a fingerprint
+		{"1", "select * from users where id = 42", selectUID, token.SELECT},
+		{"1", "prepare get_user(int) as select * from users where id = $1", selectUID, token.SELECT},
+		{"1", "execute get_user(42)", selectUID, token.SELECT},
+		{"1", "EXECUTE GET_USER(43)", selectUID, token.SELECT},
+		// get_user wasn't prepared in this session
+		{"2", "execute get_user(42)", UuidV5("EXECUTE get_user(?);").String(), token.EXECUTE_STATEMENT},
+		{"1", "deallocate get_user", UuidV5("DEALLOCATE get_user;").String(), token.DEALLOCATE_STATEMENT},
+		{"1", "execute get_user(42)", UuidV5("EXECUTE get_user(?);").String(), token.EXECUTE_STATEMENT},
+
+		// Cursors
+		{"3", "declare c cursor for select * from users where id = 1", selectUID, token.SELECT},
+		{"3", "fetch 100 from c", selectUID, token.SELECT},
+		{"3", "close all", UuidV5("CLOSE ALL;").String(), token.CLOSE_STATEMENT},
+		{"3", "fetch 100 from c", UuidV5("FETCH ? FROM c;").String(), token.FETCH},
+	}
+
+	for _, tt := range tests {
+		w := QueryWorker{
+			Databases:   databases,
+			SourceUID:   source.UID,
+			Session:     tt.session,
+			Input:       tt.input,
+			MustExtract: false,
+		}
+
+		assert.True(t, queries.Analyze(w), "input: %s", tt.input)
+		if assert.Contains(t, queries.Queries, tt.uid, "input: %s", tt.input) {
+			assert.Equal(t, tt.command, queries.Queries[tt.uid].Command, "input: %s", tt.input)
+		}
+	}
+
+	var total int64
+	for _, qbh := range queries.Queries[selectUID].QueryByHours {
+		total += qbh.TotalCount
+	}
+	assert.Equal(t, int64(6), total)
+}
+
+func TestQueriesAnalyzeErrors(t *testing.T) {
+	databases := NewDatabases("TestQueriesAnalyzeErrors")
+	queries := NewQueries("TestQueriesAnalyzeErrors")
+
+	tests := []struct {
+		input  string
+		parsed bool
+	}{
+		{"select 1; select ) from users; select * from users where id = 42;", false},
+		{"select ) from accounts", false},
+		{"delete from 5; select * from users where id = 43;", false},
+		{"select * from users where id = 44", true},
+	}
+
+	for _, tt := range tests {
+		w := QueryWorker{
+			Databases:   databases,
+			Input:       tt.input,
+			MustExtract: false,
+		}
+
+		assert.Equal(t, tt.parsed, queries.Analyze(w), "input: %s", tt.input)
+	}
+
+	// The statements that parsed are still added
+	selectUID := UuidV5("(SELECT * FROM users WHERE (id = ?));").String()
+	if assert.Contains(t, queries.Queries, selectUID) {
+		var total int64
+		for _, qbh := range queries.Queries[selectUID].QueryByHours {
+			total += qbh.TotalCount
+		}
+		assert.Equal(t, int64(3), total)
+	}
+	assert.Contains(t, queries.Queries, UuidV5("(SELECT ?);").String())
+
+	// Errors are counted once per statement, by code rather than by message
+	assert.Equal(t, map[string]int{"no_prefix_parse_fn": 2, "unexpected_token": 1}, queries.Errors)
+}
+
+func TestQueriesAnalyzeTags(t *testing.T) {
+	databases := NewDatabases("TestQueriesAnalyzeTags")
+	queries := NewQueries("TestQueriesAnalyzeTags")
+
+	tests := []struct {
+		input      string
+		durationUs int64
+	}{
+		{"select * from users where id = 1 /*controller='users',action='show',traceparent='00-5bd66ef5095369c7b0d1f8f4bd33716a-c532cb4098ac3dd2-01'*/", 10},
+		{"select * from users where id = 2 /*controller='users',action='show',traceparent='00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01'*/", 20},
+		{"/*controller='admin',action='index'*/ select * from users where id = 3", 40},
+		{"select * from users where id = 4", 80},
+	}
+
+	for _, tt := range tests {
+		w := QueryWorker{
+			Databases:             databases,
+			Input:                 tt.input,
+			DurationUs:            tt.durationUs,
+			TransactionQueryCount: 1,
+		}
+
+		assert.True(t, queries.Analyze(w), "input: %s", tt.input)
+	}
+
+	// Tags don't change the fingerprint
+	assert.Equal(t, 1, len(queries.Queries))
+	query := queries.Queries[UuidV5("(SELECT * FROM users WHERE (id = ?));").String()]
+	if !assert.NotNil(t, query) {
+		return
+	}
+
+	tags := map[string][2]int64{}
+	for _, qbh := range query.QueryByHours {
+		assert.Equal(t, int64(4), qbh.TotalCount)
+		for key, tag := range qbh.Tags {
+			tags[key] = [2]int64{tag.TotalCount, tag.TotalDurationUs}
+		}
+	}
+
+	// The trace context is different for every request, so it isn't counted
+	assert.Equal(t, map[string][2]int64{
+		"controller=users": {2, 30},
+		"action=show":      {2, 30},
+		"controller=admin": {1, 40},
+		"action=index":     {1, 40},
+	}, tags)
+}
+
+func TestQueriesAnalyzeNormalize(t *testing.T) {
+	inputs := []string{
+		"select id as user_id from users where name = 'a' and id = any(array[1, 2])",
+		"SELECT ID FROM Users WHERE ID = ANY(ARRAY[3]) AND Name = 'b'",
+		"prepare get_user as select id from users where 'c' = name and id = any(array[4, 5, 6])",
+	}
+
+	tests := []struct {
+		opts    normalize.Options
+		queries int
+	}{
+		{normalize.Options{}, 3},
+		{normalize.All(), 1},
+	}
+
+	for _, tt := range tests {
+		queries := NewQueries("TestQueriesAnalyzeNormalize")
+		queries.Normalize = tt.opts
+
+		for _, input := range inputs {
+			w := QueryWorker{
+				Databases:   NewDatabases("TestQueriesAnalyzeNormalize"),
+				Session:     "1",
+				Input:       input,
+				MustExtract: false,
+			}
+			assert.True(t, queries.Analyze(w), "input: %s", input)
+		}
+
+		assert.Equal(t, tt.queries, len(queries.Queries), "normalize: %s", tt.opts)
+		for _, query := range queries.Queries {
+			assert.Equal(t, tt.opts.String(), query.Normalization)
+		}
+	}
+}
+
+func TestQueriesAnalyzeQueryIDs(t *testing.T) {
+	databases := NewDatabases("TestQueriesAnalyzeQueryIDs")
+	queries := NewQueries("TestQueriesAnalyzeQueryIDs")
+
+	tests := []struct {
+		input   string
+		queryID int64
+		ok      bool
+	}{
+		{"select * from users where id = 1", -3962393542931145239, true},
+		{"select * from users where id = 2", -3962393542931145239, true},
+		// The same fingerprint in a different schema has a different query_id
+		{"select * from users where id = 3", 1147616880456321454, true},
+		// It isn't known which statement the query_id belongs to
+		{"select * from cars where id = 1; select * from cars where id = 2", 42, true},
+		// Logs without %Q
+		{"select * from users where id = 4", 0, true},
+		// The statement that failed to parse may be the one the query_id belongs to
+		{"select * from cars where id = 3; select ) from cars", 43, false},
+		{"select ) from cars; select * from cars where id = 4", 44, false},
+	}
+
+	for _, tt := range tests {
+		w := QueryWorker{
+			Databases:   databases,
+			Input:       tt.input,
+			QueryID:     tt.queryID,
+			MustExtract: false,
+		}
+		assert.Equal(t, tt.ok, queries.Analyze(w), "input: %s", tt.input)
+	}
+
+	users := queries.Queries[UuidV5("(SELECT * FROM users WHERE (id = ?));").String()]
+	if assert.NotNil(t, users) {
+		assert.Equal(t, 2, len(users.QueryIDs))
+		for _, id := range []int64{-3962393542931145239, 1147616880456321454} {
+			if assert.Contains(t, users.QueryIDs, id) {
+				assert.Equal(t, users.UID, users.QueryIDs[id].QueryUID)
+				assert.False(t, users.QueryIDs[id].Computed)
+			}
+		}
+	}
+
+	cars := queries.Queries[UuidV5("(SELECT * FROM cars WHERE (id = ?));").String()]
+	if assert.NotNil(t, cars) {
+		assert.Empty(t, cars.QueryIDs)
+	}
+
+	// When the log doesn't have one, it can be computed
+	queries = NewQueries("TestQueriesAnalyzeQueryIDs")
+	queries.JumbleQueryIDs = true
+
+	for _, input := range []string{"select * from users where id = 1", "SELECT * FROM users WHERE id = 2"} {
+		w := QueryWorker{Databases: databases, Input: input, MustExtract: false}
+		assert.True(t, queries.Analyze(w), "input: %s", input)
+	}
+
+	users = queries.Queries[UuidV5("(SELECT * FROM users WHERE (id = ?));").String()]
+	if assert.NotNil(t, users) && assert.Equal(t, 1, len(users.QueryIDs)) {
+		for _, id := range users.QueryIDs {
+			assert.True(t, id.Computed)
+			assert.NotZero(t, id.QueryID)
+		}
+	}
+}
+
+func TestQueriesAnalyzeCatalog(t *testing.T) {
+	databases := NewDatabases("TestQueriesAnalyzeCatalog")
+	queries := NewQueries("TestQueriesAnalyzeCatalog")
+	queries.Catalog = catalog.New()
+
+	inputs := []string{
+		"create table users (id bigint, name text)",
+		"create table addresses (id bigint, user_id bigint, city text)",
+		"select name, city, id from users u join addresses a on a.user_id = u.id",
+	}
+	for _, input := range inputs {
+		w := QueryWorker{Databases: databases, Input: input, MustExtract: true}
+		assert.True(t, queries.Analyze(w), "input: %s", input)
+	}
+
+	queries.catalogTables()
+	assert.Len(t, queries.Catalog.Tables, 2)
+
+	var query *Query
+	for _, qu := range queries.Queries {
+		if qu.Command == token.SELECT {
+			query = qu
+		}
+	}
+	if !assert.NotNil(t, query) {
+		return
+	}
+	assert.True(t, query.Process(QueryWorker{MustExtract: true}, queries))
+
+	columns := []string{}
+	for _, c := range queries.ColumnsInQueries {
+		columns = append(columns, fmt.Sprintf("%s|%s.%s.%s", c.Clause, c.Schema, c.Table, c.Name))
+	}
+	assert.Contains(t, columns, "SELECT|public.users.name")
+	assert.Contains(t, columns, "SELECT|public.addresses.city")
+
+	if assert.Len(t, queries.UnresolvedColumns, 1) {
+		for _, c := range queries.UnresolvedColumns {
+			assert.Equal(t, query.UID, c.QueryUID)
+			assert.Equal(t, "id", c.Name)
+			assert.Equal(t, extractor.Ambiguous, c.Reason)
+		}
+	}
+}
+
+func TestQueriesAnalyzeSearchPath(t *testing.T) {
+	databases := NewDatabases("TestQueriesAnalyzeSearchPath")
+	queries := NewQueries("TestQueriesAnalyzeSearchPath")
+	queries.SearchPaths = &SearchPathConfig{
+		Databases:        map[string][]string{"app": {"app", "public"}},
+		Roles:            map[string][]string{"etl": {"staging", "public"}},
+		RolesInDatabases: map[string]map[string][]string{"app": {"reporting": {"reports", "public"}}},
+	}
+
+	tests := []struct {
+		session  string
+		database string
+		user     string
+		input    string
+		path     []string
+	}{
+		{"1", "other", "bob", "select * from users", nil},
+		{"2", "app", "bob", "select * from accounts", []string{"app", "public"}},
+		{"3", "app", "etl", "select * from loads", []string{"staging", "public"}},
+		{"4", "app", "reporting", "select * from totals", []string{"reports", "public"}},
+		// The path that's set lasts for the rest of the session
+		{"1", "other", "bob", "set search_path to billing, public", nil},
+		{"1", "other", "bob", "select * from invoices", []string{"billing", "public"}},
+		{"2", "app", "bob", "select * from payments", []string{"app", "public"}},
+		// Until the end of the transaction for SET LOCAL
+		{"1", "other", "bob", "set local search_path = audit", []string{"billing", "public"}},
+		{"1", "other", "bob", "select * from events", []string{"audit"}},
+		{"1", "other", "bob", "commit", []string{"audit"}},
+		{"1", "other", "bob", "select * from refunds", []string{"billing", "public"}},
+		{"1", "other", "bob", "set search_path to default", []string{"billing", "public"}},
+		{"1", "other", "bob", "select * from credits", nil},
+	}
+
+	for _, tt := range tests {
+		w := QueryWorker{Databases: databases, Database: tt.database, UserName: tt.user, Session: tt.session, Input: tt.input, MustExtract: true}
+		assert.True(t, queries.Analyze(w), "input: %s", tt.input)
+
+		query, ok := queries.Queries[UuidV5(tt.input).String()]
+		if !ok {
+			// The masked query is what's fingerprinted, so find it by its unmasked text
+			for _, q := range queries.Queries {
+				if q.SourceQuery == tt.input {
+					query, ok = q, true
+				}
+			}
+		}
+		if assert.True(t, ok, "input: %s", tt.input) {
+			assert.Equal(t, tt.path, query.SearchPath, "input: %s", tt.input)
+		}
+	}
+
+	var invoices *Query
+	for _, q := range queries.Queries {
+		if q.SourceQuery == "select * from invoices" {
+			invoices = q
+		}
+	}
+	if !assert.NotNil(t, invoices) {
+		return
+	}
+	assert.True(t, invoices.Process(QueryWorker{MustExtract: true}, queries))
+
+	tables := []string{}
+	for _, table := range queries.TablesInQueries {
+		tables = append(tables, fmt.Sprintf("%s.%s", table.Schema, table.Name))
+	}
+	assert.Equal(t, []string{"billing.invoices"}, tables)
+}
+
+func TestQueriesProcessExtractors(t *testing.T) {
+	// The extractors are tested in pkg/sql/extractor. This checks the order of the columns in the rows
+	// that are inserted into each table, using a row that has the given match.
+	tests := []struct {
+		table    string
+		inputs   []string
+		rows     func(*Queries) []string
+		count    int
+		match    string
+		expected string
+	}{
+		{"column_lineage",
+			[]string{"insert into reporting.daily_revenue (day, total) select date_trunc('day', created_at), sum(amount) from orders group by 1;"},
+			(*Queries).insValuesColumnLineage, 2,
+			"date_trunc", "date_trunc(''?'', created_at)"},
+		{"table_joins_in_queries",
+			[]string{"select * from orders o join order_items i on i.order_id = o.id and i.region = o.region where o.status = 'open';"},
+			(*Queries).insValuesTableJoinsInQueries, 2,
+			"'region'", "'public', 'order_items', 'region', 'public', 'orders', 'region')"},
+		{"predicates_in_queries",
+			[]string{"select id from users where lower(email) = 'a@b.com' and state in ('a', 'b') and name like '%o''b%';"},
+			(*Queries).insValuesPredicatesInQueries, 3,
+			"lower", "'lower', 'lower(email)'"},
+		{"functions",
+			[]string{
+				"select billing.invoice_total(id) from invoices where created_at > now();",
+				"select billing.invoice_total(id), random() from invoices where user_id = $1;",
+			},
+			(*Queries).insValuesFunctions, 3,
+			"'random'", "'pg_catalog', 'random', 0, 'scalar', 'volatile', true)"},
+		{"functions_in_queries",
+			[]string{
+				"select billing.invoice_total(id) from invoices where created_at > now();",
+				"select billing.invoice_total(id), random() from invoices where user_id = $1;",
+			},
+			(*Queries).insValuesFunctionsInQueries, 4,
+			"'random'", "'pg_catalog', 'random', 0, 'scalar', false, 1)"},
+		{"tables_in_queries",
+			[]string{"update users set active = false where id in (select user_id from bans);"},
+			(*Queries).insValuesTablesInQueries, 2,
+			"'bans'", "'SELECT', true, false, false, '')"},
+		{"json_paths_in_queries",
+			[]string{"select data->'address'->>'city' from events where data->>'type' = 'signup' and data ? 'o''brien';"},
+			(*Queries).insValuesJSONPathsInQueries, 3,
+			"brien", "'{o''brien}'"},
+		// The same column in both queries is one row
+		{"column_types",
+			[]string{
+				"select id from orders where code = '10' and created_at > now() - interval '1 day';",
+				"select id from orders where code = 10 and data->>'state' = 'open';",
+			},
+			(*Queries).insValuesColumnTypes, 3,
+			"'code'", "'integer', 0.49, '{numeric,text}', true)"},
+	}
+
+	for _, tt := range tests {
+		databases := NewDatabases("TestQueriesProcessExtractors")
+		queries := NewQueries("TestQueriesProcessExtractors")
+
+		for _, input := range tt.inputs {
+			w := QueryWorker{Databases: databases, Database: "app", UserName: "app", Input: input, MustExtract: true}
+			assert.True(t, queries.Analyze(w), "table: %s", tt.table)
+		}
+		for _, query := range queries.Queries {
+			assert.True(t, query.Process(QueryWorker{MustExtract: true}, queries), "table: %s", tt.table)
+		}
+
+		rows := tt.rows(queries)
+		assert.Equal(t, tt.count, len(rows), "table: %s", tt.table)
+
+		found := false
+		for _, row := range rows {
+			if strings.Contains(row, tt.match) {
+				found = true
+				assert.Contains(t, row, tt.expected, "table: %s", tt.table)
+			}
+		}
+		assert.True(t, found, "table: %s", tt.table)
+	}
+}
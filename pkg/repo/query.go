@@ -15,14 +15,18 @@ import (
 )
 
 type Query struct {
-	UID           uuid.UUID               `json:"uid,omitempty"`            // unique sha of the query
-	DatabaseUID   uuid.UUID               `json:"database_uid,omitempty"`   // the dataset the query belongs to
-	SourceUID     uuid.UUID               `json:"source_uid,omitempty"`     // the source the query belongs to
-	QueryByHours  map[string]*QueryByHour `json:"query_by_hours,omitempty"` // query stats per hour
-	Command       token.TokenType         `json:"command,omitempty"`        // the type of query
-	MaskedQuery   string                  `json:"masked_query,omitempty"`   // the query with parameters masked
-	UnmaskedQuery string                  `json:"unmasked_query,omitempty"` // the query with parameters unmasked
-	SourceQuery   string                  `json:"source,omitempty"`         // the original query from the source
+	UID           uuid.UUID                  `json:"uid,omitempty"`            // unique sha of the query
+	DatabaseUID   uuid.UUID                  `json:"database_uid,omitempty"`   // the dataset the query belongs to
+	SourceUID     uuid.UUID                  `json:"source_uid,omitempty"`     // the source the query belongs to
+	QueryByHours  map[string]*QueryByHour    `json:"query_by_hours,omitempty"` // query stats per hour
+	Command       token.TokenType            `json:"command,omitempty"`        // the type of query
+	MaskedQuery   string                     `json:"masked_query,omitempty"`   // the query with parameters masked
+	UnmaskedQuery string                     `json:"unmasked_query,omitempty"` // the query with parameters unmasked
+	SourceQuery   string                     `json:"source,omitempty"`         // the original query from the source
+	Normalization string                     `json:"normalization,omitempty"`  // the normalization rules applied before the query was fingerprinted
+	QueryIDs      map[int64]*PostgresQueryID `json:"query_ids,omitempty"`      // Postgres' query_ids for the query
+	SearchPath    []string                   `json:"search_path,omitempty"`    // the search_path the query first ran with, when it isn't the default
+	UserName      string                     `json:"user_name,omitempty"`      // the role the query first ran as, which is the "$user" schema of the search_path
 
 	// TimestampByHour           time.Time               `json:"timestamp_by_hour,omitempty"`            // the time the query was executed, rounded to the hour
 	// TotalCount                int64                   `json:"total_count,omitempty"`                  // the number of times the query was executed
@@ -42,24 +46,29 @@ type QueryWorker struct {
 	Database              string
 	DatabaseUID           uuid.UUID
 	UserName              string
+	Session               string // Identifies the connection the query came from, i.e. the backend pid in a log
 	Input                 string // Original query. This may contain many queries
 	TransactionQueryCount int64  // Number of queries in a transaction
 	DurationUs            int64  // Duration of the query in microseconds
 	MustExtract           bool
 	Command               token.TokenType
-	Masked                string // Masked query. This is the query with all values replaced with ?
-	Unmasked              string // Unmasked query. This is the query with all values left alone
+	Masked                string            // Masked query. This is the query with all values replaced with ?
+	Unmasked              string            // Unmasked query. This is the query with all values left alone
+	Tags                  map[string]string // Tags from the query's comments, such as sqlcommenter's controller and action
+	QueryID               int64             // Postgres' query_id from the log, or 0 if it wasn't logged
+	ComputedQueryID       bool              // QueryID was computed by the jumble package instead of coming from the log
+	SearchPath            []string          // The session's search_path, or nil for the default
 }
 
 // Process processes a query and returns a bool whether or not the query was parsed successfully
 func (q *Query) Process(w QueryWorker, qs *Queries) bool {
-	l := lexer.New(q.UnmaskedQuery)
+	l := lexer.New(q.UnmaskedQuery, qs.Dialect)
 	p := parser.New(l)
 	program := p.ParseProgram()
 
 	if len(p.Errors()) > 0 {
-		for _, msg := range p.Errors() {
-			logit.Append("query-process-error", fmt.Sprintf("%s | Input: %s", msg, p.Input()))
+		for _, e := range p.ParseErrors() {
+			logit.Append("query-process-error", fmt.Sprintf("%s: %s | Input: %s", e.Code, e.Msg, p.Input()))
 		}
 		return false
 	}
@@ -67,11 +76,22 @@ func (q *Query) Process(w QueryWorker, qs *Queries) bool {
 	for _, stmt := range program.Statements {
 		env := object.NewEnvironment()
 		r := extractor.NewExtractor(&stmt, w.MustExtract)
+		r.Catalog = qs.Catalog
+		r.SearchPath, r.User = q.SearchPath, q.UserName
 		r.Extract(*r.Ast, env)
+		if r.Catalog != nil {
+			r.InferColumnsInTables()
+		}
 
 		qs.addTablesInQueries(q, r)
 		qs.addColumnsInQueries(q, r)
+		qs.addUnresolvedColumns(q, r)
 		qs.addTableJoinsInQueries(q, r)
+		qs.addColumnLineage(q, r)
+		qs.addPredicatesInQueries(q, r)
+		qs.addJSONPathsInQueries(q, r)
+		qs.addColumnTypes(q, r)
+		qs.addFunctionsInQueries(q, r)
 		qs.addCreateStatements(q, r)
 
 	}
@@ -106,7 +126,12 @@ func (q *Query) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
 	}
-	q.Command = token.Lookup(aux.Command)
+	// Statements like VACUUM aren't keywords, so they're found by name
+	if command, ok := token.FromString(aux.Command); ok {
+		q.Command = command
+	} else {
+		q.Command = token.Lookup(aux.Command)
+	}
 	// t, err := time.Parse("2006-01-02 15:04:05", aux.TimestampByHour)
 	// if err != nil {
 	// 	return err
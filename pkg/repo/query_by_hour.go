@@ -16,6 +16,7 @@ type QueryByHour struct {
 	TotalDurationUs           int64                 `json:"total_duration_us,omitempty"`            // the total duration of all executions of the query in microseconds
 	TotalQueriesInTransaction int64                 `json:"total_queries_in_transaction,omitempty"` // the sum total number of queries each time this query was executed in a transaction
 	Users                     map[string]*QueryUser `json:"users,omitempty"`                        // the users who executed the query
+	Tags                      map[string]*QueryTag  `json:"tags,omitempty"`                         // the tags from the query's comments, keyed by key=value
 }
 
 func (q *Queries) UpsertQueryByHours() {
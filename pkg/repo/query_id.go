@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// PostgresQueryID links a query to a Postgres query_id, so it can be joined to pg_stat_statements.
+// The mapping is many to many: Postgres gives a query a different id for each schema its tables resolve to,
+// and Lantern's normalization can give the same fingerprint to queries that Postgres tells apart.
+type PostgresQueryID struct {
+	UID      uuid.UUID `json:"uid,omitempty"`
+	QueryUID uuid.UUID `json:"query_uid,omitempty"`
+	QueryID  int64     `json:"query_id,omitempty"`
+	Computed bool      `json:"computed,omitempty"` // computed by the jumble package because the log didn't have one
+}
+
+// addQueryID links the query to the worker's query_id, if it has one
+func addQueryID(query *Query, w QueryWorker) {
+	if w.QueryID == 0 {
+		return
+	}
+	if query.QueryIDs == nil {
+		query.QueryIDs = make(map[int64]*PostgresQueryID)
+	}
+
+	if id, ok := query.QueryIDs[w.QueryID]; ok {
+		// An id from the log is better evidence than the same id computed
+		id.Computed = id.Computed && w.ComputedQueryID
+		return
+	}
+
+	query.QueryIDs[w.QueryID] = &PostgresQueryID{
+		UID:      UuidV5(fmt.Sprintf("%s|%d", query.UID, w.QueryID)),
+		QueryUID: query.UID,
+		QueryID:  w.QueryID,
+		Computed: w.ComputedQueryID,
+	}
+}
+
+func (q *Queries) UpsertQueryIDs() {
+	rows := q.insValuesQueryIDs()
+	if len(rows) == 0 {
+		return
+	}
+
+	query := fmt.Sprintf(q.insQueryIDs(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+func (q *Queries) insQueryIDs() string {
+	return `INSERT INTO postgres_query_ids (uid, query_uid, query_id, computed) 
+	VALUES %s
+	ON CONFLICT (uid) DO UPDATE 
+	SET computed = postgres_query_ids.computed AND EXCLUDED.computed;`
+}
+
+func (q *Queries) insValuesQueryIDs() []string {
+	var rows []string
+
+	for _, query := range q.Queries {
+		for _, id := range query.QueryIDs {
+			rows = append(rows,
+				fmt.Sprintf("('%s', '%s', %d, %t)",
+					id.UID, id.QueryUID, id.QueryID, id.Computed))
+		}
+	}
+
+	return rows
+}
@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// QueryTag counts the executions of a query by a tag from its comments, such as controller='users'
+// from sqlcommenter, so load can be attributed to the code that sent the query
+type QueryTag struct {
+	UID              uuid.UUID `json:"uid,omitempty"`
+	QueriesByHourUID uuid.UUID `json:"queries_by_hour,omitempty"`
+	Key              string    `json:"key,omitempty"`
+	Value            string    `json:"value,omitempty"`
+	TotalCount       int64     `json:"total_count,omitempty"`
+	TotalDurationUs  int64     `json:"total_duration_us,omitempty"`
+}
+
+// The trace context tags are different for every request, so they'd add a row for every execution
+var untrackedTags = map[string]bool{
+	"traceparent": true,
+	"tracestate":  true,
+}
+
+// addQueryTags counts an execution of a query for each of its tags
+func addQueryTags(qbh *QueryByHour, tags map[string]string, durationUs int64) {
+	for key, value := range tags {
+		if untrackedTags[key] {
+			continue
+		}
+		if qbh.Tags == nil {
+			qbh.Tags = make(map[string]*QueryTag)
+		}
+
+		tagKey := fmt.Sprintf("%s=%s", key, value)
+		if tag, ok := qbh.Tags[tagKey]; ok {
+			tag.TotalCount++
+			tag.TotalDurationUs += durationUs
+			continue
+		}
+
+		qbh.Tags[tagKey] = &QueryTag{
+			UID:              UuidV5(fmt.Sprintf("%s|%s", qbh.UID, tagKey)),
+			QueriesByHourUID: qbh.UID,
+			Key:              key,
+			Value:            value,
+			TotalCount:       1,
+			TotalDurationUs:  durationUs,
+		}
+	}
+}
+
+func (q *Queries) UpsertQueryTags() {
+	rows := q.insValuesQueryTags()
+	if len(rows) == 0 {
+		return
+	}
+
+	query := fmt.Sprintf(q.insQueryTags(), strings.Join(rows, ",\n"))
+
+	db := Conn()
+	defer db.Close()
+	ExecuteQuery(db, query)
+}
+
+func (q *Queries) insQueryTags() string {
+	return `INSERT INTO query_tags (uid, queries_by_hour_uid, key, value, total_count, total_duration_us) 
+	VALUES %s
+	ON CONFLICT (uid) DO UPDATE 
+	SET queries_by_hour_uid = EXCLUDED.queries_by_hour_uid, 
+		key = EXCLUDED.key, 
+		value = EXCLUDED.value, 
+		total_count = EXCLUDED.total_count, 
+		total_duration_us = EXCLUDED.total_duration_us;`
+}
+
+func (q *Queries) insValuesQueryTags() []string {
+	var rows []string
+
+	for _, query := range q.Queries {
+		for _, queryByHour := range query.QueryByHours {
+			for _, tag := range queryByHour.Tags {
+				key := strings.ReplaceAll(tag.Key, "'", "''")
+				value := strings.ReplaceAll(tag.Value, "'", "''")
+
+				rows = append(rows,
+					fmt.Sprintf("('%s', '%s', '%s', '%s', %d, %d)",
+						tag.UID, tag.QueriesByHourUID, key, value, tag.TotalCount, tag.TotalDurationUs))
+			}
+		}
+	}
+
+	return rows
+}
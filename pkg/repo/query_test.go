@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryJSON(t *testing.T) {
+	commands := []token.TokenType{
+		token.SELECT, token.INSERT, token.GRANT, token.SHOW_STATEMENT, token.SAVEPOINT_STATEMENT,
+		token.VACUUM_STATEMENT, token.REINDEX_STATEMENT, token.CLUSTER_STATEMENT, token.REFRESH_STATEMENT,
+		token.TRUNCATE_STATEMENT, token.REVOKE_STATEMENT, token.CALL_STATEMENT, token.PREPARE_STATEMENT,
+		token.EXECUTE_STATEMENT, token.DEALLOCATE_STATEMENT, token.DECLARE_STATEMENT, token.CLOSE_STATEMENT,
+		token.LISTEN_STATEMENT, token.UNLISTEN_STATEMENT, token.NOTIFY_STATEMENT,
+	}
+
+	// The command survives the round trip through the processed files
+	for _, command := range commands {
+		data, err := json.Marshal(&Query{Command: command})
+		assert.NoError(t, err)
+
+		var q Query
+		assert.NoError(t, json.Unmarshal(data, &q))
+		assert.Equal(t, command, q.Command, command.String())
+	}
+}
@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// SearchPathConfig is the search_path that sessions start with, like ALTER DATABASE ... SET search_path and
+// ALTER ROLE ... SET search_path. A role in a database takes precedence over a role, which takes precedence
+// over a database. When none of them match, it's Default, and then extractor.DefaultSearchPath.
+type SearchPathConfig struct {
+	Default          []string                       `json:"default,omitempty"`
+	Databases        map[string][]string            `json:"databases,omitempty"`          // by database
+	Roles            map[string][]string            `json:"roles,omitempty"`              // by role
+	RolesInDatabases map[string]map[string][]string `json:"roles_in_databases,omitempty"` // by database, then role
+}
+
+// LoadSearchPathConfig reads a JSON file of SearchPathConfig
+func LoadSearchPathConfig(name string) (*SearchPathConfig, error) {
+	file, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &SearchPathConfig{}
+	if err := json.Unmarshal(file, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return config, nil
+}
+
+// For returns the search_path of a new session of the role in the database, or nil for the default
+func (c *SearchPathConfig) For(database, role string) []string {
+	if c == nil {
+		return nil
+	}
+	if path, ok := c.RolesInDatabases[database][role]; ok {
+		return path
+	}
+	if path, ok := c.Roles[role]; ok {
+		return path
+	}
+	if path, ok := c.Databases[database]; ok {
+		return path
+	}
+	return c.Default
+}
+
+// trackSearchPath follows SET search_path in each session, so the statements that come after it resolve
+// unqualified tables with it. SET LOCAL only lasts until the transaction is committed or rolled back.
+// SET search_path TO DEFAULT goes back to the configured path.
+func (q *Queries) trackSearchPath(stmt ast.Statement, w *QueryWorker) {
+	if w.Session == "" {
+		return
+	}
+	if q.SessionSearchPaths == nil {
+		q.SessionSearchPaths = make(map[string][]string)
+	}
+
+	switch stmt.Command() {
+	case token.COMMIT, token.ROLLBACK:
+		delete(q.SessionSearchPaths, searchPathKey("local", w.Session))
+		return
+	}
+
+	path, ok := extractor.SearchPathFromSet(stmt)
+	if !ok {
+		return
+	}
+
+	key := searchPathKey("session", w.Session)
+	if stmt.(*ast.SetStatement).Local {
+		key = searchPathKey("local", w.Session)
+	}
+
+	if path == nil {
+		delete(q.SessionSearchPaths, key)
+		return
+	}
+	q.SessionSearchPaths[key] = path
+}
+
+// searchPath returns the search_path that the worker's statement runs with, or nil for the default
+func (q *Queries) searchPath(w *QueryWorker) []string {
+	if w.Session != "" {
+		if path, ok := q.SessionSearchPaths[searchPathKey("local", w.Session)]; ok {
+			return path
+		}
+		if path, ok := q.SessionSearchPaths[searchPathKey("session", w.Session)]; ok {
+			return path
+		}
+	}
+	return q.SearchPaths.For(w.Database, w.UserName)
+}
+
+func searchPathKey(scope, session string) string {
+	return fmt.Sprintf("%s|%s", scope, session)
+}
@@ -10,6 +10,7 @@ import (
 )
 
 type AnalyzeStatement struct {
+	Span
 	Token            token.Token  `json:"token,omitempty"`              // the token.ANALYZE token
 	Verbose          bool         `json:"verbose,omitempty"`            // VERBOSE
 	SkipLocked       bool         `json:"skip_locked,omitempty"`        // SKIP_LOCKED
@@ -23,6 +24,9 @@ func (x *AnalyzeStatement) Command() token.TokenType     { return x.Token.Type }
 func (x *AnalyzeStatement) SetCommand(c token.TokenType) {}
 func (x *AnalyzeStatement) statementNode()               {}
 func (x *AnalyzeStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *AnalyzeStatement) Children() []Node {
+	return join(list(x.BufferUsageLimit), nodes(x.Name))
+}
 func (x *AnalyzeStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 	count := 0
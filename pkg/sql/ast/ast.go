@@ -18,6 +18,24 @@ type Node interface {
 	SetCommand(token.TokenType)
 	Clause() token.TokenType
 	SetClause(token.TokenType)
+	Pos() token.Pos // the position of the first character of the node
+	End() token.Pos // the position of the first character after the node
+	SetSpan(start, end token.Pos)
+	Children() []Node // the nodes directly below this one, in the order they appear in the query. Nils are left out.
+}
+
+// Span records where a node starts and ends in the source query. It's embedded in every node.
+// Nodes that the parser synthesizes, such as the implied 1 in FETCH FIRST ROW ONLY, have a token.NoPos span.
+type Span struct {
+	StartPos token.Pos `json:"-"`
+	EndPos   token.Pos `json:"-"`
+}
+
+func (s *Span) Pos() token.Pos { return s.StartPos }
+func (s *Span) End() token.Pos { return s.EndPos }
+func (s *Span) SetSpan(start, end token.Pos) {
+	s.StartPos = start
+	s.EndPos = end
 }
 
 // All statement nodes implement this
@@ -35,7 +53,9 @@ type Expression interface {
 }
 
 type Program struct {
+	Span
 	Statements []Statement `json:"statements,omitempty"`
+	Comments   []*Comment  `json:"comments,omitempty"`
 }
 
 func (p *Program) Clause() token.TokenType      { return token.PROGRAM }
@@ -50,6 +70,22 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+// Children merges the statements and comments by where they start
+func (p *Program) Children() []Node {
+	children := make([]Node, 0, len(p.Statements)+len(p.Comments))
+	comments := p.Comments
+	for _, s := range p.Statements {
+		if isNil(s) {
+			continue
+		}
+		for len(comments) > 0 && comments[0].Pos().Offset < s.Pos().Offset {
+			children = append(children, comments[0])
+			comments = comments[1:]
+		}
+		children = append(children, s)
+	}
+	return append(children, list(comments)...)
+}
 func (p *Program) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -60,6 +96,24 @@ func (p *Program) String(maskParams bool) string {
 	return out.String()
 }
 
+// Tags returns the query tags in the comments of a statement, or nil if it doesn't have any.
+// If more than one comment has the same tag, the last one wins.
+func (p *Program) Tags(statement int) map[string]string {
+	var tags map[string]string
+	for _, c := range p.Comments {
+		if c.Statement != statement {
+			continue
+		}
+		for k, v := range c.Tags() {
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
 func (p *Program) Inspect(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -76,6 +130,7 @@ func (p *Program) Inspect(maskParams bool) string {
 
 // This is a statement without a leading token. For example: x + 10;
 type ExpressionStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the first token of the expression
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -86,6 +141,9 @@ func (x *ExpressionStatement) Command() token.TokenType     { return x.Expressio
 func (x *ExpressionStatement) SetCommand(c token.TokenType) {}
 func (x *ExpressionStatement) statementNode()               {}
 func (x *ExpressionStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *ExpressionStatement) Children() []Node {
+	return nodes(x.Expression)
+}
 func (x *ExpressionStatement) String(maskParams bool) string {
 	if x.Expression != nil {
 		return x.Expression.String(maskParams)
@@ -97,6 +155,7 @@ func (x *ExpressionStatement) Inspect(maskParams bool) string {
 }
 
 type SemicolonStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.SEMICOLON token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -107,6 +166,9 @@ func (x *SemicolonStatement) Command() token.TokenType     { return x.Expression
 func (x *SemicolonStatement) SetCommand(c token.TokenType) {}
 func (x *SemicolonStatement) statementNode()               {}
 func (x *SemicolonStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *SemicolonStatement) Children() []Node {
+	return nodes(x.Expression)
+}
 func (x *SemicolonStatement) String(maskParams bool) string {
 	if x.Expression != nil {
 		return x.Expression.String(maskParams)
@@ -119,6 +181,7 @@ func (x *SemicolonStatement) Inspect(maskParams bool) string {
 
 // Expressions
 type SemicolonExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.SEMICOLON token
 	Value      string          `json:"value,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`
@@ -132,6 +195,9 @@ func (x *SemicolonExpression) Command() token.TokenType     { return x.CommandTa
 func (x *SemicolonExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *SemicolonExpression) expressionNode()              {}
 func (x *SemicolonExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *SemicolonExpression) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *SemicolonExpression) String(maskParams bool) string {
 	if x.Cast != nil {
 		return fmt.Sprintf("%s::%s", x.Value, strings.ToUpper(x.Cast.String(maskParams)))
@@ -144,6 +210,7 @@ func (x *SemicolonExpression) SetCast(cast Expression) {
 }
 
 type SimpleIdentifier struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.IDENT token
 	Value      string          `json:"value,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`
@@ -157,6 +224,9 @@ func (x *SimpleIdentifier) Command() token.TokenType     { return x.CommandTag }
 func (x *SimpleIdentifier) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *SimpleIdentifier) expressionNode()              {}
 func (x *SimpleIdentifier) TokenLiteral() string         { return x.Token.Lit }
+func (x *SimpleIdentifier) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *SimpleIdentifier) String(maskParams bool) string {
 	if x.Cast != nil {
 		return fmt.Sprintf("%s::%s", x.Value, strings.ToUpper(x.Cast.String(maskParams)))
@@ -169,6 +239,7 @@ func (x *SimpleIdentifier) SetCast(cast Expression) {
 }
 
 type Identifier struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.IDENT token
 	Value      []Expression    `json:"value,omitempty"` // can have multiple values, e.g. schema.table.column
 	Cast       Expression      `json:"cast,omitempty"`
@@ -182,6 +253,9 @@ func (x *Identifier) Command() token.TokenType     { return x.CommandTag }
 func (x *Identifier) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *Identifier) expressionNode()              {}
 func (x *Identifier) TokenLiteral() string         { return x.Token.Lit }
+func (x *Identifier) Children() []Node {
+	return join(list(x.Value), nodes(x.Cast))
+}
 func (x *Identifier) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -207,6 +281,7 @@ func (x *Identifier) SetCast(cast Expression) {
 }
 
 type Boolean struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Value       bool            `json:"value,omitempty"`
 	Cast        Expression      `json:"cast,omitempty"`
@@ -221,6 +296,9 @@ func (x *Boolean) Command() token.TokenType     { return x.CommandTag }
 func (x *Boolean) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *Boolean) expressionNode()              {}
 func (x *Boolean) TokenLiteral() string         { return x.Token.Lit }
+func (x *Boolean) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *Boolean) String(maskParams bool) string {
 	literal := x.Token.Upper
 	if maskParams {
@@ -237,6 +315,7 @@ func (x *Boolean) SetCast(cast Expression) {
 }
 
 type Null struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Cast        Expression      `json:"cast,omitempty"`
 	ParamOffset int             `json:"param_offset,omitempty"`
@@ -250,6 +329,9 @@ func (x *Null) Command() token.TokenType     { return x.CommandTag }
 func (x *Null) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *Null) expressionNode()              {}
 func (x *Null) TokenLiteral() string         { return x.Token.Lit }
+func (x *Null) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *Null) String(maskParams bool) string {
 	literal := x.Token.Upper
 	if maskParams {
@@ -266,6 +348,7 @@ func (x *Null) SetCast(cast Expression) {
 }
 
 type Unknown struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Cast        Expression      `json:"cast,omitempty"`
 	ParamOffset int             `json:"param_offset,omitempty"`
@@ -279,6 +362,9 @@ func (x *Unknown) Command() token.TokenType     { return x.CommandTag }
 func (x *Unknown) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *Unknown) expressionNode()              {}
 func (x *Unknown) TokenLiteral() string         { return x.Token.Lit }
+func (x *Unknown) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *Unknown) String(maskParams bool) string {
 	literal := x.Token.Upper
 	if maskParams {
@@ -297,6 +383,7 @@ func (x *Unknown) SetCast(cast Expression) {
 // Infinity is used as the token for the hidden value after the colon in array expressions such as array[1:]
 // Infinity is not a true SQL type, and casts cannot be applied to it.
 type Infinity struct {
+	Span
 	Token      token.Token
 	Cast       Expression
 	Branch     token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
@@ -309,6 +396,9 @@ func (x *Infinity) Command() token.TokenType     { return x.CommandTag }
 func (x *Infinity) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *Infinity) expressionNode()              {}
 func (x *Infinity) TokenLiteral() string         { return "∞" }
+func (x *Infinity) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *Infinity) String(maskParams bool) string {
 	return ""
 }
@@ -317,6 +407,7 @@ func (x *Infinity) SetCast(cast Expression) {
 }
 
 type IntegerLiteral struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Value       int64           `json:"value,omitempty"`
 	Cast        Expression      `json:"cast,omitempty"`
@@ -331,6 +422,9 @@ func (x *IntegerLiteral) Command() token.TokenType     { return x.CommandTag }
 func (x *IntegerLiteral) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *IntegerLiteral) expressionNode()              {}
 func (x *IntegerLiteral) TokenLiteral() string         { return x.Token.Lit }
+func (x *IntegerLiteral) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *IntegerLiteral) String(maskParams bool) string {
 	literal := x.Token.Lit
 	if maskParams {
@@ -347,6 +441,7 @@ func (x *IntegerLiteral) SetCast(cast Expression) {
 }
 
 type ParamLiteral struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Cast        Expression      `json:"cast,omitempty"`
 	ParamOffset int             `json:"param_offset,omitempty"`
@@ -360,6 +455,9 @@ func (x *ParamLiteral) Command() token.TokenType     { return x.CommandTag }
 func (x *ParamLiteral) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *ParamLiteral) expressionNode()              {}
 func (x *ParamLiteral) TokenLiteral() string         { return x.Token.Lit }
+func (x *ParamLiteral) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *ParamLiteral) String(maskParams bool) string {
 	literal := x.Token.Lit
 	if maskParams {
@@ -376,6 +474,7 @@ func (x *ParamLiteral) SetCast(cast Expression) {
 }
 
 type FloatLiteral struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Value       float64         `json:"value,omitempty"`
 	Cast        Expression      `json:"cast,omitempty"`
@@ -390,6 +489,9 @@ func (x *FloatLiteral) Command() token.TokenType     { return x.CommandTag }
 func (x *FloatLiteral) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *FloatLiteral) expressionNode()              {}
 func (x *FloatLiteral) TokenLiteral() string         { return x.Token.Lit }
+func (x *FloatLiteral) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *FloatLiteral) String(maskParams bool) string {
 	literal := x.Token.Lit
 	if maskParams {
@@ -406,6 +508,7 @@ func (x *FloatLiteral) SetCast(cast Expression) {
 }
 
 type KeywordExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The keyword token, e.g. ALL
 	Cast       Expression      `json:"cast,omitempty"`
 	Branch     token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
@@ -418,6 +521,9 @@ func (x *KeywordExpression) Command() token.TokenType     { return x.CommandTag
 func (x *KeywordExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *KeywordExpression) expressionNode()              {}
 func (x *KeywordExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *KeywordExpression) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *KeywordExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(x.Token.Upper)
@@ -433,6 +539,7 @@ func (x *KeywordExpression) SetCast(cast Expression) {
 
 // Prefix Expressions are assumed to be unary operators such as -5 or !true
 type PrefixExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The prefix token, e.g. !
 	Operator   string          `json:"operator,omitempty"`
 	Right      Expression      `json:"right,omitempty"`
@@ -447,6 +554,9 @@ func (x *PrefixExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *PrefixExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *PrefixExpression) expressionNode()              {}
 func (x *PrefixExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *PrefixExpression) Children() []Node {
+	return nodes(x.Right, x.Cast)
+}
 func (x *PrefixExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -469,6 +579,7 @@ func (x *PrefixExpression) SetCast(cast Expression) {
 // some prefix keyword expressions, such as DISTINCT have special handling (i.e the keyword use of ON with DISTINCT)
 // so they have their own struct.
 type PrefixKeywordExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The prefix token, e.g. !
 	Operator   string          `json:"operator,omitempty"`
 	Right      Expression      `json:"right,omitempty"`
@@ -483,6 +594,9 @@ func (x *PrefixKeywordExpression) Command() token.TokenType     { return x.Comma
 func (x *PrefixKeywordExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *PrefixKeywordExpression) expressionNode()              {}
 func (x *PrefixKeywordExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *PrefixKeywordExpression) Children() []Node {
+	return nodes(x.Right, x.Cast)
+}
 func (x *PrefixKeywordExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -504,6 +618,7 @@ func (x *PrefixKeywordExpression) SetCast(cast Expression) {
 }
 
 type InfixExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The operator token, e.g. +
 	Left       Expression      `json:"left,omitempty"`
 	Not        bool            `json:"not,omitempty"` // prefix NOT to the operator
@@ -520,6 +635,9 @@ func (x *InfixExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *InfixExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *InfixExpression) expressionNode()              {}
 func (x *InfixExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *InfixExpression) Children() []Node {
+	return nodes(x.Left, x.Right, x.Cast)
+}
 func (x *InfixExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -546,6 +664,7 @@ func (x *InfixExpression) SetCast(cast Expression) {
 }
 
 type GroupedExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The '(' token
 	Elements   []Expression    `json:"elements,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`
@@ -559,6 +678,9 @@ func (x *GroupedExpression) Command() token.TokenType     { return x.CommandTag
 func (x *GroupedExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *GroupedExpression) expressionNode()              {}
 func (x *GroupedExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *GroupedExpression) Children() []Node {
+	return join(list(x.Elements), nodes(x.Cast))
+}
 func (x *GroupedExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -589,6 +711,7 @@ func (x *GroupedExpression) SetCast(cast Expression) {
 }
 
 type CallExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"`    // The '(' token
 	Distinct   Expression      `json:"distinct,omitempty"` // the DISTINCT or ALL token
 	Function   Expression      `json:"function,omitempty"` // Identifier or FunctionLiteral
@@ -604,6 +727,9 @@ func (x *CallExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *CallExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *CallExpression) expressionNode()              {}
 func (x *CallExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *CallExpression) Children() []Node {
+	return join(nodes(x.Distinct, x.Function), list(x.Arguments), nodes(x.Cast))
+}
 func (x *CallExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -635,6 +761,7 @@ func (x *CallExpression) SetCast(cast Expression) {
 }
 
 type StringLiteral struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Value       string          `json:"value,omitempty"`
 	Cast        Expression      `json:"cast,omitempty"`
@@ -649,6 +776,9 @@ func (x *StringLiteral) Command() token.TokenType     { return x.CommandTag }
 func (x *StringLiteral) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *StringLiteral) expressionNode()              {}
 func (x *StringLiteral) TokenLiteral() string         { return x.Token.Lit }
+func (x *StringLiteral) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *StringLiteral) String(maskParams bool) string {
 	literal := strings.Replace(x.Token.Lit, "'", "''", -1)
 	if maskParams {
@@ -665,6 +795,7 @@ func (x *StringLiteral) SetCast(cast Expression) {
 }
 
 type EscapeStringLiteral struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Value       string          `json:"value,omitempty"`
 	Cast        Expression      `json:"cast,omitempty"`
@@ -679,6 +810,9 @@ func (x *EscapeStringLiteral) Command() token.TokenType     { return x.CommandTa
 func (x *EscapeStringLiteral) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *EscapeStringLiteral) expressionNode()              {}
 func (x *EscapeStringLiteral) TokenLiteral() string         { return x.Token.Lit }
+func (x *EscapeStringLiteral) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *EscapeStringLiteral) String(maskParams bool) string {
 	literal := x.Token.Lit
 	if maskParams {
@@ -694,7 +828,43 @@ func (x *EscapeStringLiteral) SetCast(cast Expression) {
 	x.Cast = cast
 }
 
+// DollarStringLiteral is a dollar quoted string such as $$foobar$$ or $tag$foobar$tag$
+type DollarStringLiteral struct {
+	Span
+	Token       token.Token     `json:"token,omitempty"`
+	Tag         string          `json:"tag,omitempty"`   // the delimiter, i.e. $$ or $tag$
+	Value       string          `json:"value,omitempty"` // the string between the delimiters
+	Cast        Expression      `json:"cast,omitempty"`
+	ParamOffset int             `json:"param_offset,omitempty"`
+	Branch      token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
+	CommandTag  token.TokenType `json:"command,omitempty"`
+}
+
+func (x *DollarStringLiteral) Clause() token.TokenType      { return x.Branch }
+func (x *DollarStringLiteral) SetClause(c token.TokenType)  { x.Branch = c }
+func (x *DollarStringLiteral) Command() token.TokenType     { return x.CommandTag }
+func (x *DollarStringLiteral) SetCommand(c token.TokenType) { x.CommandTag = c }
+func (x *DollarStringLiteral) expressionNode()              {}
+func (x *DollarStringLiteral) TokenLiteral() string         { return x.Token.Lit }
+func (x *DollarStringLiteral) Children() []Node {
+	return nodes(x.Cast)
+}
+func (x *DollarStringLiteral) String(maskParams bool) string {
+	literal := x.Tag + x.Value + x.Tag
+	if maskParams {
+		literal = "?"
+	}
+	if x.Cast != nil {
+		return fmt.Sprintf("%s::%s", literal, strings.ToUpper(x.Cast.String(maskParams)))
+	}
+	return literal
+}
+func (x *DollarStringLiteral) SetCast(cast Expression) {
+	x.Cast = cast
+}
+
 type ArrayLiteral struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the '[' token
 	Left       Expression      `json:"left,omitempty"`
 	Elements   []Expression    `json:"elements,omitempty"`
@@ -709,6 +879,9 @@ func (x *ArrayLiteral) Command() token.TokenType     { return x.CommandTag }
 func (x *ArrayLiteral) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *ArrayLiteral) expressionNode()              {}
 func (x *ArrayLiteral) TokenLiteral() string         { return x.Token.Lit }
+func (x *ArrayLiteral) Children() []Node {
+	return join(nodes(x.Left), list(x.Elements), nodes(x.Cast))
+}
 func (x *ArrayLiteral) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -737,6 +910,7 @@ func (x *ArrayLiteral) SetCast(cast Expression) {
 }
 
 type IndexExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The [ token
 	Left       Expression      `json:"left,omitempty"`
 	Index      Expression      `json:"index,omitempty"`
@@ -751,6 +925,9 @@ func (x *IndexExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *IndexExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *IndexExpression) expressionNode()              {}
 func (x *IndexExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *IndexExpression) Children() []Node {
+	return nodes(x.Left, x.Index, x.Cast)
+}
 func (x *IndexExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -772,6 +949,7 @@ func (x *IndexExpression) SetCast(cast Expression) {
 }
 
 type IntervalExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The interval token
 	Value      Expression      `json:"value,omitempty"`
 	Unit       Expression      `json:"unit,omitempty"`
@@ -786,6 +964,9 @@ func (x *IntervalExpression) Command() token.TokenType     { return x.CommandTag
 func (x *IntervalExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *IntervalExpression) expressionNode()              {}
 func (x *IntervalExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *IntervalExpression) Children() []Node {
+	return nodes(x.Value, x.Unit, x.Cast)
+}
 func (x *IntervalExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -810,6 +991,7 @@ func (x *IntervalExpression) SetCast(cast Expression) {
 
 // For errors in the Resolver
 type IllegalExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.ILLEGAL token
 	Value      string          `json:"value,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`
@@ -823,6 +1005,9 @@ func (x *IllegalExpression) Command() token.TokenType     { return x.CommandTag
 func (x *IllegalExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *IllegalExpression) expressionNode()              {}
 func (x *IllegalExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *IllegalExpression) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *IllegalExpression) String(maskParams bool) string {
 	if x.Cast != nil {
 		return fmt.Sprintf("%s::%s", x.Value, strings.ToUpper(x.Cast.String(maskParams)))
@@ -8,6 +8,7 @@ import (
 )
 
 type CaseExpression struct {
+	Span
 	Token       token.Token            `json:"token,omitempty"` // the token.CASE token
 	Expression  Expression             `json:"expression,omitempty"`
 	Conditions  []*ConditionExpression `json:"conditions,omitempty"`
@@ -23,6 +24,9 @@ func (x *CaseExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *CaseExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *CaseExpression) expressionNode()              {}
 func (x *CaseExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *CaseExpression) Children() []Node {
+	return join(nodes(x.Expression), list(x.Conditions), nodes(x.Alternative, x.Cast))
+}
 func (x *CaseExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -55,6 +59,7 @@ func (x *CaseExpression) SetCast(cast Expression) {
 }
 
 type ConditionExpression struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"`
 	Condition   Expression      `json:"condition,omitempty"`
 	Consequence Expression      `json:"consequence,omitempty"`
@@ -69,6 +74,9 @@ func (x *ConditionExpression) Command() token.TokenType     { return x.CommandTa
 func (x *ConditionExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *ConditionExpression) expressionNode()              {}
 func (x *ConditionExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *ConditionExpression) Children() []Node {
+	return nodes(x.Condition, x.Consequence, x.Cast)
+}
 func (x *ConditionExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
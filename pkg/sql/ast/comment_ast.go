@@ -0,0 +1,114 @@
+package ast
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// Comment is a -- or /* */ comment. Comments don't change what a query does, so they're left out of String()
+// and the fingerprint, but they're kept on the Program for anything that needs them, such as query tags.
+type Comment struct {
+	Span
+	Token     token.Token `json:"token,omitempty"`     // the SQLCOMMENT token. Lit is the whole comment, including the -- or /* */
+	Statement int         `json:"statement,omitempty"` // the index in Program.Statements of the statement the comment belongs to, or -1 if there are none
+}
+
+func (c *Comment) Clause() token.TokenType      { return token.SQLCOMMENT }
+func (c *Comment) SetClause(t token.TokenType)  {}
+func (c *Comment) Command() token.TokenType     { return token.SQLCOMMENT }
+func (c *Comment) SetCommand(t token.TokenType) {}
+func (c *Comment) TokenLiteral() string         { return c.Token.Lit }
+func (c *Comment) Children() []Node             { return nil }
+func (c *Comment) String(maskParams bool) string {
+	return c.Token.Lit
+}
+
+// Text returns the comment without the -- or /* */ around it
+func (c *Comment) Text() string {
+	text := c.Token.Lit
+	if strings.HasPrefix(text, "--") {
+		return strings.TrimSpace(strings.TrimPrefix(text, "--"))
+	}
+	if strings.HasPrefix(text, "#") { // MySQL
+		return strings.TrimSpace(strings.TrimPrefix(text, "#"))
+	}
+	text = strings.TrimPrefix(text, "/*")
+	text = strings.TrimSuffix(text, "*/")
+	return strings.TrimSpace(text)
+}
+
+// Tags parses the key value pairs that sqlcommenter and Marginalia add to queries, such as
+// /*controller='users',action='show'*/ or /*application:blog,controller:posts*/.
+// It returns nil for a comment that isn't made up entirely of tags.
+func (c *Comment) Tags() map[string]string {
+	if !strings.HasPrefix(c.Token.Lit, "/*") {
+		return nil
+	}
+	text := c.Text()
+	if text == "" {
+		return nil
+	}
+
+	tags := make(map[string]string)
+	for _, pair := range splitTags(text) {
+		key, value, ok := parseTag(strings.TrimSpace(pair))
+		if !ok {
+			return nil
+		}
+		tags[key] = value
+	}
+
+	return tags
+}
+
+// splitTags splits on the commas that aren't inside of a quoted value
+func splitTags(text string) []string {
+	pairs := []string{}
+	quoted := false
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '\'':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				pairs = append(pairs, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(pairs, text[start:])
+}
+
+// parseTag parses key='value' from sqlcommenter, where the key and value are URL encoded,
+// or key:value from Marginalia
+func parseTag(pair string) (key, value string, ok bool) {
+	i := strings.IndexAny(pair, "=:")
+	if i <= 0 {
+		return "", "", false
+	}
+
+	key, value = pair[:i], pair[i+1:]
+	if strings.ContainsAny(key, " \t\r\n'") {
+		return "", "", false
+	}
+
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		value = strings.ReplaceAll(value[1:len(value)-1], `\'`, `'`)
+	} else if strings.ContainsAny(value, " \t\r\n'") {
+		return "", "", false
+	}
+
+	if unescaped, err := url.PathUnescape(key); err == nil {
+		key = unescaped
+	}
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		value = unescaped
+	}
+
+	return key, value, true
+}
@@ -0,0 +1,35 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentTags(t *testing.T) {
+	tests := []struct {
+		comment string
+		text    string
+		tags    map[string]string
+	}{
+		{"/*controller='users',action='show'*/", "controller='users',action='show'",
+			map[string]string{"controller": "users", "action": "show"}},
+		{"/* controller='users', route='%2Fusers%2F%3Aid' */", "controller='users', route='%2Fusers%2F%3Aid'",
+			map[string]string{"controller": "users", "route": "/users/:id"}},
+		{"/*application:BlogApp,controller:posts,action:index*/", "application:BlogApp,controller:posts,action:index",
+			map[string]string{"application": "BlogApp", "controller": "posts", "action": "index"}},
+		{`/*name='it\'s, here',traceparent='00-5bd66ef5095369c7b0d1f8f4bd33716a-c532cb4098ac3dd2-01'*/`, `name='it\'s, here',traceparent='00-5bd66ef5095369c7b0d1f8f4bd33716a-c532cb4098ac3dd2-01'`,
+			map[string]string{"name": "it's, here", "traceparent": "00-5bd66ef5095369c7b0d1f8f4bd33716a-c532cb4098ac3dd2-01"}},
+		{"/* find the users */", "find the users", nil},
+		{"/* note: this is slow */", "note: this is slow", nil},
+		{"/**/", "", nil},
+		{"-- controller='users'", "controller='users'", nil}, // sqlcommenter only uses block comments
+	}
+
+	for _, tt := range tests {
+		c := &Comment{Token: token.Token{Type: token.SQLCOMMENT, Lit: tt.comment}}
+		assert.Equal(t, tt.text, c.Text(), "comment: %s", tt.comment)
+		assert.Equal(t, tt.tags, c.Tags(), "comment: %s", tt.comment)
+	}
+}
@@ -9,22 +9,25 @@ import (
 	"github.com/brianbroderick/lantern/pkg/sql/token"
 )
 
-// Currently we're only handling CREATE TABLE AS & CREATE INDEX, but we'll need to handle CREATE TABLE, CREATE TRIGGER, etc.
+// Currently we're handling CREATE TABLE, CREATE TABLE AS & CREATE INDEX, but we'll need to handle CREATE TRIGGER, etc.
 
 type CreateStatement struct {
-	Token        token.Token `json:"token,omitempty"`        // the token.CREATE token
-	Scope        string      `json:"scope,omitempty"`        // GLOBAL or LOCAL
-	Unique       bool        `json:"unique,omitempty"`       // UNIQUE
-	Concurrently bool        `json:"concurrently,omitempty"` // CONCURRENTLY
-	Temp         bool        `json:"temp,omitempty"`         // TEMP or TEMPORARY (same thing)
-	Unlogged     bool        `json:"unlogged,omitempty"`     // UNLOGGED
-	Object       token.Token `json:"object,omitempty"`       // TABLE, INDEX, VIEW, etc.
-	Exists       bool        `json:"exists,omitempty"`       // IF NOT EXISTS
-	Name         Expression  `json:"name,omitempty"`         // the name of the object
-	OnCommit     string      `json:"on_commit,omitempty"`    // PRESERVE ROWS, DELETE ROWS, DROP
-	Operator     string      `json:"operator,omitempty"`     // AS (for CREATE TABLE AS), ON for CREATE INDEX ON, etc.
-	Expression   Expression  `json:"expression,omitempty"`   // the expression to create the object
-	Where        Expression  `json:"where,omitempty"`        // the where clause for the object
+	Span
+	Token        token.Token         `json:"token,omitempty"`        // the token.CREATE token
+	Scope        string              `json:"scope,omitempty"`        // GLOBAL or LOCAL
+	Unique       bool                `json:"unique,omitempty"`       // UNIQUE
+	Concurrently bool                `json:"concurrently,omitempty"` // CONCURRENTLY
+	Temp         bool                `json:"temp,omitempty"`         // TEMP or TEMPORARY (same thing)
+	Unlogged     bool                `json:"unlogged,omitempty"`     // UNLOGGED
+	Object       token.Token         `json:"object,omitempty"`       // TABLE, INDEX, VIEW, etc.
+	Exists       bool                `json:"exists,omitempty"`       // IF NOT EXISTS
+	Name         Expression          `json:"name,omitempty"`         // the name of the object
+	OnCommit     string              `json:"on_commit,omitempty"`    // PRESERVE ROWS, DELETE ROWS, DROP
+	Operator     string              `json:"operator,omitempty"`     // AS (for CREATE TABLE AS), ON for CREATE INDEX ON, etc.
+	Expression   Expression          `json:"expression,omitempty"`   // the expression to create the object
+	Where        Expression          `json:"where,omitempty"`        // the where clause for the object
+	Columns      []*ColumnDefinition `json:"columns,omitempty"`      // the columns of CREATE TABLE name (...)
+	Constraints  []string            `json:"constraints,omitempty"`  // the table constraints, such as PRIMARY KEY (id), as written
 }
 
 func (x *CreateStatement) Clause() token.TokenType      { return x.Token.Type }
@@ -33,6 +36,9 @@ func (x *CreateStatement) Command() token.TokenType     { return x.Token.Type }
 func (x *CreateStatement) SetCommand(c token.TokenType) {}
 func (x *CreateStatement) statementNode()               {}
 func (x *CreateStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *CreateStatement) Children() []Node {
+	return join(nodes(x.Name), list(x.Columns), nodes(x.Expression, x.Where))
+}
 func (x *CreateStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString("CREATE")
@@ -60,6 +66,20 @@ func (x *CreateStatement) String(maskParams bool) string {
 	if x.Name != nil {
 		out.WriteString(" " + x.Name.String(maskParams))
 	}
+	if len(x.Columns) > 0 || len(x.Constraints) > 0 {
+		elements := []string{}
+		for _, c := range x.Columns {
+			elements = append(elements, c.String(maskParams))
+		}
+		elements = append(elements, x.Constraints...)
+		if x.untypedColumns() {
+			// CREATE TABLE AS names its columns without types, and they're printed against the name, as they were
+			// when the list was parsed as a call, so fingerprints don't change
+			out.WriteString("(" + strings.Join(elements, ", ") + ")")
+		} else {
+			out.WriteString(" (" + strings.Join(elements, ", ") + ")")
+		}
+	}
 	if x.OnCommit != "" {
 		out.WriteString(" ON COMMIT " + strings.ToUpper(x.OnCommit))
 	}
@@ -77,6 +97,19 @@ func (x *CreateStatement) String(maskParams bool) string {
 	return out.String()
 }
 
+// untypedColumns is true when the columns only have names, as in CREATE TABLE name (a, b) AS
+func (x *CreateStatement) untypedColumns() bool {
+	if len(x.Constraints) > 0 {
+		return false
+	}
+	for _, c := range x.Columns {
+		if c.DataType != "" || c.Constraints != "" {
+			return false
+		}
+	}
+	return true
+}
+
 func (x *CreateStatement) Inspect(maskParams bool) string {
 	j, err := json.MarshalIndent(x, "", "  ")
 	if err != nil {
@@ -86,6 +119,7 @@ func (x *CreateStatement) Inspect(maskParams bool) string {
 }
 
 type LikeExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.LIKE token
 	Table      Expression      `json:"table,omitempty"`
 	Options    []string        `json:"options,omitempty"`
@@ -100,6 +134,9 @@ func (x *LikeExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *LikeExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *LikeExpression) expressionNode()              {}
 func (x *LikeExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *LikeExpression) Children() []Node {
+	return nodes(x.Table, x.Cast)
+}
 func (x *LikeExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString("(LIKE ")
@@ -116,3 +153,37 @@ func (x *LikeExpression) String(maskParams bool) string {
 func (x *LikeExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
+
+// ColumnDefinition is a column in CREATE TABLE. Its type and constraints are kept as they were written,
+// i.e. varchar(20) and NOT NULL DEFAULT ”.
+type ColumnDefinition struct {
+	Span
+	Token       token.Token       `json:"token,omitempty"` // the token of the column's name
+	Name        *SimpleIdentifier `json:"name,omitempty"`
+	DataType    string            `json:"data_type,omitempty"`
+	Constraints string            `json:"constraints,omitempty"`
+	Branch      token.TokenType   `json:"clause,omitempty"` // location in the tree representing a clause
+	CommandTag  token.TokenType   `json:"command,omitempty"`
+}
+
+func (x *ColumnDefinition) Clause() token.TokenType      { return x.Branch }
+func (x *ColumnDefinition) SetClause(c token.TokenType)  { x.Branch = c }
+func (x *ColumnDefinition) Command() token.TokenType     { return x.CommandTag }
+func (x *ColumnDefinition) SetCommand(c token.TokenType) { x.CommandTag = c }
+func (x *ColumnDefinition) expressionNode()              {}
+func (x *ColumnDefinition) TokenLiteral() string         { return x.Token.Lit }
+func (x *ColumnDefinition) SetCast(cast Expression)      {}
+func (x *ColumnDefinition) Children() []Node {
+	return nodes(x.Name)
+}
+func (x *ColumnDefinition) String(maskParams bool) string {
+	var out bytes.Buffer
+	out.WriteString(x.Name.String(maskParams))
+	if x.DataType != "" {
+		out.WriteString(" " + x.DataType)
+	}
+	if x.Constraints != "" {
+		out.WriteString(" " + x.Constraints)
+	}
+	return out.String()
+}
@@ -14,6 +14,7 @@ import (
 // with tmp as (select count(1) as counter from orders) select counter from tmp;
 
 type CTEStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"`
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -24,6 +25,9 @@ func (s *CTEStatement) Command() token.TokenType     { return s.Token.Type }
 func (x *CTEStatement) SetCommand(c token.TokenType) {}
 func (s *CTEStatement) statementNode()               {}
 func (s *CTEStatement) TokenLiteral() string         { return s.Token.Lit }
+func (s *CTEStatement) Children() []Node {
+	return nodes(s.Expression)
+}
 func (s *CTEStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(s.Expression.String(maskParams))
@@ -39,6 +43,7 @@ func (s *CTEStatement) Inspect(maskParams bool) string {
 }
 
 type CTEExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"`
 	Recursive  bool            `json:"recursive,omitempty"`
 	Auxiliary  []Expression    `json:"auxiliary,omitempty"`
@@ -54,6 +59,9 @@ func (x *CTEExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *CTEExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *CTEExpression) expressionNode()              {}
 func (x *CTEExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *CTEExpression) Children() []Node {
+	return join(list(x.Auxiliary), nodes(x.Primary, x.Cast))
+}
 func (x *CTEExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -87,6 +95,7 @@ func (x *CTEExpression) SetCast(cast Expression) {
 }
 
 type CTEAuxiliaryExpression struct {
+	Span
 	Token        token.Token     `json:"token,omitempty"`
 	Name         Expression      `json:"name,omitempty"`
 	Materialized string          `json:"materialized,omitempty"`
@@ -102,6 +111,9 @@ func (x *CTEAuxiliaryExpression) Command() token.TokenType     { return x.Comman
 func (x *CTEAuxiliaryExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *CTEAuxiliaryExpression) expressionNode()              {}
 func (x *CTEAuxiliaryExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *CTEAuxiliaryExpression) Children() []Node {
+	return nodes(x.Name, x.Expression, x.Cast)
+}
 func (x *CTEAuxiliaryExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(x.Name.String(maskParams))
@@ -9,6 +9,7 @@ import (
 )
 
 type DeleteStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.DELETE token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -19,6 +20,9 @@ func (s *DeleteStatement) Command() token.TokenType     { return s.Token.Type }
 func (x *DeleteStatement) SetCommand(c token.TokenType) {}
 func (s *DeleteStatement) statementNode()               {}
 func (s *DeleteStatement) TokenLiteral() string         { return s.Token.Upper }
+func (s *DeleteStatement) Children() []Node {
+	return nodes(s.Expression)
+}
 func (s *DeleteStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(s.Expression.String(maskParams))
@@ -34,6 +38,7 @@ func (s *DeleteStatement) Inspect(maskParams bool) string {
 }
 
 type DeleteExpression struct {
+	Span
 	Token        token.Token       `json:"token,omitempty"` // the token.DELETE token
 	Only         bool              `json:"only,omitempty"`
 	Table        Expression        `json:"table,omitempty"`
@@ -58,6 +63,10 @@ func (x *DeleteExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
 
+func (x *DeleteExpression) Children() []Node {
+	return join(nodes(x.Table, x.Alias), list(x.Using), nodes(x.Cursor, x.Where),
+		list(x.Returning), nodes(x.Cast))
+}
 func (x *DeleteExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString("(DELETE FROM ")
@@ -10,6 +10,7 @@ import (
 )
 
 type DropStatement struct {
+	Span
 	Token   token.Token  `json:"token,omitempty"` // the token.DROP token
 	Object  token.Token  `json:"object,omitempty"`
 	Exists  bool         `json:"exists,omitempty"`
@@ -23,6 +24,9 @@ func (x *DropStatement) Command() token.TokenType     { return x.Token.Type }
 func (x *DropStatement) SetCommand(c token.TokenType) {}
 func (x *DropStatement) statementNode()               {}
 func (x *DropStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *DropStatement) Children() []Node {
+	return list(x.Tables)
+}
 func (x *DropStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -0,0 +1,248 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// This file contains the AST for routines: CREATE FUNCTION, CREATE PROCEDURE, CREATE TRIGGER, DO, and CALL
+
+type CreateFunctionStatement struct {
+	Span
+	Token      token.Token          `json:"token,omitempty"`       // the token.CREATE token
+	OrReplace  bool                 `json:"or_replace,omitempty"`  // OR REPLACE
+	Object     string               `json:"object,omitempty"`      // FUNCTION or PROCEDURE
+	Name       Expression           `json:"name,omitempty"`        // the name of the routine
+	Parameters []*FunctionParameter `json:"parameters,omitempty"`  // the argument list
+	Returns    string               `json:"returns,omitempty"`     // the return type, i.e. integer, SETOF users, TABLE(id int)
+	Language   string               `json:"language,omitempty"`    // sql, plpgsql, c, etc.
+	Options    []string             `json:"options,omitempty"`     // IMMUTABLE, STRICT, SECURITY DEFINER, COST 100, etc.
+	Body       Expression           `json:"body,omitempty"`        // the string or dollar quoted string after AS
+	LinkSymbol Expression           `json:"link_symbol,omitempty"` // AS 'obj_file', 'link_symbol' for C functions
+	Program    *Program             `json:"program,omitempty"`     // the parsed body for sql and plpgsql routines
+}
+
+func (x *CreateFunctionStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *CreateFunctionStatement) SetClause(c token.TokenType)  {}
+func (x *CreateFunctionStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *CreateFunctionStatement) SetCommand(c token.TokenType) {}
+func (x *CreateFunctionStatement) statementNode()               {}
+func (x *CreateFunctionStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *CreateFunctionStatement) Children() []Node {
+	return join(nodes(x.Name), parameterDefaults(x.Parameters), nodes(x.Body, x.LinkSymbol, x.Program))
+}
+func (x *CreateFunctionStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("CREATE ")
+	if x.OrReplace {
+		out.WriteString("OR REPLACE ")
+	}
+	out.WriteString(x.Object + " ")
+	out.WriteString(x.Name.String(maskParams))
+
+	params := []string{}
+	for _, p := range x.Parameters {
+		params = append(params, p.String(maskParams))
+	}
+	out.WriteString("(" + strings.Join(params, ", ") + ")")
+
+	if x.Returns != "" {
+		out.WriteString(" RETURNS " + x.Returns)
+	}
+	if x.Language != "" {
+		out.WriteString(" LANGUAGE " + x.Language)
+	}
+	for _, o := range x.Options {
+		out.WriteString(" " + o)
+	}
+
+	// The body is code rather than data, so it's never masked
+	if x.Body != nil {
+		out.WriteString(" AS " + x.Body.String(false))
+	}
+	if x.LinkSymbol != nil {
+		out.WriteString(", " + x.LinkSymbol.String(false))
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *CreateFunctionStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+// FunctionParameter is a single argument in a CREATE FUNCTION or CREATE PROCEDURE statement
+// [ IN | OUT | INOUT | VARIADIC ] [ name ] type [ { DEFAULT | = } default_expr ]
+type FunctionParameter struct {
+	Mode    string     `json:"mode,omitempty"`
+	Name    string     `json:"name,omitempty"`
+	Type    string     `json:"type,omitempty"`
+	Default Expression `json:"default,omitempty"`
+}
+
+// parameterDefaults returns the default values of the parameters. FunctionParameter isn't a node, so these belong to the statement.
+func parameterDefaults(params []*FunctionParameter) []Node {
+	defaults := []Node{}
+	for _, param := range params {
+		defaults = append(defaults, nodes(param.Default)...)
+	}
+	return defaults
+}
+
+func (x *FunctionParameter) String(maskParams bool) string {
+	words := []string{}
+	if x.Mode != "" {
+		words = append(words, x.Mode)
+	}
+	if x.Name != "" {
+		words = append(words, x.Name)
+	}
+	words = append(words, x.Type)
+	if x.Default != nil {
+		words = append(words, "DEFAULT", x.Default.String(maskParams))
+	}
+	return strings.Join(words, " ")
+}
+
+type CreateTriggerStatement struct {
+	Span
+	Token       token.Token `json:"token,omitempty"`        // the token.CREATE token
+	OrReplace   bool        `json:"or_replace,omitempty"`   // OR REPLACE
+	Constraint  bool        `json:"constraint,omitempty"`   // CONSTRAINT
+	Name        Expression  `json:"name,omitempty"`         // the name of the trigger
+	Timing      string      `json:"timing,omitempty"`       // BEFORE, AFTER, INSTEAD OF
+	Events      []string    `json:"events,omitempty"`       // INSERT, UPDATE OF col, DELETE, TRUNCATE
+	Table       Expression  `json:"table,omitempty"`        // the table the trigger is on
+	Options     string      `json:"options,omitempty"`      // FROM, DEFERRABLE, REFERENCING, etc.
+	ForEach     string      `json:"for_each,omitempty"`     // ROW or STATEMENT
+	When        Expression  `json:"when,omitempty"`         // WHEN ( condition )
+	ExecuteType string      `json:"execute_type,omitempty"` // FUNCTION or PROCEDURE
+	Function    Expression  `json:"function,omitempty"`     // the function call that the trigger executes
+}
+
+func (x *CreateTriggerStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *CreateTriggerStatement) SetClause(c token.TokenType)  {}
+func (x *CreateTriggerStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *CreateTriggerStatement) SetCommand(c token.TokenType) {}
+func (x *CreateTriggerStatement) statementNode()               {}
+func (x *CreateTriggerStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *CreateTriggerStatement) Children() []Node {
+	return nodes(x.Name, x.Table, x.When, x.Function)
+}
+func (x *CreateTriggerStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("CREATE ")
+	if x.OrReplace {
+		out.WriteString("OR REPLACE ")
+	}
+	if x.Constraint {
+		out.WriteString("CONSTRAINT ")
+	}
+	out.WriteString("TRIGGER " + x.Name.String(maskParams))
+	out.WriteString(" " + x.Timing + " " + strings.Join(x.Events, " OR "))
+	out.WriteString(" ON " + x.Table.String(maskParams))
+	if x.Options != "" {
+		out.WriteString(" " + x.Options)
+	}
+	if x.ForEach != "" {
+		out.WriteString(" FOR EACH " + x.ForEach)
+	}
+	if x.When != nil {
+		out.WriteString(" WHEN " + x.When.String(maskParams))
+	}
+	out.WriteString(" EXECUTE " + x.ExecuteType + " " + x.Function.String(maskParams))
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *CreateTriggerStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type DoStatement struct {
+	Span
+	Token    token.Token `json:"token,omitempty"`    // the token.DO token
+	Language string      `json:"language,omitempty"` // defaults to plpgsql when not specified
+	Body     Expression  `json:"body,omitempty"`     // the string or dollar quoted string
+	Program  *Program    `json:"program,omitempty"`  // the parsed body
+}
+
+func (x *DoStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *DoStatement) SetClause(c token.TokenType)  {}
+func (x *DoStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *DoStatement) SetCommand(c token.TokenType) {}
+func (x *DoStatement) statementNode()               {}
+func (x *DoStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *DoStatement) Children() []Node {
+	return nodes(x.Body, x.Program)
+}
+func (x *DoStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("DO ")
+	if x.Language != "" {
+		out.WriteString("LANGUAGE " + x.Language + " ")
+	}
+	// The body is code rather than data, so it's never masked
+	out.WriteString(x.Body.String(false))
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *DoStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type CallStatement struct {
+	Span
+	Token      token.Token `json:"token,omitempty"`      // the token.CALL_STATEMENT token
+	Expression Expression  `json:"expression,omitempty"` // the procedure call
+}
+
+func (x *CallStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *CallStatement) SetClause(c token.TokenType)  {}
+func (x *CallStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *CallStatement) SetCommand(c token.TokenType) {}
+func (x *CallStatement) statementNode()               {}
+func (x *CallStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *CallStatement) Children() []Node {
+	return nodes(x.Expression)
+}
+func (x *CallStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("CALL ")
+	out.WriteString(x.Expression.String(maskParams))
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *CallStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
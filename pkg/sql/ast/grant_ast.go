@@ -0,0 +1,90 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// GrantStatement handles both GRANT and REVOKE since they share the same shape.
+// The token type is either token.GRANT or token.REVOKE_STATEMENT
+type GrantStatement struct {
+	Span
+	Token          token.Token  `json:"token,omitempty"`            // the token.GRANT or token.REVOKE_STATEMENT token
+	GrantOptionFor bool         `json:"grant_option_for,omitempty"` // REVOKE GRANT OPTION FOR
+	Privileges     []string     `json:"privileges,omitempty"`       // SELECT, INSERT, UPDATE (col), ALL PRIVILEGES, or role names
+	ObjectType     string       `json:"object_type,omitempty"`      // TABLE, SEQUENCE, SCHEMA, ALL TABLES IN SCHEMA, etc.
+	Objects        []Expression `json:"objects,omitempty"`          // the objects the privileges apply to
+	Grantees       []Expression `json:"grantees,omitempty"`         // the roles receiving or losing the privileges
+	WithOption     string       `json:"with_option,omitempty"`      // WITH GRANT OPTION, WITH ADMIN OPTION
+	Options        string       `json:"options,omitempty"`          // CASCADE or RESTRICT
+}
+
+func (x *GrantStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *GrantStatement) SetClause(c token.TokenType)  {}
+func (x *GrantStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *GrantStatement) SetCommand(c token.TokenType) {}
+func (x *GrantStatement) statementNode()               {}
+func (x *GrantStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *GrantStatement) Children() []Node {
+	return join(list(x.Objects), list(x.Grantees))
+}
+func (x *GrantStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	if x.IsRevoke() {
+		out.WriteString("REVOKE ")
+		if x.GrantOptionFor {
+			out.WriteString("GRANT OPTION FOR ")
+		}
+	} else {
+		out.WriteString("GRANT ")
+	}
+	out.WriteString(strings.Join(x.Privileges, ", "))
+
+	if len(x.Objects) > 0 {
+		out.WriteString(" ON ")
+		if x.ObjectType != "" {
+			out.WriteString(x.ObjectType + " ")
+		}
+		out.WriteString(expressionList(x.Objects, maskParams))
+	}
+
+	if x.IsRevoke() {
+		out.WriteString(" FROM ")
+	} else {
+		out.WriteString(" TO ")
+	}
+	out.WriteString(expressionList(x.Grantees, maskParams))
+
+	if x.WithOption != "" {
+		out.WriteString(" " + x.WithOption)
+	}
+	if x.Options != "" {
+		out.WriteString(" " + x.Options)
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *GrantStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+// IsRevoke returns true when the statement removes privileges
+func (x *GrantStatement) IsRevoke() bool {
+	return x.Token.Type == token.REVOKE_STATEMENT
+}
+
+// OnTables returns true when the objects are tables, which is the default when no object type is given
+func (x *GrantStatement) OnTables() bool {
+	return x.ObjectType == "" || x.ObjectType == "TABLE"
+}
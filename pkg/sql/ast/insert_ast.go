@@ -9,6 +9,7 @@ import (
 )
 
 type InsertStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.INSERT token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -19,6 +20,9 @@ func (s *InsertStatement) Command() token.TokenType     { return s.Token.Type }
 func (x *InsertStatement) SetCommand(c token.TokenType) {}
 func (s *InsertStatement) statementNode()               {}
 func (s *InsertStatement) TokenLiteral() string         { return s.Token.Upper }
+func (s *InsertStatement) Children() []Node {
+	return nodes(s.Expression)
+}
 func (s *InsertStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(s.Expression.String(maskParams))
@@ -36,22 +40,24 @@ func (s *InsertStatement) Inspect(maskParams bool) string {
 }
 
 type InsertExpression struct {
-	Token          token.Token     `json:"token,omitempty"` // the token.INSERT token
-	Table          Expression      `json:"table,omitempty"`
-	Alias          Expression      `json:"alias,omitempty"`
-	Columns        []Expression    `json:"columns,omitempty"`
-	Overriding     string          `json:"overriding,omitempty"`
-	Default        bool            `json:"default,omitempty"`
-	Values         [][]Expression  `json:"values,omitempty"`
-	Query          Expression      `json:"query,omitempty"`
-	ConflictTarget []Expression    `json:"conflict_target,omitempty"`
-	ConflictAction string          `json:"conflict_action,omitempty"`
-	ConflictUpdate []Expression    `json:"conflict_update,omitempty"`
-	ConflictWhere  Expression      `json:"conflict_where,omitempty"`
-	Returning      []Expression    `json:"returning,omitempty"`
-	Cast           Expression      `json:"cast,omitempty"`
-	Branch         token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
-	CommandTag     token.TokenType `json:"command,omitempty"`
+	Span
+	Token           token.Token     `json:"token,omitempty"` // the token.INSERT token
+	Table           Expression      `json:"table,omitempty"`
+	Alias           Expression      `json:"alias,omitempty"`
+	Columns         []Expression    `json:"columns,omitempty"`
+	Overriding      string          `json:"overriding,omitempty"`
+	Default         bool            `json:"default,omitempty"`
+	Values          [][]Expression  `json:"values,omitempty"`
+	Query           Expression      `json:"query,omitempty"`
+	ConflictTarget  []Expression    `json:"conflict_target,omitempty"`
+	ConflictAction  string          `json:"conflict_action,omitempty"`
+	ConflictUpdate  []Expression    `json:"conflict_update,omitempty"`
+	ConflictWhere   Expression      `json:"conflict_where,omitempty"`
+	DuplicateUpdate []Expression    `json:"duplicate_update,omitempty"` // MySQL's ON DUPLICATE KEY UPDATE
+	Returning       []Expression    `json:"returning,omitempty"`
+	Cast            Expression      `json:"cast,omitempty"`
+	Branch          token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
+	CommandTag      token.TokenType `json:"command,omitempty"`
 }
 
 func (x *InsertExpression) Clause() token.TokenType      { return x.Branch }
@@ -65,6 +71,11 @@ func (x *InsertExpression) SetCast(cast Expression) {
 }
 
 // String() is incomplete and only returns the most basic of select statements
+func (x *InsertExpression) Children() []Node {
+	return join(nodes(x.Table, x.Alias), list(x.Columns), table(x.Values), nodes(x.Query),
+		list(x.ConflictTarget), list(x.ConflictUpdate), nodes(x.ConflictWhere),
+		list(x.DuplicateUpdate), list(x.Returning), nodes(x.Cast))
+}
 func (x *InsertExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString("(INSERT INTO ")
@@ -149,6 +160,15 @@ func (x *InsertExpression) String(maskParams bool) string {
 			out.WriteString(x.ConflictWhere.String(maskParams))
 		}
 	}
+	if len(x.DuplicateUpdate) > 0 {
+		out.WriteString(" ON DUPLICATE KEY UPDATE ")
+		for i, c := range x.DuplicateUpdate {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			out.WriteString(c.String(maskParams))
+		}
+	}
 	if len(x.Returning) > 0 {
 		out.WriteString(" RETURNING ")
 		for i, c := range x.Returning {
@@ -0,0 +1,479 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// The JSON encoding of the AST
+//
+// Every node is an object whose "node" key names its Go type, such as "SelectExpression", followed by "pos" and "end"
+// when the node has a span, and then its fields under their json tags. Fields with zero values are left out.
+// Since each node names its type, a tree can be decoded without knowing its shape ahead of time.
+//
+// Tokens are objects with a "type", "literal", "pos", and "end". Token types, including the clause and command
+// of a node, are their names, such as "SELECT", rather than numbers, which change whenever a token is added.
+// Structs that aren't nodes, such as a WindowFrame, are objects of their fields without a "node" key.
+//
+// The Program object has a "version", which is JSONVersion when it was encoded.
+
+// JSONVersion is incremented when the encoding of an existing node changes in a way that older decoders can't read.
+// Adding a node or a field doesn't change the version.
+const JSONVersion = 1
+
+// nodeTypes are the nodes that can be decoded, by name
+var nodeTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, n := range []Node{
+		&Program{}, &Comment{},
+
+		// Statements
+		&AnalyzeStatement{}, &BeginStatement{}, &CallStatement{}, &CloseStatement{}, &ClusterStatement{},
+		&CommitStatement{}, &CreateFunctionStatement{}, &CreateStatement{}, &CreateTriggerStatement{},
+		&CTEStatement{}, &DeallocateStatement{}, &DeclareCursorStatement{}, &DeleteStatement{}, &DoStatement{},
+		&DropStatement{}, &ExecuteStatement{}, &ExpressionStatement{}, &FetchStatement{}, &GrantStatement{},
+		&InsertStatement{}, &ListenStatement{}, &NotifyStatement{}, &PrepareStatement{}, &RefreshStatement{},
+		&ReindexStatement{}, &RollbackStatement{}, &SavepointStatement{}, &SelectStatement{},
+		&SemicolonStatement{}, &SetStatement{}, &ShowStatement{}, &TruncateStatement{}, &UpdateStatement{},
+		&VacuumStatement{},
+
+		// Expressions
+		&AggregateExpression{}, &ArrayLiteral{}, &BeginExpression{}, &Boolean{}, &CallExpression{},
+		&CaseExpression{}, &CastExpression{}, &ColumnDefinition{}, &ColumnExpression{}, &CommitExpression{}, &ConditionExpression{},
+		&CTEAuxiliaryExpression{}, &CTEExpression{}, &DeleteExpression{}, &DistinctExpression{},
+		&DollarStringLiteral{}, &EscapeStringLiteral{}, &FetchExpression{}, &FloatLiteral{},
+		&GroupedExpression{}, &GroupingSetExpression{}, &Identifier{}, &IllegalExpression{}, &IndexExpression{},
+		&InExpression{}, &Infinity{}, &InfixExpression{}, &InsertExpression{}, &IntegerLiteral{},
+		&IntervalExpression{}, &IsExpression{}, &KeywordExpression{}, &LikeExpression{}, &LockExpression{},
+		&Null{}, &ParamLiteral{}, &PrefixExpression{}, &PrefixKeywordExpression{}, &RollbackExpression{},
+		&SelectExpression{}, &SemicolonExpression{}, &ShowExpression{}, &SimpleIdentifier{}, &SortExpression{},
+		&StringFunctionExpression{}, &StringLiteral{}, &TableExpression{}, &TimestampExpression{},
+		&TransactionExpression{}, &TrimExpression{}, &UnionExpression{}, &Unknown{}, &UpdateExpression{},
+		&ValuesExpression{}, &WhereExpression{}, &WildcardLiteral{}, &WindowExpression{},
+	} {
+		t := reflect.TypeOf(n).Elem()
+		nodeTypes[t.Name()] = t
+	}
+}
+
+var (
+	nodeInterface = reflect.TypeOf((*Node)(nil)).Elem()
+	tokenStruct   = reflect.TypeOf(token.Token{})
+	tokenTypeType = reflect.TypeOf(token.TokenType(0))
+)
+
+// Marshal encodes a node and everything below it
+func Marshal(n Node) ([]byte, error) {
+	e := &encoder{}
+	if err := e.node(n); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+// MarshalIndent is like Marshal, but indents the output
+func MarshalIndent(n Node, prefix, indent string) ([]byte, error) {
+	data, err := Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, prefix, indent); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Unmarshal decodes a node that was encoded by Marshal
+func Unmarshal(data []byte) (Node, error) {
+	return decodeNode(data)
+}
+
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return Marshal(p)
+}
+
+func (p *Program) UnmarshalJSON(data []byte) error {
+	n, err := Unmarshal(data)
+	if err != nil {
+		return err
+	}
+
+	program, ok := n.(*Program)
+	if !ok {
+		return fmt.Errorf("ast: expected a Program, got %T", n)
+	}
+
+	*p = *program
+	return nil
+}
+
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) node(n Node) error {
+	if isNil(n) {
+		e.WriteString("null")
+		return nil
+	}
+
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ast: can't encode %T", n)
+	}
+
+	e.WriteString(`{"node":`)
+	e.json(v.Elem().Type().Name())
+	if _, ok := n.(*Program); ok {
+		e.WriteString(`,"version":`)
+		e.json(JSONVersion)
+	}
+	e.pos(n.Pos(), n.End())
+
+	if err := e.fields(v.Elem(), false); err != nil {
+		return err
+	}
+	e.WriteString("}")
+	return nil
+}
+
+func (e *encoder) pos(start, end token.Pos) {
+	if !start.IsValid() {
+		return
+	}
+	e.WriteString(`,"pos":`)
+	e.json(start)
+	e.WriteString(`,"end":`)
+	e.json(end)
+}
+
+// fields writes the fields of a struct. If first is true, the struct's object doesn't have any keys yet.
+func (e *encoder) fields(v reflect.Value, first bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := fieldName(f)
+		if name == "" || v.Field(i).IsZero() {
+			continue
+		}
+
+		if !first {
+			e.WriteString(",")
+		}
+		first = false
+
+		e.json(name)
+		e.WriteString(":")
+		if err := e.value(v.Field(i)); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (e *encoder) value(v reflect.Value) error {
+	switch {
+	case v.Type() == tokenStruct:
+		e.token(v.Interface().(token.Token))
+		return nil
+	case v.Type() == tokenTypeType:
+		e.json(v.Interface().(token.TokenType).String())
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		n, ok := v.Interface().(Node)
+		if !ok {
+			return fmt.Errorf("ast: %T isn't a node", v.Interface())
+		}
+		return e.node(n)
+	case reflect.Pointer:
+		switch {
+		case v.IsNil():
+			e.WriteString("null")
+			return nil
+		case v.Type().Implements(nodeInterface):
+			return e.node(v.Interface().(Node))
+		case v.Elem().Kind() == reflect.Struct:
+			e.WriteString("{")
+			if err := e.fields(v.Elem(), true); err != nil {
+				return err
+			}
+			e.WriteString("}")
+			return nil
+		}
+		return e.value(v.Elem())
+	case reflect.Slice:
+		e.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				e.WriteString(",")
+			}
+			if err := e.value(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		e.WriteString("]")
+		return nil
+	}
+
+	return e.json(v.Interface())
+}
+
+// token writes a token. Upper is only written when it isn't the upper case of the literal.
+func (e *encoder) token(tok token.Token) {
+	e.WriteString(`{"type":`)
+	e.json(tok.Type.String())
+	if tok.Lit != "" {
+		e.WriteString(`,"literal":`)
+		e.json(tok.Lit)
+	}
+	if tok.Upper != strings.ToUpper(tok.Lit) {
+		e.WriteString(`,"upper":`)
+		e.json(tok.Upper)
+	}
+	e.pos(tok.Pos, tok.End)
+	e.WriteString("}")
+}
+
+func (e *encoder) json(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.Write(data)
+	return nil
+}
+
+// fieldName returns the key of a field, or "" if the field isn't encoded
+func fieldName(f reflect.StructField) string {
+	if !f.IsExported() || f.Anonymous {
+		return ""
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return f.Name
+}
+
+func decodeNode(data []byte) (Node, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	var name string
+	if err := json.Unmarshal(obj["node"], &name); err != nil {
+		return nil, fmt.Errorf("ast: node without a type: %s", truncate(data))
+	}
+	t, ok := nodeTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("ast: unknown node type %q", name)
+	}
+
+	if name == "Program" {
+		var version int
+		if err := json.Unmarshal(obj["version"], &version); err != nil || version > JSONVersion {
+			return nil, fmt.Errorf("ast: unsupported version %s, expected %d or lower", obj["version"], JSONVersion)
+		}
+	}
+
+	v := reflect.New(t)
+	n := v.Interface().(Node)
+
+	start, end, err := decodePos(obj)
+	if err != nil {
+		return nil, err
+	}
+	n.SetSpan(start, end)
+
+	if err := decodeFields(v.Elem(), obj); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func decodePos(obj map[string]json.RawMessage) (start, end token.Pos, err error) {
+	if raw, ok := obj["pos"]; ok {
+		if err = json.Unmarshal(raw, &start); err != nil {
+			return
+		}
+	}
+	if raw, ok := obj["end"]; ok {
+		err = json.Unmarshal(raw, &end)
+	}
+	return
+}
+
+func decodeFields(v reflect.Value, obj map[string]json.RawMessage) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := fieldName(f)
+		if name == "" {
+			continue
+		}
+
+		raw, ok := obj[name]
+		if !ok {
+			continue
+		}
+		if err := decodeValue(v.Field(i), raw); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+	}
+	return nil
+}
+
+func decodeValue(v reflect.Value, raw json.RawMessage) error {
+	switch {
+	case v.Type() == tokenStruct:
+		tok, err := decodeToken(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tok))
+		return nil
+	case v.Type() == tokenTypeType:
+		tt, err := decodeTokenType(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tt))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		n, err := decodeNode(raw)
+		if err != nil || n == nil {
+			return err
+		}
+		return setNode(v, n)
+	case reflect.Pointer:
+		switch {
+		case isNull(raw):
+			return nil
+		case v.Type().Implements(nodeInterface):
+			n, err := decodeNode(raw)
+			if err != nil || n == nil {
+				return err
+			}
+			return setNode(v, n)
+		case v.Type().Elem().Kind() == reflect.Struct:
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return err
+			}
+			p := reflect.New(v.Type().Elem())
+			if err := decodeFields(p.Elem(), obj); err != nil {
+				return err
+			}
+			v.Set(p)
+			return nil
+		}
+		p := reflect.New(v.Type().Elem())
+		if err := decodeValue(p.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	case reflect.Slice:
+		if isNull(raw) {
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(s.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+
+	return json.Unmarshal(raw, v.Addr().Interface())
+}
+
+// setNode checks that a decoded node fits the field it's going into, e.g. a Statement can't be used as an Expression
+func setNode(v reflect.Value, n Node) error {
+	nv := reflect.ValueOf(n)
+	if !nv.Type().AssignableTo(v.Type()) {
+		return fmt.Errorf("ast: %T can't be used as %s", n, v.Type())
+	}
+	v.Set(nv)
+	return nil
+}
+
+func decodeToken(raw json.RawMessage) (token.Token, error) {
+	var aux struct {
+		Type  json.RawMessage `json:"type"`
+		Lit   string          `json:"literal"`
+		Upper *string         `json:"upper"`
+		Pos   token.Pos       `json:"pos"`
+		End   token.Pos       `json:"end"`
+	}
+	if err := json.Unmarshal(raw, &aux); err != nil {
+		return token.Token{}, err
+	}
+
+	tt, err := decodeTokenType(aux.Type)
+	if err != nil {
+		return token.Token{}, err
+	}
+
+	tok := token.Token{Type: tt, Lit: aux.Lit, Upper: strings.ToUpper(aux.Lit), Pos: aux.Pos, End: aux.End}
+	if aux.Upper != nil {
+		tok.Upper = *aux.Upper
+	}
+	return tok, nil
+}
+
+func decodeTokenType(raw json.RawMessage) (token.TokenType, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err != nil {
+		return 0, err
+	}
+	tt, ok := token.FromString(name)
+	if !ok {
+		return 0, fmt.Errorf("ast: unknown token type %q", name)
+	}
+	return tt, nil
+}
+
+func isNull(data []byte) bool {
+	return len(data) == 0 || string(bytes.TrimSpace(data)) == "null"
+}
+
+func truncate(data []byte) string {
+	if len(data) > 80 {
+		return string(data[:80]) + "..."
+	}
+	return string(data)
+}
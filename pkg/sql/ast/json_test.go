@@ -0,0 +1,92 @@
+package ast
+
+import (
+	"encoding/json"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Every type with a Children method is a node, and has to be registered to be decoded
+func TestJSONNodeTypes(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi fs.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	found := 0
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*goast.FuncDecl)
+				if !ok || fn.Recv == nil || fn.Name.Name != "Children" {
+					continue
+				}
+				star, ok := fn.Recv.List[0].Type.(*goast.StarExpr)
+				if !ok {
+					continue
+				}
+				name := star.X.(*goast.Ident).Name
+				found++
+				assert.Contains(t, nodeTypes, name, "%s isn't registered in nodeTypes", name)
+			}
+		}
+	}
+
+	assert.Equal(t, found, len(nodeTypes))
+}
+
+func TestJSON(t *testing.T) {
+	maskParams := false
+
+	data, err := Marshal(walkProgram())
+	assert.NoError(t, err)
+
+	n, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "(SELECT id, name::TEXT FROM users WHERE (id = 42));", n.String(maskParams))
+
+	// Any node can be encoded, not just a program
+	where := walkProgram().Statements[0].(*SelectStatement).Expressions[0].(*SelectExpression).Where
+	data, err = Marshal(where)
+	assert.NoError(t, err)
+	// The tokens here weren't made by the lexer, so Upper isn't set and is written out
+	assert.JSONEq(t, `{"node":"InfixExpression","token":{"type":"EQ","literal":"=","upper":""},"operator":"=",
+		"left":{"node":"SimpleIdentifier","token":{"type":"IDENT","literal":"id","upper":""},"value":"id"},
+		"right":{"node":"IntegerLiteral","token":{"type":"INTEGER","literal":"42","upper":""},"value":42}}`, string(data))
+
+	n, err = Unmarshal(data)
+	assert.NoError(t, err)
+	assert.IsType(t, &InfixExpression{}, n)
+	assert.Equal(t, "(id = 42)", n.String(maskParams))
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`{"node":"Nope"}`, `ast: unknown node type "Nope"`},
+		{`{"statements":[]}`, "ast: node without a type"},
+		{`{"node":"Program","version":99}`, "ast: unsupported version 99, expected 1 or lower"},
+		{`{"node":"Program","version":1,"statements":[{"node":"IntegerLiteral"}]}`, "Program.Statements: ast: *ast.IntegerLiteral can't be used as ast.Statement"},
+		{`{"node":"IntegerLiteral","token":{"type":"NOPE"}}`, `IntegerLiteral.Token: ast: unknown token type "NOPE"`},
+	}
+
+	for _, tt := range tests {
+		_, err := Unmarshal([]byte(tt.input))
+		if assert.Error(t, err, "input: %s", tt.input) {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+
+	// A program has to decode into a Program
+	var program Program
+	assert.Error(t, json.Unmarshal([]byte(`{"node":"Null"}`), &program))
+}
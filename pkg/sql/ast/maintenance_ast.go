@@ -0,0 +1,268 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// This file contains the AST for maintenance commands such as VACUUM, REINDEX, CLUSTER,
+// REFRESH MATERIALIZED VIEW, and TRUNCATE
+
+type VacuumStatement struct {
+	Span
+	Token   token.Token    `json:"token,omitempty"`   // the token.VACUUM_STATEMENT token
+	Options []string       `json:"options,omitempty"` // (VERBOSE, ANALYZE, PARALLEL 4, etc.)
+	Full    bool           `json:"full,omitempty"`    // FULL
+	Freeze  bool           `json:"freeze,omitempty"`  // FREEZE
+	Verbose bool           `json:"verbose,omitempty"` // VERBOSE
+	Analyze bool           `json:"analyze,omitempty"` // ANALYZE
+	Tables  []Expression   `json:"tables,omitempty"`  // the tables to vacuum
+	Columns [][]Expression `json:"columns,omitempty"` // column lists, indexed the same as Tables
+}
+
+func (x *VacuumStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *VacuumStatement) SetClause(c token.TokenType)  {}
+func (x *VacuumStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *VacuumStatement) SetCommand(c token.TokenType) {}
+func (x *VacuumStatement) statementNode()               {}
+func (x *VacuumStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *VacuumStatement) Children() []Node {
+	var c []Node
+	for i, t := range x.Tables {
+		c = append(c, nodes(t)...)
+		if i < len(x.Columns) {
+			c = append(c, list(x.Columns[i])...)
+		}
+	}
+	return c
+}
+func (x *VacuumStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("VACUUM")
+	if len(x.Options) > 0 {
+		out.WriteString(" (" + strings.Join(x.Options, ", ") + ")")
+	}
+	if x.Full {
+		out.WriteString(" FULL")
+	}
+	if x.Freeze {
+		out.WriteString(" FREEZE")
+	}
+	if x.Verbose {
+		out.WriteString(" VERBOSE")
+	}
+	if x.Analyze {
+		out.WriteString(" ANALYZE")
+	}
+
+	for i, t := range x.Tables {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(" " + t.String(maskParams))
+		if i < len(x.Columns) && len(x.Columns[i]) > 0 {
+			out.WriteString(" (" + expressionList(x.Columns[i], maskParams) + ")")
+		}
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *VacuumStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type ReindexStatement struct {
+	Span
+	Token        token.Token `json:"token,omitempty"`        // the token.REINDEX_STATEMENT token
+	Options      []string    `json:"options,omitempty"`      // (VERBOSE, TABLESPACE foo, etc.)
+	Object       string      `json:"object,omitempty"`       // INDEX, TABLE, SCHEMA, DATABASE, SYSTEM
+	Concurrently bool        `json:"concurrently,omitempty"` // CONCURRENTLY
+	Name         Expression  `json:"name,omitempty"`         // the name of the object
+}
+
+func (x *ReindexStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *ReindexStatement) SetClause(c token.TokenType)  {}
+func (x *ReindexStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *ReindexStatement) SetCommand(c token.TokenType) {}
+func (x *ReindexStatement) statementNode()               {}
+func (x *ReindexStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *ReindexStatement) Children() []Node {
+	return nodes(x.Name)
+}
+func (x *ReindexStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("REINDEX")
+	if len(x.Options) > 0 {
+		out.WriteString(" (" + strings.Join(x.Options, ", ") + ")")
+	}
+	out.WriteString(" " + x.Object)
+	if x.Concurrently {
+		out.WriteString(" CONCURRENTLY")
+	}
+	if x.Name != nil {
+		out.WriteString(" " + x.Name.String(maskParams))
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *ReindexStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type ClusterStatement struct {
+	Span
+	Token   token.Token `json:"token,omitempty"`   // the token.CLUSTER_STATEMENT token
+	Verbose bool        `json:"verbose,omitempty"` // VERBOSE
+	Table   Expression  `json:"table,omitempty"`   // the table to cluster
+	Index   Expression  `json:"index,omitempty"`   // USING index_name
+}
+
+func (x *ClusterStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *ClusterStatement) SetClause(c token.TokenType)  {}
+func (x *ClusterStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *ClusterStatement) SetCommand(c token.TokenType) {}
+func (x *ClusterStatement) statementNode()               {}
+func (x *ClusterStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *ClusterStatement) Children() []Node {
+	return nodes(x.Table, x.Index)
+}
+func (x *ClusterStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("CLUSTER")
+	if x.Verbose {
+		out.WriteString(" VERBOSE")
+	}
+	if x.Table != nil {
+		out.WriteString(" " + x.Table.String(maskParams))
+	}
+	if x.Index != nil {
+		out.WriteString(" USING " + x.Index.String(maskParams))
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *ClusterStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type RefreshStatement struct {
+	Span
+	Token        token.Token `json:"token,omitempty"`        // the token.REFRESH_STATEMENT token
+	Concurrently bool        `json:"concurrently,omitempty"` // CONCURRENTLY
+	Name         Expression  `json:"name,omitempty"`         // the name of the materialized view
+	WithData     string      `json:"with_data,omitempty"`    // WITH DATA or WITH NO DATA
+}
+
+func (x *RefreshStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *RefreshStatement) SetClause(c token.TokenType)  {}
+func (x *RefreshStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *RefreshStatement) SetCommand(c token.TokenType) {}
+func (x *RefreshStatement) statementNode()               {}
+func (x *RefreshStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *RefreshStatement) Children() []Node {
+	return nodes(x.Name)
+}
+func (x *RefreshStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("REFRESH MATERIALIZED VIEW")
+	if x.Concurrently {
+		out.WriteString(" CONCURRENTLY")
+	}
+	if x.Name != nil {
+		out.WriteString(" " + x.Name.String(maskParams))
+	}
+	if x.WithData != "" {
+		out.WriteString(" " + x.WithData)
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *RefreshStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type TruncateStatement struct {
+	Span
+	Token    token.Token  `json:"token,omitempty"`    // the token.TRUNCATE_STATEMENT token
+	Only     bool         `json:"only,omitempty"`     // ONLY
+	Tables   []Expression `json:"tables,omitempty"`   // the tables to truncate
+	Identity string       `json:"identity,omitempty"` // RESTART IDENTITY or CONTINUE IDENTITY
+	Options  string       `json:"options,omitempty"`  // CASCADE or RESTRICT
+}
+
+func (x *TruncateStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *TruncateStatement) SetClause(c token.TokenType)  {}
+func (x *TruncateStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *TruncateStatement) SetCommand(c token.TokenType) {}
+func (x *TruncateStatement) statementNode()               {}
+func (x *TruncateStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *TruncateStatement) Children() []Node {
+	return list(x.Tables)
+}
+func (x *TruncateStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("TRUNCATE TABLE ")
+	if x.Only {
+		out.WriteString("ONLY ")
+	}
+	out.WriteString(expressionList(x.Tables, maskParams))
+	if x.Identity != "" {
+		out.WriteString(" " + x.Identity)
+	}
+	if x.Options != "" {
+		out.WriteString(" " + x.Options)
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *TruncateStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+// expressionList joins a list of expressions with a comma
+func expressionList(list []Expression, maskParams bool) string {
+	items := []string{}
+	for _, e := range list {
+		items = append(items, e.String(maskParams))
+	}
+	return strings.Join(items, ", ")
+}
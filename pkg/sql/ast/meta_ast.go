@@ -9,6 +9,7 @@ import (
 // This file contains the AST for metadata such as SHOW, SAVEPOINT, and RESET, DISCARD, etc.
 
 type ShowStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.IDENT token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -19,6 +20,9 @@ func (x *ShowStatement) Command() token.TokenType     { return x.Expression.Comm
 func (x *ShowStatement) SetCommand(c token.TokenType) {}
 func (x *ShowStatement) statementNode()               {}
 func (x *ShowStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *ShowStatement) Children() []Node {
+	return nodes(x.Expression)
+}
 func (x *ShowStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -36,6 +40,7 @@ func (x *ShowStatement) Inspect(maskParams bool) string {
 }
 
 type ShowExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.IDENT token
 	Cast       Expression      `json:"cast,omitempty"`
 	Branch     token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
@@ -49,6 +54,9 @@ func (x *ShowExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *ShowExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *ShowExpression) expressionNode()              {}
 func (x *ShowExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *ShowExpression) Children() []Node {
+	return nodes(x.Expression, x.Cast)
+}
 func (x *ShowExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -67,6 +75,7 @@ func (x *ShowExpression) SetCast(cast Expression) {
 }
 
 type SavepointStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.SAVEPOINT token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -77,6 +86,9 @@ func (x *SavepointStatement) Command() token.TokenType     { return x.Expression
 func (x *SavepointStatement) SetCommand(c token.TokenType) {}
 func (x *SavepointStatement) statementNode()               {}
 func (x *SavepointStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *SavepointStatement) Children() []Node {
+	return nodes(x.Expression)
+}
 func (x *SavepointStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 
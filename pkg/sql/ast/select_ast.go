@@ -10,6 +10,7 @@ import (
 )
 
 type SelectStatement struct {
+	Span
 	Token       token.Token  `json:"token,omitempty"`       // the token.SELECT token
 	Expressions []Expression `json:"expressions,omitempty"` // a select statement may consist of multiple expressions such as the with clause in a CTE along with the primary select expression
 }
@@ -20,6 +21,9 @@ func (s *SelectStatement) Command() token.TokenType     { return s.Token.Type }
 func (x *SelectStatement) SetCommand(c token.TokenType) {}
 func (s *SelectStatement) statementNode()               {}
 func (s *SelectStatement) TokenLiteral() string         { return s.Token.Upper }
+func (s *SelectStatement) Children() []Node {
+	return list(s.Expressions)
+}
 func (s *SelectStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -42,23 +46,25 @@ func (s *SelectStatement) Inspect(maskParams bool) string {
 // SelectExpression is a select inside a SELECT or WITH (Common Table Expression) statement,
 // since a select statement can have multiple select expressions. i.e. WITH clause, subqueries, and the primary select expression.
 type SelectExpression struct {
-	Token        token.Token       `json:"token,omitempty"`    // the token.SELECT token
-	Distinct     Expression        `json:"distinct,omitempty"` // the DISTINCT or ALL token
-	Columns      []Expression      `json:"columns,omitempty"`
-	Tables       []Expression      `json:"tables,omitempty"`
-	Where        Expression        `json:"where,omitempty"`
-	GroupBy      []Expression      `json:"group_by,omitempty"`
-	Having       Expression        `json:"having,omitempty"`
-	Window       []Expression      `json:"window,omitempty"`
-	OrderBy      []Expression      `json:"order_by,omitempty"`
-	Limit        Expression        `json:"limit,omitempty"`
-	Offset       Expression        `json:"offset,omitempty"`
-	Fetch        Expression        `json:"fetch,omitempty"`
-	Lock         Expression        `json:"lock,omitempty"`
-	Cast         Expression        `json:"cast,omitempty"` // probably not needed, but used for the interface
-	TableAliases map[string]string `json:"-"`
-	Branch       token.TokenType   `json:"clause,omitempty"` // location in the tree representing a clause
-	CommandTag   token.TokenType   `json:"command,omitempty"`
+	Span
+	Token           token.Token       `json:"token,omitempty"`    // the token.SELECT token
+	Distinct        Expression        `json:"distinct,omitempty"` // the DISTINCT or ALL token
+	Columns         []Expression      `json:"columns,omitempty"`
+	Tables          []Expression      `json:"tables,omitempty"`
+	Where           Expression        `json:"where,omitempty"`
+	GroupBy         []Expression      `json:"group_by,omitempty"`
+	GroupByDistinct bool              `json:"group_by_distinct,omitempty"` // GROUP BY DISTINCT removes duplicate grouping sets
+	Having          Expression        `json:"having,omitempty"`
+	Window          []Expression      `json:"window,omitempty"`
+	OrderBy         []Expression      `json:"order_by,omitempty"`
+	Limit           Expression        `json:"limit,omitempty"`
+	Offset          Expression        `json:"offset,omitempty"`
+	Fetch           Expression        `json:"fetch,omitempty"`
+	Lock            Expression        `json:"lock,omitempty"`
+	Cast            Expression        `json:"cast,omitempty"` // probably not needed, but used for the interface
+	TableAliases    map[string]string `json:"-"`
+	Branch          token.TokenType   `json:"clause,omitempty"` // location in the tree representing a clause
+	CommandTag      token.TokenType   `json:"command,omitempty"`
 }
 
 func (x *SelectExpression) Clause() token.TokenType      { return x.Branch }
@@ -69,6 +75,11 @@ func (x *SelectExpression) expressionNode()              {}
 func (x *SelectExpression) TokenLiteral() string         { return x.Token.Upper }
 
 // String() is incomplete and only returns the most basic of select statements
+func (x *SelectExpression) Children() []Node {
+	return join(nodes(x.Distinct), list(x.Columns), list(x.Tables), nodes(x.Where),
+		list(x.GroupBy), nodes(x.Having), list(x.Window), list(x.OrderBy),
+		nodes(x.Limit, x.Offset, x.Fetch, x.Lock, x.Cast))
+}
 func (x *SelectExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -121,6 +132,9 @@ func (x *SelectExpression) String(maskParams bool) string {
 	// Group By
 	if len(x.GroupBy) > 0 {
 		out.WriteString(" GROUP BY ")
+		if x.GroupByDistinct {
+			out.WriteString("DISTINCT ")
+		}
 		groupBy := []string{}
 		for _, g := range x.GroupBy {
 			groupBy = append(groupBy, g.String(maskParams))
@@ -182,6 +196,7 @@ func (x *SelectExpression) SetCast(cast Expression) {
 }
 
 type DistinctExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"`  // The keyword token, e.g. DISTINCT
 	Right      []Expression    `json:"right,omitempty"`  // The columns to be distinct
 	Cast       Expression      `json:"cast,omitempty"`   // probably not needed, but used for the interface
@@ -195,6 +210,9 @@ func (x *DistinctExpression) Command() token.TokenType     { return x.CommandTag
 func (x *DistinctExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *DistinctExpression) expressionNode()              {}
 func (x *DistinctExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *DistinctExpression) Children() []Node {
+	return join(list(x.Right), nodes(x.Cast))
+}
 func (x *DistinctExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -224,6 +242,7 @@ func (x *DistinctExpression) SetCast(cast Expression) {
 }
 
 type ColumnExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token
 	Name       Expression      `json:"name,omitempty"`  // the name of the column or alias
 	Value      Expression      `json:"value,omitempty"` // the complete expression including all of the columns
@@ -238,6 +257,9 @@ func (x *ColumnExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *ColumnExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *ColumnExpression) expressionNode()              {}
 func (x *ColumnExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *ColumnExpression) Children() []Node {
+	return nodes(x.Value, x.Name, x.Cast)
+}
 func (x *ColumnExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -263,6 +285,7 @@ func (x *ColumnExpression) SetCast(cast Expression) {
 }
 
 type SortExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"`     // the token.ASC or token.DESC token
 	Value      Expression      `json:"value,omitempty"`     // the column to sort on
 	Direction  token.Token     `json:"direction,omitempty"` // the direction to sort
@@ -278,6 +301,9 @@ func (x *SortExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *SortExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *SortExpression) expressionNode()              {}
 func (x *SortExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *SortExpression) Children() []Node {
+	return nodes(x.Value, x.Cast)
+}
 func (x *SortExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -303,6 +329,7 @@ func (x *SortExpression) SetCast(cast Expression) {
 }
 
 type FetchExpression struct {
+	Span
 	Token token.Token `json:"token,omitempty"` // the token.FETCH token
 	// Don't need to store "first" or "next" since these are synonyms. We'll convert everything to "next" when printing in .String()
 	Value Expression `json:"value,omitempty"` // the number of rows to fetch
@@ -319,6 +346,9 @@ func (x *FetchExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *FetchExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *FetchExpression) expressionNode()              {}
 func (x *FetchExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *FetchExpression) Children() []Node {
+	return nodes(x.Value, x.Cast)
+}
 func (x *FetchExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -342,6 +372,7 @@ func (x *FetchExpression) SetCast(cast Expression) {
 }
 
 type AggregateExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"`
 	Left       Expression      `json:"expression,omitempty"`
 	Operator   string          `json:"operator,omitempty"`
@@ -357,6 +388,9 @@ func (x *AggregateExpression) Command() token.TokenType     { return x.CommandTa
 func (x *AggregateExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *AggregateExpression) expressionNode()              {}
 func (x *AggregateExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *AggregateExpression) Children() []Node {
+	return join(nodes(x.Left), list(x.Right), nodes(x.Cast))
+}
 func (x *AggregateExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -387,13 +421,16 @@ func (x *AggregateExpression) SetCast(cast Expression) {
 }
 
 type WindowExpression struct {
-	Token       token.Token     `json:"token,omitempty"`        // the token.OVER token
-	Alias       Expression      `json:"alias,omitempty"`        // the alias of the window
-	PartitionBy []Expression    `json:"partition_by,omitempty"` // the columns to partition by
-	OrderBy     []Expression    `json:"order_by,omitempty"`     // the columns to order by
-	Cast        Expression      `json:"cast,omitempty"`
-	Branch      token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
-	CommandTag  token.TokenType `json:"command,omitempty"`
+	Span
+	Token          token.Token     `json:"token,omitempty"`           // the token.OVER token
+	Alias          Expression      `json:"alias,omitempty"`           // the alias of the window
+	ExistingWindow Expression      `json:"existing_window,omitempty"` // a named window that this one builds on: OVER (w ORDER BY id)
+	PartitionBy    []Expression    `json:"partition_by,omitempty"`    // the columns to partition by
+	OrderBy        []Expression    `json:"order_by,omitempty"`        // the columns to order by
+	Frame          *WindowFrame    `json:"frame,omitempty"`           // ROWS, RANGE, or GROUPS
+	Cast           Expression      `json:"cast,omitempty"`
+	Branch         token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
+	CommandTag     token.TokenType `json:"command,omitempty"`
 }
 
 func (x *WindowExpression) Clause() token.TokenType      { return x.Branch }
@@ -402,6 +439,9 @@ func (x *WindowExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *WindowExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *WindowExpression) expressionNode()              {}
 func (x *WindowExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *WindowExpression) Children() []Node {
+	return join(nodes(x.Alias, x.ExistingWindow), list(x.PartitionBy), list(x.OrderBy), x.Frame.children(), nodes(x.Cast))
+}
 func (x *WindowExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -409,28 +449,29 @@ func (x *WindowExpression) String(maskParams bool) string {
 		out.WriteString(x.Alias.String(maskParams) + " AS ")
 	}
 
-	out.WriteString("(")
+	parts := []string{}
+	if x.ExistingWindow != nil {
+		parts = append(parts, x.ExistingWindow.String(maskParams))
+	}
 	if len(x.PartitionBy) > 0 {
-		out.WriteString("PARTITION BY ")
 		partitionBy := []string{}
 		for _, p := range x.PartitionBy {
 			partitionBy = append(partitionBy, p.String(maskParams))
 		}
-		out.WriteString(strings.Join(partitionBy, ", "))
-	}
-	if len(x.PartitionBy) > 0 && len(x.OrderBy) > 0 {
-		out.WriteString(" ")
+		parts = append(parts, "PARTITION BY "+strings.Join(partitionBy, ", "))
 	}
 	if len(x.OrderBy) > 0 {
-		out.WriteString("ORDER BY ")
 		orderBy := []string{}
 		for _, o := range x.OrderBy {
 			orderBy = append(orderBy, o.String(maskParams))
 		}
-		out.WriteString(strings.Join(orderBy, ", "))
+		parts = append(parts, "ORDER BY "+strings.Join(orderBy, ", "))
+	}
+	if x.Frame != nil {
+		parts = append(parts, x.Frame.String(maskParams))
 	}
 
-	out.WriteString(")")
+	out.WriteString("(" + strings.Join(parts, " ") + ")")
 
 	if x.Cast != nil {
 		out.WriteString("::")
@@ -443,7 +484,100 @@ func (x *WindowExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
 
+// WindowFrame is the frame clause of a window:
+// { RANGE | ROWS | GROUPS } { frame_start | BETWEEN frame_start AND frame_end } [ EXCLUDE { CURRENT ROW | GROUP | TIES | NO OTHERS } ]
+type WindowFrame struct {
+	Mode    string      `json:"mode,omitempty"`    // ROWS, RANGE, or GROUPS
+	Start   *FrameBound `json:"start,omitempty"`   // the start of the frame, or the only bound when BETWEEN isn't used
+	End     *FrameBound `json:"end,omitempty"`     // the end of the frame when using BETWEEN
+	Exclude string      `json:"exclude,omitempty"` // CURRENT ROW, GROUP, TIES, or NO OTHERS
+}
+
+// children returns the offsets of the frame's bounds. WindowFrame isn't a node, so these belong to the WindowExpression.
+func (x *WindowFrame) children() []Node {
+	if x == nil {
+		return nil
+	}
+
+	var bounds []Node
+	for _, b := range []*FrameBound{x.Start, x.End} {
+		if b != nil {
+			bounds = append(bounds, nodes(b.Offset)...)
+		}
+	}
+	return bounds
+}
+
+func (x *WindowFrame) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString(x.Mode + " ")
+	if x.End != nil {
+		out.WriteString("BETWEEN " + x.Start.String(maskParams) + " AND " + x.End.String(maskParams))
+	} else {
+		out.WriteString(x.Start.String(maskParams))
+	}
+	if x.Exclude != "" {
+		out.WriteString(" EXCLUDE " + x.Exclude)
+	}
+
+	return out.String()
+}
+
+// FrameBound is one end of a window frame: UNBOUNDED PRECEDING, offset PRECEDING, CURRENT ROW, offset FOLLOWING, or UNBOUNDED FOLLOWING
+type FrameBound struct {
+	Offset    Expression `json:"offset,omitempty"`    // i.e. 1 or interval '1 day'. Nil for UNBOUNDED and CURRENT ROW
+	Direction string     `json:"direction,omitempty"` // UNBOUNDED PRECEDING, PRECEDING, CURRENT ROW, FOLLOWING, or UNBOUNDED FOLLOWING
+}
+
+func (x *FrameBound) String(maskParams bool) string {
+	if x.Offset == nil {
+		return x.Direction
+	}
+	return x.Offset.String(maskParams) + " " + x.Direction
+}
+
+// GroupingSetExpression is ROLLUP ( ... ), CUBE ( ... ), or GROUPING SETS ( ... ) in a GROUP BY clause
+type GroupingSetExpression struct {
+	Span
+	Token      token.Token     `json:"token,omitempty"` // the token.ROLLUP, token.CUBE, or token.GROUPING token
+	Type       string          `json:"type,omitempty"`  // ROLLUP, CUBE, or GROUPING SETS
+	Sets       []Expression    `json:"sets,omitempty"`  // the columns or grouped columns. () is the empty grouping set
+	Cast       Expression      `json:"cast,omitempty"`
+	Branch     token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
+	CommandTag token.TokenType `json:"command,omitempty"`
+}
+
+func (x *GroupingSetExpression) Clause() token.TokenType      { return x.Branch }
+func (x *GroupingSetExpression) SetClause(c token.TokenType)  { x.Branch = c }
+func (x *GroupingSetExpression) Command() token.TokenType     { return x.CommandTag }
+func (x *GroupingSetExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
+func (x *GroupingSetExpression) expressionNode()              {}
+func (x *GroupingSetExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *GroupingSetExpression) Children() []Node {
+	return join(list(x.Sets), nodes(x.Cast))
+}
+func (x *GroupingSetExpression) String(maskParams bool) string {
+	sets := []string{}
+	for _, s := range x.Sets {
+		sets = append(sets, groupingSetString(s, maskParams))
+	}
+	return x.Type + "(" + strings.Join(sets, ", ") + ")"
+}
+func (x *GroupingSetExpression) SetCast(cast Expression) {
+	x.Cast = cast
+}
+
+// groupingSetString keeps the parentheses around a single column in a grouping set, i.e. GROUPING SETS ((a), (b))
+func groupingSetString(x Expression, maskParams bool) string {
+	if g, ok := x.(*GroupedExpression); ok && len(g.Elements) == 1 {
+		return "(" + g.String(maskParams) + ")"
+	}
+	return x.String(maskParams)
+}
+
 type WildcardLiteral struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.ASTERISK token
 	Value      string          `json:"value,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`
@@ -457,6 +591,9 @@ func (x *WildcardLiteral) Command() token.TokenType     { return x.CommandTag }
 func (x *WildcardLiteral) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *WildcardLiteral) expressionNode()              {}
 func (x *WildcardLiteral) TokenLiteral() string         { return x.Token.Upper }
+func (x *WildcardLiteral) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *WildcardLiteral) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(x.Value)
@@ -473,6 +610,7 @@ func (x *WildcardLiteral) SetCast(cast Expression) {
 }
 
 type TimestampExpression struct {
+	Span
 	Token        token.Token     `json:"token,omitempty"` // the token.TIMESTAMP token
 	Value        string          `json:"value,omitempty"`
 	WithTimeZone bool            `json:"with_time_zone,omitempty"`
@@ -487,6 +625,9 @@ func (x *TimestampExpression) Command() token.TokenType     { return x.CommandTa
 func (x *TimestampExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *TimestampExpression) expressionNode()              {}
 func (x *TimestampExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *TimestampExpression) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *TimestampExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(x.Token.Upper)
@@ -513,17 +654,32 @@ func (x *TimestampExpression) SetCast(cast Expression) {
 // source    customers c
 // inner     addresses a     Expression
 
+// The kinds of things that can be in a FROM clause. Only relations are real tables.
+const (
+	TableKindRelation = "relation"  // a table or view: users u
+	TableKindSubquery = "subquery"  // (select ...) s
+	TableKindFunction = "function"  // a set returning function: unnest(...), generate_series(...), jsonb_to_recordset(...)
+	TableKindRowsFrom = "rows_from" // ROWS FROM ( function_call [, ...] )
+)
+
 type TableExpression struct {
-	Token         token.Token     `json:"token,omitempty"`          // the token.JOIN token
-	JoinType      string          `json:"join_type,omitempty"`      // the type of join: source, inner, left, right, full, etc
-	Schema        string          `json:"schema,omitempty"`         // the name of the schema
-	Table         Expression      `json:"table,omitempty"`          // the name of the table
-	Alias         Expression      `json:"alias,omitempty"`          // the alias of the table
-	JoinCondition Expression      `json:"join_condition,omitempty"` // the ON clause
-	Cast          Expression      `json:"cast,omitempty"`           // :: to cast the table
-	Ordinality    bool            `json:"ordinality,omitempty"`     // the WITH ORDINALITY clause
-	Branch        token.TokenType `json:"clause,omitempty"`         // location in the tree representing a clause
-	CommandTag    token.TokenType `json:"command,omitempty"`
+	Span
+	Token         token.Token         `json:"token,omitempty"`          // the token.JOIN token
+	JoinType      string              `json:"join_type,omitempty"`      // the type of join: source, inner, left, right, full, etc
+	Natural       bool                `json:"natural,omitempty"`        // NATURAL joins on the columns that both tables have
+	Kind          string              `json:"kind,omitempty"`           // relation, subquery, function, or rows_from
+	Lateral       bool                `json:"lateral,omitempty"`        // LATERAL subqueries and functions can reference earlier tables
+	Schema        string              `json:"schema,omitempty"`         // the name of the schema
+	Table         Expression          `json:"table,omitempty"`          // the name of the table
+	Functions     []Expression        `json:"functions,omitempty"`      // the function calls in ROWS FROM ( ... )
+	Alias         Expression          `json:"alias,omitempty"`          // the alias of the table
+	ColumnAliases []*ColumnAlias      `json:"column_aliases,omitempty"` // the column list after the alias: AS t(a, b) or AS x(a int, b text)
+	JoinCondition Expression          `json:"join_condition,omitempty"` // the ON clause
+	Using         []*SimpleIdentifier `json:"using,omitempty"`          // the columns of USING ( ... )
+	Cast          Expression          `json:"cast,omitempty"`           // :: to cast the table
+	Ordinality    bool                `json:"ordinality,omitempty"`     // the WITH ORDINALITY clause
+	Branch        token.TokenType     `json:"clause,omitempty"`         // location in the tree representing a clause
+	CommandTag    token.TokenType     `json:"command,omitempty"`
 }
 
 func (x *TableExpression) Clause() token.TokenType      { return x.Branch }
@@ -532,20 +688,69 @@ func (x *TableExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *TableExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *TableExpression) expressionNode()              {}
 func (x *TableExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *TableExpression) Children() []Node {
+	return join(nodes(x.Table), list(x.Functions), nodes(x.Alias, x.JoinCondition), list(x.Using), nodes(x.Cast))
+}
 func (x *TableExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
+	if x.Natural {
+		out.WriteString("NATURAL ")
+	}
 	if x.JoinType != "" {
 		out.WriteString(x.JoinType + " ")
 	}
+	if x.Lateral {
+		out.WriteString("LATERAL ")
+	}
 
-	out.WriteString(x.Table.String(maskParams))
+	var table bytes.Buffer
+	if x.Kind == TableKindRowsFrom {
+		functions := []string{}
+		for _, f := range x.Functions {
+			functions = append(functions, f.String(maskParams))
+		}
+		table.WriteString("ROWS FROM (" + strings.Join(functions, ", ") + ")")
+	} else {
+		table.WriteString(x.Table.String(maskParams))
+	}
 	if x.Ordinality {
-		out.WriteString(" WITH ORDINALITY")
+		table.WriteString(" WITH ORDINALITY")
 	}
 
+	var alias bytes.Buffer
 	if x.Alias != nil && x.Alias.String(maskParams) != "" {
-		out.WriteString(" " + x.Alias.String(maskParams))
+		alias.WriteString(x.Alias.String(maskParams))
+	}
+	if len(x.ColumnAliases) > 0 {
+		columns := []string{}
+		for _, c := range x.ColumnAliases {
+			columns = append(columns, c.String())
+		}
+		alias.WriteString("(" + strings.Join(columns, ", ") + ")")
+	}
+
+	if len(x.Using) > 0 {
+		// USING was parsed as an operator on the word before it, i.e. the alias when there is one,
+		// and it's printed the same way so fingerprints don't change
+		columns := []string{}
+		for _, c := range x.Using {
+			columns = append(columns, c.String(maskParams))
+		}
+		using := strings.Join(columns, ", ")
+		if len(columns) > 1 {
+			using = "(" + using + ")"
+		}
+		if alias.Len() > 0 {
+			out.WriteString(table.String() + " (" + alias.String() + " USING " + using + ")")
+		} else {
+			out.WriteString("(" + table.String() + " USING " + using + ")")
+		}
+	} else {
+		out.WriteString(table.String())
+		if alias.Len() > 0 {
+			out.WriteString(" " + alias.String())
+		}
 	}
 
 	if x.JoinCondition != nil {
@@ -563,7 +768,27 @@ func (x *TableExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
 
+// IsRelation is true when the table expression is a real table or view rather than a subquery or function
+func (x *TableExpression) IsRelation() bool {
+	return x.Kind == TableKindRelation
+}
+
+// ColumnAlias is a column in the alias list of a table expression. Type is only set for
+// functions returning record, i.e. jsonb_to_recordset(...) AS x(a int, b text)
+type ColumnAlias struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
+func (x *ColumnAlias) String() string {
+	if x.Type == "" {
+		return x.Name
+	}
+	return x.Name + " " + x.Type
+}
+
 type LockExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"`   // the token.FOR token
 	Lock       string          `json:"lock,omitempty"`    // the type of lock: update, share, key share, no key update
 	Tables     []Expression    `json:"tables,omitempty"`  // the tables to lock
@@ -579,6 +804,9 @@ func (x *LockExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *LockExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *LockExpression) expressionNode()              {}
 func (x *LockExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *LockExpression) Children() []Node {
+	return join(list(x.Tables), nodes(x.Cast))
+}
 func (x *LockExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(x.Lock)
@@ -606,6 +834,7 @@ func (x *LockExpression) SetCast(cast Expression) {
 }
 
 type InExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The operator token, e.g. IN, NOT IN
 	Left       Expression      `json:"left,omitempty"`
 	Not        bool            `json:"not,omitempty"`
@@ -622,6 +851,9 @@ func (x *InExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *InExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *InExpression) expressionNode()              {}
 func (x *InExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *InExpression) Children() []Node {
+	return join(nodes(x.Left), list(x.Right), nodes(x.Cast))
+}
 func (x *InExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -658,6 +890,7 @@ func (x *InExpression) SetCast(cast Expression) {
 }
 
 type CastExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.CAST token
 	Left       Expression      `json:"value,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`
@@ -671,6 +904,9 @@ func (x *CastExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *CastExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *CastExpression) expressionNode()              {}
 func (x *CastExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *CastExpression) Children() []Node {
+	return nodes(x.Left, x.Cast)
+}
 func (x *CastExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -691,6 +927,7 @@ func (x *CastExpression) SetCast(cast Expression) {
 }
 
 type WhereExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The keyword token, e.g. WHERE
 	Right      Expression      `json:"right,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`   // probably not needed, but used for the interface
@@ -704,6 +941,9 @@ func (x *WhereExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *WhereExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *WhereExpression) expressionNode()              {}
 func (x *WhereExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *WhereExpression) Children() []Node {
+	return nodes(x.Right, x.Cast)
+}
 func (x *WhereExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -727,6 +967,7 @@ func (x *WhereExpression) SetCast(cast Expression) {
 }
 
 type IsExpression struct {
+	Span
 	Token       token.Token     `json:"token,omitempty"` // the token.CAST token
 	Left        Expression      `json:"left,omitempty"`
 	Not         bool            `json:"not,omitempty"`
@@ -744,6 +985,9 @@ func (x *IsExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *IsExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *IsExpression) expressionNode()              {}
 func (x *IsExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *IsExpression) Children() []Node {
+	return nodes(x.Left, x.Right, x.Cast)
+}
 func (x *IsExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -780,6 +1024,7 @@ func (x *IsExpression) SetCast(cast Expression) {
 
 // trim(both 'x' from 'xTomxx') -> Tom
 type TrimExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.BOTH, token.LEADING, or token.TRAILING token
 	Expression Expression      `json:"expression,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`
@@ -796,6 +1041,9 @@ func (x *TrimExpression) TokenLiteral() string         { return x.Token.Upper }
 func (x *TrimExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
+func (x *TrimExpression) Children() []Node {
+	return nodes(x.Expression, x.Cast)
+}
 func (x *TrimExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(x.Token.Upper + " ")
@@ -808,6 +1056,7 @@ func (x *TrimExpression) String(maskParams bool) string {
 // substring('Thomas' from '...$')
 // substring('Thomas' from 2 for 3))
 type StringFunctionExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.STRING
 	Left       Expression      `json:"left,omitempty"`
 	From       Expression      `json:"from,omitempty"`
@@ -826,6 +1075,9 @@ func (x *StringFunctionExpression) TokenLiteral() string         { return x.Toke
 func (x *StringFunctionExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
+func (x *StringFunctionExpression) Children() []Node {
+	return nodes(x.Left, x.From, x.For, x.Cast)
+}
 func (x *StringFunctionExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -845,6 +1097,7 @@ func (x *StringFunctionExpression) String(maskParams bool) string {
 }
 
 type UnionExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // The operator token, e.g. +
 	Left       Expression      `json:"left,omitempty"`
 	Operator   string          `json:"operator,omitempty"` // UNION, INTERSECT, EXCEPT
@@ -861,6 +1114,9 @@ func (x *UnionExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *UnionExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *UnionExpression) expressionNode()              {}
 func (x *UnionExpression) TokenLiteral() string         { return x.Token.Upper }
+func (x *UnionExpression) Children() []Node {
+	return nodes(x.Left, x.Right, x.Cast)
+}
 func (x *UnionExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
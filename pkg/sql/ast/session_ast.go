@@ -0,0 +1,306 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// This file contains the AST for session level commands: PREPARE, EXECUTE, DEALLOCATE,
+// DECLARE CURSOR, FETCH, CLOSE, LISTEN, UNLISTEN, and NOTIFY
+
+type PrepareStatement struct {
+	Span
+	Token token.Token `json:"token,omitempty"` // the token.PREPARE_STATEMENT token
+	Name  string      `json:"name,omitempty"`  // the name of the prepared statement
+	Types []string    `json:"types,omitempty"` // optional parameter data types
+	Query Statement   `json:"query,omitempty"` // the statement being prepared
+}
+
+func (x *PrepareStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *PrepareStatement) SetClause(c token.TokenType)  {}
+func (x *PrepareStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *PrepareStatement) SetCommand(c token.TokenType) {}
+func (x *PrepareStatement) statementNode()               {}
+func (x *PrepareStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *PrepareStatement) Children() []Node {
+	return nodes(x.Query)
+}
+func (x *PrepareStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("PREPARE " + x.Name)
+	if len(x.Types) > 0 {
+		out.WriteString("(" + strings.Join(x.Types, ", ") + ")")
+	}
+	out.WriteString(" AS ")
+	out.WriteString(innerStatement(x.Query, maskParams))
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *PrepareStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type ExecuteStatement struct {
+	Span
+	Token     token.Token  `json:"token,omitempty"`     // the token.EXECUTE_STATEMENT token
+	Name      string       `json:"name,omitempty"`      // the name of the prepared statement
+	Arguments []Expression `json:"arguments,omitempty"` // the parameter values
+}
+
+func (x *ExecuteStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *ExecuteStatement) SetClause(c token.TokenType)  {}
+func (x *ExecuteStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *ExecuteStatement) SetCommand(c token.TokenType) {}
+func (x *ExecuteStatement) statementNode()               {}
+func (x *ExecuteStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *ExecuteStatement) Children() []Node {
+	return list(x.Arguments)
+}
+func (x *ExecuteStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("EXECUTE " + x.Name)
+	if len(x.Arguments) > 0 {
+		out.WriteString("(" + expressionList(x.Arguments, maskParams) + ")")
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *ExecuteStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type DeallocateStatement struct {
+	Span
+	Token token.Token `json:"token,omitempty"` // the token.DEALLOCATE_STATEMENT token
+	Name  string      `json:"name,omitempty"`  // the name of the prepared statement
+	All   bool        `json:"all,omitempty"`   // DEALLOCATE ALL
+}
+
+func (x *DeallocateStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *DeallocateStatement) SetClause(c token.TokenType)  {}
+func (x *DeallocateStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *DeallocateStatement) SetCommand(c token.TokenType) {}
+func (x *DeallocateStatement) statementNode()               {}
+func (x *DeallocateStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *DeallocateStatement) Children() []Node {
+	return nil
+}
+func (x *DeallocateStatement) String(maskParams bool) string {
+	if x.All {
+		return "DEALLOCATE ALL;"
+	}
+	return "DEALLOCATE " + x.Name + ";"
+}
+
+func (x *DeallocateStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type DeclareCursorStatement struct {
+	Span
+	Token   token.Token `json:"token,omitempty"`   // the token.DECLARE_STATEMENT token
+	Name    string      `json:"name,omitempty"`    // the name of the cursor
+	Options []string    `json:"options,omitempty"` // BINARY, INSENSITIVE, NO SCROLL, etc.
+	Hold    string      `json:"hold,omitempty"`    // WITH HOLD or WITHOUT HOLD
+	Query   Statement   `json:"query,omitempty"`   // the query the cursor runs
+}
+
+func (x *DeclareCursorStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *DeclareCursorStatement) SetClause(c token.TokenType)  {}
+func (x *DeclareCursorStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *DeclareCursorStatement) SetCommand(c token.TokenType) {}
+func (x *DeclareCursorStatement) statementNode()               {}
+func (x *DeclareCursorStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *DeclareCursorStatement) Children() []Node {
+	return nodes(x.Query)
+}
+func (x *DeclareCursorStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("DECLARE " + x.Name)
+	for _, o := range x.Options {
+		out.WriteString(" " + o)
+	}
+	out.WriteString(" CURSOR")
+	if x.Hold != "" {
+		out.WriteString(" " + x.Hold)
+	}
+	out.WriteString(" FOR ")
+	out.WriteString(innerStatement(x.Query, maskParams))
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *DeclareCursorStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type FetchStatement struct {
+	Span
+	Token     token.Token `json:"token,omitempty"`     // the token.FETCH token
+	Direction string      `json:"direction,omitempty"` // NEXT, PRIOR, ABSOLUTE, FORWARD, ALL, etc.
+	Count     Expression  `json:"count,omitempty"`     // the number of rows or the position
+	Cursor    string      `json:"cursor,omitempty"`    // the name of the cursor
+}
+
+func (x *FetchStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *FetchStatement) SetClause(c token.TokenType)  {}
+func (x *FetchStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *FetchStatement) SetCommand(c token.TokenType) {}
+func (x *FetchStatement) statementNode()               {}
+func (x *FetchStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *FetchStatement) Children() []Node {
+	return nodes(x.Count)
+}
+func (x *FetchStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("FETCH")
+	if x.Direction != "" {
+		out.WriteString(" " + x.Direction)
+	}
+	if x.Count != nil {
+		out.WriteString(" " + x.Count.String(maskParams))
+	}
+	out.WriteString(" FROM " + x.Cursor + ";")
+
+	return out.String()
+}
+
+func (x *FetchStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type CloseStatement struct {
+	Span
+	Token  token.Token `json:"token,omitempty"`  // the token.CLOSE_STATEMENT token
+	Cursor string      `json:"cursor,omitempty"` // the name of the cursor
+	All    bool        `json:"all,omitempty"`    // CLOSE ALL
+}
+
+func (x *CloseStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *CloseStatement) SetClause(c token.TokenType)  {}
+func (x *CloseStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *CloseStatement) SetCommand(c token.TokenType) {}
+func (x *CloseStatement) statementNode()               {}
+func (x *CloseStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *CloseStatement) Children() []Node {
+	return nil
+}
+func (x *CloseStatement) String(maskParams bool) string {
+	if x.All {
+		return "CLOSE ALL;"
+	}
+	return "CLOSE " + x.Cursor + ";"
+}
+
+func (x *CloseStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+// ListenStatement is used for both LISTEN and UNLISTEN. The token type tells them apart.
+type ListenStatement struct {
+	Span
+	Token   token.Token `json:"token,omitempty"`   // the token.LISTEN_STATEMENT or token.UNLISTEN_STATEMENT token
+	Channel string      `json:"channel,omitempty"` // the channel name, or * for UNLISTEN *
+}
+
+func (x *ListenStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *ListenStatement) SetClause(c token.TokenType)  {}
+func (x *ListenStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *ListenStatement) SetCommand(c token.TokenType) {}
+func (x *ListenStatement) statementNode()               {}
+func (x *ListenStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *ListenStatement) Children() []Node {
+	return nil
+}
+func (x *ListenStatement) String(maskParams bool) string {
+	return x.Token.Upper + " " + x.Channel + ";"
+}
+
+func (x *ListenStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+type NotifyStatement struct {
+	Span
+	Token   token.Token `json:"token,omitempty"`   // the token.NOTIFY_STATEMENT token
+	Channel string      `json:"channel,omitempty"` // the channel name
+	Payload Expression  `json:"payload,omitempty"` // the optional payload string
+}
+
+func (x *NotifyStatement) Clause() token.TokenType      { return x.Token.Type }
+func (x *NotifyStatement) SetClause(c token.TokenType)  {}
+func (x *NotifyStatement) Command() token.TokenType     { return x.Token.Type }
+func (x *NotifyStatement) SetCommand(c token.TokenType) {}
+func (x *NotifyStatement) statementNode()               {}
+func (x *NotifyStatement) TokenLiteral() string         { return x.Token.Upper }
+func (x *NotifyStatement) Children() []Node {
+	return nodes(x.Payload)
+}
+func (x *NotifyStatement) String(maskParams bool) string {
+	var out bytes.Buffer
+
+	out.WriteString("NOTIFY " + x.Channel)
+	if x.Payload != nil {
+		out.WriteString(", " + x.Payload.String(maskParams))
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (x *NotifyStatement) Inspect(maskParams bool) string {
+	j, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling data: %#v\n\n", err)
+	}
+	return string(j)
+}
+
+// innerStatement renders a nested statement without its trailing semicolon
+func innerStatement(s Statement, maskParams bool) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSuffix(s.String(maskParams), ";")
+}
@@ -10,6 +10,7 @@ import (
 )
 
 type SetStatement struct {
+	Span
 	Token              token.Token  `json:"token,omitempty"` // the token.SET token
 	Session            bool         `json:"session,omitempty"`
 	HasCharacteristics bool         `json:"has_characteristics,omitempty"`
@@ -28,6 +29,9 @@ func (s *SetStatement) Command() token.TokenType     { return s.Token.Type }
 func (x *SetStatement) SetCommand(c token.TokenType) {}
 func (s *SetStatement) statementNode()               {}
 func (s *SetStatement) TokenLiteral() string         { return s.Token.Upper }
+func (s *SetStatement) Children() []Node {
+	return join(nodes(s.Expression), list(s.Constraints))
+}
 func (s *SetStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -86,6 +90,7 @@ func (s *SetStatement) Inspect(maskParams bool) string {
 }
 
 type TransactionExpression struct {
+	Span
 	Token          token.Token     `json:"token,omitempty"` // the token.SET token
 	IsolationLevel string          `json:"isolationLevel,omitempty"`
 	Rights         string          `json:"rights,omitempty"`
@@ -104,6 +109,9 @@ func (t *TransactionExpression) TokenLiteral() string         { return t.Token.U
 func (x *TransactionExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
+func (t *TransactionExpression) Children() []Node {
+	return nodes(t.Cast)
+}
 func (t *TransactionExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -9,6 +9,7 @@ import (
 )
 
 type BeginStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.BEGIN token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -19,6 +20,9 @@ func (x *BeginStatement) Command() token.TokenType     { return x.Expression.Com
 func (x *BeginStatement) SetCommand(c token.TokenType) {}
 func (x *BeginStatement) statementNode()               {}
 func (x *BeginStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *BeginStatement) Children() []Node {
+	return nodes(x.Expression)
+}
 func (x *BeginStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -35,6 +39,7 @@ func (x *BeginStatement) Inspect(maskParams bool) string {
 }
 
 type BeginExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.BEGIN token
 	Cast       Expression      `json:"cast,omitempty"`
 	Branch     token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
@@ -47,6 +52,9 @@ func (x *BeginExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *BeginExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *BeginExpression) expressionNode()              {}
 func (x *BeginExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *BeginExpression) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *BeginExpression) String(maskParams bool) string {
 	if x.Cast != nil {
 		return fmt.Sprintf("%s::%s", x.Token.Upper, strings.ToUpper(x.Cast.String(maskParams)))
@@ -59,6 +67,7 @@ func (x *BeginExpression) SetCast(cast Expression) {
 }
 
 type CommitStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.COMMIT token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -69,6 +78,9 @@ func (x *CommitStatement) Command() token.TokenType     { return x.Expression.Co
 func (x *CommitStatement) SetCommand(c token.TokenType) {}
 func (x *CommitStatement) statementNode()               {}
 func (x *CommitStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *CommitStatement) Children() []Node {
+	return nodes(x.Expression)
+}
 func (x *CommitStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -85,6 +97,7 @@ func (x *CommitStatement) Inspect(maskParams bool) string {
 }
 
 type CommitExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.COMMIT token
 	Cast       Expression      `json:"cast,omitempty"`
 	Branch     token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
@@ -97,6 +110,9 @@ func (x *CommitExpression) Command() token.TokenType     { return x.CommandTag }
 func (x *CommitExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *CommitExpression) expressionNode()              {}
 func (x *CommitExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *CommitExpression) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *CommitExpression) String(maskParams bool) string {
 	if x.Cast != nil {
 		return fmt.Sprintf("%s::%s", x.Token.Upper, strings.ToUpper(x.Cast.String(maskParams)))
@@ -109,6 +125,7 @@ func (x *CommitExpression) SetCast(cast Expression) {
 }
 
 type RollbackStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.ROLLBACK token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -119,6 +136,9 @@ func (x *RollbackStatement) Command() token.TokenType     { return x.Expression.
 func (x *RollbackStatement) SetCommand(c token.TokenType) {}
 func (x *RollbackStatement) statementNode()               {}
 func (x *RollbackStatement) TokenLiteral() string         { return x.Token.Lit }
+func (x *RollbackStatement) Children() []Node {
+	return nodes(x.Expression)
+}
 func (x *RollbackStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 
@@ -135,6 +155,7 @@ func (x *RollbackStatement) Inspect(maskParams bool) string {
 }
 
 type RollbackExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.ROLLBACK token
 	Cast       Expression      `json:"cast,omitempty"`
 	Branch     token.TokenType `json:"clause,omitempty"` // location in the tree representing a clause
@@ -147,6 +168,9 @@ func (x *RollbackExpression) Command() token.TokenType     { return x.CommandTag
 func (x *RollbackExpression) SetCommand(c token.TokenType) { x.CommandTag = c }
 func (x *RollbackExpression) expressionNode()              {}
 func (x *RollbackExpression) TokenLiteral() string         { return x.Token.Lit }
+func (x *RollbackExpression) Children() []Node {
+	return nodes(x.Cast)
+}
 func (x *RollbackExpression) String(maskParams bool) string {
 	if x.Cast != nil {
 		return fmt.Sprintf("%s::%s", x.Token.Upper, strings.ToUpper(x.Cast.String(maskParams)))
@@ -9,6 +9,7 @@ import (
 )
 
 type UpdateStatement struct {
+	Span
 	Token      token.Token `json:"token,omitempty"` // the token.UPDATE token
 	Expression Expression  `json:"expression,omitempty"`
 }
@@ -19,6 +20,9 @@ func (s *UpdateStatement) Command() token.TokenType     { return s.Token.Type }
 func (x *UpdateStatement) SetCommand(c token.TokenType) {}
 func (s *UpdateStatement) statementNode()               {}
 func (s *UpdateStatement) TokenLiteral() string         { return s.Token.Upper }
+func (s *UpdateStatement) Children() []Node {
+	return nodes(s.Expression)
+}
 func (s *UpdateStatement) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString(s.Expression.String(maskParams))
@@ -34,6 +38,7 @@ func (s *UpdateStatement) Inspect(maskParams bool) string {
 }
 
 type UpdateExpression struct {
+	Span
 	Token        token.Token       `json:"token,omitempty"` // the token.UPDATE token
 	Only         bool              `json:"only,omitempty"`
 	Table        Expression        `json:"table,omitempty"`
@@ -60,6 +65,10 @@ func (x *UpdateExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
 
+func (x *UpdateExpression) Children() []Node {
+	return join(nodes(x.Table, x.Alias), list(x.Set), list(x.Tables), nodes(x.Cursor, x.Where),
+		list(x.Returning), nodes(x.Cast))
+}
 func (x *UpdateExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString("(UPDATE ")
@@ -7,6 +7,7 @@ import (
 )
 
 type ValuesExpression struct {
+	Span
 	Token      token.Token     `json:"token,omitempty"` // the token.VALUES token
 	Tuples     [][]Expression  `json:"values,omitempty"`
 	Cast       Expression      `json:"cast,omitempty"`
@@ -23,6 +24,9 @@ func (x *ValuesExpression) TokenLiteral() string         { return x.Token.Lit }
 func (x *ValuesExpression) SetCast(cast Expression) {
 	x.Cast = cast
 }
+func (x *ValuesExpression) Children() []Node {
+	return join(table(x.Tuples), nodes(x.Cast))
+}
 func (x *ValuesExpression) String(maskParams bool) string {
 	var out bytes.Buffer
 	out.WriteString("(VALUES")
@@ -0,0 +1,206 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A Visitor's Visit method is called for each node found by Walk. If the returned visitor w is not nil,
+// Walk visits each of the children of node with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order, starting with node and continuing through node.Children().
+// Nil nodes, including typed nils, are skipped.
+func Walk(node Node, v Visitor) {
+	if isNil(node) {
+		return
+	}
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	for _, child := range node.Children() {
+		Walk(child, v)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order. It calls f(node) for each node, and if f returns true,
+// Inspect continues with the children of node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(node, inspector(f))
+}
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// Rewrite traverses an AST bottom up, calling f on each node after its children have been rewritten.
+// The node that f returns takes the place of the original, so returning the node it was given leaves it alone.
+// The replacement has to fit the field it goes into, i.e. an Expression can only be replaced with another Expression.
+// Rewrite panics if it doesn't. The rewritten root is returned.
+func Rewrite(node Node, f func(Node) Node) Node {
+	if isNil(node) {
+		return node
+	}
+
+	rewriteValue(reflect.ValueOf(node).Elem(), f)
+
+	return f(node)
+}
+
+// rewriteValue rewrites the nodes held by v, which is a field, slice element, or struct within a node
+func rewriteValue(v reflect.Value, f func(Node) Node) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if !v.Type().Implements(nodeType) {
+			// Structs that hold nodes but aren't nodes themselves, such as *WindowFrame
+			if v.Kind() == reflect.Pointer {
+				rewriteValue(v.Elem(), f)
+			}
+			return
+		}
+
+		n := Rewrite(v.Interface().(Node), f)
+		if n == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		nv := reflect.ValueOf(n)
+		if !nv.Type().AssignableTo(v.Type()) {
+			panic(fmt.Sprintf("ast.Rewrite: %T can't replace a node of type %s", n, v.Type()))
+		}
+		v.Set(nv)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			rewriteValue(v.Index(i), f)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				rewriteValue(v.Field(i), f)
+			}
+		}
+	}
+}
+
+// Clone returns a deep copy of node, so the copy can be rewritten without changing the original
+func Clone[T Node](node T) T {
+	if isNil(node) {
+		return node
+	}
+	return cloneValue(reflect.ValueOf(node)).Interface().(T)
+}
+
+// cloneValue deep copies the pointers, interfaces, slices, and maps held by v
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				c.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
+
+// isNil checks for both a nil interface and an interface holding a nil pointer
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// Helpers for the Children methods
+
+// nodes returns the nodes that aren't nil, in order
+func nodes(list ...Node) []Node {
+	x := make([]Node, 0, len(list))
+	for _, n := range list {
+		if !isNil(n) {
+			x = append(x, n)
+		}
+	}
+	return x
+}
+
+// list converts a slice of expressions or statements to nodes, leaving out nils
+func list[T Node](items []T) []Node {
+	x := make([]Node, 0, len(items))
+	for _, n := range items {
+		if !isNil(n) {
+			x = append(x, n)
+		}
+	}
+	return x
+}
+
+// table flattens rows of expressions, such as the tuples in VALUES, in row order
+func table(rows [][]Expression) []Node {
+	x := []Node{}
+	for _, row := range rows {
+		x = append(x, list(row)...)
+	}
+	return x
+}
+
+// join concatenates lists of nodes
+func join(lists ...[]Node) []Node {
+	x := []Node{}
+	for _, l := range lists {
+		x = append(x, l...)
+	}
+	return x
+}
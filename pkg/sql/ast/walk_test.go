@@ -0,0 +1,148 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+// select id, name::text from users where id = 42;
+func walkProgram() *Program {
+	return &Program{
+		Statements: []Statement{
+			&SelectStatement{
+				Token: token.Token{Type: token.SELECT, Lit: "select", Upper: "SELECT"},
+				Expressions: []Expression{&SelectExpression{
+					Token: token.Token{Type: token.SELECT, Lit: "select", Upper: "SELECT"},
+					Columns: []Expression{
+						&ColumnExpression{Value: &SimpleIdentifier{Token: token.Token{Type: token.IDENT, Lit: "id"}, Value: "id"}},
+						&ColumnExpression{Value: &SimpleIdentifier{Token: token.Token{Type: token.IDENT, Lit: "name"}, Value: "name",
+							Cast: &SimpleIdentifier{Token: token.Token{Type: token.IDENT, Lit: "text"}, Value: "text"}}},
+					},
+					Tables: []Expression{&SimpleIdentifier{Token: token.Token{Type: token.IDENT, Lit: "users"}, Value: "users"}},
+					Where: &InfixExpression{
+						Token:    token.Token{Type: token.EQ, Lit: "="},
+						Operator: "=",
+						Left:     &SimpleIdentifier{Token: token.Token{Type: token.IDENT, Lit: "id"}, Value: "id"},
+						Right:    &IntegerLiteral{Token: token.Token{Type: token.INT, Lit: "42"}, Value: 42},
+					},
+				}},
+			},
+		},
+	}
+}
+
+func TestInspect(t *testing.T) {
+	program := walkProgram()
+
+	var visited []string
+	Inspect(program, func(n Node) bool {
+		switch n := n.(type) {
+		case *SimpleIdentifier:
+			visited = append(visited, n.Value)
+		case *IntegerLiteral:
+			visited = append(visited, n.Token.Lit)
+		}
+		return true
+	})
+	assert.Equal(t, []string{"id", "name", "text", "users", "id", "42"}, visited)
+
+	// Returning false skips the children
+	visited = []string{}
+	Inspect(program, func(n Node) bool {
+		if id, ok := n.(*SimpleIdentifier); ok {
+			visited = append(visited, id.Value)
+		}
+		_, isWhere := n.(*InfixExpression)
+		return !isWhere
+	})
+	assert.Equal(t, []string{"id", "name", "text", "users"}, visited)
+}
+
+type depthVisitor struct {
+	depth    int
+	maxDepth *int
+	closed   *int
+}
+
+func (v depthVisitor) Visit(n Node) Visitor {
+	if n == nil {
+		*v.closed++
+		return nil
+	}
+	if v.depth > *v.maxDepth {
+		*v.maxDepth = v.depth
+	}
+	return depthVisitor{depth: v.depth + 1, maxDepth: v.maxDepth, closed: v.closed}
+}
+
+func TestWalk(t *testing.T) {
+	maxDepth, closed := 0, 0
+	Walk(walkProgram(), depthVisitor{maxDepth: &maxDepth, closed: &closed})
+
+	// Program > SelectStatement > SelectExpression > ColumnExpression > SimpleIdentifier > Cast
+	assert.Equal(t, 5, maxDepth)
+	// Visit(nil) is called once for every node that was visited
+	assert.Equal(t, 12, closed)
+
+	// Typed nils are skipped
+	var stmt *SelectStatement
+	Walk(stmt, depthVisitor{maxDepth: &maxDepth, closed: &closed})
+	assert.Equal(t, 12, closed)
+}
+
+func TestRewrite(t *testing.T) {
+	maskParams := false
+
+	program := walkProgram()
+
+	// Qualify every column in the where clause and replace literals with a param
+	result := Rewrite(program, func(n Node) Node {
+		switch n := n.(type) {
+		case *InfixExpression:
+			if id, ok := n.Left.(*SimpleIdentifier); ok {
+				n.Left = &SimpleIdentifier{Token: id.Token, Value: "users." + id.Value}
+			}
+		case *IntegerLiteral:
+			return &ParamLiteral{Token: token.Token{Type: token.PARAM, Lit: "$1"}}
+		}
+		return n
+	})
+
+	assert.Same(t, program, result)
+	assert.Equal(t, "(SELECT id, name::TEXT FROM users WHERE (users.id = $1));", program.String(maskParams))
+
+	// A replacement has to fit the field it replaces
+	assert.Panics(t, func() {
+		Rewrite(walkProgram(), func(n Node) Node {
+			if _, ok := n.(*InfixExpression); ok {
+				return &SelectStatement{}
+			}
+			return n
+		})
+	})
+}
+
+func TestClone(t *testing.T) {
+	maskParams := false
+
+	program := walkProgram()
+	clone := Clone(program)
+
+	assert.NotSame(t, program, clone)
+	assert.Equal(t, program, clone)
+
+	// Changing the clone leaves the original alone
+	Rewrite(clone, func(n Node) Node {
+		if id, ok := n.(*SimpleIdentifier); ok {
+			id.Value = "x"
+		}
+		return n
+	})
+	assert.Equal(t, "(SELECT id, name::TEXT FROM users WHERE (id = 42));", program.String(maskParams))
+	assert.Equal(t, "(SELECT x, x::X FROM x WHERE (x = 42));", clone.String(maskParams))
+
+	var none *SelectStatement
+	assert.Nil(t, Clone(none))
+}
@@ -0,0 +1,201 @@
+// Package catalog is what's known about the tables in a database and their columns. It's built from the
+// CREATE TABLE statements seen in logs or migration files, or loaded from a dump of information_schema.columns.
+// The extractor uses it to find the table of a column that isn't qualified with one.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+type Column struct {
+	Name     string `json:"column_name"`
+	DataType string `json:"data_type,omitempty"`
+}
+
+type Table struct {
+	Schema  string    `json:"table_schema"`
+	Name    string    `json:"table_name"`
+	Columns []*Column `json:"columns"` // in the order they were defined
+}
+
+// Column returns the column with the name, which is folded like Postgres folds identifiers
+func (t *Table) Column(name string) (*Column, bool) {
+	name = Fold(name)
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+type Catalog struct {
+	Tables map[string]*Table `json:"tables"` // by schema.table
+}
+
+func New() *Catalog {
+	return &Catalog{Tables: make(map[string]*Table)}
+}
+
+// Fold returns the name the way Postgres stores it: unquoted names are lower cased, and quoted ones keep their case
+func Fold(name string) string {
+	if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	return strings.ToLower(name)
+}
+
+// Table returns the table, or false when the catalog doesn't know about it
+func (c *Catalog) Table(schema, name string) (*Table, bool) {
+	t, ok := c.Tables[fmt.Sprintf("%s.%s", Fold(schema), Fold(name))]
+	return t, ok
+}
+
+// AddTable adds an empty table, or returns the table when it's already in the catalog
+func (c *Catalog) AddTable(schema, name string) *Table {
+	schema, name = Fold(schema), Fold(name)
+	fqtn := fmt.Sprintf("%s.%s", schema, name)
+	if t, ok := c.Tables[fqtn]; ok {
+		return t
+	}
+	t := &Table{Schema: schema, Name: name, Columns: []*Column{}}
+	c.Tables[fqtn] = t
+	return t
+}
+
+// AddColumn adds a column to a table, adding the table if it's new. The type of a column that's already there is replaced.
+func (c *Catalog) AddColumn(schema, table, column, dataType string) *Column {
+	t := c.AddTable(schema, table)
+	if col, ok := t.Column(column); ok {
+		col.DataType = dataType
+		return col
+	}
+	col := &Column{Name: Fold(column), DataType: dataType}
+	t.Columns = append(t.Columns, col)
+	return col
+}
+
+// AddStatement adds the table created by a CREATE TABLE statement with a list of columns. Tables without a schema are
+// added to public. It returns false for any other statement.
+func (c *Catalog) AddStatement(stmt ast.Statement) bool {
+	create, ok := stmt.(*ast.CreateStatement)
+	if !ok || create.Object.Type != token.TABLE || len(create.Columns) == 0 {
+		return false
+	}
+
+	ident, ok := create.Name.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+	schema, table := "public", ""
+	switch len(ident.Value) {
+	case 1:
+		table = ident.Value[0].String(false)
+	case 2:
+		schema = ident.Value[0].String(false)
+		table = ident.Value[1].String(false)
+	default:
+		return false
+	}
+
+	// A table that's created again replaces the one that was there
+	delete(c.Tables, fmt.Sprintf("%s.%s", Fold(schema), Fold(table)))
+	c.AddTable(schema, table)
+	for _, col := range create.Columns {
+		c.AddColumn(schema, table, col.Name.Value, col.DataType)
+	}
+	return true
+}
+
+// AddSQL adds the tables created in src, such as a migration file or the output of pg_dump --schema-only.
+// Statements that don't parse are skipped, and the first parse error is returned.
+func (c *Catalog) AddSQL(src string, dialect token.Dialect) error {
+	p := parser.New(lexer.New(src, dialect))
+	program := p.ParseProgram()
+	for _, stmt := range program.Statements {
+		c.AddStatement(stmt)
+	}
+
+	if errs := p.ParseErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// informationSchemaColumn is a row of information_schema.columns
+type informationSchemaColumn struct {
+	Schema   string `json:"table_schema"`
+	Table    string `json:"table_name"`
+	Column   string `json:"column_name"`
+	DataType string `json:"data_type"`
+}
+
+// Load reads a JSON array of the rows of information_schema.columns, such as the output of:
+//
+//	select json_agg(c order by table_schema, table_name, ordinal_position) from information_schema.columns c;
+func Load(r io.Reader) (*Catalog, error) {
+	rows := []informationSchemaColumn{}
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	c := New()
+	for _, row := range rows {
+		// information_schema has the names as they're stored, so they're quoted to keep their case
+		c.AddColumn(quote(row.Schema), quote(row.Table), quote(row.Column), row.DataType)
+	}
+	return c, nil
+}
+
+// LoadFiles builds a catalog from .sql files of DDL and .json dumps of information_schema.columns.
+// Later files replace the tables of earlier ones.
+func LoadFiles(names []string, dialect token.Dialect) (*Catalog, error) {
+	c := New()
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.EqualFold(filepath.Ext(name), ".json") {
+			var loaded *Catalog
+			loaded, err = Load(f)
+			if err == nil {
+				c.Merge(loaded)
+			}
+		} else {
+			var src []byte
+			if src, err = io.ReadAll(f); err == nil {
+				// Migrations have statements that don't parse yet, such as ALTER TABLE, so they're skipped
+				_ = c.AddSQL(string(src), dialect)
+			}
+		}
+		f.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+// Merge adds the tables of other, replacing tables with the same name
+func (c *Catalog) Merge(other *Catalog) {
+	for fqtn, t := range other.Tables {
+		c.Tables[fqtn] = t
+	}
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,90 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddSQL(t *testing.T) {
+	c := New()
+	err := c.AddSQL(`
+		create table users (id bigint primary key, Name text not null, "Email" varchar(255));
+		create table billing.invoices (id bigint, user_id bigint references users (id), total numeric(10, 2));
+		alter table users add column age int;
+	`, token.Postgres)
+	assert.Error(t, err, "ALTER TABLE doesn't parse yet")
+
+	users, ok := c.Table("public", "Users")
+	if assert.True(t, ok) {
+		assert.Len(t, users.Columns, 3)
+
+		col, ok := users.Column("NAME")
+		assert.True(t, ok)
+		assert.Equal(t, "text", col.DataType)
+
+		_, ok = users.Column("email")
+		assert.False(t, ok, "quoted names keep their case")
+		_, ok = users.Column(`"Email"`)
+		assert.True(t, ok)
+	}
+
+	invoices, ok := c.Table("billing", "invoices")
+	if assert.True(t, ok) {
+		col, ok := invoices.Column("total")
+		assert.True(t, ok)
+		assert.Equal(t, "numeric(10, 2)", col.DataType)
+	}
+
+	_, ok = c.Table("public", "invoices")
+	assert.False(t, ok)
+}
+
+func TestAddSQLReplacesTables(t *testing.T) {
+	c := New()
+	assert.NoError(t, c.AddSQL("create table users (id int, name text); create table users (id bigint);", token.Postgres))
+
+	users, _ := c.Table("public", "users")
+	assert.Len(t, users.Columns, 1)
+	assert.Equal(t, "bigint", users.Columns[0].DataType)
+}
+
+func TestLoad(t *testing.T) {
+	dump := `[
+		{"table_schema": "public", "table_name": "users", "column_name": "id", "data_type": "bigint", "ordinal_position": 1},
+		{"table_schema": "public", "table_name": "users", "column_name": "Name", "data_type": "text", "ordinal_position": 2}
+	]`
+
+	c, err := Load(strings.NewReader(dump))
+	assert.NoError(t, err)
+
+	users, ok := c.Table("public", "users")
+	if assert.True(t, ok) {
+		assert.Equal(t, []*Column{{Name: "id", DataType: "bigint"}, {Name: "Name", DataType: "text"}}, users.Columns)
+
+		_, ok = users.Column(`"Name"`)
+		assert.True(t, ok)
+	}
+
+	_, err = Load(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	sql := filepath.Join(dir, "001_users.sql")
+	dump := filepath.Join(dir, "columns.json")
+	assert.NoError(t, os.WriteFile(sql, []byte("create table users (id int); alter table users add column x int;"), 0644))
+	assert.NoError(t, os.WriteFile(dump, []byte(`[{"table_schema": "public", "table_name": "cars", "column_name": "id", "data_type": "integer"}]`), 0644))
+
+	c, err := LoadFiles([]string{sql, dump}, token.Postgres)
+	assert.NoError(t, err)
+	assert.Len(t, c.Tables, 2)
+
+	_, err = LoadFiles([]string{filepath.Join(dir, "missing.sql")}, token.Postgres)
+	assert.Error(t, err)
+}
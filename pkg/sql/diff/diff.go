@@ -0,0 +1,297 @@
+// Package diff compares two queries by their trees rather than their text. Two queries are equivalent when
+// they only differ in formatting, values, aliases, the case of identifiers, or the order of their predicates.
+// When they aren't, the changes say which tables, columns, predicates, and clauses were added, removed, or changed.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/extractor"
+	"github.com/brianbroderick/lantern/pkg/sql/normalize"
+)
+
+type Kind int
+
+const (
+	Added Kind = iota
+	Removed
+	Changed
+)
+
+var kinds = [...]string{Added: "+", Removed: "-", Changed: "~"}
+
+func (k Kind) String() string { return kinds[k] }
+
+// What a change is to
+const (
+	Statement = "statement"
+	Table     = "table"
+	Column    = "column"
+	Predicate = "predicate"
+	Clause    = "clause"
+)
+
+type Change struct {
+	Kind      Kind
+	Statement int    // the index of the statement in the program
+	Object    string // statement, table, column, predicate, or clause
+	Name      string // the table, column, or predicate, or the keyword of the clause, i.e. WHERE or ORDER BY
+	From      string // the masked clause or statement before, when it changed
+	To        string // the masked clause or statement after, when it changed
+}
+
+func (c Change) String() string {
+	if c.Kind == Changed {
+		return fmt.Sprintf("%s statement %d: %s %s: %s -> %s", c.Kind, c.Statement+1, c.Object, c.Name, c.From, c.To)
+	}
+	return fmt.Sprintf("%s statement %d: %s %s", c.Kind, c.Statement+1, c.Object, c.Name)
+}
+
+// Programs compares each statement in a to the statement at the same index in b. The programs are
+// equivalent when there are no changes.
+func Programs(a, b *ast.Program) []Change {
+	changes := []Change{}
+	for i := 0; i < len(a.Statements) || i < len(b.Statements); i++ {
+		switch {
+		case i >= len(b.Statements):
+			changes = append(changes, Change{Kind: Removed, Statement: i, Object: Statement, Name: a.Statements[i].String(true)})
+		case i >= len(a.Statements):
+			changes = append(changes, Change{Kind: Added, Statement: i, Object: Statement, Name: b.Statements[i].String(true)})
+		default:
+			for _, c := range Statements(a.Statements[i], b.Statements[i]) {
+				c.Statement = i
+				changes = append(changes, c)
+			}
+		}
+	}
+	return changes
+}
+
+// Equivalent is true when the statements are the same query
+func Equivalent(a, b ast.Statement) bool {
+	return newFacts(a).masked == newFacts(b).masked
+}
+
+// Statements returns what changed from a to b, or nothing when they're equivalent. Neither statement is changed.
+func Statements(a, b ast.Statement) []Change {
+	from, to := newFacts(a), newFacts(b)
+	if from.masked == to.masked {
+		return []Change{}
+	}
+
+	changes := []Change{}
+	changes = append(changes, compareSets(Table, from.tables, to.tables)...)
+	changes = append(changes, compareSets(Column, from.columns, to.columns)...)
+	changes = append(changes, compareSets(Predicate, from.predicates, to.predicates)...)
+	changes = append(changes, compareClauses(from.clauses, to.clauses)...)
+
+	// The difference is somewhere that isn't broken down, such as the SET clause of an UPDATE
+	if len(changes) == 0 {
+		changes = append(changes, Change{Kind: Changed, Object: Statement, Name: from.command, From: from.masked, To: to.masked})
+	}
+	return changes
+}
+
+// facts are what's compared between two statements
+type facts struct {
+	command    string
+	masked     string
+	tables     map[string]bool
+	columns    map[string]bool
+	predicates map[string]bool
+	clauses    []map[string]string // the clauses of each SELECT in the statement, in order
+}
+
+func newFacts(stmt ast.Statement) *facts {
+	stmt = ast.Clone(stmt)
+
+	// The extractor replaces table aliases with the tables' names, so u.id and users.id are the same column
+	r := extractor.NewExtractor(&stmt, true)
+	r.Execute(stmt)
+	unqualify(stmt)
+	stmt = normalize.Statement(stmt, normalize.All())
+
+	f := &facts{
+		command:    stmt.Command().String(),
+		masked:     stmt.String(true),
+		tables:     make(map[string]bool),
+		columns:    make(map[string]bool),
+		predicates: make(map[string]bool),
+	}
+
+	for _, t := range r.TablesInQueries {
+		f.tables[fmt.Sprintf("%s.%s", t.Schema, t.Name)] = true
+	}
+	for _, c := range r.ColumnsInQueries {
+		f.columns[fmt.Sprintf("%s.%s.%s in %s", c.Schema, c.Table, c.Name, c.Clause)] = true
+	}
+
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectExpression:
+			f.addPredicates("WHERE", n.Where)
+			f.addPredicates("HAVING", n.Having)
+			f.clauses = append(f.clauses, clauses(n))
+		case *ast.TableExpression:
+			f.addPredicates("ON", n.JoinCondition)
+		case *ast.UpdateExpression:
+			f.addPredicates("WHERE", n.Where)
+		case *ast.DeleteExpression:
+			f.addPredicates("WHERE", n.Where)
+		}
+		return true
+	})
+
+	return f
+}
+
+// unqualify drops the table from the columns of a SELECT that only has one table, so users.id and id are the same.
+// Subqueries are left to themselves, since a column qualified with the outer table is a different column there.
+func unqualify(stmt ast.Statement) {
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		x, ok := n.(*ast.SelectExpression)
+		if !ok || len(x.Tables) != 1 {
+			return true
+		}
+		te, ok := x.Tables[0].(*ast.TableExpression)
+		if !ok || te.Kind != ast.TableKindRelation || te.Table == nil {
+			return true
+		}
+		table := te.Table.String(true)
+
+		for _, child := range x.Children() {
+			if child == x.Tables[0] {
+				continue
+			}
+			ast.Inspect(child, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.SelectExpression:
+					return false
+				case *ast.Identifier:
+					if len(n.Value) == 2 && strings.EqualFold(n.Value[0].String(true), table) {
+						n.Value = n.Value[1:]
+					}
+				}
+				return true
+			})
+		}
+		return true
+	})
+}
+
+// addPredicates adds each of the conditions that are ANDed together in a clause
+func (f *facts) addPredicates(clause string, x ast.Expression) {
+	if x == nil {
+		return
+	}
+	if infix, ok := x.(*ast.InfixExpression); ok && !infix.Not && infix.Cast == nil && strings.ToUpper(infix.Operator) == "AND" {
+		f.addPredicates(clause, infix.Left)
+		f.addPredicates(clause, infix.Right)
+		return
+	}
+	f.predicates[fmt.Sprintf("%s %s", clause, x.String(true))] = true
+}
+
+// clauses returns the masked string of each clause by its keyword. WHERE and HAVING are left out,
+// since they're compared by their predicates, and so are the ON conditions of the tables.
+func clauses(x *ast.SelectExpression) map[string]string {
+	c := make(map[string]string)
+	set := func(keyword string, list []ast.Expression) {
+		if len(list) > 0 {
+			items := []string{}
+			for _, item := range list {
+				items = append(items, item.String(true))
+			}
+			c[keyword] = strings.Join(items, ", ")
+		}
+	}
+	one := func(keyword string, item ast.Expression) {
+		if item != nil {
+			c[keyword] = item.String(true)
+		}
+	}
+
+	one("DISTINCT", x.Distinct)
+	set("SELECT", x.Columns)
+	if len(x.Tables) > 0 {
+		tables := []string{}
+		for _, t := range x.Tables {
+			if te, ok := t.(*ast.TableExpression); ok {
+				without := *te
+				without.JoinCondition = nil
+				tables = append(tables, without.String(true))
+			} else {
+				tables = append(tables, t.String(true))
+			}
+		}
+		c["FROM"] = strings.Join(tables, " ")
+	}
+	set("GROUP BY", x.GroupBy)
+	if x.GroupByDistinct {
+		c["GROUP BY"] = "DISTINCT " + c["GROUP BY"]
+	}
+	set("WINDOW", x.Window)
+	set("ORDER BY", x.OrderBy)
+	one("LIMIT", x.Limit)
+	one("OFFSET", x.Offset)
+	one("FETCH", x.Fetch)
+	one("FOR", x.Lock)
+	return c
+}
+
+// clauseOrder is the order that clauses are reported in
+var clauseOrder = []string{"DISTINCT", "SELECT", "FROM", "GROUP BY", "WINDOW", "ORDER BY", "LIMIT", "OFFSET", "FETCH", "FOR"}
+
+func compareClauses(from, to []map[string]string) []Change {
+	changes := []Change{}
+	for i := 0; i < len(from) || i < len(to); i++ {
+		a, b := map[string]string{}, map[string]string{}
+		if i < len(from) {
+			a = from[i]
+		}
+		if i < len(to) {
+			b = to[i]
+		}
+
+		for _, keyword := range clauseOrder {
+			before, inA := a[keyword]
+			after, inB := b[keyword]
+			switch {
+			case inA && !inB:
+				changes = append(changes, Change{Kind: Removed, Object: Clause, Name: fmt.Sprintf("%s %s", keyword, before)})
+			case !inA && inB:
+				changes = append(changes, Change{Kind: Added, Object: Clause, Name: fmt.Sprintf("%s %s", keyword, after)})
+			case before != after:
+				changes = append(changes, Change{Kind: Changed, Object: Clause, Name: keyword, From: before, To: after})
+			}
+		}
+	}
+	return changes
+}
+
+func compareSets(object string, from, to map[string]bool) []Change {
+	changes := []Change{}
+	for _, name := range sorted(from) {
+		if !to[name] {
+			changes = append(changes, Change{Kind: Removed, Object: object, Name: name})
+		}
+	}
+	for _, name := range sorted(to) {
+		if !from[name] {
+			changes = append(changes, Change{Kind: Added, Object: object, Name: name})
+		}
+	}
+	return changes
+}
+
+func sorted(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
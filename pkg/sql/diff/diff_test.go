@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEquivalent(t *testing.T) {
+	tests := []struct {
+		a, b       string
+		equivalent bool
+	}{
+		{"select id from users where id = 1", "SELECT id\nFROM users\nWHERE id = 42", true},
+		{"select u.id as user_id from users u where u.id = 1 and u.name = 'a'", "SELECT ID FROM Users WHERE Name = 'b' AND ID = 2", true},
+		{"select * from users u join cars c on u.id = c.user_id", "select * from users join cars on cars.user_id = users.id", true},
+		{"select id from users where id in (1, 2, 3)", "select id from users where id in (4)", true},
+		{"/* controller='users' */ select id from users", "select id from users", true},
+
+		{"select id from users", "select id from cars", false},
+		{"select id from users", "select id, name from users", false},
+		{"select id, name from users", "select name, id from users", false},
+		{"select id from users where id = 1", "select id from users where id > 1", false},
+		{"select * from users join cars on cars.user_id = users.id", "select * from users left join cars on cars.user_id = users.id", false},
+		{"select id from users where id in (select user_id from cars)", "select id from users where id in (select id from cars)", false},
+	}
+
+	for _, tt := range tests {
+		a, b := statement(t, tt.a), statement(t, tt.b)
+		assert.Equal(t, tt.equivalent, Equivalent(a, b), "%s\n%s", tt.a, tt.b)
+		assert.Equal(t, tt.equivalent, len(Statements(a, b)) == 0, "%s\n%s", tt.a, tt.b)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		changes []string
+	}{
+		{"select id from users where id = 1", "select id from users where id = 1 and deleted_at is null",
+			[]string{
+				"+ statement 1: column public.users.deleted_at in WHERE",
+				"+ statement 1: predicate WHERE (deleted_at IS ?)",
+			}},
+		{"select u.id from users u", "select u.id from users u join cars c on c.user_id = u.id",
+			[]string{
+				"+ statement 1: table public.cars",
+				"+ statement 1: predicate ON (cars.user_id = users.id)",
+				"~ statement 1: clause SELECT: id -> users.id",
+				"~ statement 1: clause FROM: users -> users INNER JOIN cars",
+			}},
+		{"select id from cars where make = 'a'", "select id from cars where model = 'a' order by id limit 10",
+			[]string{
+				"- statement 1: column public.cars.make in WHERE",
+				"+ statement 1: column public.cars.id in ORDER",
+				"+ statement 1: column public.cars.model in WHERE",
+				"- statement 1: predicate WHERE (make = '?')",
+				"+ statement 1: predicate WHERE (model = '?')",
+				"+ statement 1: clause ORDER BY id",
+				"+ statement 1: clause LIMIT ?",
+			}},
+		{"select distinct id from users", "select id from users",
+			[]string{"- statement 1: clause DISTINCT DISTINCT"}},
+		{"update users set name = 'a' where id = 1", "update users set email = 'a' where id = 1",
+			[]string{"~ statement 1: statement UPDATE: (UPDATE users SET (name = '?') WHERE (id = ?)); -> (UPDATE users SET (email = '?') WHERE (id = ?));"}},
+	}
+
+	for _, tt := range tests {
+		changes := []string{}
+		for _, c := range Statements(statement(t, tt.a), statement(t, tt.b)) {
+			changes = append(changes, c.String())
+		}
+		assert.Equal(t, tt.changes, changes, "%s\n%s", tt.a, tt.b)
+	}
+}
+
+func TestPrograms(t *testing.T) {
+	a := program(t, "select id from users; select id from cars;")
+	b := program(t, "SELECT id FROM users; select id from trucks; select 1;")
+
+	changes := []string{}
+	for _, c := range Programs(a, b) {
+		changes = append(changes, c.String())
+	}
+	assert.Equal(t, []string{
+		"- statement 2: table public.cars",
+		"+ statement 2: table public.trucks",
+		"- statement 2: column public.cars.id in SELECT",
+		"+ statement 2: column public.trucks.id in SELECT",
+		"~ statement 2: clause FROM: cars -> trucks",
+		"+ statement 3: statement (SELECT ?);",
+	}, changes)
+
+	// The programs aren't changed
+	assert.Equal(t, "(SELECT id FROM users);", b.Statements[0].String(true))
+}
+
+func program(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), "input: %s", input)
+	return program
+}
+
+func statement(t *testing.T, input string) ast.Statement {
+	program := program(t, input)
+	if !assert.Len(t, program.Statements, 1, "input: %s", input) {
+		t.FailNow()
+	}
+	return program.Statements[0]
+}
@@ -2,8 +2,11 @@ package extractor
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
 	"github.com/brianbroderick/lantern/pkg/sql/object"
 	"github.com/brianbroderick/lantern/pkg/sql/token"
 )
@@ -16,9 +19,19 @@ type Extractor struct {
 	FunctionsInQueries  map[string]*FunctionsInQueries  `json:"functions_in_queries,omitempty"`
 	Tables              map[string]*Tables              `json:"tables,omitempty"`
 	// CreateStatementsInQueries map[string]*CreateStatementsInQueries `json:"create_statements_in_queries,omitempty"`
-	CreateStatements map[string]*CreateStatements `json:"create_statements,omitempty"`
-	MustExtract      bool
-	errors           []string
+	CreateStatements    map[string]*CreateStatements    `json:"create_statements,omitempty"`
+	UnresolvedColumns   map[string]*UnresolvedColumns   `json:"unresolved_columns,omitempty"`    // only found when there's a catalog
+	ColumnLineage       map[string]*ColumnLineage       `json:"column_lineage,omitempty"`        // the columns written by INSERT, UPDATE and CREATE TABLE AS
+	PredicatesInQueries map[string]*PredicatesInQueries `json:"predicates_in_queries,omitempty"` // how the columns are filtered
+	JSONPathsInQueries  map[string]*JSONPathsInQueries  `json:"json_paths_in_queries,omitempty"`
+	ColumnTypes         map[string]*ColumnTypes         `json:"column_types,omitempty"` // the probable types of the columns, from how they're used
+	MustExtract         bool
+	Catalog             *catalog.Catalog `json:"-"` // the tables and columns of the database, when they're known
+	SearchPath          []string         `json:"-"` // the schemas of unqualified tables. DefaultSearchPath when it's nil.
+	User                string           `json:"-"` // the role that ran the query, which is the "$user" schema of the search path
+	errors              []string
+	relations           map[*ast.SelectExpression]*object.Relation // the columns each SELECT returns, for the CTEs and subqueries they're in
+	jsonPathParts       map[*ast.InfixExpression]bool              // the expressions that are part of a longer JSON path
 }
 
 func NewExtractor(stmt *ast.Statement, mustExtract bool) *Extractor {
@@ -30,9 +43,16 @@ func NewExtractor(stmt *ast.Statement, mustExtract bool) *Extractor {
 		FunctionsInQueries:  make(map[string]*FunctionsInQueries),
 		Tables:              make(map[string]*Tables),
 		// CreateStatementsInQueries: make(map[string]*CreateStatementsInQueries),
-		CreateStatements: make(map[string]*CreateStatements),
-		errors:           []string{},
-		MustExtract:      mustExtract,
+		CreateStatements:    make(map[string]*CreateStatements),
+		UnresolvedColumns:   make(map[string]*UnresolvedColumns),
+		ColumnLineage:       make(map[string]*ColumnLineage),
+		PredicatesInQueries: make(map[string]*PredicatesInQueries),
+		JSONPathsInQueries:  make(map[string]*JSONPathsInQueries),
+		ColumnTypes:         make(map[string]*ColumnTypes),
+		relations:           make(map[*ast.SelectExpression]*object.Relation),
+		jsonPathParts:       make(map[*ast.InfixExpression]bool),
+		errors:              []string{},
+		MustExtract:         mustExtract,
 	}
 }
 
@@ -52,6 +72,13 @@ func (r *Extractor) Extract(node ast.Node, env *object.Environment) {
 		r.AddCreateStatement(node, env)
 		// For the select statement in a create statement
 		r.Extract(node.Expression, env)
+		if r.MustExtract {
+			r.extractCreateLineage(node)
+			// CREATE TABLE ... AS writes the rows of its query
+			if ident, ok := node.Name.(*ast.Identifier); ok && node.Object.Type == token.TABLE && node.Expression != nil {
+				r.addTableAccess(ident, Write)
+			}
+		}
 	case *ast.CTEStatement:
 		r.Extract(node.Expression, env)
 	case *ast.InsertStatement:
@@ -60,41 +87,111 @@ func (r *Extractor) Extract(node ast.Node, env *object.Environment) {
 		r.Extract(node.Expression, env)
 	case *ast.DeleteStatement:
 		r.Extract(node.Expression, env)
+	case *ast.VacuumStatement:
+		r.extractTableNames(node.Tables, "")
+	case *ast.ReindexStatement:
+		if node.Object == "TABLE" {
+			r.extractTableNames([]ast.Expression{node.Name}, "")
+		}
+	case *ast.ClusterStatement:
+		r.extractTableNames([]ast.Expression{node.Table}, Write)
+	case *ast.RefreshStatement:
+		r.extractTableNames([]ast.Expression{node.Name}, Write)
+	case *ast.TruncateStatement:
+		r.extractTableNames(node.Tables, Write)
+	case *ast.GrantStatement:
+		if node.OnTables() {
+			r.extractTableNames(node.Objects, "")
+		}
+	case *ast.CreateFunctionStatement:
+		// Tables used inside the body of sql and plpgsql routines
+		if node.Program != nil {
+			r.Extract(node.Program, env)
+		}
+	case *ast.DoStatement:
+		if node.Program != nil {
+			r.Extract(node.Program, env)
+		}
+	case *ast.CreateTriggerStatement:
+		r.extractTableNames([]ast.Expression{node.Table}, "")
+		r.Extract(node.Function, env)
+	case *ast.CallStatement:
+		r.Extract(node.Expression, env)
+	case *ast.PrepareStatement:
+		// Prepared statements and cursors are extracted as the query they wrap
+		r.Extract(node.Query, env)
+	case *ast.DeclareCursorStatement:
+		r.Extract(node.Query, env)
 
 	// Expressions
 	case *ast.CTEExpression:
-		r.Extract(node.Primary, env)
+		// CTEs are in scope for the CTEs after them and the main query, but they aren't tables
+		envCTE := object.NewEnclosedEnvironment(env)
 		for _, a := range node.Auxiliary {
-			r.Extract(a, env)
+			r.extractCTE(a, node.Recursive, envCTE)
 		}
+		r.Extract(node.Primary, envCTE)
 	case *ast.ExpressionStatement:
 		r.Extract(node.Expression, env)
 	case *ast.SelectExpression:
-		envSE := object.NewEnvironment()
-		setTableAliases(envSE, node.TableAliases)
+		envSE := r.newSelectScope(env, node)
 		r.extractSelectExpression(node, envSE)
+		r.relations[node] = r.outputColumns(node, envSE)
 	case *ast.PrefixExpression:
 		r.Extract(node.Right, env)
 	case *ast.PrefixKeywordExpression:
 		r.Extract(node.Right, env)
 	case *ast.InfixExpression:
+		// Extracting the columns unaliases them, so the aliases that tell a self join apart are kept for the WHERE clause
+		qualifiers := [2]string{qualifierOf(node.Left), qualifierOf(node.Right)}
+		var paths []jsonPath
+		if r.MustExtract {
+			paths = r.jsonPathsOf(node)
+		}
 		r.Extract(node.Left, env)
 		r.Extract(node.Right, env)
-		// TODO: This is a hack to get the join condition, but it only works for simple cases
-		// We might be better off compiling to a stack and then popping off the stack to evaluate the expression
-		if node.Clause() == token.ON && r.MustExtract {
-			r.extractOnExpression(*node, env)
+		if r.MustExtract {
+			r.addJSONPaths(node, paths, env)
+			r.inferInfixTypes(node, env)
+			if call, ok := node.Left.(*ast.CallExpression); ok {
+				switch strings.ToUpper(node.Operator) {
+				case "OVER":
+					r.markFunctionCall(call, Window)
+				case "FILTER":
+					r.markFunctionCall(call, Aggregate)
+					// sum(x) FILTER (WHERE ...) OVER (...) is parsed with OVER in the filter
+					ast.Inspect(node.Right, func(n ast.Node) bool {
+						if over, ok := n.(*ast.InfixExpression); ok && strings.EqualFold(over.Operator, "OVER") {
+							r.markFunctionCall(call, Window)
+						}
+						_, sub := n.(*ast.SelectExpression)
+						return !sub
+					})
+				}
+			}
+			switch node.Clause() {
+			case token.ON:
+				r.extractOnExpression(*node, env)
+			case token.WHERE:
+				r.extractWhereJoin(node, qualifiers, env)
+			}
 		}
 	case *ast.GroupedExpression:
 		for _, e := range node.Elements {
 			r.Extract(e, env)
 		}
 	case *ast.CallExpression:
+		if r.MustExtract {
+			r.AddFunctionsInQueries(node)
+		}
 		for _, a := range node.Arguments {
 			r.Extract(a, env)
 		}
 		r.Extract(node.Distinct, env)
 		r.Extract(node.Function, env)
+		if r.MustExtract {
+			r.inferCallTypes(node, env)
+		}
 	case *ast.ArrayLiteral:
 		for _, e := range node.Elements {
 			r.Extract(e, env)
@@ -139,6 +236,16 @@ func (r *Extractor) Extract(node ast.Node, env *object.Environment) {
 		for _, o := range node.OrderBy {
 			r.Extract(o, env)
 		}
+		if node.Frame != nil {
+			r.Extract(node.Frame.Start.Offset, env)
+			if node.Frame.End != nil {
+				r.Extract(node.Frame.End.Offset, env)
+			}
+		}
+	case *ast.GroupingSetExpression:
+		for _, s := range node.Sets {
+			r.Extract(s, env)
+		}
 	case *ast.TableExpression:
 		// remove the table alias from the environment
 		switch node.Table.(type) {
@@ -147,25 +254,55 @@ func (r *Extractor) Extract(node ast.Node, env *object.Environment) {
 		}
 		// set jointype as a variable in the env
 		setJoinType(env, node.JoinType)
-		r.Extract(node.JoinCondition, env)
 
-		r.Extract(node.Table, env)
+		switch node.Kind {
+		case ast.TableKindFunction, ast.TableKindRowsFrom:
+			// Functions aren't tables, but their arguments may be columns from other tables, i.e. LATERAL unnest(u.tags)
+			envTF := object.NewEnclosedEnvironment(env)
+			setTableFunction(envTF)
+			r.Extract(node.Table, envTF)
+			for _, f := range node.Functions {
+				r.Extract(f, envTF)
+			}
+		case ast.TableKindSubquery:
+			r.extractSubquery(node, env)
+		default:
+			r.Extract(node.Table, env)
+		}
+
+		// The join condition can reference the subquery, so it's extracted after it's in scope
+		r.Extract(node.JoinCondition, env)
+		if r.MustExtract {
+			r.extractPredicates(token.ON, node.JoinCondition, false, env)
+		}
 	case *ast.LockExpression:
 		for _, t := range node.Tables {
 			r.Extract(t, env)
 		}
+		if r.MustExtract {
+			r.extractLock(node, env)
+		}
 	case *ast.InExpression:
 		r.Extract(node.Left, env)
 		for _, e := range node.Right {
 			r.Extract(e, env)
 		}
+		if r.MustExtract {
+			r.inferInTypes(node, env)
+		}
 	case *ast.CastExpression:
 		r.Extract(node.Left, env)
+		if r.MustExtract {
+			r.inferCastType(node.Left, node.Cast, env)
+		}
 	case *ast.WhereExpression:
 		r.Extract(node.Right, env)
 	case *ast.IsExpression:
 		r.Extract(node.Left, env)
 		r.Extract(node.Right, env)
+		if r.MustExtract {
+			r.inferIsTypes(node, env)
+		}
 	case *ast.TrimExpression:
 		r.Extract(node.Expression, env)
 	case *ast.StringFunctionExpression:
@@ -181,19 +318,34 @@ func (r *Extractor) Extract(node ast.Node, env *object.Environment) {
 		// we used to call out SimpleIdentifier here, but it would break the AddTablesInQueries function
 		// Not sure why we were doing that, so there might be a bug here
 		case *ast.Identifier:
-			r.AddTablesInQueries(node.Table.(*ast.Identifier))
+			r.addTableAccess(node.Table.(*ast.Identifier), Write)
 		}
 		// The query in an insert statement is when we're inserting a select statement
 		if node.Query != nil {
 			r.Extract(node.Query, env)
+			if r.MustExtract {
+				r.extractInsertLineage(node)
+			}
 		}
 	case *ast.UpdateExpression:
-		envUE := object.NewEnvironment()
-		setTableAliases(envUE, node.TableAliases)
+		envUE := object.NewEnclosedEnvironment(env)
+		setTableAliases(envUE, scopedAliases(env, targetAlias(node.TableAliases, node.Table, node.Alias)))
+		r.setFromClause(envUE, append([]ast.Expression{&ast.TableExpression{Kind: ast.TableKindRelation, Table: node.Table}}, node.Tables...))
 
 		switch n := node.Table.(type) {
 		case *ast.Identifier:
-			r.AddTablesInQueries(n)
+			r.addTableAccess(n, Write)
+		}
+
+		// Subqueries in the FROM clause are put in scope before the columns that reference them
+		qualifiers := joinQualifiers(node.Tables, envUE)
+		if len(node.Tables) > 0 {
+			for _, t := range node.Tables {
+				r.Extract(t, envUE)
+			}
+		}
+		if r.MustExtract {
+			r.extractUsingJoins(node.Tables, qualifiers, envUE)
 		}
 
 		if node.Set != nil {
@@ -202,32 +354,65 @@ func (r *Extractor) Extract(node ast.Node, env *object.Environment) {
 			}
 		}
 
-		if len(node.Tables) > 0 {
-			for _, t := range node.Tables {
-				r.Extract(t, envUE)
+		if node.Where != nil {
+			r.Extract(node.Where, envUE)
+			if r.MustExtract {
+				r.extractPredicates(token.WHERE, node.Where, false, envUE)
 			}
 		}
 
+		if r.MustExtract {
+			r.extractUpdateLineage(node, envUE)
+		}
+	case *ast.DeleteExpression:
+		envDE := object.NewEnclosedEnvironment(env)
+		setTableAliases(envDE, scopedAliases(env, targetAlias(node.TableAliases, node.Table, node.Alias)))
+		r.setFromClause(envDE, append([]ast.Expression{&ast.TableExpression{Kind: ast.TableKindRelation, Table: node.Table}}, node.Using...))
+
+		switch n := node.Table.(type) {
+		case *ast.Identifier:
+			r.addTableAccess(n, Write)
+		}
+
+		qualifiers := joinQualifiers(node.Using, envDE)
+		for _, u := range node.Using {
+			r.Extract(u, envDE)
+		}
+		if r.MustExtract {
+			r.extractUsingJoins(node.Using, qualifiers, envDE)
+		}
+
 		if node.Where != nil {
-			r.Extract(node.Where, envUE)
+			r.Extract(node.Where, envDE)
+			if r.MustExtract {
+				r.extractPredicates(token.WHERE, node.Where, false, envDE)
+			}
 		}
 
 	// Primitive Expressions
 	case *ast.Identifier:
 		r.extractIdentifier(node, env)
+		if r.MustExtract {
+			r.inferCastType(node, node.Cast, env)
+		}
 
 		// Noops
 	case nil, *ast.AnalyzeStatement, *ast.DropStatement, *ast.SetStatement,
+		*ast.ExecuteStatement, *ast.DeallocateStatement, *ast.FetchStatement, *ast.CloseStatement,
+		*ast.ListenStatement, *ast.NotifyStatement,
 		*ast.ValuesExpression,
 		*ast.WildcardLiteral, *ast.Boolean, *ast.Null,
 		*ast.Unknown, *ast.Infinity, *ast.IllegalExpression,
-		*ast.SimpleIdentifier, *ast.IntegerLiteral, *ast.FloatLiteral,
-		*ast.StringLiteral, *ast.EscapeStringLiteral,
+		*ast.SimpleIdentifier, *ast.IntegerLiteral, *ast.FloatLiteral, *ast.ParamLiteral,
+		*ast.StringLiteral, *ast.EscapeStringLiteral, *ast.DollarStringLiteral,
 		*ast.TimestampExpression, *ast.KeywordExpression:
 		// Do nothing
 
+	// Anything without special handling is walked generically, so new node types don't need to be added here
 	default:
-		r.newError("unknown node type: %T", node)
+		for _, child := range node.Children() {
+			r.Extract(child, env)
+		}
 	}
 }
 
@@ -244,18 +429,27 @@ func (r *Extractor) extractSelectStatement(s *ast.SelectStatement, env *object.E
 }
 
 func (r *Extractor) extractSelectExpression(x *ast.SelectExpression, env *object.Environment) {
+	// Subqueries in the FROM clause are put in scope before the columns that reference them
+	qualifiers := joinQualifiers(x.Tables, env)
+	for _, t := range x.Tables {
+		r.Extract(t, env)
+	}
+	if r.MustExtract {
+		r.extractUsingJoins(x.Tables, qualifiers, env)
+	}
 	r.Extract(x.Distinct, env)
 	for _, c := range x.Columns {
 		r.Extract(c, env)
 	}
-	for _, t := range x.Tables {
-		r.Extract(t, env)
-	}
 	r.Extract(x.Where, env)
 	for _, g := range x.GroupBy {
 		r.Extract(g, env)
 	}
 	r.Extract(x.Having, env)
+	if r.MustExtract {
+		r.extractPredicates(token.WHERE, x.Where, false, env)
+		r.extractPredicates(token.HAVING, x.Having, false, env)
+	}
 	for _, w := range x.Window {
 		r.Extract(w, env)
 	}
@@ -268,7 +462,8 @@ func (r *Extractor) extractSelectExpression(x *ast.SelectExpression, env *object
 	r.Extract(x.Lock, env)
 }
 
-// TODO: This only handles simple cases. We need to handle more complex cases
+// extractOnExpression records a comparison of two columns in a join condition. Each comparison in a condition like
+// a.x = b.x AND a.y = b.y is extracted on its own, so each column of a composite key is recorded.
 func (r *Extractor) extractOnExpression(node ast.InfixExpression, env *object.Environment) {
 	var (
 		left, right   *ast.Identifier
@@ -286,10 +481,59 @@ func (r *Extractor) extractOnExpression(node ast.InfixExpression, env *object.En
 	}
 
 	if shouldProcess == 2 {
+		var ok bool
+		if left, ok = r.joinColumn(left, env); !ok {
+			return
+		}
+		if right, ok = r.joinColumn(right, env); !ok {
+			return
+		}
 		r.AddJoinInQuery(left, right, node.String(false), env)
 	}
 }
 
+// joinColumn follows a column of a CTE or subquery in a join condition to the table column it selects.
+// It returns false when the CTE or subquery computes the column.
+func (r *Extractor) joinColumn(i *ast.Identifier, env *object.Environment) (*ast.Identifier, bool) {
+	schema, table, column, derived := r.resolveColumn(i, env)
+	if !derived {
+		if table == "UNKNOWN" {
+			return r.catalogColumn(i, env), true
+		}
+		return i, true
+	}
+	if table == "" {
+		return nil, false
+	}
+	return &ast.Identifier{Token: i.Token, Value: []ast.Expression{
+		&ast.SimpleIdentifier{Token: i.Token, Value: schema},
+		&ast.SimpleIdentifier{Token: i.Token, Value: table},
+		&ast.SimpleIdentifier{Token: i.Token, Value: column},
+	}, Branch: i.Branch, CommandTag: i.CommandTag}, true
+}
+
+// addScopedColumn adds a column with the table it's resolved to in its scope. Columns of a CTE or subquery are added
+// as the table column they select, and not at all when they're computed.
+func (r *Extractor) addScopedColumn(i *ast.Identifier, env *object.Environment) {
+	schema, table, column, derived := r.resolveColumn(i, env)
+	if derived && table == "" {
+		return
+	}
+	r.addColumnsInQueries(schema, table, column, i.Clause(), i.Command())
+}
+
+// extractTableNames is for maintenance and privilege statements where the tables are listed directly
+// The command of the table in the query is the command of the statement, i.e. VACUUM or GRANT
+// Statements that rewrite the table, like TRUNCATE, write it. The rest neither read nor write its rows.
+func (r *Extractor) extractTableNames(tables []ast.Expression, access string) {
+	for _, t := range tables {
+		switch n := t.(type) {
+		case *ast.Identifier:
+			r.addTableAccess(n, access)
+		}
+	}
+}
+
 func (r *Extractor) extractColumnExpression(c *ast.ColumnExpression, env *object.Environment) {
 	r.Extract(c.Value, env)
 }
@@ -301,6 +545,11 @@ func (r *Extractor) extractIdentifier(i *ast.Identifier, env *object.Environment
 		return
 	}
 
+	// Columns returned by a function in the FROM clause don't belong to a table
+	if isFunctionColumn(env, i) {
+		return
+	}
+
 	switch len(i.Value) {
 	case 2:
 		alias := i.Value[0].(*ast.SimpleIdentifier).Value
@@ -317,12 +566,15 @@ func (r *Extractor) extractIdentifier(i *ast.Identifier, env *object.Environment
 	// Extract based on the clause we're in
 	if r.MustExtract {
 		switch i.Clause() {
-		case token.SELECT, token.WHERE, token.GROUP_BY, token.HAVING, token.ORDER: // These columns are what are selected (select id...)
-			r.AddColumnsInQueries(i)
-		case token.UPDATE, token.INSERT, token.FROM: // The FROM clause will have tables
-			r.AddTablesInQueries(i)
-		case token.FUNCTION_CALL:
-			r.AddFunctionsInQueries(i)
+		case token.SELECT, token.WHERE, token.GROUP_BY, token.HAVING, token.WINDOW, token.ORDER: // These columns are what are selected (select id...)
+			r.addScopedColumn(i, env)
+		case token.UPDATE, token.INSERT, token.DELETE, token.FROM: // The FROM clause will have tables, like UPDATE ... FROM and DELETE ... USING
+			if inTableFunction(env) {
+				r.addScopedColumn(i, env)
+			} else if _, ok := relationName(env, i); !ok {
+				// CTEs aren't tables
+				r.addTableAccess(i, Read)
+			}
 		}
 	}
 }
@@ -345,9 +597,19 @@ func (r *Extractor) PrintErrors() {
 	}
 }
 
-// InferColumnsInTables will set the table if there is only one table in the query
+// InferColumnsInTables sets the table of the columns that aren't qualified with one. Without a catalog,
+// this only works when there's one table in the query, since there's no way to know which table has the column.
 func (r *Extractor) InferColumnsInTables() {
-	// If there are more than one table in the query, there's no way to map a column directly to a table
+	defer r.inferJSONPathTables()
+	defer r.inferColumnTypeTables()
+	defer r.inferPredicateTables()
+	defer r.inferLineageSources()
+
+	if r.Catalog != nil {
+		r.resolveColumnsInTables()
+		return
+	}
+
 	if len(r.TablesInQueries) != 1 {
 		return
 	}
@@ -357,14 +619,107 @@ func (r *Extractor) InferColumnsInTables() {
 		table = t
 	}
 
+	for _, column := range r.ColumnsInQueries {
+		column.Schema, column.Table = table.Schema, table.Name
+	}
+	r.rekeyColumns()
+}
+
+// resolveColumnsInTables uses the catalog to find the table in the query that has each unqualified column.
+// Columns that more than one table has are ambiguous, and columns that aren't in any of them are missing,
+// which is only known when the catalog has all of the query's tables.
+func (r *Extractor) resolveColumnsInTables() {
+	type known struct {
+		query *TablesInQueries
+		table *catalog.Table
+	}
+
+	names := make([]string, 0, len(r.TablesInQueries))
+	for fqtn := range r.TablesInQueries {
+		names = append(names, fqtn)
+	}
+	sort.Strings(names)
+
+	tables := []known{}
+	for _, fqtn := range names {
+		t := r.TablesInQueries[fqtn]
+		if ct, ok := r.Catalog.Table(t.Schema, t.Name); ok {
+			tables = append(tables, known{query: t, table: ct})
+		}
+	}
+	allKnown := len(tables) > 0 && len(tables) == len(r.TablesInQueries)
+
+	for _, column := range r.ColumnsInQueries {
+		if column.Name == "*" {
+			continue
+		}
+
+		if column.Table != "UNKNOWN" {
+			if t, ok := r.Catalog.Table(column.Schema, column.Table); ok {
+				if _, ok := t.Column(column.Name); !ok {
+					r.addUnresolvedColumn(column, Missing, nil)
+				}
+			}
+			continue
+		}
+
+		found := []known{}
+		for _, t := range tables {
+			if _, ok := t.table.Column(column.Name); ok {
+				found = append(found, t)
+			}
+		}
+
+		switch {
+		case len(found) == 1:
+			column.Schema, column.Table = found[0].query.Schema, found[0].query.Name
+		case len(found) > 1:
+			candidates := []string{}
+			for _, t := range found {
+				candidates = append(candidates, fmt.Sprintf("%s.%s", t.query.Schema, t.query.Name))
+			}
+			r.addUnresolvedColumn(column, Ambiguous, candidates)
+		case allKnown && column.Clause != token.ORDER && column.Clause != token.GROUP_BY:
+			// ORDER BY and GROUP BY can name the output columns of the SELECT, which aren't in any table
+			r.addUnresolvedColumn(column, Missing, nil)
+		}
+	}
+
+	// Like without a catalog, a column that's still unknown belongs to the only table in the query
+	if len(r.TablesInQueries) == 1 {
+		for _, t := range r.TablesInQueries {
+			for _, column := range r.ColumnsInQueries {
+				if column.Table == "UNKNOWN" {
+					column.Schema, column.Table = t.Schema, t.Name
+				}
+			}
+		}
+	}
+	r.rekeyColumns()
+}
+
+func (r *Extractor) addUnresolvedColumn(column *ColumnsInQueries, reason string, candidates []string) {
+	fqcn := fmt.Sprintf("%s|%s.%s.%s", column.Clause, column.Schema, column.Table, column.Name)
+	r.UnresolvedColumns[fqcn] = &UnresolvedColumns{
+		Schema:     column.Schema,
+		Table:      column.Table,
+		Name:       column.Name,
+		Clause:     column.Clause,
+		Reason:     reason,
+		Candidates: candidates,
+	}
+}
+
+// rekeyColumns rebuilds the columns after their tables were set, since the table is part of their keys and UIDs
+func (r *Extractor) rekeyColumns() {
 	newColumnsInQueries := make(map[string]*ColumnsInQueries)
 
 	for _, column := range r.ColumnsInQueries {
-		column.Table = table.Name
 		fqcn := fmt.Sprintf("%s|%s.%s.%s", column.Clause, column.Schema, column.Table, column.Name)
 
 		newColumnsInQueries[fqcn] = &ColumnsInQueries{
 			UID:       UuidV5(fqcn),
+			Command:   column.Command,
 			Schema:    column.Schema,
 			Table:     column.Table,
 			TableUID:  UuidV5(fmt.Sprintf("%s.%s", column.Schema, column.Table)),
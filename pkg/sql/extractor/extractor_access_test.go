@@ -0,0 +1,71 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractTableAccess(t *testing.T) {
+	// Each table is fqtn|command|access|lock mode
+	tests := []struct {
+		input  string
+		tables []string
+	}{
+		{"select u.id from users u join orders o on o.user_id = u.id;",
+			[]string{"public.users|SELECT|read|", "public.orders|SELECT|read|"}},
+		// A table only read by the subquery of a write isn't written
+		{"update users set active = false where id in (select user_id from bans);",
+			[]string{"public.users|UPDATE|write|", "public.bans|SELECT|read|"}},
+		{"update users u set total = s.total from stats s where s.user_id = u.id;",
+			[]string{"public.users|UPDATE|write|", "public.stats|UPDATE|read|"}},
+		{"update users set total = 0 from users u2 where u2.id = users.parent_id;",
+			[]string{"public.users|UPDATE|read,write|"}},
+		{"insert into logs select * from events;",
+			[]string{"public.logs|INSERT|write|", "public.events|SELECT|read|"}},
+		{"delete from sessions using users where users.id = sessions.user_id;",
+			[]string{"public.sessions|DELETE|write|", "public.users|DELETE|read|"}},
+		{"create table archive as select * from orders;",
+			[]string{"public.archive|CREATE|write|", "public.orders|SELECT|read|"}},
+		{"truncate users, logs;",
+			[]string{"public.users|TRUNCATE|write|", "public.logs|TRUNCATE|write|"}},
+		{"vacuum users;", []string{"public.users|VACUUM||"}},
+		// FOR UPDATE and FOR SHARE lock the tables in the FROM clause, or the ones listed with OF
+		{"select id from users for update;", []string{"public.users|SELECT|read,lock|UPDATE"}},
+		{"select u.id from users u join orders o on o.user_id = u.id for no key update of u nowait;",
+			[]string{"public.users|SELECT|read,lock|NO KEY UPDATE", "public.orders|SELECT|read|"}},
+		{"with t as (select id from accounts) select id from users, t where id in (select user_id from bans) for share skip locked;",
+			[]string{"public.accounts|SELECT|read|", "public.users|SELECT|read,lock|SHARE", "public.bans|SELECT|read|"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			tables := []string{}
+			for fqtn, table := range r.TablesInQueries {
+				access := []string{}
+				for _, a := range []struct {
+					ok   bool
+					name string
+				}{{table.Reads, Read}, {table.Writes, Write}, {table.Locks, Lock}} {
+					if a.ok {
+						access = append(access, a.name)
+					}
+				}
+				tables = append(tables, fmt.Sprintf("%s|%s|%s|%s", fqtn, table.Command, strings.Join(access, ","), table.LockMode))
+			}
+			assert.ElementsMatch(t, tt.tables, tables, "input: %s", tt.input)
+		}
+	}
+}
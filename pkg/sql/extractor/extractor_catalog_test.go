@@ -0,0 +1,89 @@
+package extractor
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractColumnsWithCatalog(t *testing.T) {
+	c := catalog.New()
+	err := c.AddSQL(`
+		create table users (id bigint, name text, email text);
+		create table addresses (id bigint, user_id bigint, city text);
+	`, token.Postgres)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		input      string
+		columns    []string
+		unresolved []string
+	}{
+		// Each column is in one of the tables
+		{"select name, city from users u join addresses a on a.user_id = u.id;",
+			[]string{"SELECT|public.users.name", "SELECT|public.addresses.city"},
+			[]string{}},
+		{"select email from users, addresses where city = 'Boise';",
+			[]string{"SELECT|public.users.email", "WHERE|public.addresses.city"},
+			[]string{}},
+		// id is in both tables
+		{"select id, name from users u join addresses a on a.user_id = u.id;",
+			[]string{"SELECT|public.UNKNOWN.id", "SELECT|public.users.name"},
+			[]string{"ambiguous SELECT|public.UNKNOWN.id public.addresses,public.users"}},
+		// zip isn't in any of them
+		{"select zip from users, addresses;",
+			[]string{"SELECT|public.UNKNOWN.zip"},
+			[]string{"missing SELECT|public.UNKNOWN.zip"}},
+		{"select u.zip from users u;",
+			[]string{"SELECT|public.users.zip"},
+			[]string{"missing SELECT|public.users.zip"}},
+		// Output columns can be ordered by. The parser records n in the GROUP BY clause.
+		{"select count(*) as n, city from addresses, users group by city order by n;",
+			[]string{"SELECT|public.addresses.city", "GROUP_BY|public.addresses.city", "GROUP_BY|public.UNKNOWN.n"},
+			[]string{}},
+		// The catalog doesn't know about cars, so a column that isn't in users could be in cars
+		{"select make, name from users, cars;",
+			[]string{"SELECT|public.UNKNOWN.make", "SELECT|public.users.name"},
+			[]string{}},
+		// With one table, columns are inferred like they are without a catalog
+		{"select make from cars;",
+			[]string{"SELECT|public.cars.make"},
+			[]string{}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Catalog = c
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			columns := []string{}
+			for fqcn := range r.ColumnsInQueries {
+				columns = append(columns, fqcn)
+			}
+			assert.ElementsMatch(t, tt.columns, columns, "input: %s", tt.input)
+
+			unresolved := []string{}
+			for fqcn, u := range r.UnresolvedColumns {
+				desc := u.Reason + " " + fqcn
+				if len(u.Candidates) > 0 {
+					sort.Strings(u.Candidates)
+					desc += " " + strings.Join(u.Candidates, ",")
+				}
+				unresolved = append(unresolved, desc)
+			}
+			assert.ElementsMatch(t, tt.unresolved, unresolved, "input: %s", tt.input)
+		}
+	}
+}
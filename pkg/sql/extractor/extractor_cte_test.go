@@ -0,0 +1,108 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractScopes(t *testing.T) {
+	tests := []struct {
+		input   string
+		tables  []string
+		columns []string
+		joins   []string
+	}{
+		// CTEs aren't tables, and their columns are the columns they select
+		{"with tmp as (select id, name from users) select name from tmp;",
+			[]string{"public.users"},
+			[]string{"SELECT|public.users.id", "SELECT|public.users.name"},
+			[]string{}},
+		{"with tmp(a, b) as (select id, name from users) select a from tmp t where t.b = 'x';",
+			[]string{"public.users"},
+			[]string{"SELECT|public.users.id", "SELECT|public.users.name", "WHERE|public.users.name"},
+			[]string{}},
+		{"with active as (select id from users where active), totals as (select user_id, sum(amount) as total from orders group by user_id) select a.id, t.total from active a join totals t on t.user_id = a.id;",
+			[]string{"public.users", "public.orders"},
+			[]string{"SELECT|public.users.id", "WHERE|public.users.active", "SELECT|public.orders.user_id", "SELECT|public.orders.amount", "GROUP_BY|public.orders.user_id"},
+			[]string{"public.orders|public.users"}},
+		// A CTE is in scope for the CTEs after it
+		{"with a as (select id, name from users), b as (select name from a) select name from b;",
+			[]string{"public.users"},
+			[]string{"SELECT|public.users.id", "SELECT|public.users.name"},
+			[]string{}},
+		// Columns selected with * are in the one table the CTE selects from
+		{"with tmp as (select * from users) select name from tmp;",
+			[]string{"public.users"},
+			[]string{"SELECT|public.users.name"},
+			[]string{}},
+		{"with tmp as (select u.* from users u join orders o on o.user_id = u.id) select name from tmp;",
+			[]string{"public.users", "public.orders"},
+			[]string{"SELECT|public.users.*", "SELECT|public.users.name"},
+			[]string{"public.orders|public.users"}},
+		// A recursive CTE references itself
+		{"with recursive tree as (select id, parent_id from nodes where id = 1 union all select n.id, n.parent_id from nodes n join tree t on n.parent_id = t.id) select id from tree;",
+			[]string{"public.nodes"},
+			[]string{"SELECT|public.nodes.id", "SELECT|public.nodes.parent_id", "WHERE|public.nodes.id"},
+			[]string{"public.nodes|public.nodes"}},
+		{"with recursive nums(n) as (select 1 union all select n + 1 from nums where n < 10) select n from nums;",
+			[]string{},
+			[]string{},
+			[]string{}},
+		// Subqueries in the FROM clause
+		{"select s.total, u.name from (select user_id, sum(amount) as total from orders group by user_id) s join users u on u.id = s.user_id;",
+			[]string{"public.orders", "public.users"},
+			[]string{"SELECT|public.orders.user_id", "SELECT|public.orders.amount", "GROUP_BY|public.orders.user_id", "SELECT|public.users.name"},
+			[]string{"public.orders|public.users"}},
+		{"select x from (select name as x from users) s;",
+			[]string{"public.users"},
+			[]string{"SELECT|public.users.name"},
+			[]string{}},
+		{"select a, b from (select id, name from users) s(a, b);",
+			[]string{"public.users"},
+			[]string{"SELECT|public.users.id", "SELECT|public.users.name"},
+			[]string{}},
+		// Correlated subqueries reference the tables around them
+		{"select name from users u where exists (select 1 from orders o where o.user_id = u.id);",
+			[]string{"public.users", "public.orders"},
+			[]string{"SELECT|public.users.name", "WHERE|public.orders.user_id", "WHERE|public.users.id"},
+			[]string{"public.orders|public.users"}},
+		// CTEs in an UPDATE
+		{"with stale as (select id from sessions where expired) update users set active = false from stale where users.session_id = stale.id;",
+			[]string{"public.sessions", "public.users"},
+			[]string{"SELECT|public.sessions.id", "WHERE|public.sessions.expired", "WHERE|public.users.session_id", "WHERE|public.sessions.id"},
+			[]string{"public.sessions|public.users"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			tables := []string{}
+			for fqtn := range r.TablesInQueries {
+				tables = append(tables, fqtn)
+			}
+			assert.ElementsMatch(t, tt.tables, tables, "input: %s", tt.input)
+
+			columns := []string{}
+			for fqcn := range r.ColumnsInQueries {
+				columns = append(columns, fqcn)
+			}
+			assert.ElementsMatch(t, tt.columns, columns, "input: %s", tt.input)
+
+			joins := []string{}
+			for _, j := range r.TableJoinsInQueries {
+				joins = append(joins, j.SchemaA+"."+j.TableA+"|"+j.SchemaB+"."+j.TableB)
+			}
+			assert.ElementsMatch(t, tt.joins, joins, "input: %s", tt.input)
+		}
+	}
+}
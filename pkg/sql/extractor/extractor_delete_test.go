@@ -0,0 +1,79 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractDeleteStatements(t *testing.T) {
+	t1 := time.Now()
+
+	// Each table is fqtn|command, and each column is fqcn|clause
+	tests := []struct {
+		input   string
+		tables  []string
+		columns []string
+	}{
+		{"delete from users;",
+			[]string{"public.users|DELETE"},
+			[]string{}},
+		{"delete from films where kind <> 'Musical';",
+			[]string{"public.films|DELETE"},
+			[]string{"public.films.kind|WHERE"}},
+		{"delete from only audit.logs l where l.created_at < now() - interval '1 year';",
+			[]string{"audit.logs|DELETE"},
+			[]string{"audit.logs.created_at|WHERE"}},
+		{"delete from tasks where status = 'DONE' returning *;",
+			[]string{"public.tasks|DELETE"},
+			[]string{"public.tasks.status|WHERE"}},
+		{"delete from films using producers where producer_id = producers.id and producers.name = 'foo';",
+			[]string{"public.films|DELETE", "public.producers|DELETE"},
+			[]string{"public.UNKNOWN.producer_id|WHERE", "public.producers.id|WHERE", "public.producers.name|WHERE"}},
+		{"delete from films f using producers p where f.producer_id = p.id;",
+			[]string{"public.films|DELETE", "public.producers|DELETE"},
+			[]string{"public.films.producer_id|WHERE", "public.producers.id|WHERE"}},
+		{"delete from films where producer_id in (select id from producers where name = 'foo');",
+			[]string{"public.films|DELETE", "public.producers|SELECT"},
+			[]string{"public.films.producer_id|WHERE", "public.producers.id|SELECT", "public.producers.name|WHERE"}},
+		{"delete from tasks where current of c_tasks;",
+			[]string{"public.tasks|DELETE"},
+			[]string{}},
+		// A table is recorded once, with the command it's first found in
+		{"with old as (select id from sessions where expires_at < now()) delete from sessions where id in (select id from old);",
+			[]string{"public.sessions|SELECT"},
+			[]string{"public.sessions.id|SELECT", "public.sessions.expires_at|WHERE", "public.sessions.id|WHERE"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			tables := []string{}
+			for fqtn, table := range r.TablesInQueries {
+				tables = append(tables, fmt.Sprintf("%s|%s", fqtn, table.Command))
+			}
+			assert.ElementsMatch(t, tt.tables, tables, "input: %s", tt.input)
+
+			columns := []string{}
+			for _, c := range r.ColumnsInQueries {
+				columns = append(columns, fmt.Sprintf("%s.%s.%s|%s", c.Schema, c.Table, c.Name, c.Clause))
+			}
+			assert.ElementsMatch(t, tt.columns, columns, "input: %s", tt.input)
+		}
+	}
+
+	t2 := time.Now()
+	timeDiff := t2.Sub(t1)
+	fmt.Printf("TestExtractDeleteStatements, Elapsed Time: %s\n", timeDiff)
+}
@@ -0,0 +1,113 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractRoutines(t *testing.T) {
+	t1 := time.Now()
+
+	tests := []struct {
+		input  string
+		tables [][]string
+	}{
+		{"create function total(a int) returns bigint language sql as $$ select sum(amount) from payments where user_id = a $$;",
+			[][]string{{"public.payments", "SELECT"}}},
+		{"create or replace function log_change() returns trigger as $body$ begin insert into audit.changes (id) values (new.id); perform pg_notify('changes', new.id::text); return new; end; $body$ language plpgsql;",
+			[][]string{{"audit.changes", "INSERT"}}},
+		{"create procedure expire(cutoff timestamp) language plpgsql as $$ declare n int; begin select count(*) into n from users; delete from sessions where created_at < cutoff; end $$;",
+			[][]string{{"public.users", "SELECT"}, {"public.sessions", "DELETE"}}},
+		{"create function add(int, int) returns int as 'select $1 + $2' language sql immutable;", [][]string{}},
+		{"create function c_func(int) returns int as 'lib', 'c_func' language c;", [][]string{}},
+		{"do $$ begin update users set active = false where last_seen < now() - interval '1 year'; end $$;",
+			[][]string{{"public.users", "UPDATE"}}},
+		{"create trigger users_audit after insert or update on users for each row execute function log_change();",
+			[][]string{{"public.users", "CREATE"}}},
+		{"call expire(now());", [][]string{}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		assert.Equal(t, 0, len(p.Errors()), "input: %s\nParser errors: %v", tt.input, p.Errors())
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			assert.Equal(t, len(tt.tables), len(r.TablesInQueries), "input: %s\nNumber of tables not equal", tt.input)
+
+			for _, ss := range tt.tables {
+				table, ok := r.TablesInQueries[ss[0]]
+				if assert.True(t, ok, "input: %s\nTable %s not found", tt.input, ss[0]) {
+					assert.Equal(t, ss[1], table.Command.String(), "input: %s\nCommand not equal", tt.input)
+				}
+			}
+		}
+	}
+
+	t2 := time.Now()
+	timeDiff := t2.Sub(t1)
+	fmt.Printf("TestExtractRoutines, Elapsed Time: %s\n", timeDiff)
+}
+
+func TestExtractFunctionsInQueries(t *testing.T) {
+	// Each function is schema.name/arguments|kind|volatility|builtin|windowed|calls
+	tests := []struct {
+		input     string
+		functions []string
+	}{
+		{"select count(*), count(distinct id), lower(email), lower(name) from users;",
+			[]string{
+				"pg_catalog.count/0|aggregate|immutable|true|false|1",
+				"pg_catalog.count/1|aggregate|immutable|true|false|1",
+				"pg_catalog.lower/1|scalar|immutable|true|false|2",
+			}},
+		{"select row_number() over (partition by a), sum(x) filter (where y > 1) over () from t;",
+			[]string{
+				"pg_catalog.row_number/0|window|immutable|true|true|1",
+				"pg_catalog.sum/1|aggregate|immutable|true|true|1",
+			}},
+		// User-defined functions are in the first schema on the search path, unless they're qualified
+		{"select billing.invoice_total(id, $1), my_agg(x) filter (where y), my_rank() over () from invoices where created_at > now() and id = nextval('s');",
+			[]string{
+				"billing.invoice_total/2|scalar||false|false|1",
+				"public.my_agg/1|aggregate||false|false|1",
+				"public.my_rank/0|window||false|true|1",
+				"pg_catalog.now/0|scalar|stable|true|false|1",
+				"pg_catalog.nextval/1|scalar|volatile|true|false|1",
+			}},
+		{"select * from generate_series(1, 10), public.lower(x);",
+			[]string{
+				"pg_catalog.generate_series/2|scalar|immutable|true|false|1",
+				"public.lower/1|scalar||false|false|1",
+			}},
+		{"select id from users;", []string{}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			functions := []string{}
+			for fqn, f := range r.FunctionsInQueries {
+				functions = append(functions, fmt.Sprintf("%s|%s|%s|%t|%t|%d", fqn, f.Kind, f.Volatility, f.Builtin, f.Windowed, f.Calls))
+			}
+			assert.ElementsMatch(t, tt.functions, functions, "input: %s", tt.input)
+		}
+	}
+}
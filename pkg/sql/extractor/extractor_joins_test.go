@@ -0,0 +1,104 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractJoins(t *testing.T) {
+	c := catalog.New()
+	err := c.AddSQL(`
+		create table public.orders (id bigint, customer_id bigint, region text);
+		create table public.customers (customer_id bigint, region text, name text);
+		create table public.shipments (id bigint, order_id bigint);
+	`, token.Postgres)
+	assert.NoError(t, err)
+
+	// Each join is column_a|column_b|join_condition|on_condition
+	tests := []struct {
+		input   string
+		catalog *catalog.Catalog
+		joins   []string
+	}{
+		{"select * from users u join addresses a on a.user_id = u.id;", nil,
+			[]string{"public.addresses.user_id|public.users.id|INNER JOIN|(addresses.user_id = users.id)"}},
+		// Composite keys are a join for each column
+		{"select * from orders o left join order_items i on i.order_id = o.id and i.region = o.region and i.active;", nil,
+			[]string{
+				"public.order_items.order_id|public.orders.id|LEFT JOIN|(order_items.order_id = orders.id)",
+				"public.order_items.region|public.orders.region|LEFT JOIN|(order_items.region = orders.region)",
+			}},
+		{"select * from orders join order_items using (order_id, region);", nil,
+			[]string{
+				"public.order_items.order_id|public.orders.order_id|INNER JOIN|USING (order_id, region)",
+				"public.order_items.region|public.orders.region|INNER JOIN|USING (order_id, region)",
+			}},
+		// USING joins the closest table before it that has the column
+		{"select * from orders o join customers c using (customer_id) join shipments s on s.order_id = o.id;", c,
+			[]string{
+				"public.customers.customer_id|public.orders.customer_id|INNER JOIN|USING (customer_id)",
+				"public.orders.id|public.shipments.order_id|INNER JOIN|(shipments.order_id = orders.id)",
+			}},
+		{"select * from orders o join shipments s on s.order_id = o.id join customers c using (region);", c,
+			[]string{
+				"public.orders.id|public.shipments.order_id|INNER JOIN|(shipments.order_id = orders.id)",
+				"public.customers.region|public.orders.region|INNER JOIN|USING (region)",
+			}},
+		// NATURAL joins the columns that both tables have, which are known with a catalog
+		{"select * from orders natural join customers;", c,
+			[]string{
+				"public.customers.customer_id|public.orders.customer_id|INNER JOIN|NATURAL",
+				"public.customers.region|public.orders.region|INNER JOIN|NATURAL",
+			}},
+		{"select * from orders natural left join customers;", nil,
+			[]string{"public.customers.|public.orders.|LEFT JOIN|NATURAL"}},
+		// Tables listed with commas are joined in the WHERE clause
+		{"select * from orders o, customers c where c.customer_id = o.customer_id and o.region = 'us';", nil,
+			[]string{"public.customers.customer_id|public.orders.customer_id|WHERE|(customers.customer_id = orders.customer_id)"}},
+		{"select * from orders, shipments where order_id = orders.id;", c,
+			[]string{"public.orders.id|public.shipments.order_id|WHERE|(order_id = orders.id)"}},
+		{"select * from nodes a, nodes b where a.parent_id = b.id;", nil,
+			[]string{"public.nodes.id|public.nodes.parent_id|WHERE|(nodes.parent_id = nodes.id)"}},
+		{"select * from nodes a where a.parent_id = a.id;", nil,
+			[]string{}},
+		{"select * from orders o, customers c where c.customer_id > o.customer_id;", nil,
+			[]string{}},
+		// Joins in subqueries and CTEs
+		{"select * from users where id in (select o.user_id from orders o join payments p on p.order_id = o.id);", nil,
+			[]string{"public.orders.id|public.payments.order_id|INNER JOIN|(payments.order_id = orders.id)"}},
+		{"with recent as (select id, user_id from orders where created_at > now()) select * from recent r join users u on u.id = r.user_id;", nil,
+			[]string{"public.orders.user_id|public.users.id|INNER JOIN|(users.id = recent.user_id)"}},
+		{"with recent as (select id, user_id from orders) select * from recent join users using (id);", nil,
+			[]string{"public.orders.id|public.users.id|INNER JOIN|USING (id)"}},
+		// UPDATE ... FROM and DELETE ... USING
+		{"update orders o set status = 'shipped' from shipments s where s.order_id = o.id;", nil,
+			[]string{"public.orders.id|public.shipments.order_id|WHERE|(shipments.order_id = orders.id)"}},
+		{"delete from orders o using customers c where c.customer_id = o.customer_id and c.name = 'x';", nil,
+			[]string{"public.customers.customer_id|public.orders.customer_id|WHERE|(customers.customer_id = orders.customer_id)"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Catalog = tt.catalog
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			joins := []string{}
+			for _, j := range r.TableJoinsInQueries {
+				joins = append(joins, fmt.Sprintf("%s.%s.%s|%s.%s.%s|%s|%s", j.SchemaA, j.TableA, j.ColumnA, j.SchemaB, j.TableB, j.ColumnB, j.JoinCondition, j.OnCondition))
+			}
+			assert.ElementsMatch(t, tt.joins, joins, "input: %s", tt.input)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractJSONPaths(t *testing.T) {
+	c := catalog.New()
+	err := c.AddSQL(`create table public.accounts (id bigint, meta jsonb);`, token.Postgres)
+	assert.NoError(t, err)
+
+	// Each path is clause|column|path|operator|count
+	tests := []struct {
+		input string
+		paths []string
+	}{
+		// A chain of -> and ->> is one path
+		{"select data->'address'->>'city', data #>> '{address,zip}', data->0, data->>$1 from events;",
+			[]string{
+				"SELECT|public.events.data|{address,city}|->>|1",
+				"SELECT|public.events.data|{address,zip}|#>>|1",
+				"SELECT|public.events.data|{0}|->|1",
+				"SELECT|public.events.data|{?}|->>|1",
+			}},
+		{"select id from events e where e.data->>'type' = $1 and data ? 'user' and data ?| array['a', 'b'] and data ?& '{c,d}' order by data->>'type';",
+			[]string{
+				"WHERE|public.events.data|{type}|->>|1",
+				"WHERE|public.events.data|{user}|?|1",
+				"WHERE|public.events.data|{a}|?||1",
+				"WHERE|public.events.data|{b}|?||1",
+				"WHERE|public.events.data|{c}|?&|1",
+				"WHERE|public.events.data|{d}|?&|1",
+				"ORDER|public.events.data|{type}|->>|1",
+			}},
+		// Containment uses the keys of the JSON value. Arrays also have @>, so a value that isn't JSON isn't a path.
+		{`select id from events where data @> '{"user": {"id": 1}, "tags": ["a"]}' and data->'extra' @> $1 and tags @> array['x'] and payload @> $2;`,
+			[]string{
+				"WHERE|public.events.data|{user,id}|@>|1",
+				"WHERE|public.events.data|{tags}|@>|1",
+				"WHERE|public.events.data|{extra}|@>|1",
+			}},
+		// Uses of the same path are counted
+		{"select data->>'type', upper(data->>'type') from events group by data->>'type';",
+			[]string{
+				"SELECT|public.events.data|{type}|->>|2",
+				"GROUP_BY|public.events.data|{type}|->>|1",
+			}},
+		// Columns that aren't qualified are inferred with the catalog
+		{"select u.prefs->>'theme' from users u join accounts a on a.id = u.account_id where meta->>'plan' = 'pro';",
+			[]string{
+				"SELECT|public.users.prefs|{theme}|->>|1",
+				"WHERE|public.accounts.meta|{plan}|->>|1",
+			}},
+		{"select (data->'a')->>'b', (data->'c') @> '{\"d\": 1}' from events;",
+			[]string{
+				"SELECT|public.events.data|{a,b}|->>|1",
+				"SELECT|public.events.data|{c,d}|@>|1",
+			}},
+		{"update events set data = jsonb_set(data, '{seen}', 'true') where data->>'id' = $1;",
+			[]string{"WHERE|public.events.data|{id}|->>|1"}},
+		{"select id from events;", []string{}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Catalog = c
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			paths := []string{}
+			for _, p := range r.JSONPathsInQueries {
+				paths = append(paths, fmt.Sprintf("%s|%s.%s.%s|%s|%s|%d", p.Clause, p.Schema, p.Table, p.Column, p.Path, p.Operator, p.Count))
+			}
+			assert.ElementsMatch(t, tt.paths, paths, "input: %s", tt.input)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractColumnLineage(t *testing.T) {
+	c := catalog.New()
+	err := c.AddSQL(`create table public.totals (user_id bigint, total numeric);`, token.Postgres)
+	assert.NoError(t, err)
+
+	// Each lineage is target|source|expression|functions
+	tests := []struct {
+		input   string
+		lineage []string
+	}{
+		{"insert into reporting.daily_revenue (day, total) select date_trunc('day', created_at), sum(amount) from orders group by 1;",
+			[]string{
+				"reporting.daily_revenue.day|public.orders.created_at|date_trunc('?', created_at)|date_trunc",
+				"reporting.daily_revenue.total|public.orders.amount|sum(amount)|sum",
+			}},
+		// Without a column list, the columns are in the order of the catalog
+		{"insert into totals select user_id, sum(amount) from orders group by user_id;",
+			[]string{
+				"public.totals.user_id|public.orders.user_id||",
+				"public.totals.total|public.orders.amount|sum(amount)|sum",
+			}},
+		// Through CTEs and scalar subqueries
+		{"with t as (select user_id, amount * 2 as x from orders) insert into big (uid, amt) select t.user_id, t.x + (select max(fee) from fees) from t;",
+			[]string{
+				"public.big.uid|public.orders.user_id||",
+				"public.big.amt|public.orders.amount|(t.x + (SELECT max(fee) FROM fees))|max",
+				"public.big.amt|public.fees.fee|(t.x + (SELECT max(fee) FROM fees))|max",
+			}},
+		// A column that isn't qualified is inferred from the columns of the query
+		{"insert into t (a) select o.x from orders o join items i on i.oid = o.id;",
+			[]string{"public.t.a|public.orders.x||"}},
+		{"update users u set total = s.total, name = upper(u.name) from (select user_id, sum(amount) as total from orders group by user_id) s where s.user_id = u.id;",
+			[]string{
+				"public.users.total|public.orders.amount||",
+				"public.users.name|public.users.name|upper(users.name)|upper",
+			}},
+		// A value that isn't computed from a column has no source
+		{"update users set (a, b) = (1, c);",
+			[]string{
+				"public.users.a||?|",
+				"public.users.b|public.users.c||",
+			}},
+		{"create table daily as select user_id, sum(amount) as total from orders group by user_id;",
+			[]string{
+				"public.daily.user_id|public.orders.user_id||",
+				"public.daily.total|public.orders.amount|sum(amount)|sum",
+			}},
+		{"create table daily (u, t) as select user_id, sum(amount) from orders group by user_id;",
+			[]string{
+				"public.daily.u|public.orders.user_id||",
+				"public.daily.t|public.orders.amount|sum(amount)|sum",
+			}},
+		// Queries that don't write columns from a query have no lineage
+		{"insert into users (id, name) values (1, 'a');", []string{}},
+		{"select id from users;", []string{}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Catalog = c
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			lineage := []string{}
+			for _, l := range r.ColumnLineage {
+				source := ""
+				if l.SourceColumn != "" {
+					source = fmt.Sprintf("%s.%s.%s", l.SourceSchema, l.SourceTable, l.SourceColumn)
+				}
+				lineage = append(lineage, fmt.Sprintf("%s.%s.%s|%s|%s|%s", l.TargetSchema, l.TargetTable, l.TargetColumn, source, l.Expression, strings.Join(l.Functions, ",")))
+			}
+			assert.ElementsMatch(t, tt.lineage, lineage, "input: %s", tt.input)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractMaintenanceStatements(t *testing.T) {
+	t1 := time.Now()
+
+	tests := []struct {
+		input  string
+		tables [][]string
+	}{
+		{"vacuum;", [][]string{}},
+		{"vacuum (verbose, analyze) public.users, accounts (id, name);",
+			[][]string{{"public.users", "VACUUM"}, {"public.accounts", "VACUUM"}}},
+		{"reindex table concurrently users;", [][]string{{"public.users", "REINDEX"}}},
+		{"reindex index idx_users_id;", [][]string{}},
+		{"cluster verbose users using idx_users_id;", [][]string{{"public.users", "CLUSTER"}}},
+		{"refresh materialized view concurrently reports.daily_totals;", [][]string{{"reports.daily_totals", "REFRESH"}}},
+		{"truncate only users, accounts restart identity cascade;",
+			[][]string{{"public.users", "TRUNCATE"}, {"public.accounts", "TRUNCATE"}}},
+		{"grant select, update (name) on table users, reports.totals to bob;",
+			[][]string{{"public.users", "GRANT"}, {"reports.totals", "GRANT"}}},
+		{"grant usage on schema reports to bob;", [][]string{}},
+		{"revoke all on users from public;", [][]string{{"public.users", "REVOKE"}}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			assert.Equal(t, len(tt.tables), len(r.TablesInQueries), "input: %s\nNumber of tables not equal", tt.input)
+
+			for _, ss := range tt.tables {
+				table, ok := r.TablesInQueries[ss[0]]
+				if assert.True(t, ok, "input: %s\nTable %s not found", tt.input, ss[0]) {
+					assert.Equal(t, ss[1], table.Command.String(), "input: %s\nCommand not equal", tt.input)
+				}
+			}
+		}
+	}
+
+	t2 := time.Now()
+	timeDiff := t2.Sub(t1)
+	fmt.Printf("TestExtractMaintenanceStatements, Elapsed Time: %s\n", timeDiff)
+}
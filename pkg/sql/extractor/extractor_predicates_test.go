@@ -0,0 +1,113 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractPredicates(t *testing.T) {
+	c := catalog.New()
+	err := c.AddSQL(`create table public.accounts (id bigint, plan text);`, token.Postgres)
+	assert.NoError(t, err)
+
+	// Each predicate is clause|column|operator|value kind|class|function|expression|in list size
+	tests := []struct {
+		input      string
+		predicates []string
+	}{
+		{"select id from users where id = $1;",
+			[]string{"WHERE|public.users.id|=|parameter|equality|||0"}},
+		{"select id from users where lower(email) = $1 and created_at between $2 and $3;",
+			[]string{
+				"WHERE|public.users.email|=|parameter|equality|lower|lower(email)|0",
+				"WHERE|public.users.created_at|BETWEEN|parameter|range|||0",
+			}},
+		{"select id from users where state in ('a', 'b', 'c') or role not in (select role from admins);",
+			[]string{
+				"WHERE|public.users.state|IN|list|in_list|||3",
+				"WHERE|public.users.role|NOT IN|subquery|negation|||0",
+			}},
+		{"select id from users where id = any($1) and 5 < age;",
+			[]string{
+				"WHERE|public.users.id|= ANY|parameter|in_list|||0",
+				"WHERE|public.users.age|>|constant|range|||0",
+			}},
+		{"select id from users where email like '%@example.com' and name ilike 'bob%' and title like $1 and bio ~ 'x';",
+			[]string{
+				"WHERE|public.users.email|LIKE|constant|leading_wildcard|||0",
+				"WHERE|public.users.name|ILIKE|constant|prefix|||0",
+				"WHERE|public.users.title|LIKE|parameter|pattern|||0",
+				"WHERE|public.users.bio|~|constant|pattern|||0",
+			}},
+		{"select id from users where deleted_at is null and email is not null and tags @> $1 and data->>'k' = 'v';",
+			[]string{
+				"WHERE|public.users.deleted_at|IS NULL|null|null|||0",
+				"WHERE|public.users.email|IS NOT NULL|null|null|||0",
+				"WHERE|public.users.tags|@>|parameter|containment|||0",
+				"WHERE|public.users.data|=|constant|equality|->>|(data ->> '?')|0",
+			}},
+		// NOT is folded into the predicate
+		{"select id from users where not active and not (age < 18) and state <> 'x' and email not like '%x';",
+			[]string{
+				"WHERE|public.users.active|IS FALSE|constant|equality|||0",
+				"WHERE|public.users.age|>=|constant|range|||0",
+				"WHERE|public.users.state|<>|constant|negation|||0",
+				"WHERE|public.users.email|NOT LIKE|constant|negation|||0",
+			}},
+		{"select id from users where (a, b) = ($1, $2) and created_at::date = now();",
+			[]string{
+				"WHERE|public.users.a|=|parameter|equality|||0",
+				"WHERE|public.users.b|=|parameter|equality|||0",
+				"WHERE|public.users.created_at|=|function|equality||created_at::DATE|0",
+			}},
+		// Joins, HAVING, and the predicates of subqueries
+		{"select u.id from users u join orders o on o.user_id = u.id and o.state = 'open' where u.id in (select user_id from refunds r where r.amount > 10) group by u.id having max(o.total) > 100;",
+			[]string{
+				"ON|public.orders.user_id|=|column|equality|||0",
+				"ON|public.users.id|=|column|equality|||0",
+				"ON|public.orders.state|=|constant|equality|||0",
+				"WHERE|public.users.id|IN|subquery|in_list|||0",
+				"WHERE|public.refunds.amount|>|constant|range|||0",
+				"HAVING|public.orders.total|>|constant|range|max|max(orders.total)|0",
+			}},
+		// Columns that aren't qualified are inferred with the catalog
+		{"select a.id from accounts a join users u on u.account_id = a.id where plan = $1;",
+			[]string{
+				"ON|public.users.account_id|=|column|equality|||0",
+				"ON|public.accounts.id|=|column|equality|||0",
+				"WHERE|public.accounts.plan|=|parameter|equality|||0",
+			}},
+		{"update users set name = $1 where id = $2;",
+			[]string{"WHERE|public.users.id|=|parameter|equality|||0"}},
+		{"delete from sessions where expires_at < now() - interval '1 day';",
+			[]string{"WHERE|public.sessions.expires_at|<|expression|range|||0"}},
+		// Columns computed by a CTE aren't in a table
+		{"with t as (select count(*) as n from users) select n from t where n > 1;", []string{}},
+		{"select id from users;", []string{}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Catalog = c
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			predicates := []string{}
+			for _, p := range r.PredicatesInQueries {
+				predicates = append(predicates, fmt.Sprintf("%s|%s.%s.%s|%s|%s|%s|%s|%s|%d", p.Clause, p.Schema, p.Table, p.Column, p.Operator, p.ValueKind, p.Class, p.Function, p.Expression, p.InListSize))
+			}
+			assert.ElementsMatch(t, tt.predicates, predicates, "input: %s", tt.input)
+		}
+	}
+}
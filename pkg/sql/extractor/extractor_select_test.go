@@ -113,14 +113,25 @@ func TestExtractColumns(t *testing.T) {
 		{"select avg(salary) over (partition by salary order by depname desc) from empsalary",
 			[]string{"SELECT|public.empsalary.salary", "SELECT|public.empsalary.depname"}},
 		{"select wf1() over w from table_name;",
-			[]string{"SELECT|public.table_name.w"}},
+			[]string{}},
 		{"select wf1() over w, wf2() over w from table_name;",
-			[]string{"SELECT|public.table_name.w"}},
-		// TODO: remove window function from the column list, add window partition by and order by to the column list
+			[]string{}},
 		{"select wf1() over w, wf2() over w from table_name window w as (partition by c1 order by c2);",
-			[]string{"SELECT|public.table_name.w"}},
+			[]string{"WINDOW|public.table_name.c1", "WINDOW|public.table_name.c2"}},
 		{"select wf1() over w, wf2() over w from table_name window w as (partition by c1 order by c2), foo as (partition by c3 order by c4);",
-			[]string{"SELECT|public.table_name.w"}},
+			[]string{"WINDOW|public.table_name.c1", "WINDOW|public.table_name.c2", "WINDOW|public.table_name.c3", "WINDOW|public.table_name.c4"}},
+		{"select sum(amount) over (partition by account_id order by created_at rows between unbounded preceding and current row exclude ties) from payments;",
+			[]string{"SELECT|public.payments.amount", "SELECT|public.payments.account_id", "SELECT|public.payments.created_at"}},
+		{"select sum(amount) over (w range between interval '1 day' preceding and current row) from payments window w as (order by created_at);",
+			[]string{"SELECT|public.payments.amount", "WINDOW|public.payments.created_at"}},
+
+		// Select: grouping sets
+		{"select region, product, sum(amount) from sales group by rollup(region, product);",
+			[]string{"SELECT|public.sales.region", "SELECT|public.sales.product", "SELECT|public.sales.amount", "GROUP_BY|public.sales.region", "GROUP_BY|public.sales.product"}},
+		{"select s.region from sales s group by cube(s.region, (s.product, s.channel));",
+			[]string{"SELECT|public.sales.region", "GROUP_BY|public.sales.region", "GROUP_BY|public.sales.product", "GROUP_BY|public.sales.channel"}},
+		{"select region from sales group by grouping sets ((region), (product), ()) having grouping(region) = 0;",
+			[]string{"SELECT|public.sales.region", "GROUP_BY|public.sales.region", "GROUP_BY|public.sales.product", "HAVING|public.sales.region"}},
 
 		// Select: joins
 		{"select c.id from customers c join addresses a on c.id = a.customer_id;",
@@ -252,8 +263,9 @@ func TestExtractColumns(t *testing.T) {
 
 		// TODO: THIS IS A BUG: we need to look at all of this again.
 		// Select: EXISTS operator. In this case, NOT is a prefix operator
+		// Each SELECT resolves its own columns
 		{"select id from cars where exists (select id from colors where car_id = cars.id);",
-			[]string{"SELECT|public.UNKNOWN.id", "WHERE|public.UNKNOWN.car_id", "WHERE|public.cars.id"}},
+			[]string{"SELECT|public.cars.id", "SELECT|public.colors.id", "WHERE|public.colors.car_id", "WHERE|public.cars.id"}},
 		// {"select id from users where not exists (select id from addresses where user_id = users.id);",
 		// 	[]string{"id", "name"}},
 
@@ -267,7 +279,7 @@ func TestExtractColumns(t *testing.T) {
 
 		// Single tables
 		{"select id from users union select id from customers;",
-			[]string{"SELECT|public.UNKNOWN.id"}},
+			[]string{"SELECT|public.users.id", "SELECT|public.customers.id"}},
 		// {"select id from users except select id from customers;", []string{"id", "name"}},
 		// {"select id from users intersect select id from customers;", []string{"id", "name"}},
 		// {"select id from users union all select id from customers;", []string{"id", "name"}},
@@ -342,8 +354,20 @@ func TestExtractColumns(t *testing.T) {
 		// Select: With Ordinality
 		{"select * from unnest(array [ 4, 2, 1, 3, 7 ]) ;",
 			[]string{}},
-		// {"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality;", []string{"id", "name"}},
-		// {"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality as t(key, index);", []string{"id", "name"}},
+		{"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality;",
+			[]string{}},
+		{"select t.key, t.index from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality as t(key, index);",
+			[]string{}},
+
+		// Select: Table functions and LATERAL
+		{"select x.a from jsonb_to_recordset('[{\"a\":1}]') as x(a int, b text) where x.b = 'c';",
+			[]string{}},
+		{"select t.n, s from rows from (generate_series(1, 3), unnest(array['a'])) as t(n, s);",
+			[]string{}},
+		{"select u.name, t.tag from users u cross join lateral unnest(u.tags) as t(tag) where t.tag = 'a';",
+			[]string{"SELECT|public.users.name", "FROM|public.users.tags"}},
+		{"select u.name, tag from users u, lateral unnest(u.tags) tag;",
+			[]string{"SELECT|public.users.name", "FROM|public.users.tags"}},
 
 		// Select: reserved words
 		// any
@@ -775,8 +799,20 @@ func TestExtractSelectedTables(t *testing.T) {
 		// Select: With Ordinality
 		{"select * from unnest(array [ 4, 2, 1, 3, 7 ]) ;",
 			[][]string{{}}},
-		// {"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality;", [][]string{{"id", "name"}}},
-		// {"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality as t(key, index);", [][]string{{"id", "name"}}},
+		{"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality;",
+			[][]string{{}}},
+		{"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality as t(key, index);",
+			[][]string{{}}},
+
+		// Select: Table functions and LATERAL
+		{"select x.a from jsonb_to_recordset('[{\"a\":1}]') as x(a int, b text);",
+			[][]string{{}}},
+		{"select * from rows from (generate_series(1, 3), unnest(array['a'])) with ordinality as t(n, s, o);",
+			[][]string{{}}},
+		{"select u.name, t.tag from users u cross join lateral unnest(u.tags) as t(tag);",
+			[][]string{{"public.users"}}},
+		{"select * from users u left join lateral (select * from orders o where o.user_id = u.id limit 1) x on true;",
+			[][]string{{"public.users", "public.orders"}}},
 
 		// Select: reserved words
 		// any
@@ -0,0 +1,68 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractSessionStatements(t *testing.T) {
+	t1 := time.Now()
+
+	tests := []struct {
+		input   string
+		tables  [][]string
+		columns []string
+	}{
+		{"prepare get_user(int) as select name from users where id = $1;",
+			[][]string{{"public.users", "SELECT"}}, []string{"SELECT|public.users.name", "WHERE|public.users.id"}},
+		{"prepare upd(int) as update users set active = false where id = $1;",
+			[][]string{{"public.users", "UPDATE"}}, []string{}},
+		{"declare c cursor with hold for select u.id from users u join accounts a on a.user_id = u.id;",
+			[][]string{{"public.users", "SELECT"}, {"public.accounts", "SELECT"}}, []string{"SELECT|public.users.id"}},
+		{"execute get_user(42);", [][]string{}, []string{}},
+		{"fetch 100 from c;", [][]string{}, []string{}},
+		{"close c;", [][]string{}, []string{}},
+		{"deallocate all;", [][]string{}, []string{}},
+		{"listen changes;", [][]string{}, []string{}},
+		{"notify changes, 'hello';", [][]string{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		assert.Equal(t, 0, len(p.Errors()), "input: %s\nParser errors: %v", tt.input, p.Errors())
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			assert.Equal(t, len(tt.tables), len(r.TablesInQueries), "input: %s\nNumber of tables not equal", tt.input)
+
+			for _, ss := range tt.tables {
+				table, ok := r.TablesInQueries[ss[0]]
+				if assert.True(t, ok, "input: %s\nTable %s not found", tt.input, ss[0]) {
+					assert.Equal(t, ss[1], table.Command.String(), "input: %s\nCommand not equal", tt.input)
+				}
+			}
+
+			if len(tt.columns) > 0 {
+				assert.Equal(t, len(tt.columns), len(r.ColumnsInQueries), "input: %s\nNumber of columns not equal", tt.input)
+				for _, c := range tt.columns {
+					_, ok := r.ColumnsInQueries[c]
+					assert.True(t, ok, "input: %s\nColumn %s not found", tt.input, c)
+				}
+			}
+		}
+	}
+
+	t2 := time.Now()
+	timeDiff := t2.Sub(t1)
+	fmt.Printf("TestExtractSessionStatements, Elapsed Time: %s\n", timeDiff)
+}
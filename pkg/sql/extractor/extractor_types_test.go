@@ -0,0 +1,151 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractColumnTypes(t *testing.T) {
+	// Each type is column|type|confidence|used as|inconsistent
+	tests := []struct {
+		input string
+		types []string
+	}{
+		{"select id from users where id = 1 and name = 'bob' and score > 1.5 and active = true;",
+			[]string{
+				"public.users.id|integer|0.8|numeric|false",
+				"public.users.name|text|0.5|text|false",
+				"public.users.score|numeric|0.8|numeric|false",
+				"public.users.active|boolean|0.8|boolean|false",
+			}},
+		// Typed values, and string literals that look like a type
+		{"select id from users where id = $1::uuid and org_id = cast($2 as int8) and created_at > now() - interval '1 day' and born = '2000-01-01' and token = 'a81bc81b-dead-4e5d-abff-90865d1e13b1';",
+			[]string{
+				"public.users.id|uuid|0.8|uuid|false",
+				"public.users.org_id|bigint|0.8|numeric|false",
+				"public.users.created_at|timestamptz|0.8|temporal|false",
+				"public.users.born|date|0.5|temporal|false",
+				"public.users.token|uuid|0.5|uuid|false",
+			}},
+		// Casts, but not to text, since anything can be cast to text
+		{"select id::text, external_id::uuid, cast(paid_at as date) from users;",
+			[]string{
+				"public.users.external_id|uuid|0.6|uuid|false",
+				"public.users.paid_at|date|0.6|temporal|false",
+			}},
+		// Operators
+		{"select data->>'name' from users where email ilike $1 and tags && $2 and meta @> '{\"a\": 1}' and roles @> '{admin}' and id = any(group_ids);",
+			[]string{
+				"public.users.data|jsonb|0.8|json|false",
+				"public.users.email|text|0.8|text|false",
+				"public.users.tags|array|0.8|array|false",
+				"public.users.meta|jsonb|0.8|json|false",
+				"public.users.roles|array|0.8|array|false",
+				"public.users.group_ids|array|0.8|array|false",
+			}},
+		{"select id from users where active and not deleted and verified is true and expires_at + interval '1 day' < current_date;",
+			[]string{
+				"public.users.active|boolean|0.8|boolean|false",
+				"public.users.deleted|boolean|0.8|boolean|false",
+				"public.users.verified|boolean|0.8|boolean|false",
+				"public.users.expires_at|timestamp|0.8|temporal|false",
+			}},
+		// Functions
+		{"select date_trunc('day', created_at), extract(year from born), lower(email), jsonb_array_length(data), array_length(tags, 1), sum(total) from users group by 1, 2, 3, 4, 5;",
+			[]string{
+				"public.users.created_at|timestamp|0.7|temporal|false",
+				"public.users.born|timestamp|0.7|temporal|false",
+				"public.users.email|text|0.7|text|false",
+				"public.users.data|jsonb|0.7|json|false",
+				"public.users.tags|array|0.7|array|false",
+				"public.users.total|numeric|0.7|numeric|false",
+			}},
+		// IN lists and BETWEEN
+		{"select id from users where state in (1, 2, 3) and created_at between '2024-01-01' and '2024-02-01 10:00';",
+			[]string{
+				"public.users.state|integer|0.99|numeric|false",
+				"public.users.created_at|date|0.75|temporal|false",
+			}},
+		// Used inconsistently, compared to both text and integers
+		{"select id from users where code = '10' or code = 10;",
+			[]string{"public.users.code|integer|0.49|numeric,text|true"}},
+		{"select id from users where created_at > '2024-01-01' and created_at < now();",
+			[]string{"public.users.created_at|timestamptz|0.9|temporal|false"}},
+		// Aliases and unqualified columns in a join
+		{"select u.id from users u join orders o on o.user_id = u.id where o.total > 10 and u.email like 'a%' and status = 'open';",
+			[]string{
+				"public.orders.total|integer|0.8|numeric|false",
+				"public.users.email|text|0.8|text|false",
+				"public.UNKNOWN.status|text|0.5|text|false",
+			}},
+		// Columns computed by a CTE aren't in a table
+		{"with t as (select count(*) as n from users) select n from t where n > 1;", []string{}},
+		{"select id, name from users where id = other_id;", []string{}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			types := []string{}
+			for _, c := range r.ColumnTypes {
+				types = append(types, fmt.Sprintf("%s.%s.%s|%s|%g|%s|%t", c.Schema, c.Table, c.Column, c.Type, c.Confidence, strings.Join(c.UsedAs, ","), c.Inconsistent))
+			}
+			assert.ElementsMatch(t, tt.types, types, "input: %s", tt.input)
+		}
+	}
+}
+
+func TestExtractColumnTypesWithCatalog(t *testing.T) {
+	c := catalog.New()
+	err := c.AddSQL(`create table public.accounts (id bigint, plan text);`, token.Postgres)
+	assert.NoError(t, err)
+
+	input := "select a.id from accounts a join users u on u.account_id = a.id where plan = 'pro' and a.id = 5;"
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors())
+
+	r := NewExtractor(&program.Statements[0], true)
+	r.Catalog = c
+	r.Execute(program.Statements[0])
+	checkExtractErrors(t, r, input)
+
+	// The unqualified column is moved to its table once the catalog resolves it
+	types := []string{}
+	for key, c := range r.ColumnTypes {
+		types = append(types, fmt.Sprintf("%s|%s|%s", key, c.Type, c.UID))
+	}
+	assert.ElementsMatch(t, []string{
+		fmt.Sprintf("public.accounts.plan|text|%s", UuidV5("public.accounts.plan")),
+		fmt.Sprintf("public.accounts.id|integer|%s", UuidV5("public.accounts.id")),
+	}, types)
+}
+
+func TestColumnTypesAdd(t *testing.T) {
+	c := &ColumnTypes{Evidence: map[string]int{}}
+	c.Add(&ColumnTypes{Evidence: map[string]int{"comparison:integer": 1}})
+	assert.Equal(t, "integer", c.Type)
+	assert.False(t, c.Inconsistent)
+
+	// The same column in another query
+	c.Add(&ColumnTypes{Evidence: map[string]int{"literal:text": 1, "comparison:bigint": 2}})
+	assert.Equal(t, map[string]int{"comparison:integer": 1, "comparison:bigint": 2, "literal:text": 1}, c.Evidence)
+	assert.Equal(t, "bigint", c.Type)
+	assert.Equal(t, []string{"numeric", "text"}, c.UsedAs)
+	assert.True(t, c.Inconsistent)
+	assert.Equal(t, 0.66, c.Confidence)
+}
@@ -0,0 +1,203 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+)
+
+// The kinds of functions
+const (
+	Scalar    = "scalar"
+	Aggregate = "aggregate"
+	Window    = "window"
+)
+
+// The volatility of functions, like Postgres has it. A volatile function is called for each row,
+// so a predicate on it can't use an index.
+const (
+	Immutable = "immutable"
+	Stable    = "stable"
+	Volatile  = "volatile"
+)
+
+type builtin struct {
+	kind       string
+	volatility string
+}
+
+// builtins are the functions in pg_catalog that are commonly called. Functions that aren't here are user-defined,
+// unless they're qualified with pg_catalog.
+var builtins = map[string]builtin{
+	// Aggregates
+	"array_agg":        {Aggregate, Immutable},
+	"avg":              {Aggregate, Immutable},
+	"bit_and":          {Aggregate, Immutable},
+	"bit_or":           {Aggregate, Immutable},
+	"bool_and":         {Aggregate, Immutable},
+	"bool_or":          {Aggregate, Immutable},
+	"corr":             {Aggregate, Immutable},
+	"count":            {Aggregate, Immutable},
+	"covar_pop":        {Aggregate, Immutable},
+	"covar_samp":       {Aggregate, Immutable},
+	"every":            {Aggregate, Immutable},
+	"json_agg":         {Aggregate, Stable},
+	"json_object_agg":  {Aggregate, Stable},
+	"jsonb_agg":        {Aggregate, Stable},
+	"jsonb_object_agg": {Aggregate, Stable},
+	"max":              {Aggregate, Immutable},
+	"min":              {Aggregate, Immutable},
+	"mode":             {Aggregate, Immutable},
+	"percentile_cont":  {Aggregate, Immutable},
+	"percentile_disc":  {Aggregate, Immutable},
+	"stddev":           {Aggregate, Immutable},
+	"stddev_pop":       {Aggregate, Immutable},
+	"stddev_samp":      {Aggregate, Immutable},
+	"string_agg":       {Aggregate, Immutable},
+	"sum":              {Aggregate, Immutable},
+	"variance":         {Aggregate, Immutable},
+	"var_pop":          {Aggregate, Immutable},
+	"var_samp":         {Aggregate, Immutable},
+	"xmlagg":           {Aggregate, Immutable},
+
+	// Window functions
+	"cume_dist":    {Window, Immutable},
+	"dense_rank":   {Window, Immutable},
+	"first_value":  {Window, Immutable},
+	"lag":          {Window, Immutable},
+	"last_value":   {Window, Immutable},
+	"lead":         {Window, Immutable},
+	"nth_value":    {Window, Immutable},
+	"ntile":        {Window, Immutable},
+	"percent_rank": {Window, Immutable},
+	"rank":         {Window, Immutable},
+	"row_number":   {Window, Immutable},
+
+	// Volatile
+	"clock_timestamp":      {Scalar, Volatile},
+	"currval":              {Scalar, Volatile},
+	"gen_random_uuid":      {Scalar, Volatile},
+	"lastval":              {Scalar, Volatile},
+	"nextval":              {Scalar, Volatile},
+	"pg_advisory_lock":     {Scalar, Volatile},
+	"pg_advisory_unlock":   {Scalar, Volatile},
+	"pg_sleep":             {Scalar, Volatile},
+	"pg_try_advisory_lock": {Scalar, Volatile},
+	"random":               {Scalar, Volatile},
+	"setseed":              {Scalar, Volatile},
+	"setval":               {Scalar, Volatile},
+	"statement_timestamp":  {Scalar, Stable},
+	"timeofday":            {Scalar, Volatile},
+	"txid_current":         {Scalar, Stable},
+
+	// Stable
+	"age":                   {Scalar, Stable},
+	"current_database":      {Scalar, Stable},
+	"current_schema":        {Scalar, Stable},
+	"current_setting":       {Scalar, Stable},
+	"now":                   {Scalar, Stable},
+	"pg_backend_pid":        {Scalar, Stable},
+	"to_char":               {Scalar, Stable},
+	"to_date":               {Scalar, Stable},
+	"to_timestamp":          {Scalar, Stable},
+	"transaction_timestamp": {Scalar, Stable},
+
+	// Immutable
+	"abs":                {Scalar, Immutable},
+	"array_length":       {Scalar, Immutable},
+	"array_to_string":    {Scalar, Immutable},
+	"btrim":              {Scalar, Immutable},
+	"ceil":               {Scalar, Immutable},
+	"char_length":        {Scalar, Immutable},
+	"coalesce":           {Scalar, Immutable},
+	"concat":             {Scalar, Stable},
+	"concat_ws":          {Scalar, Stable},
+	"date_part":          {Scalar, Stable},
+	"date_trunc":         {Scalar, Stable},
+	"extract":            {Scalar, Stable},
+	"floor":              {Scalar, Immutable},
+	"generate_series":    {Scalar, Immutable},
+	"greatest":           {Scalar, Immutable},
+	"initcap":            {Scalar, Immutable},
+	"json_build_object":  {Scalar, Stable},
+	"jsonb_build_object": {Scalar, Stable},
+	"jsonb_set":          {Scalar, Immutable},
+	"least":              {Scalar, Immutable},
+	"left":               {Scalar, Immutable},
+	"length":             {Scalar, Immutable},
+	"lower":              {Scalar, Immutable},
+	"lpad":               {Scalar, Immutable},
+	"ltrim":              {Scalar, Immutable},
+	"md5":                {Scalar, Immutable},
+	"nullif":             {Scalar, Immutable},
+	"regexp_replace":     {Scalar, Immutable},
+	"replace":            {Scalar, Immutable},
+	"right":              {Scalar, Immutable},
+	"round":              {Scalar, Immutable},
+	"rpad":               {Scalar, Immutable},
+	"rtrim":              {Scalar, Immutable},
+	"split_part":         {Scalar, Immutable},
+	"strpos":             {Scalar, Immutable},
+	"substr":             {Scalar, Immutable},
+	"substring":          {Scalar, Immutable},
+	"to_json":            {Scalar, Stable},
+	"to_jsonb":           {Scalar, Stable},
+	"trim":               {Scalar, Immutable},
+	"trunc":              {Scalar, Immutable},
+	"unnest":             {Scalar, Immutable},
+	"upper":              {Scalar, Immutable},
+}
+
+// functionName returns the schema, name and number of arguments of a function call. Functions that aren't qualified
+// with a schema are in pg_catalog when they're builtin, since it's searched first, and otherwise in the first schema
+// of the search path.
+func (r *Extractor) functionName(call *ast.CallExpression) (schema, name string, arguments int, ok bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok || len(ident.Value) == 0 || len(ident.Value) > 2 {
+		return "", "", 0, false
+	}
+
+	name = ident.Value[len(ident.Value)-1].String(false)
+	switch _, isBuiltin := builtins[strings.ToLower(name)]; {
+	case len(ident.Value) == 2:
+		schema = ident.Value[0].String(false)
+	case isBuiltin:
+		schema = "pg_catalog"
+	default:
+		schema = r.defaultSchema()
+	}
+
+	arguments = len(call.Arguments)
+	if arguments == 1 {
+		// count(*) has no arguments
+		if a := call.Arguments[0]; a != nil && a.String(false) == "*" {
+			arguments = 0
+		}
+	}
+	return schema, name, arguments, true
+}
+
+// functionKey is the function's schema and name, with its number of arguments
+func functionKey(schema, name string, arguments int) string {
+	return fmt.Sprintf("%s.%s/%d", schema, name, arguments)
+}
+
+// markFunctionCall records how a function is called: with OVER ( ... ) it's a window call, and with FILTER ( ... )
+// it's an aggregate. A user-defined function called this way has to be that kind of function.
+func (r *Extractor) markFunctionCall(call *ast.CallExpression, kind string) {
+	schema, name, arguments, ok := r.functionName(call)
+	if !ok {
+		return
+	}
+	f, ok := r.FunctionsInQueries[functionKey(schema, name, arguments)]
+	if !ok {
+		return
+	}
+	if kind == Window {
+		f.Windowed = true
+	}
+	if f.Kind == Scalar {
+		f.Kind = kind
+	}
+}
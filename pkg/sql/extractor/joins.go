@@ -0,0 +1,252 @@
+package extractor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/object"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// Joins are recorded as pairs of columns, so a composite key is a join for each of its columns. Besides the comparisons
+// in ON, tables are joined by the columns of USING ( ... ), the columns that both tables have in a NATURAL join, and
+// comparisons of columns of two tables in a WHERE clause, which is how tables listed with commas are joined.
+
+// extractWhereJoin records a comparison of columns of two tables in a WHERE clause. The same table is joined to itself
+// when the columns are qualified with different aliases, which is why it's given the qualifiers before they're unaliased.
+func (r *Extractor) extractWhereJoin(node *ast.InfixExpression, qualifiers [2]string, env *object.Environment) {
+	if node.Operator != "=" {
+		return
+	}
+	left, ok := node.Left.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	right, ok := node.Right.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	if isFunctionColumn(env, left) || isFunctionColumn(env, right) {
+		return
+	}
+
+	if left, ok = r.joinColumn(left, env); !ok {
+		return
+	}
+	if right, ok = r.joinColumn(right, env); !ok {
+		return
+	}
+
+	tableA, tableB := r.joinTable(left), r.joinTable(right)
+	if tableA[1] == "UNKNOWN" || tableB[1] == "UNKNOWN" {
+		return
+	}
+	if tableA[2] == tableB[2] && (qualifiers[0] == "" || qualifiers[0] == qualifiers[1]) {
+		return
+	}
+
+	envW := object.NewEnclosedEnvironment(env)
+	setJoinType(envW, "WHERE")
+	r.addJoin(tableA, tableB, node.String(false), envW)
+}
+
+// qualifierOf returns the table or alias that a column is qualified with, or "" when it isn't
+func qualifierOf(x ast.Expression) string {
+	ident, ok := x.(*ast.Identifier)
+	if !ok || len(ident.Value) < 2 {
+		return ""
+	}
+	return ident.Value[len(ident.Value)-2].String(false)
+}
+
+// extractUsingJoins records the joins of USING ( ... ) and NATURAL in a FROM clause. Each column is joined to the
+// closest table before it that has the column. Without a catalog, a table's columns are only known for CTEs and
+// subqueries, so a table that may have the column is used when none are known to have it.
+func (r *Extractor) extractUsingJoins(tables []ast.Expression, qualifiers [][]string, env *object.Environment) {
+	for i, t := range tables {
+		te, ok := t.(*ast.TableExpression)
+		if !ok || (len(te.Using) == 0 && !te.Natural) || qualifiers[i] == nil {
+			continue
+		}
+
+		envJ := object.NewEnclosedEnvironment(env)
+		setJoinType(envJ, te.JoinType)
+
+		if len(te.Using) > 0 {
+			columns := []string{}
+			for _, c := range te.Using {
+				columns = append(columns, c.Value)
+			}
+			on := fmt.Sprintf("USING (%s)", strings.Join(columns, ", "))
+			for _, column := range columns {
+				r.addUsingJoin(qualifiers[:i], qualifiers[i], column, on, envJ)
+			}
+			continue
+		}
+
+		// NATURAL
+		columns, known := r.columnsOf(qualifiers[i], env)
+		joined := false
+		for _, column := range columns {
+			joined = r.addUsingJoin(qualifiers[:i], qualifiers[i], column, "NATURAL", envJ) || joined
+		}
+		if !joined && !known && i > 0 && qualifiers[i-1] != nil && !isRelation(qualifiers[i-1], env) && !isRelation(qualifiers[i], env) {
+			// The columns aren't known, so the join is between the tables
+			a, b := r.joinTable(qualifiedColumn(qualifiers[i-1], "")), r.joinTable(qualifiedColumn(qualifiers[i], ""))
+			r.addJoin(a, b, "NATURAL", envJ)
+		}
+	}
+}
+
+// addUsingJoin joins a column of a table to the same column of the closest table before it that has it
+func (r *Extractor) addUsingJoin(before [][]string, qualifier []string, column, on string, env *object.Environment) bool {
+	var maybe []string
+	var found []string
+	for i := len(before) - 1; i >= 0 && found == nil; i-- {
+		if before[i] == nil {
+			continue
+		}
+		has, known := r.hasColumn(before[i], column, env)
+		switch {
+		case has:
+			found = before[i]
+		case !known && maybe == nil:
+			maybe = before[i]
+		}
+	}
+	if found == nil {
+		found = maybe
+	}
+	if found == nil {
+		return false
+	}
+
+	left, ok := r.joinColumn(qualifiedColumn(found, column), env)
+	if !ok {
+		return false
+	}
+	right, ok := r.joinColumn(qualifiedColumn(qualifier, column), env)
+	if !ok {
+		return false
+	}
+	r.AddJoinInQuery(left, right, on, env)
+	return true
+}
+
+// joinQualifiers returns what the columns of each table in a FROM clause are qualified with once they're unaliased:
+// the name of a CTE or subquery, or the schema and name of a table. It's nil for functions and subqueries without an alias.
+// It has to be called before the tables are extracted, since that removes their aliases.
+func joinQualifiers(tables []ast.Expression, env *object.Environment) [][]string {
+	qualifiers := make([][]string, len(tables))
+	for i, t := range tables {
+		te, ok := t.(*ast.TableExpression)
+		if !ok {
+			continue
+		}
+		switch te.Kind {
+		case ast.TableKindRelation:
+			ident, ok := te.Table.(*ast.Identifier)
+			if !ok || len(ident.Value) == 0 || len(ident.Value) > 2 {
+				continue
+			}
+			for _, v := range ident.Value {
+				qualifiers[i] = append(qualifiers[i], v.String(false))
+			}
+		case ast.TableKindSubquery:
+			if te.Alias != nil {
+				qualifiers[i] = []string{te.Alias.String(false)}
+			}
+		}
+	}
+	return qualifiers
+}
+
+// isRelation is whether a table in the FROM clause is a CTE or subquery
+func isRelation(qualifier []string, env *object.Environment) bool {
+	if len(qualifier) != 1 {
+		return false
+	}
+	_, ok := getRelation(env, qualifier[0])
+	return ok
+}
+
+// hasColumn is whether a table in the FROM clause has a column, and whether that's known
+func (r *Extractor) hasColumn(qualifier []string, column string, env *object.Environment) (has, known bool) {
+	columns, known := r.columnsOf(qualifier, env)
+	for _, c := range columns {
+		if c == column {
+			return true, true
+		}
+	}
+	return false, known
+}
+
+// columnsOf returns the columns of a table in the FROM clause, and whether they're all known. They're known for CTEs
+// and subqueries that don't select *, and for tables in the catalog.
+func (r *Extractor) columnsOf(qualifier []string, env *object.Environment) ([]string, bool) {
+	if len(qualifier) == 1 {
+		if rel, ok := getRelation(env, qualifier[0]); ok {
+			return rel.Order, !rel.Star
+		}
+	}
+	if r.Catalog == nil {
+		return nil, false
+	}
+
+	schema, table := "", qualifier[len(qualifier)-1]
+	if len(qualifier) == 2 {
+		schema = qualifier[0]
+	} else {
+		schema = r.schemaOf(table)
+	}
+	t, ok := r.Catalog.Table(schema, table)
+	if !ok {
+		return nil, false
+	}
+	columns := []string{}
+	for _, c := range t.Columns {
+		columns = append(columns, c.Name)
+	}
+	return columns, true
+}
+
+// qualifiedColumn returns the column qualified with its table
+func qualifiedColumn(qualifier []string, column string) *ast.Identifier {
+	ident := &ast.Identifier{Token: token.Token{Type: token.IDENT, Lit: column}, Branch: token.ON}
+	for _, q := range qualifier {
+		ident.Value = append(ident.Value, &ast.SimpleIdentifier{Token: token.Token{Type: token.IDENT, Lit: q}, Value: q})
+	}
+	ident.Value = append(ident.Value, &ast.SimpleIdentifier{Token: token.Token{Type: token.IDENT, Lit: column}, Value: column})
+	return ident
+}
+
+// catalogColumn qualifies a column that isn't qualified with the one table in the FROM clause that the catalog says has it
+func (r *Extractor) catalogColumn(i *ast.Identifier, env *object.Environment) *ast.Identifier {
+	if r.Catalog == nil || len(i.Value) != 1 {
+		return i
+	}
+	column := i.Value[0].String(false)
+
+	names := []string{}
+	for _, fqtn := range fromClause(env, "from_tables") {
+		names = append(names, fqtn)
+	}
+	sort.Strings(names)
+
+	var found []string
+	for _, fqtn := range names {
+		schema, table := splitTable(fqtn)
+		if has, _ := r.hasColumn([]string{schema, table}, column, env); has {
+			if found != nil {
+				return i
+			}
+			found = []string{schema, table}
+		}
+	}
+	if found == nil {
+		return i
+	}
+	return qualifiedColumn(found, column)
+}
@@ -0,0 +1,237 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/object"
+)
+
+// JSON paths are the keys of JSON columns that queries use, like {address,city} in data->'address'->>'city'.
+// A chain of -> and ->> is one path, recorded with the operator that ends it. A key that's a parameter is ?,
+// since the keys it stands for aren't known.
+
+// jsonPathOperators follow a path into a JSON value
+var jsonPathOperators = map[string]bool{"->": true, "->>": true, "#>": true, "#>>": true}
+
+type jsonPath struct {
+	column   *ast.Identifier
+	keys     []string
+	operator string
+}
+
+// jsonPathsOf returns the JSON paths that an expression uses. It's called before the expression is extracted,
+// and the paths are recorded after, once their columns are unaliased. The expressions that are part of a longer
+// path are remembered, so they aren't recorded on their own when they're extracted.
+func (r *Extractor) jsonPathsOf(x *ast.InfixExpression) []jsonPath {
+	if r.jsonPathParts[x] {
+		return nil
+	}
+
+	operator := x.Operator
+	if jsonPathOperators[operator] {
+		column, keys, ok := r.jsonChain(x)
+		if !ok {
+			return nil
+		}
+		return []jsonPath{{column: column, keys: keys, operator: operator}}
+	}
+
+	switch operator {
+	case "?", "?|", "?&", "@>", "<@":
+	default:
+		return nil
+	}
+
+	column, prefix, ok := r.jsonChain(x.Left)
+	if !ok {
+		return nil
+	}
+	r.jsonPathPart(x.Left)
+
+	paths := []jsonPath{}
+	add := func(keys ...string) {
+		paths = append(paths, jsonPath{column: column, keys: append(append([]string{}, prefix...), keys...), operator: operator})
+	}
+
+	switch operator {
+	case "?":
+		add(jsonKey(x.Right))
+	case "?|", "?&":
+		for _, key := range jsonKeys(x.Right) {
+			add(key)
+		}
+	case "@>", "<@":
+		// Arrays and ranges have these operators too, so they're only JSON with a JSON value or a path
+		s, isString := x.Right.(*ast.StringLiteral)
+		var value interface{}
+		switch {
+		case isString && json.Unmarshal([]byte(s.Value), &value) == nil:
+			leaves := jsonLeaves(value, nil)
+			if len(leaves) == 0 {
+				add()
+			}
+			for _, keys := range leaves {
+				add(keys...)
+			}
+		case len(prefix) > 0:
+			add()
+		}
+	}
+	return paths
+}
+
+// jsonChain returns the column and keys of a chain of -> and ->>, like data->'a'->>'b'
+func (r *Extractor) jsonChain(x ast.Expression) (*ast.Identifier, []string, bool) {
+	switch x := x.(type) {
+	case *ast.Identifier:
+		if len(x.Value) == 0 || x.Value[len(x.Value)-1].String(false) == "*" {
+			return nil, nil, false
+		}
+		return x, []string{}, true
+	case *ast.GroupedExpression:
+		if len(x.Elements) == 1 {
+			return r.jsonChain(x.Elements[0])
+		}
+	case *ast.InfixExpression:
+		if !jsonPathOperators[x.Operator] {
+			return nil, nil, false
+		}
+		column, keys, ok := r.jsonChain(x.Left)
+		if !ok {
+			return nil, nil, false
+		}
+		r.jsonPathPart(x.Left)
+		switch x.Operator {
+		case "#>", "#>>":
+			keys = append(keys, jsonKeys(x.Right)...)
+		default:
+			keys = append(keys, jsonKey(x.Right))
+		}
+		return column, keys, true
+	}
+	return nil, nil, false
+}
+
+// jsonPathPart remembers that an expression is part of a longer path, like data->'a' in (data->'a')->>'b'
+func (r *Extractor) jsonPathPart(x ast.Expression) {
+	switch x := x.(type) {
+	case *ast.InfixExpression:
+		r.jsonPathParts[x] = true
+	case *ast.GroupedExpression:
+		if len(x.Elements) == 1 {
+			r.jsonPathPart(x.Elements[0])
+		}
+	}
+}
+
+// jsonKey is a key or array index, or ? when it isn't a constant
+func jsonKey(x ast.Expression) string {
+	switch x := x.(type) {
+	case *ast.StringLiteral:
+		return x.Value
+	case *ast.IntegerLiteral:
+		return x.String(false)
+	}
+	return "?"
+}
+
+// jsonKeys are the keys of a text array like '{a,b}' or array['a', 'b']
+func jsonKeys(x ast.Expression) []string {
+	switch x := x.(type) {
+	case *ast.StringLiteral:
+		keys := []string{}
+		for _, key := range strings.Split(strings.Trim(x.Value, "{}"), ",") {
+			if key = strings.Trim(strings.TrimSpace(key), `"`); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	case *ast.ArrayLiteral:
+		keys := []string{}
+		for _, e := range x.Elements {
+			keys = append(keys, jsonKey(e))
+		}
+		return keys
+	}
+	return []string{"?"}
+}
+
+// jsonLeaves are the paths to the values in a JSON object, like {x,y} in {"x": {"y": 1}}. Arrays aren't followed,
+// since containment matches their elements anywhere in the array.
+func jsonLeaves(value interface{}, prefix []string) [][]string {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		if len(prefix) == 0 {
+			return nil
+		}
+		return [][]string{prefix}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	leaves := [][]string{}
+	for _, key := range keys {
+		leaves = append(leaves, jsonLeaves(object[key], append(append([]string{}, prefix...), key))...)
+	}
+	return leaves
+}
+
+// addJSONPaths records the JSON paths of an expression, once its columns are unaliased
+func (r *Extractor) addJSONPaths(x *ast.InfixExpression, paths []jsonPath, env *object.Environment) {
+	for _, path := range paths {
+		if isFunctionColumn(env, path.column) {
+			continue
+		}
+		schema, table, column, derived := r.resolveColumn(path.column, env)
+		if derived && table == "" {
+			// computed by a CTE or subquery
+			continue
+		}
+
+		p := &JSONPathsInQueries{
+			Schema:   schema,
+			Table:    table,
+			Column:   column,
+			Path:     fmt.Sprintf("{%s}", strings.Join(path.keys, ",")),
+			Operator: path.operator,
+			Clause:   x.Clause(),
+		}
+		key := jsonPathKey(p)
+		if existing, ok := r.JSONPathsInQueries[key]; ok {
+			p = existing
+		}
+		p.Count++
+		r.JSONPathsInQueries[key] = p
+	}
+}
+
+// inferJSONPathTables sets the table of the JSON paths of unqualified columns, once the columns' tables are inferred
+func (r *Extractor) inferJSONPathTables() {
+	inferred := make(map[string]*JSONPathsInQueries)
+	for _, p := range r.JSONPathsInQueries {
+		if p.Table == "UNKNOWN" {
+			if schema, table, ok := r.inferredTable(p.Column); ok {
+				p.Schema, p.Table = schema, table
+			}
+		}
+		key := jsonPathKey(p)
+		if existing, ok := inferred[key]; ok {
+			existing.Count += p.Count
+			continue
+		}
+		inferred[key] = p
+	}
+	r.JSONPathsInQueries = inferred
+}
+
+func jsonPathKey(p *JSONPathsInQueries) string {
+	return fmt.Sprintf("%s|%s.%s.%s|%s|%s", p.Clause, p.Schema, p.Table, p.Column, p.Path, p.Operator)
+}
@@ -0,0 +1,286 @@
+package extractor
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/object"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// Lineage is how data flows from the columns a query reads to the columns it writes. Each output column of a SELECT
+// knows the columns it's computed from, which INSERT ... SELECT and CREATE TABLE AS map to the columns they write.
+// UPDATE maps each SET column to the columns in its expression.
+
+// lineageOf returns the columns an expression is computed from, followed through CTEs and subqueries to their tables
+func (r *Extractor) lineageOf(x ast.Expression, env *object.Environment) *object.Lineage {
+	l := &object.Lineage{}
+	if _, ok := x.(*ast.Identifier); !ok {
+		l.Expression = x.String(true)
+	}
+
+	sources := map[string]bool{}
+	functions := map[string]bool{}
+	addSources := func(s []string) {
+		for _, source := range s {
+			if !sources[source] {
+				sources[source] = true
+				l.Sources = append(l.Sources, source)
+			}
+		}
+	}
+	addFunction := func(name string) {
+		if !functions[name] {
+			functions[name] = true
+			l.Functions = append(l.Functions, name)
+		}
+	}
+
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectExpression:
+			// A scalar subquery is computed from the column it selects
+			if rel := r.relations[n]; rel != nil && len(rel.Order) > 0 {
+				if sub := rel.Lineage[rel.Order[0]]; sub != nil {
+					addSources(sub.Sources)
+					for _, f := range sub.Functions {
+						addFunction(f)
+					}
+				}
+			}
+			return false
+		case *ast.CallExpression:
+			if n.Function != nil {
+				addFunction(n.Function.String(false))
+			}
+			for _, a := range n.Arguments {
+				ast.Inspect(a, inspect)
+			}
+			return false
+		case *ast.Identifier:
+			addSources(r.columnSources(n, env))
+			return false
+		}
+		return true
+	}
+	ast.Inspect(x, inspect)
+
+	return l
+}
+
+// columnSources returns the schema.table.column of the columns that a column reference comes from
+func (r *Extractor) columnSources(i *ast.Identifier, env *object.Environment) []string {
+	if len(i.Value) == 0 || isFunctionColumn(env, i) {
+		return nil
+	}
+	if _, ok := i.Value[len(i.Value)-1].(*ast.WildcardLiteral); ok {
+		return nil
+	}
+
+	schema, table, column, derived := r.resolveColumn(i, env)
+	if derived {
+		if rel, name, ok := relationColumn(i, env); ok {
+			if l := rel.Lineage[name]; l != nil {
+				return l.Sources
+			}
+		}
+	}
+	if table == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s.%s.%s", schema, table, column)}
+}
+
+// relationColumn returns the CTE or subquery that a column reference is a column of
+func relationColumn(i *ast.Identifier, env *object.Environment) (*object.Relation, string, bool) {
+	column := i.Value[len(i.Value)-1].String(false)
+
+	switch len(i.Value) {
+	case 1:
+		names := []string{}
+		for name := range fromClause(env, "from_relations") {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if rel, ok := getRelation(env, name); ok {
+				if _, ok := rel.Columns[column]; ok {
+					return rel, column, true
+				}
+			}
+		}
+	case 2:
+		if rel, ok := getRelation(env, i.Value[0].String(false)); ok {
+			return rel, column, true
+		}
+	}
+	return nil, "", false
+}
+
+// extractInsertLineage maps the columns of INSERT ... SELECT to the columns the query selects. Without a column list,
+// the columns of the table are only known with a catalog.
+func (r *Extractor) extractInsertLineage(x *ast.InsertExpression) {
+	schema, table, ok := r.targetTable(x.Table)
+	if !ok || x.Query == nil {
+		return
+	}
+	query := r.relationOf(x.Query)
+	if query == nil || query.Star {
+		return
+	}
+
+	columns := []string{}
+	for _, c := range x.Columns {
+		if ident, ok := c.(*ast.Identifier); ok && len(ident.Value) > 0 {
+			columns = append(columns, ident.Value[len(ident.Value)-1].String(false))
+		}
+	}
+	if len(columns) == 0 && r.Catalog != nil {
+		if t, ok := r.Catalog.Table(schema, table); ok {
+			for _, c := range t.Columns {
+				columns = append(columns, c.Name)
+			}
+		}
+	}
+
+	for i, column := range columns {
+		if i >= len(query.Order) {
+			break
+		}
+		r.addColumnLineage(token.INSERT, schema, table, column, query.Lineage[query.Order[i]])
+	}
+}
+
+// extractUpdateLineage maps each column of UPDATE ... SET to the columns in its expression
+func (r *Extractor) extractUpdateLineage(x *ast.UpdateExpression, env *object.Environment) {
+	schema, table, ok := r.targetTable(x.Table)
+	if !ok {
+		return
+	}
+
+	for _, s := range x.Set {
+		set, ok := s.(*ast.InfixExpression)
+		if !ok || set.Operator != "=" {
+			continue
+		}
+
+		switch left := set.Left.(type) {
+		case *ast.Identifier:
+			r.addColumnLineage(token.UPDATE, schema, table, left.Value[len(left.Value)-1].String(false), r.lineageOf(set.Right, env))
+		case *ast.GroupedExpression:
+			// SET (a, b) = (x, y)
+			right, ok := set.Right.(*ast.GroupedExpression)
+			if !ok || len(right.Elements) != len(left.Elements) {
+				continue
+			}
+			for i, e := range left.Elements {
+				if ident, ok := e.(*ast.Identifier); ok && len(ident.Value) > 0 {
+					r.addColumnLineage(token.UPDATE, schema, table, ident.Value[len(ident.Value)-1].String(false), r.lineageOf(right.Elements[i], env))
+				}
+			}
+		}
+	}
+}
+
+// extractCreateLineage maps the columns of CREATE TABLE AS to the columns the query selects
+func (r *Extractor) extractCreateLineage(x *ast.CreateStatement) {
+	if x.Object.Type != token.TABLE || x.Expression == nil {
+		return
+	}
+	schema, table, ok := r.targetTable(x.Name)
+	if !ok {
+		return
+	}
+	query := r.relationOf(x.Expression)
+	if query == nil {
+		return
+	}
+
+	for i, name := range query.Order {
+		column := name
+		if i < len(x.Columns) {
+			column = x.Columns[i].Name.Value
+		}
+		r.addColumnLineage(token.CREATE, schema, table, column, query.Lineage[name])
+	}
+}
+
+// targetTable returns the schema and name of the table a query writes to
+func (r *Extractor) targetTable(x ast.Expression) (string, string, bool) {
+	ident, ok := x.(*ast.Identifier)
+	if !ok {
+		return "", "", false
+	}
+	switch len(ident.Value) {
+	case 1:
+		table := ident.Value[0].String(false)
+		return r.schemaOf(table), table, true
+	case 2:
+		return ident.Value[0].String(false), ident.Value[1].String(false), true
+	}
+	return "", "", false
+}
+
+func (r *Extractor) addColumnLineage(command token.TokenType, schema, table, column string, l *object.Lineage) {
+	if l == nil {
+		l = &object.Lineage{}
+	}
+
+	target := fmt.Sprintf("%s.%s.%s", schema, table, column)
+	lineage := func() *ColumnLineage {
+		return &ColumnLineage{
+			Command:      command,
+			TargetSchema: schema,
+			TargetTable:  table,
+			TargetColumn: column,
+			Expression:   l.Expression,
+			Functions:    l.Functions,
+		}
+	}
+
+	if len(l.Sources) == 0 {
+		r.ColumnLineage[target+"|"] = lineage()
+		return
+	}
+	for _, source := range l.Sources {
+		cl := lineage()
+		cl.SourceSchema, cl.SourceTable, cl.SourceColumn = splitColumn(source, "")
+		r.ColumnLineage[target+"|"+source] = cl
+	}
+}
+
+// inferLineageSources sets the table of the sources that weren't qualified with one, once their columns were inferred
+func (r *Extractor) inferLineageSources() {
+	inferred := make(map[string]*ColumnLineage)
+
+	for key, l := range r.ColumnLineage {
+		if l.SourceTable == "UNKNOWN" {
+			if schema, table, ok := r.inferredTable(l.SourceColumn); ok {
+				l.SourceSchema, l.SourceTable = schema, table
+				key = fmt.Sprintf("%s.%s.%s|%s.%s.%s", l.TargetSchema, l.TargetTable, l.TargetColumn, l.SourceSchema, l.SourceTable, l.SourceColumn)
+			}
+		}
+		inferred[key] = l
+	}
+
+	r.ColumnLineage = inferred
+}
+
+// inferredTable returns the table of an unqualified column, once InferColumnsInTables has found the one table that has it
+func (r *Extractor) inferredTable(column string) (string, string, bool) {
+	tables := map[string]*ColumnsInQueries{}
+	for _, c := range r.ColumnsInQueries {
+		if c.Name == column {
+			tables[c.Schema+"."+c.Table] = c
+		}
+	}
+	for _, c := range tables {
+		if len(tables) == 1 && c.Table != "UNKNOWN" {
+			return c.Schema, c.Table, true
+		}
+	}
+	return "", "", false
+}
@@ -2,6 +2,7 @@ package extractor
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/brianbroderick/lantern/pkg/sql/ast"
 	"github.com/brianbroderick/lantern/pkg/sql/object"
@@ -18,7 +19,14 @@ type FunctionsInQueries struct {
 	UID         uuid.UUID `json:"uid"`
 	FunctionUID uuid.UUID `json:"function_uid"`
 	QueryUID    uuid.UUID `json:"query_uid"`
+	Schema      string    `json:"schema_name"`
 	Name        string    `json:"function_name"`
+	Arguments   int       `json:"argument_count"`
+	Kind        string    `json:"kind"`                 // scalar, aggregate or window
+	Volatility  string    `json:"volatility,omitempty"` // immutable, stable or volatile, when it's known
+	Builtin     bool      `json:"builtin"`
+	Windowed    bool      `json:"windowed,omitempty"` // called with OVER ( ... )
+	Calls       int       `json:"calls"`              // the number of times the query calls it
 }
 
 type ColumnsInQueries struct {
@@ -33,6 +41,92 @@ type ColumnsInQueries struct {
 	Clause    token.TokenType `json:"clause"`
 }
 
+// Why a column couldn't be resolved to a table with the catalog
+const (
+	Ambiguous = "ambiguous" // more than one of the tables in the query has the column
+	Missing   = "missing"   // none of the tables in the query have the column
+)
+
+// UnresolvedColumns are columns that the catalog couldn't resolve to exactly one table
+type UnresolvedColumns struct {
+	UID        uuid.UUID       `json:"uid"`
+	QueryUID   uuid.UUID       `json:"query_uid"`
+	Schema     string          `json:"schema_name"`
+	Table      string          `json:"table_name"`
+	Name       string          `json:"column_name"`
+	Clause     token.TokenType `json:"clause"`
+	Reason     string          `json:"reason"`               // ambiguous or missing
+	Candidates []string        `json:"candidates,omitempty"` // the tables that have an ambiguous column
+}
+
+// How selective a predicate is, by the kind of index that could serve it
+const (
+	Equality        = "equality"         // =, IS NOT DISTINCT FROM, and boolean columns
+	Range           = "range"            // <, >, <=, >=, BETWEEN
+	InList          = "in_list"          // IN ( ... ) and = ANY ( ... )
+	Prefix          = "prefix"           // LIKE 'abc%'
+	LeadingWildcard = "leading_wildcard" // LIKE '%abc'
+	Pattern         = "pattern"          // regular expressions, and LIKE with a pattern that isn't a constant
+	Null            = "null"             // IS NULL, IS NOT NULL
+	Containment     = "containment"      // @>, <@, ?, ?|, ?&, &&
+	Negation        = "negation"         // <>, NOT IN, NOT LIKE, IS DISTINCT FROM
+	Other           = "other"
+)
+
+// PredicatesInQueries is how a column is filtered in WHERE, HAVING or ON: the operator, the kind of value it's
+// compared to, and the function or expression the column is in, like lower(email) = ?
+type PredicatesInQueries struct {
+	UID        uuid.UUID       `json:"uid"`
+	QueryUID   uuid.UUID       `json:"query_uid"`
+	Schema     string          `json:"schema_name"`
+	Table      string          `json:"table_name"`
+	Column     string          `json:"column_name"`
+	Clause     token.TokenType `json:"clause"`
+	Operator   string          `json:"operator"`               // =, <, BETWEEN, IN, LIKE, IS NULL, @>, etc
+	ValueKind  string          `json:"value_kind"`             // parameter, constant, column, subquery, function, null, or expression
+	Class      string          `json:"selectivity_class"`      // equality, range, in_list, etc
+	Function   string          `json:"function,omitempty"`     // the function the column is passed to
+	Expression string          `json:"expression,omitempty"`   // the masked expression, when the column isn't compared as is
+	InListSize int             `json:"in_list_size,omitempty"` // the number of values in IN ( ... )
+}
+
+// How a query accesses a table
+const (
+	Read  = "read"
+	Write = "write"
+	Lock  = "lock"
+)
+
+// JSONPathsInQueries are the keys of a JSON column that a query uses, and the operator it uses them with
+type JSONPathsInQueries struct {
+	UID      uuid.UUID       `json:"uid"`
+	QueryUID uuid.UUID       `json:"query_uid"`
+	Schema   string          `json:"schema_name"`
+	Table    string          `json:"table_name"`
+	Column   string          `json:"column_name"`
+	Path     string          `json:"path"`     // the keys, like {address,city}, or {} when no keys are used
+	Operator string          `json:"operator"` // ->, ->>, #>, #>>, ?, ?|, ?&, @> or <@
+	Clause   token.TokenType `json:"clause"`
+	Count    int             `json:"count"` // the number of times the query uses it
+}
+
+// ColumnTypes is the probable type of a column, inferred from how it's used. The evidence is the number of uses
+// that suggest each type, like comparison:integer for id = 1.
+type ColumnTypes struct {
+	UID          uuid.UUID      `json:"uid"` // the column's UID
+	TableUID     uuid.UUID      `json:"table_uid"`
+	Schema       string         `json:"schema_name"`
+	Table        string         `json:"table_name"`
+	Column       string         `json:"column_name"`
+	Type         string         `json:"type"`
+	Confidence   float64        `json:"confidence"`   // from 0 to 1
+	UsedAs       []string       `json:"used_as"`      // the kinds of types it's used as, like text or numeric
+	Inconsistent bool           `json:"inconsistent"` // used as more than one kind, like compared to both text and integers
+	Evidence     map[string]int `json:"evidence"`
+}
+
+// TablesInQueries are the tables in a query. The command is the statement's command, so a table that's only read
+// by the subquery of an UPDATE has the UPDATE command, which is why how the table is accessed is recorded too.
 type TablesInQueries struct {
 	UID      uuid.UUID       `json:"uid"`
 	TableUID uuid.UUID       `json:"table_uid"`
@@ -40,6 +134,21 @@ type TablesInQueries struct {
 	Schema   string          `json:"schema_name"`
 	Name     string          `json:"table_name"`
 	Command  token.TokenType `json:"command"`
+	Reads    bool            `json:"reads,omitempty"`     // selected from, joined, or in a subquery
+	Writes   bool            `json:"writes,omitempty"`    // inserted into, updated, deleted from, truncated, etc
+	Locks    bool            `json:"locks,omitempty"`     // locked with FOR UPDATE or FOR SHARE
+	LockMode string          `json:"lock_mode,omitempty"` // UPDATE, NO KEY UPDATE, SHARE or KEY SHARE
+}
+
+// Functions are identified by their schema, name and number of arguments, since functions can be overloaded
+type Functions struct {
+	UID        uuid.UUID `json:"uid"`
+	Schema     string    `json:"schema_name"`
+	Name       string    `json:"function_name"`
+	Arguments  int       `json:"argument_count"`
+	Kind       string    `json:"kind"`
+	Volatility string    `json:"volatility,omitempty"`
+	Builtin    bool      `json:"builtin"`
 }
 
 type Tables struct {
@@ -55,12 +164,30 @@ type TableJoinsInQueries struct {
 	QueryUID      uuid.UUID `json:"query_uid"`
 	TableUIDa     uuid.UUID `json:"table_uid_a"`
 	TableUIDb     uuid.UUID `json:"table_uid_b"`
-	JoinCondition string    `json:"join_condition"` // LEFT, RIGHT, INNER, OUTER, etc
-	OnCondition   string    `json:"on_condition"`   // Right now, this is the String() of the expression
+	JoinCondition string    `json:"join_condition"` // LEFT, RIGHT, INNER, OUTER, etc, or WHERE when the tables are joined in the WHERE clause
+	OnCondition   string    `json:"on_condition"`   // the comparison of the columns, USING ( ... ), or NATURAL
 	SchemaA       string    `json:"schema_a"`
 	TableA        string    `json:"table_a"`
+	ColumnA       string    `json:"column_a,omitempty"` // empty when the columns of a NATURAL join aren't known
 	SchemaB       string    `json:"schema_b"`
 	TableB        string    `json:"table_b"`
+	ColumnB       string    `json:"column_b,omitempty"`
+}
+
+// ColumnLineage is a column written by a query and one of the columns its value comes from.
+// A column whose value doesn't come from any column, such as a constant, has one lineage without a source.
+type ColumnLineage struct {
+	UID          uuid.UUID       `json:"uid"`
+	QueryUID     uuid.UUID       `json:"query_uid"`
+	Command      token.TokenType `json:"command"`
+	TargetSchema string          `json:"target_schema"`
+	TargetTable  string          `json:"target_table"`
+	TargetColumn string          `json:"target_column"`
+	SourceSchema string          `json:"source_schema,omitempty"`
+	SourceTable  string          `json:"source_table,omitempty"`
+	SourceColumn string          `json:"source_column,omitempty"`
+	Expression   string          `json:"expression,omitempty"` // the masked expression, when the value isn't a column as is
+	Functions    []string        `json:"functions,omitempty"`  // the functions called in the expression
 }
 
 // type CreateStatementsInQueries struct {
@@ -129,48 +256,44 @@ func (d *Extractor) AddCreateStatement(c *ast.CreateStatement, env *object.Envir
 	return d.CreateStatements[uidStr]
 }
 
-// This passes around a 3 element slice. []string{schema, table, fully_qualified_table_name}
+// AddJoinInQuery adds the join of two columns. Each pair of columns in a join is its own join, so composite keys have one for each column.
+// This passes around a 4 element slice. []string{schema, table, fully_qualified_table_name, column}
 func (d *Extractor) AddJoinInQuery(columnA, columnB *ast.Identifier, on_condition string, env *object.Environment) *TableJoinsInQueries {
-	alphabetical := func(a, b []string) ([]string, []string) {
-		if a[2] < b[2] {
-			return a, b
-		}
-		return b, a
-	}
+	return d.addJoin(d.joinTable(columnA), d.joinTable(columnB), on_condition, env)
+}
 
-	tableA := []string{"public", "UNKNOWN", "public.UNKNOWN"}
-	tableB := []string{"public", "UNKNOWN", "public.UNKNOWN"}
+// joinTable returns the table of a column in a join
+func (d *Extractor) joinTable(column *ast.Identifier) []string {
+	schema := d.schemaOf("UNKNOWN")
+	table := []string{schema, "UNKNOWN", "", column.Value[len(column.Value)-1].String(false)}
 
-	switch len(columnA.Value) {
+	switch len(column.Value) {
 	case 1:
 		// TODO: add support in resolver to add tables when not specified
 		fmt.Println("AddJoin: columns do not have tables associated with them")
 	case 2:
-		// assume the schema is public if it's not specified. This is a safe assumption for now.
-		// TODO: add support for search_path
-		tableA[1] = columnA.Value[0].(*ast.SimpleIdentifier).Value
+		table[1] = column.Value[0].(*ast.SimpleIdentifier).Value
+		table[0] = d.schemaOf(table[1])
 	case 3:
-		tableA[0] = columnA.Value[0].(*ast.SimpleIdentifier).Value
-		tableA[1] = columnA.Value[1].(*ast.SimpleIdentifier).Value
+		table[0] = column.Value[0].(*ast.SimpleIdentifier).Value
+		table[1] = column.Value[1].(*ast.SimpleIdentifier).Value
 	}
-	tableA[2] = fmt.Sprintf("%s.%s", tableA[0], tableA[1])
+	table[2] = fmt.Sprintf("%s.%s", table[0], table[1])
 
-	switch len(columnB.Value) {
-	case 1:
-		// TODO: add support in resolver to add tables when not specified
-		fmt.Println("AddJoin: columns do not have tables associated with them")
-	case 2:
-		// TODO: add support for search_path
-		tableB[1] = columnB.Value[0].(*ast.SimpleIdentifier).Value
-	case 3:
-		tableB[0] = columnB.Value[0].(*ast.SimpleIdentifier).Value
-		tableB[1] = columnB.Value[1].(*ast.SimpleIdentifier).Value
+	return table
+}
+
+func (d *Extractor) addJoin(tableA, tableB []string, on_condition string, env *object.Environment) *TableJoinsInQueries {
+	alphabetical := func(a, b []string) ([]string, []string) {
+		if a[2] < b[2] || a[2] == b[2] && a[3] < b[3] {
+			return a, b
+		}
+		return b, a
 	}
-	tableB[2] = fmt.Sprintf("%s.%s", tableB[0], tableB[1])
 
 	a, b := alphabetical(tableA, tableB)
 
-	uniq := UuidV5(fmt.Sprintf("%s|%s", a[2], b[2]))
+	uniq := UuidV5(fmt.Sprintf("%s.%s|%s.%s", a[2], a[3], b[2], b[3]))
 	uniqStr := uniq.String()
 
 	if _, ok := d.TableJoinsInQueries[uniqStr]; !ok {
@@ -196,8 +319,10 @@ func (d *Extractor) AddJoinInQuery(columnA, columnB *ast.Identifier, on_conditio
 			JoinCondition: joinType,
 			SchemaA:       a[0],
 			TableA:        a[1],
+			ColumnA:       a[3],
 			SchemaB:       b[0],
 			TableB:        b[1],
+			ColumnB:       b[3],
 		}
 	}
 
@@ -213,12 +338,9 @@ func (d *Extractor) AddColumnsInQueries(i *ast.Identifier) *ColumnsInQueries {
 		table  string
 		column string
 	)
-	// it's public by default though this can be overridden.
-	// TODO: PG allows you to set the search path, which can change the default schema
-	// though this is rare enough that we don't yet support it.
-	schema = "public"
 	// TODO: add support for adding tables in the resolver when not specified.
 	table = "UNKNOWN"
+	schema = d.schemaOf(table)
 
 	switch len(i.Value) {
 	case 1:
@@ -230,6 +352,7 @@ func (d *Extractor) AddColumnsInQueries(i *ast.Identifier) *ColumnsInQueries {
 		}
 	case 2:
 		table = i.Value[0].(*ast.SimpleIdentifier).Value
+		schema = d.schemaOf(table)
 		switch i.Value[1].(type) {
 		case *ast.SimpleIdentifier:
 			column = i.Value[1].(*ast.SimpleIdentifier).Value
@@ -248,20 +371,24 @@ func (d *Extractor) AddColumnsInQueries(i *ast.Identifier) *ColumnsInQueries {
 		}
 	}
 
-	fqcn := fmt.Sprintf("%s|%s.%s.%s", i.Clause().String(), schema, table, column) // fqcn is the fully qualified column name with clause
+	return d.addColumnsInQueries(schema, table, column, i.Clause(), i.Command())
+}
+
+func (d *Extractor) addColumnsInQueries(schema, table, column string, clause, command token.TokenType) *ColumnsInQueries {
+	fqcn := fmt.Sprintf("%s|%s.%s.%s", clause.String(), schema, table, column) // fqcn is the fully qualified column name with clause
 
 	if _, ok := d.ColumnsInQueries[fqcn]; !ok {
 		uid := UuidV5(fqcn)
 
 		d.ColumnsInQueries[fqcn] = &ColumnsInQueries{
 			UID:       uid,
-			Command:   i.Command(),
+			Command:   command,
 			Schema:    schema,
 			Table:     table,
 			TableUID:  UuidV5(fmt.Sprintf("%s.%s", schema, table)),
 			Name:      column,
 			ColumnUID: UuidV5(fmt.Sprintf("%s.%s.%s", schema, table, column)), // don't include the clause in the column UID
-			Clause:    i.Clause(),
+			Clause:    clause,
 		}
 	}
 
@@ -277,6 +404,7 @@ func (d *Extractor) AddTablesInQueries(i *ast.Identifier) *TablesInQueries {
 	switch len(i.Value) {
 	case 1:
 		table = i.Value[0].(*ast.SimpleIdentifier).Value
+		schema = d.schemaOf(table)
 	case 2:
 		schema = i.Value[0].(*ast.SimpleIdentifier).Value
 		table = i.Value[1].(*ast.SimpleIdentifier).Value
@@ -306,17 +434,46 @@ func (d *Extractor) AddTablesInQueries(i *ast.Identifier) *TablesInQueries {
 	return d.TablesInQueries[fqtn]
 }
 
-func (d *Extractor) AddFunctionsInQueries(ident *ast.Identifier) *FunctionsInQueries {
-	fqn := ident.String(false) // fqn is the fully qualified function name
+// addTableAccess adds a table to the extractor along with how the query accesses it
+func (d *Extractor) addTableAccess(i *ast.Identifier, access string) *TablesInQueries {
+	t := d.AddTablesInQueries(i)
+	t.access(access)
+	return t
+}
 
-	if _, ok := d.FunctionsInQueries[fqn]; !ok {
-		uid := UuidV5(fqn)
+func (t *TablesInQueries) access(access string) {
+	switch access {
+	case Read:
+		t.Reads = true
+	case Write:
+		t.Writes = true
+	case Lock:
+		t.Locks = true
+	}
+}
+
+// AddFunctionsInQueries records a function call
+func (d *Extractor) AddFunctionsInQueries(call *ast.CallExpression) *FunctionsInQueries {
+	schema, name, arguments, ok := d.functionName(call)
+	if !ok {
+		return nil
+	}
 
-		d.FunctionsInQueries[fqn] = &FunctionsInQueries{
-			UID:  uid,
-			Name: fqn,
+	fqn := functionKey(schema, name, arguments)
+	if _, ok := d.FunctionsInQueries[fqn]; !ok {
+		f := &FunctionsInQueries{
+			FunctionUID: UuidV5(fqn),
+			Schema:      schema,
+			Name:        name,
+			Arguments:   arguments,
+			Kind:        Scalar,
+		}
+		if b, ok := builtins[strings.ToLower(name)]; ok && strings.EqualFold(schema, "pg_catalog") {
+			f.Builtin, f.Kind, f.Volatility = true, b.kind, b.volatility
 		}
+		d.FunctionsInQueries[fqn] = f
 	}
+	d.FunctionsInQueries[fqn].Calls++
 
 	return d.FunctionsInQueries[fqn]
 }
@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
 	"github.com/brianbroderick/lantern/pkg/sql/object"
 	"github.com/google/uuid"
 )
@@ -16,3 +17,72 @@ func setDatabaseUID(env *object.Environment, uid uuid.UUID) {
 func setJoinType(env *object.Environment, joinType string) {
 	env.Set("join_type", &object.String{Value: joinType})
 }
+
+// setFunctionAliases records the aliases and column aliases of functions in the FROM clause, i.e. unnest(...) AS t(a, b)
+// Columns that reference them belong to the function rather than a real table.
+func setFunctionAliases(env *object.Environment, tables []ast.Expression) {
+	aliases := map[string]string{}
+	columns := map[string]string{}
+
+	for _, t := range tables {
+		te, ok := t.(*ast.TableExpression)
+		if !ok || te.Alias == nil {
+			continue
+		}
+		switch te.Kind {
+		case ast.TableKindFunction, ast.TableKindRowsFrom:
+			alias := te.Alias.String(false)
+			aliases[alias] = te.Kind
+			// Without a column list, a function returning a scalar uses the alias as the column name
+			if len(te.ColumnAliases) == 0 {
+				columns[alias] = alias
+			}
+			for _, c := range te.ColumnAliases {
+				columns[c.Name] = alias
+			}
+		}
+	}
+
+	env.Set("function_aliases", &object.StringHash{Value: aliases})
+	env.Set("function_columns", &object.StringHash{Value: columns})
+}
+
+// isFunctionColumn is true when the identifier is a column returned by a function in the FROM clause
+func isFunctionColumn(env *object.Environment, i *ast.Identifier) bool {
+	var key, name string
+	switch len(i.Value) {
+	case 1:
+		key = "function_columns"
+	case 2:
+		key = "function_aliases"
+	default:
+		return false
+	}
+
+	simple, ok := i.Value[0].(*ast.SimpleIdentifier)
+	if !ok {
+		return false
+	}
+	name = simple.Value
+
+	obj, ok := env.Get(key)
+	if !ok {
+		return false
+	}
+	_, ok = obj.(*object.StringHash).Value[name]
+	return ok
+}
+
+// setTableFunction marks that we're inside of a function in the FROM clause, so its arguments are columns rather than tables
+func setTableFunction(env *object.Environment) {
+	env.Set("table_function", &object.Boolean{Value: true})
+}
+
+func inTableFunction(env *object.Environment) bool {
+	obj, ok := env.Get("table_function")
+	if !ok {
+		return false
+	}
+	b, ok := obj.(*object.Boolean)
+	return ok && b.Value
+}
@@ -0,0 +1,343 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/object"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// Predicates are how the columns of a query are filtered. AND, OR and NOT are followed to the predicates they combine,
+// and each predicate is recorded for the column it filters, along with the operator, the kind of value, and its
+// selectivity class. Subqueries aren't followed, since their predicates are extracted in their own scope.
+
+// extractPredicates records the predicates of a WHERE, HAVING or ON clause. It's called after the clause is extracted,
+// so the columns are unaliased.
+func (r *Extractor) extractPredicates(clause token.TokenType, x ast.Expression, not bool, env *object.Environment) {
+	switch x := x.(type) {
+	case nil:
+		return
+	case *ast.InfixExpression:
+		switch strings.ToUpper(x.Operator) {
+		case "AND", "OR":
+			r.extractPredicates(clause, x.Left, not, env)
+			r.extractPredicates(clause, x.Right, not, env)
+			return
+		}
+	case *ast.PrefixKeywordExpression:
+		if strings.ToUpper(x.Operator) == "NOT" {
+			r.extractPredicates(clause, x.Right, !not, env)
+			return
+		}
+	case *ast.GroupedExpression:
+		if len(x.Elements) == 1 {
+			r.extractPredicates(clause, x.Elements[0], not, env)
+			return
+		}
+	}
+	r.extractPredicate(clause, x, not, env)
+}
+
+func (r *Extractor) extractPredicate(clause token.TokenType, x ast.Expression, not bool, env *object.Environment) {
+	switch x := x.(type) {
+	case *ast.Identifier:
+		// A boolean column
+		r.addColumnType(x, "boolean", Operator, env)
+		operator := "IS TRUE"
+		if not {
+			operator = "IS FALSE"
+		}
+		r.addPredicate(clause, x, operator, "constant", Equality, 0, env)
+	case *ast.InfixExpression:
+		r.extractComparison(clause, x, not != x.Not, env)
+	case *ast.InExpression:
+		size := len(x.Right)
+		kind := "list"
+		if len(x.Right) == 1 && valueKind(x.Right[0]) == "subquery" {
+			size, kind = 0, "subquery"
+		}
+		operator, class := negate("IN", InList, not != x.Not)
+		r.addPredicate(clause, x.Left, operator, kind, class, size, env)
+	case *ast.IsExpression:
+		operator, class := "IS", Equality
+		switch {
+		case x.Distinct:
+			operator, class = "IS DISTINCT FROM", Negation
+			if x.Not {
+				operator, class = "IS NOT DISTINCT FROM", Equality
+			}
+		case valueKind(x.Right) == "null":
+			operator, class = "IS NULL", Null
+			if x.Not {
+				operator = "IS NOT NULL"
+			}
+		default:
+			operator = "IS " + strings.ToUpper(x.Right.String(false))
+			if x.Not {
+				operator = "IS NOT " + strings.ToUpper(x.Right.String(false))
+			}
+		}
+		if not {
+			operator, class = negate(operator, class, true)
+		}
+		r.addPredicate(clause, x.Left, operator, valueKind(x.Right), class, 0, env)
+	}
+}
+
+// extractComparison records a comparison of a column to a value. When both sides are columns, it's recorded for each.
+func (r *Extractor) extractComparison(clause token.TokenType, x *ast.InfixExpression, not bool, env *object.Environment) {
+	operator := strings.ToUpper(x.Operator)
+
+	// (a, b) = (?, ?)
+	if left, ok := x.Left.(*ast.GroupedExpression); ok {
+		if right, ok := x.Right.(*ast.GroupedExpression); ok && len(left.Elements) == len(right.Elements) && len(left.Elements) > 1 {
+			for i := range left.Elements {
+				r.extractComparison(clause, &ast.InfixExpression{Token: x.Token, Operator: x.Operator, Left: left.Elements[i], Right: right.Elements[i]}, not, env)
+			}
+			return
+		}
+	}
+
+	value := x.Right
+	class := operatorClass(operator)
+	switch operator {
+	case "BETWEEN":
+		// The bounds are (low AND high)
+		if bounds, ok := x.Right.(*ast.InfixExpression); ok {
+			value = bounds.Left
+			if valueKind(bounds.Left) != valueKind(bounds.Right) {
+				value = bounds
+			}
+		}
+	case "LIKE", "ILIKE":
+		class = likeClass(x.Right)
+	}
+	// = ANY ( ... ) is a list of values
+	if call, ok := x.Right.(*ast.CallExpression); ok && call.Function != nil && len(call.Arguments) == 1 {
+		switch fn := strings.ToUpper(call.Function.String(false)); fn {
+		case "ANY", "SOME", "ALL":
+			operator = fmt.Sprintf("%s %s", operator, fn)
+			value = call.Arguments[0]
+			if fn != "ALL" && class == Equality {
+				class = InList
+			}
+		}
+	}
+	operator, class = negate(operator, class, not)
+
+	leftColumn, rightColumn := hasColumns(x.Left), hasColumns(value)
+	switch {
+	case leftColumn && rightColumn:
+		r.addPredicate(clause, x.Left, operator, "column", class, 0, env)
+		r.addPredicate(clause, value, flip(operator), "column", class, 0, env)
+	case leftColumn:
+		r.addPredicate(clause, x.Left, operator, valueKind(value), class, 0, env)
+	case rightColumn && operator != "BETWEEN":
+		// ? < a is a > ?
+		r.addPredicate(clause, value, flip(operator), valueKind(x.Left), class, 0, env)
+	}
+}
+
+// addPredicate records a predicate on the one column in an expression, like email or lower(email)
+func (r *Extractor) addPredicate(clause token.TokenType, x ast.Expression, operator, kind, class string, size int, env *object.Environment) {
+	columns := columnsIn(x)
+	if len(columns) != 1 || isFunctionColumn(env, columns[0]) {
+		return
+	}
+	ident := columns[0]
+
+	schema, table, column, derived := r.resolveColumn(ident, env)
+	if derived && table == "" {
+		// computed by a CTE or subquery
+		return
+	}
+	if column == "*" {
+		return
+	}
+
+	p := &PredicatesInQueries{
+		Schema:     schema,
+		Table:      table,
+		Column:     column,
+		Clause:     clause,
+		Operator:   operator,
+		ValueKind:  kind,
+		Class:      class,
+		InListSize: size,
+	}
+	if x != ast.Expression(ident) || ident.Cast != nil {
+		p.Expression = x.String(true)
+	}
+	switch x := x.(type) {
+	case *ast.CallExpression:
+		if x.Function != nil {
+			p.Function = x.Function.String(false)
+		}
+	case *ast.InfixExpression:
+		switch x.Operator {
+		case "->", "->>", "#>", "#>>":
+			// data->>'key'
+			p.Function = x.Operator
+		}
+	}
+
+	r.PredicatesInQueries[predicateKey(p)] = p
+}
+
+// inferPredicateTables sets the table of the predicates on unqualified columns, once the columns' tables are inferred
+func (r *Extractor) inferPredicateTables() {
+	inferred := make(map[string]*PredicatesInQueries)
+	for _, p := range r.PredicatesInQueries {
+		if p.Table == "UNKNOWN" {
+			if schema, table, ok := r.inferredTable(p.Column); ok {
+				p.Schema, p.Table = schema, table
+			}
+		}
+		inferred[predicateKey(p)] = p
+	}
+	r.PredicatesInQueries = inferred
+}
+
+func predicateKey(p *PredicatesInQueries) string {
+	return fmt.Sprintf("%s|%s.%s.%s|%s|%s|%s|%s|%d", p.Clause, p.Schema, p.Table, p.Column, p.Expression, p.Operator, p.ValueKind, p.Class, p.InListSize)
+}
+
+// columnsIn returns the columns in an expression, but not the ones in its subqueries
+func columnsIn(x ast.Expression) []*ast.Identifier {
+	columns := []*ast.Identifier{}
+	if x == nil {
+		return columns
+	}
+	ast.Inspect(x, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectExpression:
+			return false
+		case *ast.CallExpression:
+			// the function's name isn't a column
+			for _, a := range n.Arguments {
+				columns = append(columns, columnsIn(a)...)
+			}
+			return false
+		case *ast.Identifier:
+			if len(n.Value) > 0 {
+				if _, ok := n.Value[len(n.Value)-1].(*ast.WildcardLiteral); !ok {
+					columns = append(columns, n)
+				}
+			}
+			return false
+		}
+		return true
+	})
+	return columns
+}
+
+func hasColumns(x ast.Expression) bool {
+	return len(columnsIn(x)) > 0
+}
+
+// valueKind is the kind of value that a column is compared to
+func valueKind(x ast.Expression) string {
+	switch x := x.(type) {
+	case *ast.ParamLiteral:
+		return "parameter"
+	case *ast.Null:
+		return "null"
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		return "constant"
+	case *ast.Identifier:
+		return "column"
+	case *ast.SelectExpression, *ast.UnionExpression, *ast.CTEExpression:
+		return "subquery"
+	case *ast.GroupedExpression:
+		if len(x.Elements) == 1 {
+			return valueKind(x.Elements[0])
+		}
+	case *ast.CallExpression:
+		if !hasColumns(x) {
+			return "function"
+		}
+	}
+	return "expression"
+}
+
+// operatorClass is the selectivity class of a comparison operator
+func operatorClass(operator string) string {
+	switch operator {
+	case "=", "==":
+		return Equality
+	case "<", ">", "<=", ">=", "BETWEEN":
+		return Range
+	case "<>", "!=":
+		return Negation
+	case "~", "~*", "!~", "!~*", "SIMILAR", "SIMILAR TO":
+		return Pattern
+	case "@>", "<@", "?", "?|", "?&", "&&":
+		return Containment
+	}
+	return Other
+}
+
+// likeClass is the selectivity class of LIKE, which depends on where the pattern's first wildcard is
+func likeClass(pattern ast.Expression) string {
+	s, ok := pattern.(*ast.StringLiteral)
+	if !ok {
+		return Pattern
+	}
+	switch i := strings.IndexAny(s.Value, "%_"); {
+	case i == 0:
+		return LeadingWildcard
+	case i > 0:
+		return Prefix
+	}
+	return Equality
+}
+
+// negate is the operator and class of a predicate with NOT
+func negate(operator, class string, not bool) (string, string) {
+	if !not {
+		return operator, class
+	}
+	switch operator {
+	case "IS NULL":
+		return "IS NOT NULL", class
+	case "IS NOT NULL":
+		return "IS NULL", class
+	case "=":
+		return "<>", Negation
+	case "<>", "!=":
+		return "=", Equality
+	case "<":
+		return ">=", class
+	case ">":
+		return "<=", class
+	case "<=":
+		return ">", class
+	case ">=":
+		return "<", class
+	}
+	if class == Range {
+		return "NOT " + operator, class
+	}
+	return "NOT " + operator, Negation
+}
+
+// flip is the operator with its sides swapped, so the column is on the left
+func flip(operator string) string {
+	switch operator {
+	case "<":
+		return ">"
+	case ">":
+		return "<"
+	case "<=":
+		return ">="
+	case ">=":
+		return "<="
+	case "@>":
+		return "<@"
+	case "<@":
+		return "@>"
+	}
+	return operator
+}
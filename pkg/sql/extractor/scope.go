@@ -0,0 +1,469 @@
+package extractor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/object"
+)
+
+// Each SELECT is a scope enclosed by the scope it's in, so subqueries can reference the tables and CTEs around them.
+// A scope knows the tables and relations (CTEs and subqueries) in its FROM clause, which is how unqualified columns
+// are resolved to a table, and how columns of a relation are followed through to the table columns they select.
+
+// newSelectScope returns the environment of a SELECT, enclosed by the one it's in
+func (r *Extractor) newSelectScope(env *object.Environment, x *ast.SelectExpression) *object.Environment {
+	envSE := object.NewEnclosedEnvironment(env)
+	setTableAliases(envSE, scopedAliases(env, x.TableAliases))
+	setFunctionAliases(envSE, x.Tables)
+	envSE.Set("table_function", &object.Boolean{Value: false})
+	r.setFromClause(envSE, x.Tables)
+	return envSE
+}
+
+// scopedAliases adds the table aliases of the enclosing scopes, which correlated subqueries reference
+func scopedAliases(env *object.Environment, aliases map[string]string) map[string]string {
+	scoped := map[string]string{}
+	if obj, ok := env.Get("table_aliases"); ok {
+		for alias, table := range obj.(*object.StringHash).Value {
+			scoped[alias] = table
+		}
+	}
+	for alias, table := range aliases {
+		scoped[alias] = table
+	}
+	return scoped
+}
+
+// targetAlias adds the alias of the table that UPDATE or DELETE writes to, which isn't in the aliases of its FROM clause
+func targetAlias(aliases map[string]string, table, alias ast.Expression) map[string]string {
+	ident, ok := table.(*ast.Identifier)
+	if !ok || alias == nil || len(ident.Value) == 0 {
+		return aliases
+	}
+
+	withTarget := map[string]string{alias.String(false): ident.Value[len(ident.Value)-1].String(false)}
+	for a, t := range aliases {
+		withTarget[a] = t
+	}
+	return withTarget
+}
+
+// setFromClause records the tables and relations in a FROM clause. Tables are by name, and relations by alias.
+func (r *Extractor) setFromClause(env *object.Environment, tables []ast.Expression) {
+	fromTables := map[string]string{}
+	fromRelations := map[string]string{}
+
+	for _, t := range tables {
+		te, ok := t.(*ast.TableExpression)
+		if !ok {
+			continue
+		}
+		switch te.Kind {
+		case ast.TableKindRelation:
+			ident, ok := te.Table.(*ast.Identifier)
+			if !ok {
+				continue
+			}
+			if name, ok := relationName(env, ident); ok {
+				fromRelations[name] = name
+				continue
+			}
+			switch len(ident.Value) {
+			case 1:
+				name := ident.Value[0].String(false)
+				fromTables[name] = fmt.Sprintf("%s.%s", r.schemaOf(name), name)
+			case 2:
+				name := ident.Value[1].String(false)
+				fromTables[name] = fmt.Sprintf("%s.%s", ident.Value[0].String(false), name)
+			}
+		case ast.TableKindSubquery:
+			if te.Alias != nil {
+				alias := te.Alias.String(false)
+				fromRelations[alias] = alias
+			}
+		}
+	}
+
+	env.Set("from_tables", &object.StringHash{Value: fromTables})
+	env.Set("from_relations", &object.StringHash{Value: fromRelations})
+}
+
+func fromClause(env *object.Environment, key string) map[string]string {
+	obj, ok := env.Get(key)
+	if !ok {
+		return map[string]string{}
+	}
+	return obj.(*object.StringHash).Value
+}
+
+func setRelation(env *object.Environment, rel *object.Relation) {
+	env.Set("relation|"+rel.Name, rel)
+}
+
+func getRelation(env *object.Environment, name string) (*object.Relation, bool) {
+	obj, ok := env.Get("relation|" + name)
+	if !ok {
+		return nil, false
+	}
+	rel, ok := obj.(*object.Relation)
+	return rel, ok
+}
+
+// relationName returns the name of the CTE or subquery that an unqualified table name references
+func relationName(env *object.Environment, ident *ast.Identifier) (string, bool) {
+	if len(ident.Value) != 1 {
+		return "", false
+	}
+	name := ident.Value[0].String(false)
+	_, ok := getRelation(env, name)
+	return name, ok
+}
+
+// extractCTE extracts the query of a CTE and puts the CTE in scope for the CTEs after it and the main query.
+// A recursive CTE references itself, so it's in scope for its own query, and its columns come from the non-recursive term.
+func (r *Extractor) extractCTE(x ast.Expression, recursive bool, env *object.Environment) {
+	aux, ok := x.(*ast.CTEAuxiliaryExpression)
+	if !ok {
+		r.Extract(x, env)
+		return
+	}
+
+	name, columns := cteName(aux.Name)
+	if name == "" {
+		r.Extract(aux.Expression, env)
+		return
+	}
+	rel := newRelation(name)
+
+	if !recursive {
+		r.Extract(aux.Expression, env)
+		fillRelation(rel, r.relationOf(aux.Expression), columns)
+		setRelation(env, rel)
+		return
+	}
+
+	setRelation(env, rel)
+	if union, ok := aux.Expression.(*ast.UnionExpression); ok {
+		r.Extract(union.Left, env)
+		fillRelation(rel, r.relationOf(union.Left), columns)
+		r.Extract(union.Right, env)
+		return
+	}
+	r.Extract(aux.Expression, env)
+	fillRelation(rel, r.relationOf(aux.Expression), columns)
+}
+
+// extractSubquery extracts a subquery in the FROM clause and puts it in scope by its alias
+func (r *Extractor) extractSubquery(x *ast.TableExpression, env *object.Environment) {
+	r.Extract(x.Table, env)
+	if x.Alias == nil {
+		return
+	}
+
+	rel := newRelation(x.Alias.String(false))
+	columns := []string{}
+	for _, c := range x.ColumnAliases {
+		columns = append(columns, c.Name)
+	}
+	fillRelation(rel, r.relationOf(x.Table), columns)
+	setRelation(env, rel)
+}
+
+func newRelation(name string) *object.Relation {
+	return &object.Relation{Name: name, Columns: map[string]string{}, Lineage: map[string]*object.Lineage{}}
+}
+
+// cteName returns the name of a CTE and its column list: name (a, b) AS (...)
+func cteName(name ast.Expression) (string, []string) {
+	switch n := name.(type) {
+	case *ast.Identifier:
+		return n.String(false), nil
+	case *ast.SimpleIdentifier:
+		return n.Value, nil
+	case *ast.CallExpression:
+		fn, _ := cteName(n.Function)
+		columns := []string{}
+		for _, a := range n.Arguments {
+			column, _ := cteName(a)
+			columns = append(columns, column)
+		}
+		return fn, columns
+	}
+	return "", nil
+}
+
+// fillRelation copies the columns of the query behind a relation, renaming them with the relation's column list
+func fillRelation(rel, query *object.Relation, columns []string) {
+	if query == nil {
+		return
+	}
+	rel.Tables = query.Tables
+	rel.Star = query.Star
+	for i, name := range query.Order {
+		base, lineage := query.Columns[name], query.Lineage[name]
+		if i < len(columns) {
+			name = columns[i]
+		}
+		rel.Columns[name] = base
+		rel.Lineage[name] = lineage
+		rel.Order = append(rel.Order, name)
+	}
+}
+
+// relationOf returns the columns of a query that was extracted, or nil when they aren't known
+func (r *Extractor) relationOf(x ast.Expression) *object.Relation {
+	switch x := x.(type) {
+	case *ast.SelectExpression:
+		return r.relations[x]
+	case *ast.UnionExpression:
+		return r.relationOf(x.Left)
+	case *ast.CTEExpression:
+		return r.relationOf(x.Primary)
+	case *ast.GroupedExpression:
+		if len(x.Elements) == 1 {
+			return r.relationOf(x.Elements[0])
+		}
+	}
+	return nil
+}
+
+// outputColumns maps the columns a SELECT returns to the table columns they select
+func (r *Extractor) outputColumns(x *ast.SelectExpression, env *object.Environment) *object.Relation {
+	rel := newRelation("")
+	for _, table := range fromClause(env, "from_tables") {
+		rel.Tables = append(rel.Tables, table)
+	}
+	sort.Strings(rel.Tables)
+
+	for _, c := range x.Columns {
+		col, ok := c.(*ast.ColumnExpression)
+		if !ok {
+			continue
+		}
+
+		name := ""
+		if col.Name != nil {
+			name = col.Name.String(false)
+		}
+		if name == "" {
+			name = defaultColumnName(col.Value)
+		}
+
+		if _, ok := col.Value.(*ast.WildcardLiteral); ok {
+			r.addStar(rel, "", env)
+			continue
+		}
+
+		base := ""
+		if ident, ok := col.Value.(*ast.Identifier); ok && len(ident.Value) > 0 {
+			last := ident.Value[len(ident.Value)-1]
+			if _, ok := last.(*ast.WildcardLiteral); ok {
+				qualifier := ""
+				if len(ident.Value) == 2 {
+					qualifier = ident.Value[0].String(false)
+				}
+				r.addStar(rel, qualifier, env)
+				continue
+			}
+			if col.Name == nil {
+				name = last.String(false)
+			}
+			if schema, table, column, _ := r.resolveColumn(ident, env); table != "" && table != "UNKNOWN" {
+				base = fmt.Sprintf("%s.%s.%s", schema, table, column)
+			}
+		}
+
+		if name == "" {
+			continue
+		}
+		if _, ok := rel.Columns[name]; ok && col.Name == nil {
+			name = fmt.Sprintf("%s%d", name, len(rel.Order)+1)
+		}
+		if _, ok := rel.Columns[name]; !ok {
+			rel.Order = append(rel.Order, name)
+		}
+		rel.Columns[name] = base
+		rel.Lineage[name] = r.lineageOf(col.Value, env)
+	}
+
+	return rel
+}
+
+// defaultColumnName is the name of a column without an alias that isn't a column of a table. Like Postgres, it's the name of
+// the function that computes it, or ?column?.
+func defaultColumnName(x ast.Expression) string {
+	name := "?column?"
+	if call, ok := x.(*ast.CallExpression); ok && call.Function != nil {
+		name = call.Function.String(false)
+	}
+	return name
+}
+
+// addStar adds the columns of * or table.* to the output of a SELECT
+func (r *Extractor) addStar(rel *object.Relation, qualifier string, env *object.Environment) {
+	var relations []string
+	if qualifier != "" {
+		relations = []string{qualifier}
+	} else {
+		rel.Star = true
+		for name := range fromClause(env, "from_relations") {
+			relations = append(relations, name)
+		}
+		sort.Strings(relations)
+	}
+
+	for _, name := range relations {
+		from, ok := getRelation(env, name)
+		if !ok {
+			if qualifier != "" {
+				// table.* has all of the table's columns
+				rel.Star = true
+				rel.Tables = []string{fmt.Sprintf("%s.%s", r.schemaOf(name), name)}
+			}
+			continue
+		}
+		for _, column := range from.Order {
+			if _, ok := rel.Columns[column]; !ok {
+				rel.Order = append(rel.Order, column)
+			}
+			rel.Columns[column] = from.Columns[column]
+			rel.Lineage[column] = from.Lineage[column]
+		}
+		if from.Star {
+			rel.Star = true
+			rel.Tables = append(rel.Tables, from.Tables...)
+		}
+	}
+}
+
+// resolveColumn finds the table of a column in the scope it's in. Columns of a CTE or subquery are followed through
+// to the table column they select, and derived is true. Table is "" when the CTE or subquery computes the column,
+// and "UNKNOWN" when the scope has more than one table that it could be in.
+func (r *Extractor) resolveColumn(i *ast.Identifier, env *object.Environment) (schema, table, column string, derived bool) {
+	column = i.Value[len(i.Value)-1].String(false)
+	if _, ok := i.Value[len(i.Value)-1].(*ast.WildcardLiteral); ok {
+		column = "*"
+	}
+
+	switch len(i.Value) {
+	case 1:
+		relations := fromClause(env, "from_relations")
+		tables := fromClause(env, "from_tables")
+
+		names := []string{}
+		for name := range relations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var found *object.Relation
+		count := 0
+		for _, name := range names {
+			if rel, ok := getRelation(env, name); ok {
+				if _, ok := rel.Columns[column]; ok {
+					found = rel
+					count++
+				}
+			}
+		}
+		if count == 1 {
+			schema, table, column = splitColumn(found.Columns[column], column)
+			return schema, table, column, true
+		}
+
+		star := false
+		for _, name := range names {
+			if rel, ok := getRelation(env, name); ok && rel.Star {
+				star = true
+			}
+		}
+
+		switch {
+		case count == 0 && !star && len(tables) == 1:
+			for _, fqtn := range tables {
+				schema, table = splitTable(fqtn)
+			}
+			return schema, table, column, false
+		case count == 0 && len(relations) == 1 && len(tables) == 0:
+			for name := range relations {
+				schema, table = r.starTable(env, name)
+			}
+			return schema, table, column, true
+		}
+		return r.schemaOf("UNKNOWN"), "UNKNOWN", column, false
+	case 2:
+		name := i.Value[0].String(false)
+		if rel, ok := getRelation(env, name); ok {
+			if base, ok := rel.Columns[column]; ok {
+				schema, table, column = splitColumn(base, column)
+				return schema, table, column, true
+			}
+			schema, table = r.starTable(env, name)
+			return schema, table, column, true
+		}
+		return r.schemaOf(name), name, column, false
+	default:
+		return i.Value[0].String(false), i.Value[1].String(false), column, false
+	}
+}
+
+// starTable is the table of a column that a relation has because it selects *. It's only known with one table.
+func (r *Extractor) starTable(env *object.Environment, name string) (string, string) {
+	rel, ok := getRelation(env, name)
+	if !ok || !rel.Star || len(rel.Tables) != 1 {
+		return "", ""
+	}
+	return splitTable(rel.Tables[0])
+}
+
+// splitColumn splits schema.table.column, or returns no table for a column that's computed
+func splitColumn(base, column string) (string, string, string) {
+	parts := strings.SplitN(base, ".", 3)
+	if len(parts) != 3 {
+		return "", "", column
+	}
+	return parts[0], parts[1], parts[2]
+}
+
+func splitTable(fqtn string) (string, string) {
+	schema, table, _ := strings.Cut(fqtn, ".")
+	return schema, table
+}
+
+// extractLock records the tables that SELECT ... FOR UPDATE or FOR SHARE locks. Without OF, it's the tables in
+// the FROM clause, but not the tables of its CTEs and subqueries, which aren't locked.
+func (r *Extractor) extractLock(x *ast.LockExpression, env *object.Environment) {
+	tables := fromClause(env, "from_tables")
+
+	fqtns := []string{}
+	if len(x.Tables) == 0 {
+		for _, fqtn := range tables {
+			fqtns = append(fqtns, fqtn)
+		}
+	}
+	for _, t := range x.Tables {
+		ident, ok := t.(*ast.Identifier)
+		if !ok || len(ident.Value) == 0 {
+			continue
+		}
+		name := ident.Value[len(ident.Value)-1].String(false)
+		if aliases, ok := env.Get("table_aliases"); ok {
+			if table, ok := aliases.(*object.StringHash).Value[name]; ok {
+				name = table
+			}
+		}
+		if fqtn, ok := tables[name]; ok {
+			fqtns = append(fqtns, fqtn)
+		}
+	}
+
+	for _, fqtn := range fqtns {
+		if t, ok := r.TablesInQueries[fqtn]; ok {
+			t.access(Lock)
+			t.LockMode = strings.ToUpper(x.Lock)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+)
+
+// DefaultSearchPath is the search_path Postgres uses when it isn't set
+var DefaultSearchPath = []string{`"$user"`, "public"}
+
+// SearchPathFromSet returns the schemas of a SET search_path statement. It returns false for any other statement.
+// SET search_path TO DEFAULT returns a nil path.
+func SearchPathFromSet(stmt ast.Statement) ([]string, bool) {
+	set, ok := stmt.(*ast.SetStatement)
+	if !ok || set.TimeZone || set.HasCharacteristics || set.HasConstraints {
+		return nil, false
+	}
+	infix, ok := set.Expression.(*ast.InfixExpression)
+	if !ok || infix.Left == nil || !strings.EqualFold(infix.Left.String(false), "search_path") {
+		return nil, false
+	}
+
+	values := []ast.Expression{infix.Right}
+	if list, ok := infix.Right.(*ast.GroupedExpression); ok {
+		values = list.Elements
+	}
+
+	path := []string{}
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		value := v.String(false)
+		if strings.EqualFold(value, "default") {
+			return nil, true
+		}
+		// A string such as 'app, public' is a whole list
+		if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2 {
+			path = append(path, ParseSearchPath(strings.ReplaceAll(value[1:len(value)-1], "''", "'"))...)
+			continue
+		}
+		path = append(path, value)
+	}
+	return path, true
+}
+
+// ParseSearchPath splits a search_path written like it's shown by SHOW search_path, i.e. "$user", public
+func ParseSearchPath(s string) []string {
+	path := []string{}
+	for _, schema := range strings.Split(s, ",") {
+		if schema = strings.TrimSpace(schema); schema != "" {
+			path = append(path, schema)
+		}
+	}
+	return path
+}
+
+// schemaOf returns the schema of a table that isn't qualified with one. That's the first schema on the search path
+// that the catalog has the table in, like Postgres does it. Without a catalog, or when the catalog doesn't have
+// the table, it's the first schema on the path other than "$user", which is skipped because it usually doesn't exist.
+func (d *Extractor) schemaOf(table string) string {
+	path := d.SearchPath
+	if path == nil {
+		path = DefaultSearchPath
+	}
+
+	if d.Catalog != nil {
+		for _, schema := range path {
+			if isUserSchema(schema) {
+				if d.User == "" {
+					continue
+				}
+				// The role's name is used as it is, so it's quoted to keep its case
+				if t, ok := d.Catalog.Table(`"`+strings.ReplaceAll(d.User, `"`, `""`)+`"`, table); ok {
+					return t.Schema
+				}
+				continue
+			}
+			if _, ok := d.Catalog.Table(schema, table); ok {
+				return schema
+			}
+		}
+	}
+
+	return d.defaultSchema()
+}
+
+// defaultSchema is the first schema on the search path other than "$user" and pg_catalog, which is where
+// objects that aren't qualified with a schema are when there's no catalog to say otherwise
+func (d *Extractor) defaultSchema() string {
+	path := d.SearchPath
+	if path == nil {
+		path = DefaultSearchPath
+	}
+
+	for _, schema := range path {
+		if !isUserSchema(schema) && !strings.EqualFold(schema, "pg_catalog") {
+			return schema
+		}
+	}
+	return "public"
+}
+
+func isUserSchema(schema string) bool {
+	return schema == `"$user"` || schema == "$user"
+}
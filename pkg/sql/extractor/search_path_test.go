@@ -0,0 +1,107 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/catalog"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchPathFromSet(t *testing.T) {
+	tests := []struct {
+		input string
+		path  []string
+		ok    bool
+	}{
+		{"set search_path = app;", []string{"app"}, true},
+		{"set search_path to app, \"$user\", public;", []string{"app", `"$user"`, "public"}, true},
+		{"set session search_path = 'app', public;", []string{"app", "public"}, true},
+		{"set search_path = 'app, public';", []string{"app", "public"}, true},
+		{"SET SEARCH_PATH TO app;", []string{"app"}, true},
+		{"set search_path to default;", nil, true},
+		{"set application_name = 'app';", nil, false},
+		{"set local time zone 'UTC';", nil, false},
+		{"select 1;", nil, false},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		path, ok := SearchPathFromSet(program.Statements[0])
+		assert.Equal(t, tt.ok, ok, "input: %s", tt.input)
+		assert.Equal(t, tt.path, path, "input: %s", tt.input)
+	}
+}
+
+func TestExtractWithSearchPath(t *testing.T) {
+	c := catalog.New()
+	err := c.AddSQL(`
+		create table app.users (id bigint, name text);
+		create table public.users (id bigint, name text);
+		create table public.addresses (id bigint, user_id bigint, city text);
+		create table bob.addresses (id bigint, user_id bigint, city text);
+	`, token.Postgres)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		input   string
+		path    []string
+		user    string
+		catalog *catalog.Catalog
+		tables  []string
+		joins   []string
+	}{
+		// Without a search path, it's public
+		{"select name from users;", nil, "", nil,
+			[]string{"public.users"}, []string{}},
+		// Without a catalog, it's the first schema on the path
+		{"select name from users;", []string{"app", "public"}, "", nil,
+			[]string{"app.users"}, []string{}},
+		{"select name from users;", []string{`"$user"`, "app"}, "bob", nil,
+			[]string{"app.users"}, []string{}},
+		// With a catalog, it's the first schema on the path that has the table
+		{"select city from users u join addresses a on a.user_id = u.id;", []string{"app", "public"}, "", c,
+			[]string{"app.users", "public.addresses"}, []string{"app.users|public.addresses"}},
+		{"select city from users u join addresses a on a.user_id = u.id;", nil, "bob", c,
+			[]string{"public.users", "bob.addresses"}, []string{"bob.addresses|public.users"}},
+		{"select city from users u join addresses a on a.user_id = u.id;", nil, "alice", c,
+			[]string{"public.users", "public.addresses"}, []string{"public.addresses|public.users"}},
+		// Tables that are qualified aren't looked up
+		{"select name from public.users;", []string{"app", "public"}, "", c,
+			[]string{"public.users"}, []string{}},
+		// A table that isn't in the catalog is in the first schema on the path
+		{"select make from cars;", []string{"app", "public"}, "", c,
+			[]string{"app.cars"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+
+		for _, s := range program.Statements {
+			r := NewExtractor(&s, true)
+			r.Catalog = tt.catalog
+			r.SearchPath, r.User = tt.path, tt.user
+			r.Execute(s)
+			checkExtractErrors(t, r, tt.input)
+
+			tables := []string{}
+			for fqtn := range r.TablesInQueries {
+				tables = append(tables, fqtn)
+			}
+			assert.ElementsMatch(t, tt.tables, tables, "input: %s", tt.input)
+
+			joins := []string{}
+			for _, j := range r.TableJoinsInQueries {
+				joins = append(joins, j.SchemaA+"."+j.TableA+"|"+j.SchemaB+"."+j.TableB)
+			}
+			assert.ElementsMatch(t, tt.joins, joins, "input: %s", tt.input)
+		}
+	}
+}
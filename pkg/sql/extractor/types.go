@@ -0,0 +1,589 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/object"
+)
+
+// Column types are guessed from how the columns are used, since without a catalog there's no other way to know them.
+// Each use is evidence for a type: id = 1 suggests an integer, data->'key' suggests jsonb, and so on. Some uses are
+// better evidence than others. A string literal can be compared to almost any type in Postgres, and a cast says
+// what the values are used as, though the column is often another type, like created_at::date.
+
+// How a use of a column suggests its type
+const (
+	Comparison = "comparison" // compared to a value of a known type, like id = 1 or id = $1::uuid
+	Literal    = "literal"    // compared to a string literal, like state = 'open'
+	Operator   = "operator"   // used with an operator of a type, like data->'key' or name LIKE ?
+	Argument   = "argument"   // an argument of a function of a type, like date_trunc('day', created_at)
+	Cast       = "cast"       // cast to a type, like id::uuid
+)
+
+// typeEvidence is how likely a use is to be right about the column's type
+var typeEvidence = map[string]float64{
+	Comparison: 0.8,
+	Operator:   0.8,
+	Argument:   0.7,
+	Cast:       0.6,
+	Literal:    0.5,
+}
+
+// typeNames are the names of types that have aliases
+var typeNames = map[string]string{
+	"int":                         "integer",
+	"int4":                        "integer",
+	"int8":                        "bigint",
+	"int2":                        "smallint",
+	"decimal":                     "numeric",
+	"float":                       "double precision",
+	"float8":                      "double precision",
+	"float4":                      "real",
+	"bool":                        "boolean",
+	"varchar":                     "text",
+	"character varying":           "text",
+	"char":                        "text",
+	"character":                   "text",
+	"bpchar":                      "text",
+	"citext":                      "text",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"time without time zone":      "time",
+	"time with time zone":         "timetz",
+}
+
+// typeKinds group the types that a column can be used as without being used inconsistently
+var typeKinds = map[string]string{
+	"smallint":         "numeric",
+	"integer":          "numeric",
+	"bigint":           "numeric",
+	"numeric":          "numeric",
+	"real":             "numeric",
+	"double precision": "numeric",
+	"text":             "text",
+	"boolean":          "boolean",
+	"date":             "temporal",
+	"time":             "temporal",
+	"timetz":           "temporal",
+	"timestamp":        "temporal",
+	"timestamptz":      "temporal",
+	"json":             "json",
+	"jsonb":            "json",
+}
+
+// typedKeywords are the values that look like columns but aren't
+var typedKeywords = map[string]string{
+	"current_date":      "date",
+	"current_time":      "timetz",
+	"current_timestamp": "timestamptz",
+	"localtime":         "time",
+	"localtimestamp":    "timestamp",
+}
+
+// functionTypes are the types that builtin functions return
+var functionTypes = map[string]string{
+	"now":                   "timestamptz",
+	"clock_timestamp":       "timestamptz",
+	"statement_timestamp":   "timestamptz",
+	"transaction_timestamp": "timestamptz",
+	"to_timestamp":          "timestamptz",
+	"to_date":               "date",
+	"gen_random_uuid":       "uuid",
+	"uuid_generate_v4":      "uuid",
+	"lower":                 "text",
+	"upper":                 "text",
+	"concat":                "text",
+	"md5":                   "text",
+	"length":                "integer",
+	"char_length":           "integer",
+	"count":                 "bigint",
+	"to_jsonb":              "jsonb",
+	"jsonb_build_object":    "jsonb",
+	"jsonb_build_array":     "jsonb",
+	"to_json":               "json",
+	"json_build_object":     "json",
+	"json_build_array":      "json",
+}
+
+type argumentType struct {
+	typ       string
+	arguments []int // the arguments that are the type, or all of them when it's nil
+}
+
+// argumentTypes are the types of the arguments of builtin functions
+var argumentTypes = map[string]argumentType{
+	// Dates and times
+	"date_trunc": {"timestamp", []int{1}},
+	"date_part":  {"timestamp", []int{1}},
+	"extract":    {"timestamp", nil},
+	"age":        {"timestamp", nil},
+	// Text
+	"lower":                 {"text", nil},
+	"upper":                 {"text", nil},
+	"initcap":               {"text", nil},
+	"length":                {"text", nil},
+	"char_length":           {"text", nil},
+	"btrim":                 {"text", []int{0}},
+	"ltrim":                 {"text", []int{0}},
+	"rtrim":                 {"text", []int{0}},
+	"left":                  {"text", []int{0}},
+	"right":                 {"text", []int{0}},
+	"replace":               {"text", []int{0}},
+	"split_part":            {"text", []int{0}},
+	"starts_with":           {"text", []int{0}},
+	"strpos":                {"text", []int{0}},
+	"substr":                {"text", []int{0}},
+	"substring":             {"text", []int{0}},
+	"regexp_match":          {"text", []int{0}},
+	"regexp_matches":        {"text", []int{0}},
+	"regexp_replace":        {"text", []int{0}},
+	"regexp_split_to_array": {"text", []int{0}},
+	// Numbers
+	"abs":     {"numeric", nil},
+	"ceil":    {"numeric", nil},
+	"ceiling": {"numeric", nil},
+	"floor":   {"numeric", []int{0}},
+	"round":   {"numeric", []int{0}},
+	"sqrt":    {"numeric", nil},
+	"avg":     {"numeric", nil},
+	"sum":     {"numeric", nil},
+	// JSON
+	"jsonb_array_elements":      {"jsonb", []int{0}},
+	"jsonb_array_elements_text": {"jsonb", []int{0}},
+	"jsonb_array_length":        {"jsonb", []int{0}},
+	"jsonb_each":                {"jsonb", []int{0}},
+	"jsonb_each_text":           {"jsonb", []int{0}},
+	"jsonb_extract_path":        {"jsonb", []int{0}},
+	"jsonb_extract_path_text":   {"jsonb", []int{0}},
+	"jsonb_object_keys":         {"jsonb", []int{0}},
+	"jsonb_path_exists":         {"jsonb", []int{0}},
+	"jsonb_path_query":          {"jsonb", []int{0}},
+	"jsonb_pretty":              {"jsonb", []int{0}},
+	"jsonb_set":                 {"jsonb", []int{0}},
+	"jsonb_strip_nulls":         {"jsonb", []int{0}},
+	"jsonb_typeof":              {"jsonb", []int{0}},
+	"json_array_elements":       {"json", []int{0}},
+	"json_array_elements_text":  {"json", []int{0}},
+	"json_array_length":         {"json", []int{0}},
+	"json_each":                 {"json", []int{0}},
+	"json_each_text":            {"json", []int{0}},
+	"json_extract_path":         {"json", []int{0}},
+	"json_extract_path_text":    {"json", []int{0}},
+	"json_object_keys":          {"json", []int{0}},
+	"json_typeof":               {"json", []int{0}},
+	// Arrays
+	"array_append":    {"array", []int{0}},
+	"array_cat":       {"array", nil},
+	"array_dims":      {"array", []int{0}},
+	"array_length":    {"array", []int{0}},
+	"array_lower":     {"array", []int{0}},
+	"array_ndims":     {"array", []int{0}},
+	"array_position":  {"array", []int{0}},
+	"array_positions": {"array", []int{0}},
+	"array_prepend":   {"array", []int{1}},
+	"array_remove":    {"array", []int{0}},
+	"array_replace":   {"array", []int{0}},
+	"array_to_string": {"array", []int{0}},
+	"array_upper":     {"array", []int{0}},
+	"cardinality":     {"array", []int{0}},
+	"unnest":          {"array", nil},
+}
+
+var (
+	dateLiteral      = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	timestampLiteral = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}`)
+	uuidLiteral      = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+// inferInfixTypes records the types that an operator suggests for the columns it's used with. It's called after
+// the expression is extracted, so the columns are unaliased.
+func (r *Extractor) inferInfixTypes(x *ast.InfixExpression, env *object.Environment) {
+	operator := strings.ToUpper(x.Operator)
+	switch operator {
+	case "=", "==", "<>", "!=", "<", ">", "<=", ">=":
+		// = ANY (tags) uses an array
+		if call, ok := x.Right.(*ast.CallExpression); ok && len(call.Arguments) == 1 {
+			if name, ok := builtinFunction(call); ok && (name == "any" || name == "some" || name == "all") {
+				r.addColumnType(call.Arguments[0], "array", Operator, env)
+				return
+			}
+		}
+		r.inferComparisonTypes(x.Left, x.Right, env)
+		r.inferComparisonTypes(x.Right, x.Left, env)
+	case "BETWEEN":
+		if bounds, ok := x.Right.(*ast.InfixExpression); ok {
+			r.inferComparisonTypes(x.Left, bounds.Left, env)
+			r.inferComparisonTypes(x.Left, bounds.Right, env)
+		}
+	case "LIKE", "ILIKE", "SIMILAR", "SIMILAR TO", "~", "~*", "!~", "!~*":
+		r.addColumnType(x.Left, "text", Operator, env)
+	case "->", "->>", "#>", "#>>", "#-", "?", "?|", "?&":
+		r.addColumnType(x.Left, "jsonb", Operator, env)
+	case "@>", "<@":
+		// Arrays and ranges have these operators too, so the value says which it is
+		switch typ, _ := r.typeOfValue(x.Right); typeKind(typ) {
+		case "json", "array":
+			r.addColumnType(x.Left, typ, Operator, env)
+		}
+	case "&&":
+		r.addColumnType(x.Left, "array", Operator, env)
+		r.addColumnType(x.Right, "array", Operator, env)
+	case "+", "-":
+		// created_at + interval '1 day'
+		if _, ok := x.Right.(*ast.IntervalExpression); ok {
+			r.addColumnType(x.Left, "timestamp", Operator, env)
+		}
+		if _, ok := x.Left.(*ast.IntervalExpression); ok {
+			r.addColumnType(x.Right, "timestamp", Operator, env)
+		}
+	}
+}
+
+// inferComparisonTypes records the type of the value that a column is compared to
+func (r *Extractor) inferComparisonTypes(column, value ast.Expression, env *object.Environment) {
+	if typ, use := r.typeOfValue(value); typ != "" {
+		r.addColumnType(column, typ, use, env)
+	}
+}
+
+// inferInTypes records the types of the values in an IN list
+func (r *Extractor) inferInTypes(x *ast.InExpression, env *object.Environment) {
+	for _, value := range x.Right {
+		r.inferComparisonTypes(x.Left, value, env)
+	}
+}
+
+// inferIsTypes records that IS TRUE and IS FALSE are booleans, and the type of the value of IS DISTINCT FROM
+func (r *Extractor) inferIsTypes(x *ast.IsExpression, env *object.Environment) {
+	if x.Distinct {
+		r.inferComparisonTypes(x.Left, x.Right, env)
+		return
+	}
+	if _, ok := x.Right.(*ast.Boolean); ok {
+		r.addColumnType(x.Left, "boolean", Operator, env)
+	}
+}
+
+// inferCallTypes records the types of the columns that are arguments of builtin functions
+func (r *Extractor) inferCallTypes(call *ast.CallExpression, env *object.Environment) {
+	name, ok := builtinFunction(call)
+	if !ok {
+		return
+	}
+	at, ok := argumentTypes[name]
+	if !ok {
+		return
+	}
+
+	for i, a := range call.Arguments {
+		if at.arguments != nil && !containsInt(at.arguments, i) {
+			continue
+		}
+		// extract(year FROM created_at) and substring(name FROM 1 FOR 3)
+		if s, ok := a.(*ast.StringFunctionExpression); ok {
+			a = s.Left
+			if name == "extract" {
+				a = s.From
+			}
+		}
+		r.addColumnType(a, at.typ, Argument, env)
+	}
+}
+
+// inferCastType records the type that a column is cast to. Anything can be cast to text, so that isn't evidence.
+func (r *Extractor) inferCastType(x ast.Expression, cast ast.Expression, env *object.Environment) {
+	if cast == nil {
+		return
+	}
+	if typ := typeName(cast); typeKind(typ) != "text" {
+		r.addColumnType(x, typ, Cast, env)
+	}
+}
+
+// addColumnType records a use of a column that suggests its type. Columns that are cast, or in an expression,
+// aren't the value that's used, so only a bare column is recorded.
+func (r *Extractor) addColumnType(x ast.Expression, typ, use string, env *object.Environment) {
+	if typ == "" {
+		return
+	}
+	if cast := castOf(x); cast != nil && use != Cast {
+		return
+	}
+	if g, ok := x.(*ast.GroupedExpression); ok && len(g.Elements) == 1 {
+		r.addColumnType(g.Elements[0], typ, use, env)
+		return
+	}
+	ident, ok := x.(*ast.Identifier)
+	if !ok || !isColumn(ident) || isFunctionColumn(env, ident) {
+		return
+	}
+
+	schema, table, column, derived := r.resolveColumn(ident, env)
+	if derived && table == "" {
+		// computed by a CTE or subquery
+		return
+	}
+
+	t := &ColumnTypes{Schema: schema, Table: table, Column: column, Evidence: map[string]int{use + ":" + typ: 1}}
+	r.addColumnTypes(t)
+}
+
+// addColumnTypes adds the evidence of a column's type to what's known about the column
+func (r *Extractor) addColumnTypes(t *ColumnTypes) {
+	key := fmt.Sprintf("%s.%s.%s", t.Schema, t.Table, t.Column)
+	existing, ok := r.ColumnTypes[key]
+	if !ok {
+		existing = &ColumnTypes{
+			UID:      UuidV5(key),
+			TableUID: UuidV5(fmt.Sprintf("%s.%s", t.Schema, t.Table)),
+			Schema:   t.Schema,
+			Table:    t.Table,
+			Column:   t.Column,
+			Evidence: make(map[string]int),
+		}
+		r.ColumnTypes[key] = existing
+	}
+	existing.Add(t)
+}
+
+// inferColumnTypeTables sets the table of the types of unqualified columns, once the columns' tables are inferred
+func (r *Extractor) inferColumnTypeTables() {
+	types := r.ColumnTypes
+	r.ColumnTypes = make(map[string]*ColumnTypes)
+	for _, t := range types {
+		if t.Table == "UNKNOWN" {
+			if schema, table, ok := r.inferredTable(t.Column); ok {
+				t.Schema, t.Table = schema, table
+			}
+		}
+		r.addColumnTypes(t)
+	}
+}
+
+// Add adds the evidence of another column's uses, like the same column in another query, and infers the type again.
+// The type is the one with the strongest evidence, and the confidence is how strong that evidence is, scaled by its
+// share of all the evidence. A column used as more than one kind of type, like text and numeric, is inconsistent.
+func (t *ColumnTypes) Add(other *ColumnTypes) {
+	if t.Evidence == nil {
+		t.Evidence = make(map[string]int)
+	}
+	for use, count := range other.Evidence {
+		t.Evidence[use] += count
+	}
+
+	// The doubt of a type is the chance that every use suggesting it is wrong
+	typeDoubt := map[string]float64{}
+	kindDoubt := map[string]float64{}
+	for evidence, count := range t.Evidence {
+		use, typ, _ := strings.Cut(evidence, ":")
+		doubt := math.Pow(1-typeEvidence[use], float64(count))
+		if _, ok := typeDoubt[typ]; !ok {
+			typeDoubt[typ] = 1
+		}
+		typeDoubt[typ] *= doubt
+		if _, ok := kindDoubt[typeKind(typ)]; !ok {
+			kindDoubt[typeKind(typ)] = 1
+		}
+		kindDoubt[typeKind(typ)] *= doubt
+	}
+
+	t.UsedAs = make([]string, 0, len(kindDoubt))
+	for kind := range kindDoubt {
+		t.UsedAs = append(t.UsedAs, kind)
+	}
+	sort.Strings(t.UsedAs)
+	t.Inconsistent = len(t.UsedAs) > 1
+
+	kind, total := "", 0.0
+	for _, k := range t.UsedAs {
+		total += 1 - kindDoubt[k]
+		if kind == "" || kindDoubt[k] < kindDoubt[kind] {
+			kind = k
+		}
+	}
+
+	types := make([]string, 0, len(typeDoubt))
+	for typ := range typeDoubt {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	t.Type = ""
+	for _, typ := range types {
+		if typeKind(typ) == kind && (t.Type == "" || typeDoubt[typ] < typeDoubt[t.Type]) {
+			t.Type = typ
+		}
+	}
+
+	t.Confidence = 0
+	if total > 0 {
+		strength := 1 - kindDoubt[kind]
+		t.Confidence = math.Round(strength*strength/total*100) / 100
+	}
+}
+
+// typeOfValue is the type of a value that a column is used with, and the kind of use that it is
+func (r *Extractor) typeOfValue(x ast.Expression) (string, string) {
+	if cast := castOf(x); cast != nil {
+		return typeName(cast), Comparison
+	}
+
+	switch x := x.(type) {
+	case *ast.IntegerLiteral:
+		return "integer", Comparison
+	case *ast.FloatLiteral:
+		return "numeric", Comparison
+	case *ast.Boolean:
+		return "boolean", Comparison
+	case *ast.StringLiteral:
+		return literalType(x.Value), Literal
+	case *ast.CastExpression:
+		return typeName(x.Cast), Comparison
+	case *ast.IntervalExpression:
+		return "interval", Comparison
+	case *ast.ArrayLiteral:
+		// array[...], but not tags[1]
+		if ident, ok := x.Left.(*ast.Identifier); ok && strings.EqualFold(ident.String(false), "array") {
+			return "array", Comparison
+		}
+	case *ast.Identifier:
+		if len(x.Value) == 1 {
+			return typedKeywords[strings.ToLower(x.String(false))], Comparison
+		}
+	case *ast.CallExpression:
+		if name, ok := builtinFunction(x); ok {
+			return functionTypes[name], Comparison
+		}
+	case *ast.GroupedExpression:
+		if len(x.Elements) == 1 {
+			return r.typeOfValue(x.Elements[0])
+		}
+	case *ast.InfixExpression:
+		// now() - interval '1 day'
+		switch x.Operator {
+		case "+", "-":
+			if _, ok := x.Right.(*ast.IntervalExpression); ok {
+				if typ, use := r.typeOfValue(x.Left); typeKind(typ) == "temporal" {
+					return typ, use
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+// literalType is the type that a string literal looks like. Postgres converts a string literal to the type it's
+// compared to, so '2024-01-01' is probably compared to a date.
+func literalType(s string) string {
+	switch {
+	case dateLiteral.MatchString(s):
+		return "date"
+	case timestampLiteral.MatchString(s):
+		return "timestamp"
+	case uuidLiteral.MatchString(s):
+		return "uuid"
+	case strings.HasPrefix(s, "{") || strings.HasPrefix(s, "["):
+		if json.Valid([]byte(s)) {
+			return "jsonb"
+		}
+		// '{a,b}'
+		if strings.HasSuffix(s, "}") {
+			return "array"
+		}
+	}
+	return "text"
+}
+
+// typeName is the name of the type in a cast, like integer for ::int4 and text[] for ::varchar(255)[]
+func typeName(cast ast.Expression) string {
+	name := strings.ToLower(strings.TrimSpace(cast.String(false)))
+	array := strings.HasSuffix(name, "[]")
+	name = strings.TrimSuffix(name, "[]")
+	if i := strings.Index(name, "("); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	if n, ok := typeNames[name]; ok {
+		name = n
+	}
+	if array {
+		return name + "[]"
+	}
+	return name
+}
+
+// typeKind is the kind of a type. Types that aren't grouped with others are their own kind.
+func typeKind(typ string) string {
+	if strings.HasSuffix(typ, "[]") {
+		return "array"
+	}
+	if kind, ok := typeKinds[typ]; ok {
+		return kind
+	}
+	return typ
+}
+
+// castOf is the cast of a column or value, like ::uuid
+func castOf(x ast.Expression) ast.Expression {
+	switch x := x.(type) {
+	case *ast.Identifier:
+		return x.Cast
+	case *ast.StringLiteral:
+		return x.Cast
+	case *ast.IntegerLiteral:
+		return x.Cast
+	case *ast.FloatLiteral:
+		return x.Cast
+	case *ast.ParamLiteral:
+		return x.Cast
+	case *ast.Boolean:
+		return x.Cast
+	}
+	return nil
+}
+
+// isColumn is true for an identifier that could be a column, and not a value like current_date
+func isColumn(i *ast.Identifier) bool {
+	if len(i.Value) == 0 {
+		return false
+	}
+	if _, ok := i.Value[len(i.Value)-1].(*ast.WildcardLiteral); ok {
+		return false
+	}
+	if len(i.Value) == 1 {
+		_, ok := typedKeywords[strings.ToLower(i.Value[0].String(false))]
+		return !ok
+	}
+	return true
+}
+
+// builtinFunction is the name of a call to a function in pg_catalog, which is where unqualified names usually are
+func builtinFunction(call *ast.CallExpression) (string, bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return "", false
+	}
+	switch len(ident.Value) {
+	case 1:
+	case 2:
+		if !strings.EqualFold(ident.Value[0].String(false), "pg_catalog") {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return strings.ToLower(ident.Value[len(ident.Value)-1].String(false)), true
+}
+
+func containsInt(list []int, n int) bool {
+	for _, i := range list {
+		if i == n {
+			return true
+		}
+	}
+	return false
+}
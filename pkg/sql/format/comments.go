@@ -0,0 +1,52 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+)
+
+// The layout of a statement comes from the AST, so comments are placed by their position instead.
+
+// leadingComments returns the comments before the statement along with any inside of it,
+// since the layout of a statement doesn't leave room for comments between its clauses.
+func (p *printer) leadingComments(stmt ast.Statement) []string {
+	lines := []string{}
+	if !stmt.End().IsValid() {
+		return lines
+	}
+
+	for p.next < len(p.comments) && p.comments[p.next].Pos().Offset < stmt.End().Offset {
+		lines = append(lines, p.comments[p.next].Token.Lit)
+		p.next++
+	}
+
+	return lines
+}
+
+// trailingComment returns a comment that follows the statement on the same line. Only one comment fits at the end of a line.
+func (p *printer) trailingComment(stmt ast.Statement) string {
+	if p.next >= len(p.comments) || !stmt.End().IsValid() {
+		return ""
+	}
+
+	c := p.comments[p.next]
+	if c.Pos().Line != stmt.End().Line {
+		return ""
+	}
+	// A block comment can be followed by more code on the same line, which belongs to the next statement
+	if strings.HasPrefix(c.Token.Lit, "/*") && strings.Contains(c.Token.Lit, "\n") {
+		return ""
+	}
+
+	p.next++
+	return " " + c.Token.Lit
+}
+
+func (p *printer) remainingComments() []string {
+	lines := []string{}
+	for ; p.next < len(p.comments); p.next++ {
+		lines = append(lines, p.comments[p.next].Token.Lit)
+	}
+	return lines
+}
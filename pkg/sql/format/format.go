@@ -0,0 +1,193 @@
+// Package format renders parsed SQL as indented, multi-line SQL.
+//
+// The parser is the front end: clauses are laid out from the AST, while the expressions inside of them are
+// copied from the source text using each node's span. That keeps the output valid SQL even where the
+// AST's String() normalizes things, such as the extra parentheses around conditions.
+package format
+
+import (
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+type KeywordCase int
+
+const (
+	KeywordUpper KeywordCase = iota
+	KeywordLower
+)
+
+type CommaStyle int
+
+const (
+	CommaTrailing CommaStyle = iota // a,\n  b
+	CommaLeading                    // a\n  , b
+)
+
+type Options struct {
+	KeywordCase KeywordCase
+	Indent      int        // spaces per level of indentation
+	CommaStyle  CommaStyle // where commas go when a list is broken across lines
+	LineWidth   int        // a clause that fits within this many columns stays on one line
+	Dialect     token.Dialect
+}
+
+func DefaultOptions() Options {
+	return Options{
+		KeywordCase: KeywordUpper,
+		Indent:      2,
+		CommaStyle:  CommaTrailing,
+		LineWidth:   80,
+	}
+}
+
+// Source parses src and formats it. Comments are kept: comments inside of a statement are moved above it,
+// and a comment at the end of a statement's last line stays there.
+// If src doesn't parse, the first parse error is returned, since formatting would drop the statements with errors.
+func Source(src string, opts Options) (string, error) {
+	l := lexer.New(src, opts.Dialect)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if errs := p.ParseErrors(); len(errs) > 0 {
+		return "", errs[0]
+	}
+
+	return Program(program, src, opts), nil
+}
+
+// Program formats a program that was parsed from src. Expressions are copied from src, so it needs to be the
+// same text the program came from. If src is empty, or a node doesn't have a span, the node's String() is used instead.
+func Program(program *ast.Program, src string, opts Options) string {
+	p := newPrinter(program, src, opts)
+
+	var out strings.Builder
+	prevMultiline := false
+	for i, stmt := range program.Statements {
+		lines := p.leadingComments(stmt)
+		lines = append(lines, p.statement(stmt)...)
+		lines[len(lines)-1] += p.trailingComment(stmt)
+
+		multiline := len(lines) > 1
+		if i > 0 {
+			out.WriteString("\n")
+			if multiline || prevMultiline {
+				out.WriteString("\n")
+			}
+		}
+		out.WriteString(strings.Join(lines, "\n"))
+		prevMultiline = multiline
+	}
+
+	if rest := p.remainingComments(); len(rest) > 0 {
+		if len(program.Statements) > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(strings.Join(rest, "\n"))
+	}
+
+	if out.Len() > 0 {
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+type printer struct {
+	opts     Options
+	src      string
+	idents   map[int]bool    // offsets of identifier tokens, which keep their case
+	words    map[string]bool // words that are keywords in the statement being printed, though the lexer reads them as identifiers
+	comments []*ast.Comment
+	next     int // index of the next comment that hasn't been printed
+}
+
+func newPrinter(program *ast.Program, src string, opts Options) *printer {
+	p := &printer{opts: opts, src: src, idents: map[int]bool{}}
+
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Program:
+			// Routine bodies are parsed into their own program with positions relative to the body
+			return n == program
+		case *ast.Comment:
+			return false
+		case *ast.SimpleIdentifier:
+			p.idents[n.Token.Pos.Offset] = true
+		case *ast.Identifier:
+			p.idents[n.Token.Pos.Offset] = true
+		}
+		return true
+	})
+
+	p.comments = program.Comments
+
+	return p
+}
+
+func (p *printer) indent() string {
+	return strings.Repeat(" ", p.opts.Indent)
+}
+
+// kw cases a keyword the printer writes itself, such as SELECT or GROUP BY
+func (p *printer) kw(keyword string) string {
+	if p.opts.KeywordCase == KeywordLower {
+		return strings.ToLower(keyword)
+	}
+	return strings.ToUpper(keyword)
+}
+
+// node returns the source text of a node on a single line
+func (p *printer) node(n ast.Node) string {
+	if p.src == "" || !n.Pos().IsValid() {
+		return n.String(false)
+	}
+	return p.text(n.Pos(), n.End(), false)
+}
+
+// text returns the source between start and end on a single line. Runs of whitespace and comments become a
+// single space, and keywords are cased. If lead is true, the first word is cased too since it starts a statement,
+// even for statements like VACUUM where the lexer sees an identifier.
+func (p *printer) text(start, end token.Pos, lead bool) string {
+	src := p.src[start.Offset:end.Offset]
+	l := lexer.New(src, p.opts.Dialect)
+
+	var out strings.Builder
+	prevEnd := -1
+	for {
+		tok, _ := l.Scan()
+		if tok.Type == token.EOF {
+			break
+		}
+		if tok.Type == token.SQLCOMMENT {
+			continue
+		}
+
+		if prevEnd >= 0 && tok.Pos.Offset > prevEnd {
+			out.WriteString(" ")
+		}
+
+		lit := src[tok.Pos.Offset:tok.End.Offset]
+		if !p.idents[start.Offset+tok.Pos.Offset] && (isKeyword(tok, lit) || p.isStatementWord(tok, lit) || (lead && prevEnd < 0 && tok.Type == token.IDENT)) {
+			lit = p.kw(lit)
+		}
+		out.WriteString(lit)
+		prevEnd = tok.End.Offset
+	}
+
+	return out.String()
+}
+
+// isKeyword is true when the lexer recognized the word as a keyword, as opposed to an identifier or a quoted string
+func isKeyword(tok token.Token, lit string) bool {
+	return tok.Type != token.IDENT && tok.Type == token.Lookup(lit)
+}
+
+// isStatementWord is true when the word is a keyword of the statement being printed, such as EXISTS or RETURNS
+func (p *printer) isStatementWord(tok token.Token, lit string) bool {
+	return tok.Type == token.IDENT && p.words[strings.ToUpper(lit)]
+}
@@ -0,0 +1,246 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func fingerprint(t *testing.T, input string) string {
+	maskParams := false
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), "input: %s", input)
+
+	return program.String(maskParams)
+}
+
+// Formatting only changes the layout, so the formatted SQL parses to the same AST as the original,
+// and formatting it again doesn't change it.
+func TestFormatRoundTrip(t *testing.T) {
+	tests := []string{
+		"select distinct on (u.id) u.id, count(*) as n, sum(x) filter (where x > 0), name::text from users u join addresses a on a.user_id = u.id where u.id in (1, 2) and u.name like 'b%' and (a.x = 1 or a.y = 2) group by rollup (u.id) having count(*) > 1 order by n desc nulls last limit 10 offset 5 for update;",
+		"select id, row_number() over (partition by a order by b rows between 1 preceding and unbounded following) from users fetch first 5 rows only;",
+		"select case when a = 1 then 'one' else 'other' end, interval '1 day', array[1, 2][1], trim(both 'x' from y), substring(z from 1 for 2), created_at at time zone 'utc' from t;",
+		"select * from users u, lateral unnest(u.tags) with ordinality as t(tag, n), rows from (generate_series(1, 3), unnest(u.ids)) as r;",
+		"select * from (select id from users where x = 1) as s join lateral (select 1 from b where b.id = s.id) t on true;",
+		"select * from orders o natural left join customers c join items using (order_id, region);",
+		"select a from t where a = 1 or b = 2 or c = 3 and d = 4;",
+		"select a from t where not (a = 1 and b = 2);",
+		"select a from t where (a = 1 and b = 2) and c = 3;",
+		"select * from t for update of t skip locked;",
+		"select * from t fetch next row with ties;",
+		"select * from t limit all;",
+		"select a from t union select b from u except select c from v;",
+		"select x from t group by distinct a, b having sum(x) > 1 order by 1;",
+		"select x from t window w as (partition by a) order by 1;",
+		`select "Select", "from" from "Users";`,
+		"with recursive cte as materialized (select 1 union all select n + 1 from cte) select * from cte;",
+		"with a as (select 1), b as (select 2) insert into t select * from a;",
+		"insert into users (id, name) values (1, 'a'), (2, 'b') on conflict (id) do update set name = excluded.name where users.id > 0 returning id;",
+		"insert into users select * from old_users;",
+		"insert into users default values;",
+		"insert into users as u (id) values (1) on conflict do nothing;",
+		"update users u set name = 'a' from accounts a where a.id = u.id returning *;",
+		"update users set name = 'a' where current of c;",
+		"delete from users u using accounts a where a.id = u.id returning u.id;",
+		"delete from t where id in (select id from u where u.x = 1);",
+		"create index concurrently if not exists idx on users (id) where active;",
+		"vacuum (analyze) users (id, name), accounts; reindex table users; grant select on users to bob; set search_path = public;",
+		"prepare p(int) as select * from users where id = $1; execute p(1);",
+	}
+
+	narrow := Options{KeywordCase: KeywordLower, Indent: 4, CommaStyle: CommaLeading, LineWidth: 30}
+
+	for _, input := range tests {
+		for _, opts := range []Options{DefaultOptions(), narrow} {
+			output, err := Source(input, opts)
+			assert.NoError(t, err)
+			assert.Equal(t, fingerprint(t, input), fingerprint(t, output), "output:\n%s", output)
+
+			again, err := Source(output, opts)
+			assert.NoError(t, err)
+			assert.Equal(t, output, again)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input  string
+		opts   Options
+		output string
+	}{
+		{"select id, name from users where id = 1;", DefaultOptions(),
+			"SELECT id, name FROM users WHERE id = 1;\n"},
+		{"SELECT Id FROM Users", Options{KeywordCase: KeywordLower, Indent: 2, LineWidth: 80},
+			"select Id from Users;\n"},
+		{"select u.id, u.name, a.city from users u join addresses a on a.user_id = u.id where u.active and a.city = 'Boise' order by u.name;", DefaultOptions(),
+			"SELECT u.id, u.name, a.city\n" +
+				"FROM users u INNER JOIN addresses a ON a.user_id = u.id\n" +
+				"WHERE u.active AND a.city = 'Boise'\n" +
+				"ORDER BY u.name;\n"},
+		{"select u.id, u.name, a.city from users u join addresses a on a.user_id = u.id where u.active and a.city = 'Boise';", Options{Indent: 4, LineWidth: 30},
+			"SELECT u.id, u.name, a.city\n" +
+				"FROM\n" +
+				"    users u\n" +
+				"    INNER JOIN addresses a ON a.user_id = u.id\n" +
+				"WHERE\n" +
+				"    u.active\n" +
+				"    AND a.city = 'Boise';\n"},
+		{"select id, first_name, last_name from users", Options{CommaStyle: CommaLeading, Indent: 2, LineWidth: 20},
+			"SELECT\n" +
+				"  id\n" +
+				"  , first_name\n" +
+				"  , last_name\n" +
+				"FROM users;\n"},
+		{"select id, first_name, last_name from users", Options{CommaStyle: CommaTrailing, Indent: 2, LineWidth: 20},
+			"SELECT\n" +
+				"  id,\n" +
+				"  first_name,\n" +
+				"  last_name\n" +
+				"FROM users;\n"},
+		{"select * from (select id, name from users where active) u where u.id > 10", Options{Indent: 2, LineWidth: 30},
+			"SELECT *\n" +
+				"FROM\n" +
+				"  (\n" +
+				"    SELECT id, name\n" +
+				"    FROM users\n" +
+				"    WHERE active\n" +
+				"  ) u\n" +
+				"WHERE u.id > 10;\n"},
+		{"with active as (select id from users where active) select count(*) from active", Options{Indent: 2, LineWidth: 40},
+			"WITH\n" +
+				"  active AS (\n" +
+				"    SELECT id FROM users WHERE active\n" +
+				"  )\n" +
+				"SELECT count(*) FROM active;\n"},
+		{"select 1 union all select 2", DefaultOptions(),
+			"SELECT 1\nUNION ALL\nSELECT 2;\n"},
+		{"begin; update users set active = false where id = 1; commit;", DefaultOptions(),
+			"BEGIN;\nUPDATE users SET active = FALSE WHERE id = 1;\nCOMMIT;\n"},
+		{"select 1;\nselect id, name from users where id = 1 and name = 'a';", Options{Indent: 2, LineWidth: 30},
+			"SELECT 1;\n\nSELECT id, name\nFROM users\nWHERE id = 1 AND name = 'a';\n"},
+		{"", DefaultOptions(), ""},
+	}
+
+	for _, tt := range tests {
+		output, err := Source(tt.input, tt.opts)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.output, output, "input: %s", tt.input)
+	}
+}
+
+// Words like EXISTS, KEY, and RETURNS aren't keywords to the lexer, but they're cased like keywords in the statements that use them
+func TestFormatKeywordCase(t *testing.T) {
+	tests := []struct {
+		input string
+		upper string
+		lower string
+	}{
+		{"create table if NOT exists users (id bigint primary Key, org_id int references orgs (id) on delete Cascade, unique (email));",
+			"CREATE TABLE IF NOT EXISTS users (id bigint PRIMARY KEY, org_id int REFERENCES orgs (id) ON DELETE CASCADE, UNIQUE (email));\n",
+			"create table if not exists users (id bigint primary key, org_id int references orgs (id) on delete cascade, unique (email));\n"},
+		{"CREATE TEMP TABLE t ON COMMIT DROP AS SELECT id FROM users;",
+			"CREATE TEMP TABLE t ON COMMIT DROP AS SELECT id FROM users;\n",
+			"create temp table t on commit drop as select id from users;\n"},
+		{"create unique index concurrently if not exists idx_name on users using btree (name);",
+			"CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_name ON users USING btree (name);\n",
+			"create unique index concurrently if not exists idx_name on users using btree (name);\n"},
+		{"DROP TABLE IF EXISTS users CASCADE;",
+			"DROP TABLE IF EXISTS users CASCADE;\n",
+			"drop table if exists users cascade;\n"},
+		{"create or replace function add(a integer, b integer) returns integer language sql immutable strict security definer cost 10 set search_path = public as $$ select a + b $$;",
+			"CREATE OR REPLACE FUNCTION add(a integer, b integer) RETURNS integer LANGUAGE sql IMMUTABLE STRICT SECURITY DEFINER COST 10 SET search_path = public AS $$ select a + b $$;\n",
+			"create or replace function add(a integer, b integer) returns integer language sql immutable strict security definer cost 10 set search_path = public as $$ select a + b $$;\n"},
+		{"CREATE FUNCTION f() RETURNS SETOF users LANGUAGE sql STABLE PARALLEL SAFE RETURNS NULL ON NULL INPUT AS 'select 1';",
+			"CREATE FUNCTION f() RETURNS SETOF users LANGUAGE sql STABLE PARALLEL SAFE RETURNS NULL ON NULL INPUT AS 'select 1';\n",
+			"create function f() returns setof users language sql stable parallel safe returns null on null input as 'select 1';\n"},
+		{"CREATE PROCEDURE p() LANGUAGE plpgsql AS $$ BEGIN NULL; END $$;",
+			"CREATE PROCEDURE p() LANGUAGE plpgsql AS $$ BEGIN NULL; END $$;\n",
+			"create procedure p() language plpgsql as $$ BEGIN NULL; END $$;\n"},
+		{"DO LANGUAGE plpgsql $$ begin null; end $$;",
+			"DO LANGUAGE plpgsql $$ begin null; end $$;\n",
+			"do language plpgsql $$ begin null; end $$;\n"},
+		{"create trigger trg before insert on users for each row execute function f();",
+			"CREATE TRIGGER trg BEFORE INSERT ON users FOR EACH ROW EXECUTE FUNCTION f();\n",
+			"create trigger trg before insert on users for each row execute function f();\n"},
+		{"GRANT SELECT ON TABLE users TO bob WITH GRANT OPTION;",
+			"GRANT SELECT ON TABLE users TO bob WITH GRANT OPTION;\n",
+			"grant select on table users to bob with grant option;\n"},
+		{"declare c no scroll cursor with hold for select 1;",
+			"DECLARE c NO SCROLL CURSOR WITH HOLD FOR SELECT 1;\n",
+			"declare c no scroll cursor with hold for select 1;\n"},
+		// A column named like a keyword keeps its case
+		{"create table t (Key text primary key);",
+			"CREATE TABLE t (Key text PRIMARY KEY);\n",
+			"create table t (Key text primary key);\n"},
+	}
+
+	for _, tt := range tests {
+		output, err := Source(tt.input, Options{KeywordCase: KeywordUpper, Indent: 2, LineWidth: 80})
+		assert.NoError(t, err)
+		assert.Equal(t, tt.upper, output, "input: %s", tt.input)
+
+		output, err = Source(tt.input, Options{KeywordCase: KeywordLower, Indent: 2, LineWidth: 80})
+		assert.NoError(t, err)
+		assert.Equal(t, tt.lower, output, "input: %s", tt.input)
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"-- all users\nselect id from users; -- by id\n", "-- all users\nSELECT id FROM users; -- by id\n"},
+		{"select id /* the key */ from users;", "/* the key */\nSELECT id FROM users;\n"},
+		{"select 1; /* one */ select 2;", "SELECT 1; /* one */\nSELECT 2;\n"},
+		{"select 1;\n-- the end", "SELECT 1;\n-- the end\n"},
+		{"/* multi\n   line */ select 1;", "/* multi\n   line */\nSELECT 1;\n"},
+	}
+
+	for _, tt := range tests {
+		output, err := Source(tt.input, DefaultOptions())
+		assert.NoError(t, err)
+		assert.Equal(t, tt.output, output, "input: %s", tt.input)
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := Source("select id from users; select ) from users;", DefaultOptions())
+	assert.Error(t, err)
+
+	perr, ok := err.(*parser.ParseError)
+	assert.True(t, ok)
+	assert.Equal(t, 1, perr.Statement)
+}
+
+// Without the source, expressions fall back to their String()
+func TestFormatProgramWithoutSource(t *testing.T) {
+	input := "select id, name from users where id = 1"
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors())
+
+	assert.Equal(t, "SELECT id, name FROM users WHERE (id = 1);\n", Program(program, "", DefaultOptions()))
+}
+
+func TestFormatDialect(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Dialect = token.MySQL
+
+	output, err := Source("insert into `users` (id, name) values (?, 'a') on duplicate key update name = 'b'; # done", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `users` (id, name)\nVALUES (?, 'a')\nON DUPLICATE KEY UPDATE name = 'b'; # done\n", output)
+
+	_, err = Source("select `id` from users;", DefaultOptions())
+	assert.Error(t, err)
+}
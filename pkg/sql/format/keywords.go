@@ -0,0 +1,114 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+)
+
+// Words in the constraints of CREATE TABLE, which are kept as they were written
+var constraintWords = words("CONSTRAINT PRIMARY FOREIGN KEY UNIQUE CHECK EXCLUDE NOT NULL DEFAULT REFERENCES COLLATE " +
+	"GENERATED ALWAYS BY AS IDENTITY STORED ON DELETE UPDATE CASCADE RESTRICT SET NO ACTION MATCH FULL PARTIAL SIMPLE " +
+	"DEFERRABLE INITIALLY DEFERRED IMMEDIATE USING INDEX WITH AUTO_INCREMENT AUTOINCREMENT")
+
+// Words in the return type and options of CREATE FUNCTION, such as SETOF or SECURITY DEFINER
+var routineWords = words("SETOF TABLE IMMUTABLE STABLE VOLATILE STRICT CALLED RETURNS ON NULL INPUT SECURITY DEFINER " +
+	"INVOKER EXTERNAL PARALLEL SAFE UNSAFE RESTRICTED COST ROWS SET TO FROM CURRENT LEAKPROOF NOT WINDOW SUPPORT TRANSFORM FOR TYPE")
+
+// Words in the timing, events, and options of CREATE TRIGGER
+var triggerWords = words("BEFORE AFTER INSTEAD OF INSERT UPDATE DELETE TRUNCATE FROM NOT DEFERRABLE INITIALLY " +
+	"DEFERRED IMMEDIATE REFERENCING OLD NEW TABLE AS")
+
+// Privileges of GRANT and REVOKE. Anything else in the list is a role.
+var privilegeWords = words("SELECT INSERT UPDATE DELETE TRUNCATE REFERENCES TRIGGER USAGE CREATE CONNECT TEMPORARY TEMP " +
+	"EXECUTE MAINTAIN ALL PRIVILEGES")
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// statementWords returns the words of a statement that the lexer reads as identifiers, but that the parser
+// used as keywords, such as EXISTS in CREATE TABLE IF NOT EXISTS or RETURNS in CREATE FUNCTION.
+// They're found from the statement's AST, so they're only cased in the statements that have them.
+func statementWords(stmt ast.Statement) map[string]bool {
+	found := map[string]bool{}
+
+	// add adds every word of the strings, or only the known ones when known isn't nil
+	add := func(known map[string]bool, strs ...string) {
+		for _, s := range strs {
+			for _, w := range strings.FieldsFunc(s, func(r rune) bool { return !isWordChar(r) }) {
+				w = strings.ToUpper(w)
+				if known == nil || known[w] {
+					found[w] = true
+				}
+			}
+		}
+	}
+
+	switch stmt := stmt.(type) {
+	case *ast.CreateStatement:
+		add(nil, stmt.Scope, stmt.Object.Lit, stmt.OnCommit, stmt.Operator)
+		if stmt.Temp {
+			add(nil, "TEMP TEMPORARY")
+		}
+		if stmt.Unlogged {
+			add(nil, "UNLOGGED")
+		}
+		if stmt.Concurrently {
+			add(nil, "CONCURRENTLY")
+		}
+		if stmt.Exists {
+			add(nil, "IF NOT EXISTS")
+		}
+		for _, c := range stmt.Columns {
+			add(constraintWords, c.Constraints)
+		}
+		add(constraintWords, stmt.Constraints...)
+	case *ast.DropStatement:
+		add(nil, stmt.Object.Lit, stmt.Options)
+		if stmt.Exists {
+			add(nil, "IF EXISTS")
+		}
+	case *ast.CreateFunctionStatement:
+		add(nil, stmt.Object)
+		if stmt.OrReplace {
+			add(nil, "REPLACE")
+		}
+		if stmt.Returns != "" {
+			add(nil, "RETURNS")
+			add(routineWords, stmt.Returns)
+		}
+		if stmt.Language != "" {
+			add(nil, "LANGUAGE")
+		}
+		add(routineWords, stmt.Options...)
+	case *ast.CreateTriggerStatement:
+		add(nil, "TRIGGER", stmt.ForEach, stmt.ExecuteType, "EACH EXECUTE")
+		if stmt.OrReplace {
+			add(nil, "REPLACE")
+		}
+		add(triggerWords, stmt.Timing, stmt.Options)
+		add(triggerWords, stmt.Events...)
+	case *ast.DoStatement:
+		add(nil, "LANGUAGE")
+	case *ast.GrantStatement:
+		add(nil, stmt.ObjectType, stmt.WithOption, stmt.Options)
+		if stmt.GrantOptionFor {
+			add(nil, "GRANT OPTION FOR")
+		}
+		add(privilegeWords, stmt.Privileges...)
+	case *ast.DeclareCursorStatement:
+		add(nil, "CURSOR", stmt.Hold)
+		add(nil, stmt.Options...)
+	}
+
+	return found
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
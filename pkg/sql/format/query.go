@@ -0,0 +1,511 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// statement returns the lines of a statement, ending with a semicolon.
+// Queries are laid out clause by clause. Other statements are printed on a single line.
+func (p *printer) statement(stmt ast.Statement) []string {
+	width := p.opts.LineWidth
+
+	var lines []string
+	switch stmt := stmt.(type) {
+	case *ast.SelectStatement:
+		for _, x := range stmt.Expressions {
+			lines = append(lines, p.query(x, width)...)
+		}
+	case *ast.CTEStatement:
+		lines = p.query(stmt.Expression, width)
+	case *ast.InsertStatement:
+		lines = p.query(stmt.Expression, width)
+	case *ast.UpdateStatement:
+		lines = p.query(stmt.Expression, width)
+	case *ast.DeleteStatement:
+		lines = p.query(stmt.Expression, width)
+	}
+
+	if len(lines) == 0 {
+		var line string
+		if p.src == "" || !stmt.Pos().IsValid() {
+			line = stmt.String(false)
+		} else {
+			p.words = statementWords(stmt)
+			line = p.text(stmt.Pos(), stmt.End(), true)
+			p.words = nil
+		}
+		lines = []string{strings.TrimSuffix(line, ";")}
+	}
+
+	lines[len(lines)-1] += ";"
+	return lines
+}
+
+// query lays out anything that can be used as a query: a SELECT, set operations, a CTE, or DML.
+// Anything else is printed on a single line.
+func (p *printer) query(x ast.Expression, width int) []string {
+	switch x := x.(type) {
+	case *ast.SelectExpression:
+		return p.selectExpression(x, width)
+	case *ast.UnionExpression:
+		return p.union(x, width)
+	case *ast.CTEExpression:
+		return p.cte(x, width)
+	case *ast.InsertExpression:
+		return p.insert(x, width)
+	case *ast.UpdateExpression:
+		return p.update(x, width)
+	case *ast.DeleteExpression:
+		return p.delete(x, width)
+	case nil:
+		return nil
+	}
+	return []string{p.node(x)}
+}
+
+// item is an element of a clause. Most items are separated by commas, but joins and conditions aren't.
+type item struct {
+	lines []string
+	comma bool // a comma goes between this item and the previous one
+}
+
+// single is an item on one line that is separated from the previous item by a comma
+func single(line string) item { return item{lines: []string{line}, comma: true} }
+
+func (p *printer) list(nodes []ast.Expression) []item {
+	items := []item{}
+	for _, n := range nodes {
+		items = append(items, single(p.node(n)))
+	}
+	return items
+}
+
+// clause lays out a keyword followed by its items. If everything fits on one line, it stays there.
+// Otherwise the keyword goes on its own line and each item goes on its own line, indented underneath.
+func (p *printer) clause(keyword string, items []item, width int) []string {
+	if inline, ok := inlineItems(items); ok {
+		if line := keyword + " " + inline; len(line) <= width {
+			return []string{line}
+		}
+	}
+
+	lines := []string{keyword}
+
+	for i, it := range items {
+		itemLines := append([]string{}, it.lines...)
+		if p.opts.CommaStyle == CommaTrailing && i+1 < len(items) && items[i+1].comma {
+			itemLines[len(itemLines)-1] += ","
+		}
+		if p.opts.CommaStyle == CommaLeading && i > 0 && it.comma {
+			itemLines[0] = ", " + itemLines[0]
+		}
+		lines = append(lines, p.indentLines(itemLines)...)
+	}
+
+	return lines
+}
+
+// inlineItems joins items onto a single line, as long as none of them need more than one
+func inlineItems(items []item) (string, bool) {
+	var out strings.Builder
+	for i, it := range items {
+		if len(it.lines) != 1 {
+			return "", false
+		}
+		if i > 0 {
+			if it.comma {
+				out.WriteString(",")
+			}
+			out.WriteString(" ")
+		}
+		out.WriteString(it.lines[0])
+	}
+	return out.String(), true
+}
+
+func (p *printer) indentLines(lines []string) []string {
+	indented := make([]string, len(lines))
+	for i, line := range lines {
+		indented[i] = p.indent() + line
+	}
+	return indented
+}
+
+// clauses joins the clauses of a query onto one line if they're all short, otherwise each clause starts a new line
+func (p *printer) clauses(clauses [][]string, width int) []string {
+	oneLine := []string{}
+	for _, c := range clauses {
+		if len(c) != 1 {
+			oneLine = nil
+			break
+		}
+		oneLine = append(oneLine, c[0])
+	}
+	if oneLine != nil {
+		if line := strings.Join(oneLine, " "); len(line) <= width {
+			return []string{line}
+		}
+	}
+
+	lines := []string{}
+	for _, c := range clauses {
+		lines = append(lines, c...)
+	}
+	return lines
+}
+
+func (p *printer) selectExpression(x *ast.SelectExpression, width int) []string {
+	inner := width - p.opts.Indent
+	clauses := [][]string{}
+
+	keyword := p.kw("SELECT")
+	if x.Distinct != nil {
+		keyword += " " + p.node(x.Distinct)
+	}
+	clauses = append(clauses, p.clause(keyword, p.list(x.Columns), width))
+
+	if len(x.Tables) > 0 {
+		clauses = append(clauses, p.clause(p.kw("FROM"), p.tables(x.Tables, inner), width))
+	}
+	if len(x.Window) > 0 {
+		clauses = append(clauses, p.clause(p.kw("WINDOW"), p.list(x.Window), width))
+	}
+	if x.Where != nil {
+		clauses = append(clauses, p.clause(p.kw("WHERE"), p.conditions(x.Where), width))
+	}
+	if len(x.GroupBy) > 0 {
+		keyword := p.kw("GROUP BY")
+		if x.GroupByDistinct {
+			keyword += " " + p.kw("DISTINCT")
+		}
+		clauses = append(clauses, p.clause(keyword, p.list(x.GroupBy), width))
+	}
+	if x.Having != nil {
+		clauses = append(clauses, p.clause(p.kw("HAVING"), p.conditions(x.Having), width))
+	}
+	if len(x.OrderBy) > 0 {
+		clauses = append(clauses, p.clause(p.kw("ORDER BY"), p.list(x.OrderBy), width))
+	}
+	if x.Limit != nil {
+		clauses = append(clauses, []string{p.kw("LIMIT") + " " + p.node(x.Limit)})
+	}
+	if x.Offset != nil {
+		clauses = append(clauses, []string{p.kw("OFFSET") + " " + p.node(x.Offset)})
+	}
+	if fetch, ok := x.Fetch.(*ast.FetchExpression); ok {
+		clauses = append(clauses, []string{p.fetch(fetch)})
+	}
+	if lock, ok := x.Lock.(*ast.LockExpression); ok {
+		clauses = append(clauses, []string{p.lock(lock)})
+	}
+
+	return p.clauses(clauses, width)
+}
+
+// fetch is built from its fields since the row count may be implied and not in the source
+func (p *printer) fetch(x *ast.FetchExpression) string {
+	line := p.kw("FETCH FIRST") + " " + p.node(x.Value) + " " + p.kw("ROWS")
+	switch x.Option.Type {
+	case token.ONLY:
+		line += " " + p.kw("ONLY")
+	case token.TIES:
+		line += " " + p.kw("WITH TIES")
+	}
+	return line
+}
+
+func (p *printer) lock(x *ast.LockExpression) string {
+	line := p.kw("FOR " + x.Lock)
+	if len(x.Tables) > 0 {
+		tables := []string{}
+		for _, t := range x.Tables {
+			tables = append(tables, p.node(t))
+		}
+		line += " " + p.kw("OF") + " " + strings.Join(tables, ", ")
+	}
+	if x.Options != "" {
+		line += " " + p.kw(x.Options)
+	}
+	return line
+}
+
+// tables returns the items of a FROM clause. Joins follow the table they join to without a comma,
+// and subqueries are laid out like any other query.
+func (p *printer) tables(tables []ast.Expression, width int) []item {
+	items := []item{}
+	for _, t := range tables {
+		te, ok := t.(*ast.TableExpression)
+		if !ok {
+			items = append(items, single(p.node(t)))
+			continue
+		}
+
+		it := item{lines: p.table(te, width), comma: true}
+		if te.JoinType != "" && te.JoinType != "," {
+			it.lines[0] = p.kw(te.JoinType) + " " + it.lines[0]
+			it.comma = false
+		}
+		if te.Natural {
+			it.lines[0] = p.kw("NATURAL") + " " + it.lines[0]
+		}
+		items = append(items, it)
+	}
+	return items
+}
+
+func (p *printer) table(x *ast.TableExpression, width int) []string {
+	sub := x.Table
+	if g, ok := sub.(*ast.GroupedExpression); ok && len(g.Elements) == 1 && g.Cast == nil {
+		sub = g.Elements[0]
+	}
+	switch sub.(type) {
+	case *ast.SelectExpression, *ast.UnionExpression, *ast.CTEExpression:
+	default:
+		return []string{p.node(x)}
+	}
+	if p.src == "" || !x.Pos().IsValid() || !sub.Pos().IsValid() {
+		return []string{p.node(x)}
+	}
+
+	// The text around the subquery, i.e. LATERAL before it and the alias and join condition after it.
+	// The subquery's span may or may not include its parentheses, so they're trimmed here and always added back.
+	before := strings.TrimSuffix(strings.TrimSpace(p.text(x.Pos(), sub.Pos(), false)), "(")
+	after := strings.TrimPrefix(p.text(sub.End(), x.End(), false), ")")
+	before = strings.TrimSpace(before)
+	after = strings.TrimSpace(after)
+	if before != "" {
+		before += " "
+	}
+	if after != "" {
+		after = " " + after
+	}
+
+	lines := p.query(sub, width-p.opts.Indent)
+	if len(lines) == 1 && len(before)+len(lines[0])+len(after)+2 <= width {
+		return []string{before + "(" + lines[0] + ")" + after}
+	}
+
+	out := []string{before + "("}
+	out = append(out, p.indentLines(lines)...)
+	out = append(out, ")"+after)
+	return out
+}
+
+// conditions splits a chain of ANDs or ORs into one item per condition, with the operator leading each one after the first
+func (p *printer) conditions(x ast.Expression) []item {
+	infix, ok := x.(*ast.InfixExpression)
+	if !ok || infix.Not {
+		return []item{{lines: []string{p.node(x)}}}
+	}
+
+	op := strings.ToUpper(infix.Operator)
+	if op != "AND" && op != "OR" {
+		return []item{{lines: []string{p.node(x)}}}
+	}
+
+	// Only the left side is flattened: a AND b AND c is parsed as (a AND b) AND c, while a AND (b AND c) needs its parentheses
+	operands := []ast.Expression{infix.Right}
+	left := infix.Left
+	for {
+		l, ok := left.(*ast.InfixExpression)
+		if !ok || l.Not || strings.ToUpper(l.Operator) != op || p.parenthesized(l) {
+			break
+		}
+		operands = append(operands, l.Right)
+		left = l.Left
+	}
+	operands = append(operands, left)
+
+	items := []item{}
+	for i := len(operands) - 1; i >= 0; i-- {
+		line := p.node(operands[i])
+		if i < len(operands)-1 {
+			line = p.kw(op) + " " + line
+		}
+		items = append(items, item{lines: []string{line}})
+	}
+	return items
+}
+
+// parenthesized is true when the source wraps the node in parentheses, which have to be kept
+func (p *printer) parenthesized(n ast.Node) bool {
+	if p.src == "" || !n.Pos().IsValid() {
+		return false
+	}
+	text := p.src[n.Pos().Offset:n.End().Offset]
+	return strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")")
+}
+
+func (p *printer) union(x *ast.UnionExpression, width int) []string {
+	keyword := p.kw(x.Operator)
+	if x.All {
+		keyword += " " + p.kw("ALL")
+	}
+
+	lines := p.query(x.Left, width)
+	lines = append(lines, keyword)
+	lines = append(lines, p.query(x.Right, width)...)
+	return lines
+}
+
+func (p *printer) cte(x *ast.CTEExpression, width int) []string {
+	keyword := p.kw("WITH")
+	if x.Recursive {
+		keyword += " " + p.kw("RECURSIVE")
+	}
+
+	items := []item{}
+	for _, a := range x.Auxiliary {
+		aux, ok := a.(*ast.CTEAuxiliaryExpression)
+		if !ok {
+			items = append(items, single(p.node(a)))
+			continue
+		}
+
+		head := p.node(aux.Name) + " " + p.kw("AS") + " "
+		if aux.Materialized != "" {
+			head += p.kw(aux.Materialized) + " "
+		}
+		body := p.query(aux.Expression, width-p.opts.Indent)
+		if len(body) == 1 && len(head)+len(body[0])+2 <= width {
+			items = append(items, single(head+"("+body[0]+")"))
+			continue
+		}
+
+		lines := []string{head + "("}
+		lines = append(lines, p.indentLines(body)...)
+		lines = append(lines, ")")
+		items = append(items, item{lines: lines, comma: true})
+	}
+
+	lines := p.clause(keyword, items, width)
+	return append(lines, p.query(x.Primary, width)...)
+}
+
+func (p *printer) insert(x *ast.InsertExpression, width int) []string {
+	clauses := [][]string{}
+
+	head := p.kw("INSERT INTO") + " " + p.node(x.Table)
+	if x.Alias != nil {
+		head += " " + p.kw("AS") + " " + p.node(x.Alias)
+	}
+	if len(x.Columns) > 0 {
+		head += " (" + joinNodes(p, x.Columns) + ")"
+	}
+	clauses = append(clauses, []string{head})
+
+	if x.Default {
+		clauses = append(clauses, []string{p.kw("DEFAULT VALUES")})
+	}
+	if len(x.Values) > 0 {
+		tuples := []item{}
+		for _, v := range x.Values {
+			tuples = append(tuples, single("("+joinNodes(p, v)+")"))
+		}
+		clauses = append(clauses, p.clause(p.kw("VALUES"), tuples, width))
+	}
+	if x.Query != nil {
+		clauses = append(clauses, p.query(x.Query, width))
+	}
+
+	if x.ConflictAction != "" {
+		conflict := p.kw("ON CONFLICT")
+		if len(x.ConflictTarget) > 0 {
+			conflict += " (" + joinNodes(p, x.ConflictTarget) + ")"
+		}
+		conflict += " " + p.kw("DO "+x.ConflictAction)
+		clauses = append(clauses, []string{conflict})
+
+		if len(x.ConflictUpdate) > 0 {
+			clauses = append(clauses, p.clause(p.kw("SET"), p.list(x.ConflictUpdate), width))
+		}
+		if x.ConflictWhere != nil {
+			clauses = append(clauses, p.clause(p.kw("WHERE"), p.conditions(x.ConflictWhere), width))
+		}
+	}
+
+	if len(x.DuplicateUpdate) > 0 {
+		clauses = append(clauses, p.clause(p.kw("ON DUPLICATE KEY UPDATE"), p.list(x.DuplicateUpdate), width))
+	}
+
+	if len(x.Returning) > 0 {
+		clauses = append(clauses, p.clause(p.kw("RETURNING"), p.list(x.Returning), width))
+	}
+
+	return p.clauses(clauses, width)
+}
+
+func (p *printer) update(x *ast.UpdateExpression, width int) []string {
+	clauses := [][]string{}
+
+	head := p.kw("UPDATE") + " "
+	if x.Only {
+		head += p.kw("ONLY") + " "
+	}
+	head += p.node(x.Table)
+	if x.Asterisk {
+		head += " *"
+	}
+	if x.Alias != nil {
+		head += " " + p.node(x.Alias)
+	}
+	clauses = append(clauses, []string{head})
+
+	if len(x.Set) > 0 {
+		clauses = append(clauses, p.clause(p.kw("SET"), p.list(x.Set), width))
+	}
+	if len(x.Tables) > 0 {
+		clauses = append(clauses, p.clause(p.kw("FROM"), p.tables(x.Tables, width-p.opts.Indent), width))
+	}
+	clauses = append(clauses, p.whereOrCursor(x.Where, x.Cursor, width)...)
+	if len(x.Returning) > 0 {
+		clauses = append(clauses, p.clause(p.kw("RETURNING"), p.list(x.Returning), width))
+	}
+
+	return p.clauses(clauses, width)
+}
+
+func (p *printer) delete(x *ast.DeleteExpression, width int) []string {
+	clauses := [][]string{}
+
+	head := p.kw("DELETE FROM") + " "
+	if x.Only {
+		head += p.kw("ONLY") + " "
+	}
+	head += p.node(x.Table)
+	if x.Alias != nil {
+		head += " " + p.node(x.Alias)
+	}
+	clauses = append(clauses, []string{head})
+
+	if len(x.Using) > 0 {
+		clauses = append(clauses, p.clause(p.kw("USING"), p.tables(x.Using, width-p.opts.Indent), width))
+	}
+	clauses = append(clauses, p.whereOrCursor(x.Where, x.Cursor, width)...)
+	if len(x.Returning) > 0 {
+		clauses = append(clauses, p.clause(p.kw("RETURNING"), p.list(x.Returning), width))
+	}
+
+	return p.clauses(clauses, width)
+}
+
+func (p *printer) whereOrCursor(where, cursor ast.Expression, width int) [][]string {
+	if cursor != nil {
+		return [][]string{{p.kw("WHERE CURRENT OF") + " " + p.node(cursor)}}
+	}
+	if where != nil {
+		return [][]string{p.clause(p.kw("WHERE"), p.conditions(where), width)}
+	}
+	return nil
+}
+
+func joinNodes(p *printer, nodes []ast.Expression) string {
+	parts := []string{}
+	for _, n := range nodes {
+		parts = append(parts, p.node(n))
+	}
+	return strings.Join(parts, ", ")
+}
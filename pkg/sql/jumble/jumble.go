@@ -0,0 +1,108 @@
+// Package jumble computes a query id from a statement's tree, for logs that don't include Postgres' query_id.
+//
+// Postgres jumbles the tree it has after parse analysis, which refers to tables and functions by their OIDs,
+// so the ids computed here can't be equal to the ones in pg_stat_statements. They follow the same rules though:
+// constants, aliases, comments, and the case of unquoted identifiers don't change the id, lists of constants
+// are squashed like they are in Postgres 18, and the id is a signed 64 bit integer.
+package jumble
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"reflect"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/normalize"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// QueryID returns the id of the statement. The statement isn't changed.
+func QueryID(stmt ast.Statement) int64 {
+	stmt = normalize.Statement(ast.Clone(stmt), normalize.Options{StripAliases: true, Identifiers: true})
+	stmt = ast.Rewrite(stmt, func(n ast.Node) ast.Node {
+		if in, ok := n.(*ast.InExpression); ok && len(in.Right) > 1 && constants(in.Right) {
+			in.Right = in.Right[:1]
+		}
+		return n
+	}).(ast.Statement)
+
+	h := fnv.New64a()
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if n == nil {
+			h.Write([]byte{')'}) // the end of a node's children, so a tree has a different id than its flattened nodes
+			return false
+		}
+		jumble(h, n)
+		return true
+	})
+	return int64(h.Sum64())
+}
+
+// jumble writes the type of the node and its scalar fields. Constants are only written as their type.
+func jumble(h hash.Hash64, n ast.Node) {
+	v := reflect.ValueOf(n)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	h.Write([]byte(v.Type().Name()))
+	if constant(n) {
+		return
+	}
+
+	buf := make([]byte, 8)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		// Branch and CommandTag are where the node is, which the children of its parent already show
+		switch field.Name {
+		case "Branch", "CommandTag":
+			continue
+		}
+
+		f := v.Field(i)
+		if tok, ok := f.Interface().(token.Token); ok {
+			// The literal of a keyword's token changes with its case, but its type doesn't
+			binary.LittleEndian.PutUint64(buf, uint64(tok.Type))
+			h.Write(buf)
+			continue
+		}
+
+		switch f.Kind() {
+		case reflect.String:
+			h.Write([]byte(field.Name))
+			h.Write([]byte(f.String()))
+			h.Write([]byte{0})
+		case reflect.Bool:
+			if f.Bool() {
+				h.Write([]byte(field.Name))
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			h.Write([]byte(field.Name))
+			binary.LittleEndian.PutUint64(buf, uint64(f.Int()))
+			h.Write(buf)
+		}
+	}
+}
+
+// constant is true for the nodes that Postgres jumbles as a Const or a Param
+func constant(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.EscapeStringLiteral,
+		*ast.DollarStringLiteral, *ast.ParamLiteral, *ast.Boolean, *ast.Null:
+		return true
+	}
+	return false
+}
+
+func constants(list []ast.Expression) bool {
+	for _, e := range list {
+		if !constant(e) {
+			return false
+		}
+	}
+	return true
+}
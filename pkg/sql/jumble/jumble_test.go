@@ -0,0 +1,68 @@
+package jumble
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryID(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		// Constants, aliases, comments and case don't change the id
+		{"select * from users where id = 1", "select * from users where id = 2", true},
+		{"select * from users where name = 'a'", "SELECT * FROM Users WHERE Name = 'b'", true},
+		{"select u.id as user_id from users u", "select u.id from users u", true},
+		{"select * from users where id in (1, 2, 3)", "select * from users where id in (4)", true},
+		{"select 1 /* controller='users' */", "/* controller='admin' */ select 2", true},
+		{`select "id" from "users"`, "select id from users", true},
+
+		// Everything else does
+		{"select * from users where id = 1", "select * from cars where id = 1", false},
+		{"select * from users where id = 1", "select * from users where id > 1", false},
+		{"select * from users where id = 1", "select * from users where id = $1", false}, // a Const and a Param are different in Postgres too
+		{"select * from users where id = 1", "select * from users where id <> 1", false},
+		{"select * from users where id in (1, 2)", "select * from users where id not in (1, 2)", false},
+		{"select * from users where id in (1, 2)", "select * from users where id in (1, a)", false},
+		{"select id from users", "select distinct id from users", false},
+		{"select a, b from users", "select b, a from users", false},
+		{"select f(a, g(b)) from users", "select f(a, g(), b) from users", false},
+		{`select "Id" from users`, "select id from users", false},
+		{"select * from users u join cars c on c.user_id = u.id", "select * from users u left join cars c on c.user_id = u.id", false},
+	}
+
+	for _, tt := range tests {
+		a, b := queryID(t, tt.a), queryID(t, tt.b)
+		if tt.same {
+			assert.Equal(t, a, b, "%s\n%s", tt.a, tt.b)
+		} else {
+			assert.NotEqual(t, a, b, "%s\n%s", tt.a, tt.b)
+		}
+	}
+}
+
+func TestQueryIDDoesNotChangeStatement(t *testing.T) {
+	maskParams := false
+
+	p := parser.New(lexer.New("select u.ID as user_id from users u where id in (1, 2, 3)"))
+	program := p.ParseProgram()
+	stmt := program.Statements[0]
+	before := stmt.String(maskParams)
+
+	assert.Equal(t, QueryID(stmt), QueryID(stmt))
+	assert.Equal(t, before, stmt.String(maskParams))
+}
+
+func queryID(t *testing.T, input string) int64 {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), "input: %s", input)
+	if !assert.Len(t, program.Statements, 1, "input: %s", input) {
+		return 0
+	}
+	return QueryID(program.Statements[0])
+}
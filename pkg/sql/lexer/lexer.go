@@ -13,31 +13,52 @@ type Lexer struct {
 	Input   string
 	r       io.RuneScanner
 	lastPos Pos
-	pos     Pos
+	pos     Pos // the position of the next character to be read
 	ch      rune
 	eof     bool // true if reader has ever seen eof.
+	dialect token.Dialect
+	open    int // the number of MySQL /*! ... */ comments that are open, since their contents are lexed as SQL
 }
 
-// Pos specifies the line and character position of a token.
-// The Char and Line are both zero-based indexes.
-type Pos struct {
-	Line int
-	Char int
-}
+// Pos specifies the byte offset, line, and character position of a token.
+type Pos = token.Pos
 
 // eof is a marker code to signify that the reader can't read any more.
 const eof = rune(0)
 const eol = '\n'
 
-func New(input string) *Lexer {
-	l := &Lexer{Input: input, r: strings.NewReader(input)}
+// New returns a lexer for the input. The input is lexed as Postgres, unless a different dialect is given.
+func New(input string, dialect ...token.Dialect) *Lexer {
+	l := &Lexer{Input: input, r: strings.NewReader(input), pos: Pos{Offset: 0, Line: 1, Char: 1}}
+	if len(dialect) > 0 {
+		l.dialect = dialect[0]
+	}
 	return l
 }
 
+// Dialect returns the dialect the lexer was created with
+func (l *Lexer) Dialect() token.Dialect { return l.dialect }
+
+// Scan returns the next token along with the position where it starts.
+// The token also records where it starts and ends.
 func (l *Lexer) Scan() (tok token.Token, pos Pos) {
 	l.skipWhitespace()
-	l.read()
+	for l.skipConditionalComment() {
+		l.skipWhitespace()
+	}
 	pos = l.pos
+	tok = l.scan()
+	tok.Pos = pos
+	tok.End = l.pos
+	if tok.Type == token.SQLCOMMENT {
+		// The newline that ends a -- comment is part of its span, but not its text
+		tok.Lit = strings.TrimRight(l.Input[pos.Offset:l.pos.Offset], "\r\n")
+	}
+	return tok, pos
+}
+
+func (l *Lexer) scan() (tok token.Token) {
+	l.read()
 
 	switch l.ch {
 	case '+':
@@ -52,15 +73,8 @@ func (l *Lexer) Scan() (tok token.Token, pos Pos) {
 				tok = token.Token{Type: token.JSONGETBYKEY, Lit: "->", Upper: "->"}
 			}
 			// Comments out the rest of the line by using --
-		} else if l.peek() == '-' {
-			for {
-				l.read()
-				if l.ch == eof {
-					break
-				} else if l.ch == eol {
-					break
-				}
-			}
+		} else if l.peek() == '-' && l.startsLineComment() {
+			l.skipLine()
 			tok = token.Token{Type: token.SQLCOMMENT}
 		} else {
 			tok = newToken(token.MINUS, l.ch)
@@ -92,7 +106,9 @@ func (l *Lexer) Scan() (tok token.Token, pos Pos) {
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ':':
-		if l.peek() == ':' {
+		if l.dialect == token.SQLite && isIdentStart(l.peek()) {
+			tok = l.scanNamedParam()
+		} else if l.peek() == ':' {
 			l.read()
 			tok = token.Token{Type: token.DOUBLECOLON, Lit: "::", Upper: "::"}
 		} else {
@@ -109,6 +125,10 @@ func (l *Lexer) Scan() (tok token.Token, pos Pos) {
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
 	case '[':
+		if l.dialect == token.SQLite { // SQLite doesn't have arrays, but it does allow [quoted] identifiers
+			tok = l.scanQuotedIdent(']')
+			return tok
+		}
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
@@ -124,7 +144,13 @@ func (l *Lexer) Scan() (tok token.Token, pos Pos) {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	case '?':
-		if l.peek() == '|' {
+		if l.dialect != token.Postgres { // a placeholder, or a numbered one like ?1 in SQLite
+			lit := "?"
+			if l.dialect == token.SQLite && isDigit(l.peek()) {
+				lit += l.scanNumber().Lit
+			}
+			tok = token.Token{Type: token.PARAM, Lit: lit, Upper: lit}
+		} else if l.peek() == '|' {
 			l.read()
 			tok = token.Token{Type: token.JSONHASANYKEYS, Lit: "?|", Upper: "?|"}
 		} else if l.peek() == '&' {
@@ -182,23 +208,44 @@ func (l *Lexer) Scan() (tok token.Token, pos Pos) {
 			tok = newToken(token.SLASH, l.ch)
 		}
 	case '\'':
+		if l.dialect == token.MySQL {
+			tok = l.scanMySQLString('\'')
+			return tok
+		}
 		tok = l.scanString()
-		return tok, pos
+		return tok
 	case '"': // double quotes are allowed to surround identities
+		if l.dialect == token.MySQL { // but they're strings in MySQL, unless ANSI_QUOTES is set
+			tok = l.scanMySQLString('"')
+			return tok
+		}
 		// tok = l.scanIdent()
 		tok = l.scanDoubleQuoteString()
-		return tok, pos
+		return tok
+	case '`':
+		if l.dialect == token.MySQL || l.dialect == token.SQLite { // SQLite accepts MySQL's quoted identifiers
+			tok = l.scanQuotedIdent('`')
+			return tok
+		}
+		tok = newToken(token.ILLEGAL, l.ch)
 	case '$':
-		if isDigit(l.peek()) {
-			pos = l.pos
+		if l.dialect == token.SQLite && isIdentStart(l.peek()) {
+			tok = l.scanNamedParam()
+		} else if isDigit(l.peek()) {
 			num := l.scanNumber()
 			lit := fmt.Sprintf("$%s", num.Lit)
 			tok = token.Token{Type: token.PARAM, Lit: lit, Upper: lit}
+		} else if l.peek() == '$' || isLetter(l.peek()) || l.peek() == '_' {
+			tok = l.scanDollarString()
+			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	case '#': // JSON operators
-		if l.peek() == '>' {
+		if l.dialect == token.MySQL { // MySQL comments out the rest of the line with #
+			l.skipLine()
+			tok = token.Token{Type: token.SQLCOMMENT}
+		} else if l.peek() == '>' {
 			l.read()
 			if l.peek() == '>' {
 				l.read()
@@ -213,7 +260,12 @@ func (l *Lexer) Scan() (tok token.Token, pos Pos) {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	case '@': // JSON operators
-		if l.peek() == '>' {
+		if l.dialect == token.SQLite && isIdentStart(l.peek()) {
+			tok = l.scanNamedParam()
+		} else if l.dialect == token.MySQL && (isIdentStart(l.peek()) || l.peek() == '@') {
+			tok = l.scanVariable()
+			return tok
+		} else if l.peek() == '>' {
 			l.read()
 			tok = token.Token{Type: token.JSONCONTAINS, Lit: "@>", Upper: "@>"}
 		} else {
@@ -238,22 +290,18 @@ func (l *Lexer) Scan() (tok token.Token, pos Pos) {
 	default:
 		if isLetter(l.ch) {
 			l.unread()
-			// get position before we scan the identity
-			pos = l.pos
 			tok = l.scanIdent()
-			return tok, pos
+			return tok
 		} else if isDigit(l.ch) {
 			l.unread()
-			// get position before we scan the number
-			pos = l.pos
 			tok = l.scanNumber()
-			return tok, pos
+			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
 
-	return tok, pos
+	return tok
 }
 
 func newToken(tokenType token.TokenType, ch rune) token.Token {
@@ -262,23 +310,26 @@ func newToken(tokenType token.TokenType, ch rune) token.Token {
 }
 
 func (l *Lexer) read() {
-	var err error
-	l.ch, _, err = l.r.ReadRune()
+	var (
+		err  error
+		size int
+	)
+	l.ch, size, err = l.r.ReadRune()
 	if err != nil {
 		l.ch = eof
 	}
 
-	l.lastPos.Char = l.pos.Char
-	l.lastPos.Line = l.pos.Line
+	l.lastPos = l.pos
 
 	// Update position
-	// Only count EOF once.
+	// EOF doesn't take up any space, so it doesn't move the position.
 	if l.ch == eol {
 		l.pos.Line++
-		l.pos.Char = 0
-	} else if !l.eof {
+		l.pos.Char = 1
+	} else if l.ch != eof {
 		l.pos.Char++
 	}
+	l.pos.Offset += size
 
 	if l.ch == eof {
 		l.eof = true
@@ -287,8 +338,7 @@ func (l *Lexer) read() {
 
 func (l *Lexer) unread() {
 	l.r.UnreadRune()
-	l.pos.Char = l.lastPos.Char
-	l.pos.Line = l.lastPos.Line
+	l.pos = l.lastPos
 }
 
 func (l *Lexer) peek() rune {
@@ -312,6 +362,53 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// skipLine reads to the end of the line, for comments
+func (l *Lexer) skipLine() {
+	for {
+		l.read()
+		if l.ch == eof || l.ch == eol {
+			break
+		}
+	}
+}
+
+// startsLineComment is called after the first - of --. MySQL only treats -- as a comment
+// when it's followed by whitespace, so 5--1 is 5 minus -1.
+func (l *Lexer) startsLineComment() bool {
+	if l.dialect != token.MySQL {
+		return true
+	}
+	rest := l.Input[l.pos.Offset:] // starts with the second -
+	return len(rest) == 1 || isWhitespace(rune(rest[1]))
+}
+
+// skipConditionalComment skips the /*! and */ around a MySQL executable comment, such as /*!50503 IF NOT EXISTS */,
+// since MySQL runs what's inside of it. It returns true if it skipped anything.
+func (l *Lexer) skipConditionalComment() bool {
+	if l.dialect != token.MySQL {
+		return false
+	}
+
+	rest := l.Input[l.pos.Offset:]
+	switch {
+	case strings.HasPrefix(rest, "/*!"):
+		for i := 0; i < 3; i++ {
+			l.read()
+		}
+		for isDigit(l.peek()) { // the minimum version of MySQL that runs it
+			l.read()
+		}
+		l.open++
+		return true
+	case l.open > 0 && strings.HasPrefix(rest, "*/"):
+		l.read()
+		l.read()
+		l.open--
+		return true
+	}
+	return false
+}
+
 func (l *Lexer) scanIdent() token.Token {
 	var buf bytes.Buffer
 
@@ -364,6 +461,40 @@ func (l *Lexer) scanString() token.Token {
 	return token.Token{Type: token.STRING, Lit: lit} // Don't need upper for strings
 }
 
+// Dollar quoted strings are of the form $$foobar$$ or $tag$foobar$tag$
+// The literal keeps the delimiters so the tag can be recovered
+func (l *Lexer) scanDollarString() token.Token {
+	var tag bytes.Buffer
+	_, _ = tag.WriteRune('$')
+	for {
+		l.read()
+		if l.ch == '$' {
+			_, _ = tag.WriteRune(l.ch)
+			break
+		} else if isIdentChar(l.ch) {
+			_, _ = tag.WriteRune(l.ch)
+		} else {
+			l.unread()
+			return token.Token{Type: token.ILLEGAL, Lit: tag.String()}
+		}
+	}
+
+	delim := tag.Bytes()
+	var buf bytes.Buffer
+	for {
+		l.read()
+		if l.ch == eof {
+			return token.Token{Type: token.ILLEGAL, Lit: tag.String() + buf.String()}
+		}
+		_, _ = buf.WriteRune(l.ch)
+		if l.ch == '$' && bytes.HasSuffix(buf.Bytes(), delim) {
+			break
+		}
+	}
+	lit := tag.String() + buf.String()
+	return token.Token{Type: token.DOLLARSTRING, Lit: lit} // Don't need upper for strings
+}
+
 func (l *Lexer) scanDoubleQuoteString() token.Token {
 	var buf bytes.Buffer
 	for {
@@ -384,6 +515,86 @@ func (l *Lexer) scanDoubleQuoteString() token.Token {
 	return token.Token{Type: token.IDENT, Lit: lit} // Don't need upper for strings
 }
 
+// scanMySQLString scans a string quoted with ' or ". A backslash escapes the next character. An escaped quote
+// is kept as the quote, but any other escape is kept as is, since the literal can't hold what it stands for.
+func (l *Lexer) scanMySQLString(quote rune) token.Token {
+	var buf bytes.Buffer
+	for {
+		l.read()
+		switch {
+		case l.ch == eof:
+			return token.Token{Type: token.STRING, Lit: buf.String()}
+		case l.ch == '\\':
+			l.read()
+			if l.ch == eof {
+				return token.Token{Type: token.STRING, Lit: buf.String()}
+			}
+			if l.ch != '\'' && l.ch != '"' {
+				_, _ = buf.WriteRune('\\')
+			}
+			_, _ = buf.WriteRune(l.ch)
+		case l.ch == quote:
+			if l.peek() != quote {
+				return token.Token{Type: token.STRING, Lit: buf.String()} // Don't need upper for strings
+			}
+			l.read()
+			_, _ = buf.WriteRune(l.ch)
+		default:
+			_, _ = buf.WriteRune(l.ch)
+		}
+	}
+}
+
+// scanQuotedIdent scans an identifier quoted with backticks in MySQL and SQLite, or [brackets] in SQLite.
+// Like a double quoted identifier, the literal keeps its quotes.
+func (l *Lexer) scanQuotedIdent(closing rune) token.Token {
+	var buf bytes.Buffer
+	_, _ = buf.WriteRune(l.ch)
+	for {
+		l.read()
+		if l.ch == eof {
+			return token.Token{Type: token.ILLEGAL, Lit: buf.String()}
+		}
+		_, _ = buf.WriteRune(l.ch)
+		if l.ch == closing {
+			if closing == ']' || l.peek() != closing { // a doubled backtick is an escaped one
+				break
+			}
+			l.read()
+			_, _ = buf.WriteRune(l.ch)
+		}
+	}
+	return token.Token{Type: token.IDENT, Lit: buf.String()} // Don't need upper for quoted identifiers
+}
+
+// scanNamedParam scans the rest of a SQLite parameter like :name, @name, or $name
+func (l *Lexer) scanNamedParam() token.Token {
+	var buf bytes.Buffer
+	_, _ = buf.WriteRune(l.ch)
+	for isIdentChar(l.peek()) {
+		l.read()
+		_, _ = buf.WriteRune(l.ch)
+	}
+	lit := buf.String()
+	return token.Token{Type: token.PARAM, Lit: lit, Upper: lit}
+}
+
+// scanVariable scans a MySQL user variable like @total, or a system variable like @@session.sql_mode
+func (l *Lexer) scanVariable() token.Token {
+	var buf bytes.Buffer
+	_, _ = buf.WriteRune(l.ch)
+	if l.peek() == '@' {
+		l.read()
+		_, _ = buf.WriteRune(l.ch)
+	}
+	for isIdentChar(l.peek()) {
+		l.read()
+		_, _ = buf.WriteRune(l.ch)
+	}
+	lit := buf.String()
+	return token.Token{Type: token.IDENT, Lit: lit, Upper: strings.ToUpper(lit)}
+}
+
 func (l *Lexer) scanNumber() token.Token {
 	numType := token.INT // default to INT
 	var buf bytes.Buffer
@@ -411,6 +622,9 @@ func isLetter(ch rune) bool { return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && c
 // isDigit returns true if the rune is a digit.
 func isDigit(ch rune) bool { return (ch >= '0' && ch <= '9') }
 
+// isIdentStart returns true if the rune can start an unquoted identifier
+func isIdentStart(ch rune) bool { return isLetter(ch) || ch == '_' }
+
 // isIdentChar returns true if the rune can be used in an unquoted identifier.
 // identities can be surrounded by double quotes and can contain any character.
 func isIdentChar(ch rune) bool { return isLetter(ch) || isDigit(ch) || ch == '_' }
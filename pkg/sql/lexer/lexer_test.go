@@ -106,3 +106,162 @@ func TestSimpleScan(t *testing.T) {
 		assert.Equal(t, tt.Lit, tok.Lit)
 	}
 }
+
+func TestDollarQuotedScan(t *testing.T) {
+	input := `$$select 'a'$$ $body$ begin return $1; end $body$ $fn$has $$ inside$fn$ $1 $bad`
+
+	tests := []token.Token{
+		{Type: token.DOLLARSTRING, Lit: "$$select 'a'$$"},
+		{Type: token.DOLLARSTRING, Lit: "$body$ begin return $1; end $body$"},
+		{Type: token.DOLLARSTRING, Lit: "$fn$has $$ inside$fn$"},
+		{Type: token.PARAM, Lit: "$1"},
+		{Type: token.ILLEGAL, Lit: "$bad"},
+		{Type: token.EOF, Lit: ""},
+	}
+
+	l := New(input)
+
+	for _, tt := range tests {
+		tok, _ := l.Scan()
+
+		assert.Equal(t, token.Tokens[tt.Type], token.Tokens[tok.Type])
+		assert.Equal(t, tt.Lit, tok.Lit)
+	}
+}
+
+func TestScanPositions(t *testing.T) {
+	input := "select 'héllo', u.id\n  from \"Users\" u -- comment\nwhere x >= $1;"
+
+	tests := []struct {
+		lit   string
+		start token.Pos
+		end   token.Pos
+	}{
+		{"select", token.Pos{Offset: 0, Line: 1, Char: 1}, token.Pos{Offset: 6, Line: 1, Char: 7}},
+		{"héllo", token.Pos{Offset: 7, Line: 1, Char: 8}, token.Pos{Offset: 15, Line: 1, Char: 15}},
+		{",", token.Pos{Offset: 15, Line: 1, Char: 15}, token.Pos{Offset: 16, Line: 1, Char: 16}},
+		{"u", token.Pos{Offset: 17, Line: 1, Char: 17}, token.Pos{Offset: 18, Line: 1, Char: 18}},
+		{".", token.Pos{Offset: 18, Line: 1, Char: 18}, token.Pos{Offset: 19, Line: 1, Char: 19}},
+		{"id", token.Pos{Offset: 19, Line: 1, Char: 19}, token.Pos{Offset: 21, Line: 1, Char: 21}},
+		{"from", token.Pos{Offset: 24, Line: 2, Char: 3}, token.Pos{Offset: 28, Line: 2, Char: 7}},
+		{`"Users"`, token.Pos{Offset: 29, Line: 2, Char: 8}, token.Pos{Offset: 36, Line: 2, Char: 15}},
+		{"u", token.Pos{Offset: 37, Line: 2, Char: 16}, token.Pos{Offset: 38, Line: 2, Char: 17}},
+		{"-- comment", token.Pos{Offset: 39, Line: 2, Char: 18}, token.Pos{Offset: 50, Line: 3, Char: 1}}, // the newline is in the span, but not the text
+		{"where", token.Pos{Offset: 50, Line: 3, Char: 1}, token.Pos{Offset: 55, Line: 3, Char: 6}},
+		{"x", token.Pos{Offset: 56, Line: 3, Char: 7}, token.Pos{Offset: 57, Line: 3, Char: 8}},
+		{">=", token.Pos{Offset: 58, Line: 3, Char: 9}, token.Pos{Offset: 60, Line: 3, Char: 11}},
+		{"$1", token.Pos{Offset: 61, Line: 3, Char: 12}, token.Pos{Offset: 63, Line: 3, Char: 14}},
+		{";", token.Pos{Offset: 63, Line: 3, Char: 14}, token.Pos{Offset: 64, Line: 3, Char: 15}},
+		{"", token.Pos{Offset: 64, Line: 3, Char: 15}, token.Pos{Offset: 64, Line: 3, Char: 15}},
+	}
+
+	l := New(input)
+
+	for _, tt := range tests {
+		tok, pos := l.Scan()
+
+		assert.Equal(t, tt.lit, tok.Lit)
+		assert.Equal(t, tt.start, pos, "start of %q", tt.lit)
+		assert.Equal(t, tt.start, tok.Pos, "start of %q", tt.lit)
+		assert.Equal(t, tt.end, tok.End, "end of %q", tt.lit)
+	}
+}
+
+func TestMySQLScan(t *testing.T) {
+	input := "select `id`, `odd``name` from users where a = ? and b = \"x\" and c = 'it\\'s' and d = 5--1 # rest of line\n" +
+		"-- comment\n@total @@session.sql_mode /*!50503 limit 1 */"
+
+	tests := []token.Token{
+		{Type: token.SELECT, Lit: "select"},
+		{Type: token.IDENT, Lit: "`id`"},
+		{Type: token.COMMA, Lit: ","},
+		{Type: token.IDENT, Lit: "`odd``name`"},
+		{Type: token.FROM, Lit: "from"},
+		{Type: token.IDENT, Lit: "users"},
+		{Type: token.WHERE, Lit: "where"},
+		{Type: token.IDENT, Lit: "a"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.PARAM, Lit: "?"},
+		{Type: token.AND, Lit: "and"},
+		{Type: token.IDENT, Lit: "b"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.STRING, Lit: "x"},
+		{Type: token.AND, Lit: "and"},
+		{Type: token.IDENT, Lit: "c"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.STRING, Lit: "it's"},
+		{Type: token.AND, Lit: "and"},
+		{Type: token.IDENT, Lit: "d"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.INT, Lit: "5"},
+		{Type: token.MINUS, Lit: "-"},
+		{Type: token.MINUS, Lit: "-"},
+		{Type: token.INT, Lit: "1"},
+		{Type: token.SQLCOMMENT, Lit: "# rest of line"},
+		{Type: token.SQLCOMMENT, Lit: "-- comment"},
+		{Type: token.IDENT, Lit: "@total"},
+		{Type: token.IDENT, Lit: "@@session"},
+		{Type: token.DOT, Lit: "."},
+		{Type: token.IDENT, Lit: "sql_mode"},
+		{Type: token.LIMIT, Lit: "limit"},
+		{Type: token.INT, Lit: "1"},
+		{Type: token.EOF, Lit: ""},
+	}
+
+	l := New(input, token.MySQL)
+	assert.Equal(t, token.MySQL, l.Dialect())
+
+	for _, tt := range tests {
+		tok, _ := l.Scan()
+
+		assert.Equal(t, token.Tokens[tt.Type], token.Tokens[tok.Type])
+		assert.Equal(t, tt.Lit, tok.Lit)
+	}
+}
+
+func TestSQLiteScan(t *testing.T) {
+	input := "select `id`, [first name] from users where a = ? and b = ?2 and c = :c and d = @d and e = $e and f = $1"
+
+	tests := []token.Token{
+		{Type: token.SELECT, Lit: "select"},
+		{Type: token.IDENT, Lit: "`id`"},
+		{Type: token.COMMA, Lit: ","},
+		{Type: token.IDENT, Lit: "[first name]"},
+		{Type: token.FROM, Lit: "from"},
+		{Type: token.IDENT, Lit: "users"},
+		{Type: token.WHERE, Lit: "where"},
+		{Type: token.IDENT, Lit: "a"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.PARAM, Lit: "?"},
+		{Type: token.AND, Lit: "and"},
+		{Type: token.IDENT, Lit: "b"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.PARAM, Lit: "?2"},
+		{Type: token.AND, Lit: "and"},
+		{Type: token.IDENT, Lit: "c"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.PARAM, Lit: ":c"},
+		{Type: token.AND, Lit: "and"},
+		{Type: token.IDENT, Lit: "d"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.PARAM, Lit: "@d"},
+		{Type: token.AND, Lit: "and"},
+		{Type: token.IDENT, Lit: "e"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.PARAM, Lit: "$e"},
+		{Type: token.AND, Lit: "and"},
+		{Type: token.IDENT, Lit: "f"},
+		{Type: token.ASSIGN, Lit: "="},
+		{Type: token.PARAM, Lit: "$1"},
+		{Type: token.EOF, Lit: ""},
+	}
+
+	l := New(input, token.SQLite)
+
+	for _, tt := range tests {
+		tok, _ := l.Scan()
+
+		assert.Equal(t, token.Tokens[tt.Type], token.Tokens[tok.Type])
+		assert.Equal(t, tt.Lit, tok.Lit)
+	}
+}
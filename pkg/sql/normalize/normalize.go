@@ -0,0 +1,250 @@
+// Package normalize rewrites a statement before it's fingerprinted, so queries that only differ in ways that
+// don't matter, such as the case of an identifier or the order of the conditions in a WHERE clause, end up
+// with the same masked string and the same fingerprint.
+//
+// Masking already replaces values with ? and collapses IN lists and the rows of INSERT ... VALUES to their
+// first item. The rules here go further, and each one has to be turned on, since turning one on changes the
+// fingerprints of queries that were already stored.
+package normalize
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// Options are the normalization rules to apply. The zero value applies none of them, so a fingerprint
+// is the same as the one from String(true).
+type Options struct {
+	CollapseLists bool `json:"collapse_lists,omitempty"` // ARRAY[...] of values and the rows of a VALUES statement collapse to the first item
+	StripAliases  bool `json:"strip_aliases,omitempty"`  // column and table aliases are dropped
+	Identifiers   bool `json:"identifiers,omitempty"`    // unquoted identifiers are lower cased, and quotes that aren't needed are dropped
+	Commutative   bool `json:"commutative,omitempty"`    // the operands of AND, OR, =, and <> are sorted
+}
+
+// rules are the names of the options, in the order they're written by String
+var rules = []struct {
+	name  string
+	field func(o *Options) *bool
+}{
+	{"lists", func(o *Options) *bool { return &o.CollapseLists }},
+	{"aliases", func(o *Options) *bool { return &o.StripAliases }},
+	{"identifiers", func(o *Options) *bool { return &o.Identifiers }},
+	{"commutative", func(o *Options) *bool { return &o.Commutative }},
+}
+
+// All turns on every rule
+func All() Options {
+	return Options{CollapseLists: true, StripAliases: true, Identifiers: true, Commutative: true}
+}
+
+// String returns the rules that are turned on as a comma separated list, such as "lists,identifiers",
+// or an empty string when none are. It's stored with each query to record how it was fingerprinted.
+func (o Options) String() string {
+	names := []string{}
+	for _, r := range rules {
+		if *r.field(&o) {
+			names = append(names, r.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Parse reads the list written by String. "all" turns on every rule and "none" turns them all off.
+func Parse(s string) (Options, error) {
+	var o Options
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "", "none":
+			continue
+		case "all":
+			o = All()
+			continue
+		}
+
+		found := false
+		for _, r := range rules {
+			if r.name == name {
+				*r.field(&o) = true
+				found = true
+			}
+		}
+		if !found {
+			return Options{}, fmt.Errorf("unknown normalization rule %q", name)
+		}
+	}
+	return o, nil
+}
+
+// Masked returns the masked string of the statement after it's normalized. The statement itself isn't changed.
+func Masked(stmt ast.Statement, opts Options) string {
+	if opts == (Options{}) {
+		return stmt.String(true)
+	}
+	return Statement(ast.Clone(stmt), opts).String(true)
+}
+
+// Statement normalizes the statement in place and returns it
+func Statement(stmt ast.Statement, opts Options) ast.Statement {
+	// The AND between the bounds of BETWEEN isn't commutative, and the bounds are rewritten before the BETWEEN is
+	bounds := map[*ast.InfixExpression]bool{}
+	if opts.Commutative {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if x, ok := n.(*ast.InfixExpression); ok && strings.ToUpper(x.Operator) == "BETWEEN" {
+				if b, ok := x.Right.(*ast.InfixExpression); ok {
+					bounds[b] = true
+				}
+			}
+			return true
+		})
+	}
+
+	return ast.Rewrite(stmt, func(n ast.Node) ast.Node {
+		switch n := n.(type) {
+		case *ast.ArrayLiteral:
+			if opts.CollapseLists && len(n.Elements) > 1 && allValues(n.Elements) {
+				n.Elements = n.Elements[:1]
+			}
+		case *ast.ValuesExpression:
+			if opts.CollapseLists && len(n.Tuples) > 1 {
+				n.Tuples = n.Tuples[:1]
+			}
+		case *ast.ColumnExpression:
+			if opts.StripAliases {
+				n.Name = nil
+			}
+		case *ast.TableExpression:
+			if opts.StripAliases {
+				n.Alias = nil
+				n.ColumnAliases = nil
+			}
+			if opts.Identifiers && n.Schema != "" {
+				n.Schema = identifier(n.Schema)
+			}
+		case *ast.SimpleIdentifier:
+			if opts.Identifiers {
+				n.Value = identifier(n.Value)
+			}
+		case *ast.InfixExpression:
+			if opts.Commutative && !bounds[n] {
+				sortOperands(n)
+			}
+		}
+		return n
+	}).(ast.Statement)
+}
+
+// allValues is true when every element is a literal or a param, or an array of them
+func allValues(elements []ast.Expression) bool {
+	for _, e := range elements {
+		switch e := e.(type) {
+		case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.EscapeStringLiteral,
+			*ast.DollarStringLiteral, *ast.ParamLiteral, *ast.Boolean, *ast.Null:
+		case *ast.ArrayLiteral:
+			if e.Left != nil || !allValues(e.Elements) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// quotes are the pairs that quote an identifier: double quotes, MySQL and SQLite backticks, and SQLite brackets
+var quotes = [][2]string{{`"`, `"`}, {"`", "`"}, {"[", "]"}}
+
+// identifier lower cases an unquoted identifier, since Postgres folds those to lower case anyway.
+// A quoted identifier keeps its case, but its quotes are dropped when it would mean the same thing without them.
+func identifier(s string) string {
+	quoted := false
+	for _, q := range quotes {
+		if len(s) >= 2 && strings.HasPrefix(s, q[0]) && strings.HasSuffix(s, q[1]) {
+			quoted = true
+		}
+	}
+	if !quoted {
+		return strings.ToLower(s)
+	}
+
+	name := s[1 : len(s)-1]
+	if plainIdentifier.MatchString(name) && token.Lookup(name) == token.IDENT {
+		return name
+	}
+	return s
+}
+
+// sortOperands puts the operands of a commutative operator in a stable order. A chain of the same
+// AND or OR, such as ((a AND b) AND c), is flattened first, so the conditions are sorted as a whole.
+func sortOperands(n *ast.InfixExpression) {
+	if n.Not || n.Cast != nil {
+		return
+	}
+
+	op := strings.ToUpper(n.Operator)
+	switch op {
+	case "=", "<>", "!=":
+		if n.Left != nil && n.Right != nil && !before(n.Left, n.Right) {
+			n.Left, n.Right = n.Right, n.Left
+		}
+	case "AND", "OR":
+		links := []*ast.InfixExpression{}
+		operands := flatten(n, op, &links)
+		sort.SliceStable(operands, func(i, j int) bool {
+			return operands[i].String(true) < operands[j].String(true)
+		})
+
+		// Rebuild the chain with the same nodes, so it stays nested to the left
+		for i, link := range links {
+			if i == 0 {
+				link.Left = operands[0]
+			} else {
+				link.Left = links[i-1]
+			}
+			link.Right = operands[i+1]
+		}
+	}
+}
+
+// before is true when a should stay on the left side of an equality. Values go on the right, as in id = ?,
+// and everything else is ordered by its masked string.
+func before(a, b ast.Expression) bool {
+	aValue, bValue := allValues([]ast.Expression{a}), allValues([]ast.Expression{b})
+	if aValue != bValue {
+		return bValue
+	}
+	return a.String(true) <= b.String(true)
+}
+
+// flatten returns the operands of a chain of the same operator, with the links of the chain from the innermost out.
+// Parentheses don't change the chain, so a AND (b AND c) is the same as (a AND b) AND c.
+func flatten(n *ast.InfixExpression, op string, links *[]*ast.InfixExpression) []ast.Expression {
+	operands := []ast.Expression{}
+	for _, side := range []ast.Expression{n.Left, n.Right} {
+		if inner, ok := ungroup(side).(*ast.InfixExpression); ok && !inner.Not && inner.Cast == nil && strings.ToUpper(inner.Operator) == op {
+			operands = append(operands, flatten(inner, op, links)...)
+		} else {
+			operands = append(operands, side)
+		}
+	}
+	*links = append(*links, n)
+	return operands
+}
+
+// ungroup returns the expression inside parentheses that only group it
+func ungroup(x ast.Expression) ast.Expression {
+	for {
+		g, ok := x.(*ast.GroupedExpression)
+		if !ok || len(g.Elements) != 1 || g.Cast != nil {
+			return x
+		}
+		x = g.Elements[0]
+	}
+}
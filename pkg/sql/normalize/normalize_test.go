@@ -0,0 +1,167 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/parser"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasked(t *testing.T) {
+	tests := []struct {
+		opts   Options
+		input  string
+		output string
+	}{
+		// The zero value is the same as String(true)
+		{Options{}, "select id as user_id from users u where b = 1 and a = array[1, 2]", "(SELECT id AS user_id FROM users u WHERE ((b = ?) AND (a = array[?, ?])));"},
+
+		// Lists
+		{Options{CollapseLists: true}, "select * from users where id = any(array[1, 2, 3])", "(SELECT * FROM users WHERE (id = any(array[?])));"},
+		{Options{CollapseLists: true}, "select * from users where id = any(array[[1, 2], [3, 4]])", "(SELECT * FROM users WHERE (id = any(array[[?]])));"},
+		{Options{CollapseLists: true}, "select * from users where id = any(array[a, b])", "(SELECT * FROM users WHERE (id = any(array[a, b])));"},
+		{Options{CollapseLists: true}, "values (1, 2), (3, 4), (5, 6)", "(VALUES (?, ?))"},
+		{Options{CollapseLists: true}, "select * from users where id in (1, 2, 3)", "(SELECT * FROM users WHERE id IN (?));"},
+
+		// Aliases
+		{Options{StripAliases: true}, "select id as user_id from users u", "(SELECT id FROM users);"},
+		{Options{StripAliases: true}, "select x.a from generate_series(1, 10) as x(a)", "(SELECT x.a FROM generate_series(?, ?));"},
+
+		// Identifiers
+		{Options{Identifiers: true}, "select ID, Name from Public.Users", "(SELECT id, name FROM public.users);"},
+		{Options{Identifiers: true}, `select "id", "Name", "select" from "public"."users"`, `(SELECT id, "Name", "select" FROM public.users);`},
+
+		// Commutative operators
+		{Options{Commutative: true}, "select * from users where b = 1 and a = 2", "(SELECT * FROM users WHERE ((a = ?) AND (b = ?)));"},
+		{Options{Commutative: true}, "select * from users where c = 1 and (b = 2 and a = 3)", "(SELECT * FROM users WHERE (((a = ?) AND (b = ?)) AND (c = ?)));"},
+		{Options{Commutative: true}, "select * from users where 1 = id or name <> 'x'", "(SELECT * FROM users WHERE ((id = ?) OR (name <> '?')));"},
+		{Options{Commutative: true}, "select * from users u join cars c on u.id = c.user_id", "(SELECT * FROM users u INNER JOIN cars c ON (c.user_id = u.id));"},
+		{Options{Commutative: true}, "select * from users where b = 1 and (d = 1 or c = 2)", "(SELECT * FROM users WHERE (((c = ?) OR (d = ?)) AND (b = ?)));"},
+		{Options{Commutative: true}, "select * from users where b < 1 and a > 2", "(SELECT * FROM users WHERE ((a > ?) AND (b < ?)));"},
+		{Options{Commutative: true}, "select * from users where not (b = 1 and a = 2)", "(SELECT * FROM users WHERE (NOT ((a = ?) AND (b = ?))));"},
+		{Options{Commutative: true}, "select * from users where x between b and a", "(SELECT * FROM users WHERE (x BETWEEN (b AND a)));"},
+		{Options{Commutative: true}, "select * from users where y = 1 and x not between b and a", "(SELECT * FROM users WHERE ((x NOT BETWEEN (b AND a)) AND (y = ?)));"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if !assert.Empty(t, p.Errors(), "input: %s", tt.input) || !assert.Len(t, program.Statements, 1) {
+			continue
+		}
+
+		stmt := program.Statements[0]
+		original := stmt.String(true)
+		assert.Equal(t, tt.output, Masked(stmt, tt.opts), "input: %s", tt.input)
+
+		// The parsed statement isn't changed
+		assert.Equal(t, original, stmt.String(true), "input: %s", tt.input)
+	}
+}
+
+func TestMaskedSameFingerprint(t *testing.T) {
+	inputs := []string{
+		"select id as user_id from users u where name = 'a' and id = any(array[1, 2])",
+		"SELECT ID FROM Users WHERE ID = ANY(ARRAY[3]) AND Name = 'b'",
+		`select "id" from "users" where 'c' = name and id = any(array[4, 5, 6])`,
+	}
+
+	masked := map[string]bool{}
+	for _, input := range inputs {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", input)
+		masked[Masked(program.Statements[0], All())] = true
+	}
+	assert.Len(t, masked, 1, "%v", masked)
+}
+
+func TestMaskedCommutativeGroups(t *testing.T) {
+	// Each set of inputs has the same fingerprint
+	tests := [][]string{
+		{
+			"select * from users where a = 1 and (b = 2 and c = 3)",
+			"select * from users where (c = 1 and a = 2) and b = 3",
+			"select * from users where ((b = 1) and (c = 2 and a = 3))",
+			"select * from users where a = 1 and b = 2 and c = 3",
+		},
+		{
+			"select * from users where a = 1 or (b = 2 or (c = 3 or d = 4))",
+			"select * from users where (d = 1 or c = 2) or (b = 3 or a = 4)",
+		},
+		{
+			"select * from users where a = 1 and (b = 2 or c = 3)",
+			"select * from users where (c = 1 or b = 2) and a = 3",
+		},
+	}
+
+	for _, inputs := range tests {
+		masked := map[string]bool{}
+		for _, input := range inputs {
+			p := parser.New(lexer.New(input))
+			program := p.ParseProgram()
+			assert.Empty(t, p.Errors(), "input: %s", input)
+			masked[Masked(program.Statements[0], Options{Commutative: true})] = true
+		}
+		assert.Len(t, masked, 1, "%v", masked)
+	}
+
+	// A group of another operator is one operand, so it isn't the same chain
+	masked := map[string]bool{}
+	for _, input := range []string{"select * from users where a = 1 and (b = 2 or c = 3)", "select * from users where (a = 1 and b = 2) or c = 3"} {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		masked[Masked(program.Statements[0], Options{Commutative: true})] = true
+	}
+	assert.Len(t, masked, 2, "%v", masked)
+}
+
+func TestMaskedQuotedIdentifiers(t *testing.T) {
+	tests := []struct {
+		dialect token.Dialect
+		input   string
+		output  string
+	}{
+		{token.MySQL, "select `id`, `Name` from `users`", "(SELECT id, `Name` FROM users);"},
+		{token.SQLite, "select [id], [first name] from people", "(SELECT id, [first name] FROM people);"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input, tt.dialect))
+		program := p.ParseProgram()
+		assert.Empty(t, p.Errors(), "input: %s", tt.input)
+		assert.Equal(t, tt.output, Masked(program.Statements[0], Options{Identifiers: true}), "input: %s", tt.input)
+	}
+}
+
+func TestOptionsString(t *testing.T) {
+	tests := []struct {
+		opts Options
+		str  string
+	}{
+		{Options{}, ""},
+		{Options{Identifiers: true}, "identifiers"},
+		{Options{Commutative: true, CollapseLists: true}, "lists,commutative"},
+		{All(), "lists,aliases,identifiers,commutative"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.str, tt.opts.String())
+
+		opts, err := Parse(tt.str)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.opts, opts)
+	}
+
+	opts, err := Parse(" All ")
+	assert.NoError(t, err)
+	assert.Equal(t, All(), opts)
+
+	opts, err = Parse("none")
+	assert.NoError(t, err)
+	assert.Equal(t, Options{}, opts)
+
+	_, err = Parse("lists,sorted")
+	assert.EqualError(t, err, `unknown normalization rule "sorted"`)
+}
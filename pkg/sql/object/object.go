@@ -19,6 +19,7 @@ const (
 	STRING
 	STRING_HASH_OBJ // This is only used internally. It is not a part of the language.
 	UUID
+	RELATION
 )
 
 var Objects = [...]string{
@@ -29,6 +30,7 @@ var Objects = [...]string{
 	STRING:          "STRING",
 	STRING_HASH_OBJ: "STRING_HASH",
 	UUID:            "UUID",
+	RELATION:        "RELATION",
 }
 
 type HashKey struct {
@@ -118,3 +120,24 @@ type StringHash struct {
 
 func (s *StringHash) Type() ObjectType { return STRING }
 func (s *StringHash) Inspect() string  { return fmt.Sprintf("%v", s.Value) }
+
+// Relation is a CTE or a subquery in the FROM clause. Its columns are mapped to the table columns they select,
+// so references to them can be followed through to the tables. This is only used internally.
+type Relation struct {
+	Name    string
+	Columns map[string]string   // by the name of the output column: the schema.table.column it selects, or "" when it's computed
+	Order   []string            // the names of the output columns in order
+	Tables  []string            // the schema.table of the tables it selects from
+	Star    bool                // it selects *, so it also has all of the columns of its tables
+	Lineage map[string]*Lineage // by the name of the output column: what it's computed from
+}
+
+// Lineage is what a column is computed from. This is only used internally.
+type Lineage struct {
+	Sources    []string // the schema.table.column of the columns its value comes from
+	Expression string   // the masked expression that computes it, or "" when it's a column as is
+	Functions  []string // the functions called in the expression
+}
+
+func (r *Relation) Type() ObjectType { return RELATION }
+func (r *Relation) Inspect() string  { return fmt.Sprintf("%s%v", r.Name, r.Order) }
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+func (p *Parser) addComment(tok token.Token) {
+	c := &ast.Comment{Token: tok, Statement: -1}
+	c.SetSpan(tok.Pos, tok.End)
+	p.comments = append(p.comments, c)
+}
+
+// attachComments assigns each comment to a statement. A comment belongs to the statement it's inside of.
+// Otherwise, a comment on the same line as the end of a statement belongs to that statement,
+// and any other comment belongs to the statement after it, or the last statement if there isn't one.
+// starts are the offsets of every statement, so a comment after one that was left out for errors isn't trailing.
+func attachComments(program *ast.Program, starts []int) {
+	stmts := program.Statements
+
+	i := 0
+	for _, c := range program.Comments {
+		for i < len(stmts) && stmts[i].End().Offset <= c.Pos().Offset && !trailing(c, stmts[i], starts) {
+			i++
+		}
+
+		switch {
+		case len(stmts) == 0:
+			c.Statement = -1
+		case i == len(stmts):
+			c.Statement = len(stmts) - 1
+		default:
+			c.Statement = i
+		}
+	}
+}
+
+// trailing is true when the comment follows the statement on its last line, with no other statement between them
+func trailing(c *ast.Comment, stmt ast.Statement, starts []int) bool {
+	if stmt.End().Offset > c.Pos().Offset || stmt.End().Line != c.Pos().Line {
+		return false
+	}
+	for _, s := range starts {
+		if stmt.End().Offset <= s && s < c.Pos().Offset {
+			return false
+		}
+	}
+	return true
+}
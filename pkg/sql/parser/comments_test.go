@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComments(t *testing.T) {
+	maskParams := false
+
+	input := "-- first\nselect id /* inline */ from users; -- trailing\n/* second */ select 2\n/*controller='users'*/;\n-- the end"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p, input)
+
+	// Comments don't change the statements
+	assert.Equal(t, "(SELECT id FROM users);(SELECT 2);", program.String(maskParams))
+
+	tests := []struct {
+		lit       string
+		statement int
+	}{
+		{"-- first", 0},
+		{"/* inline */", 0},
+		{"-- trailing", 0},
+		{"/* second */", 1},
+		{"/*controller='users'*/", 1},
+		{"-- the end", 1},
+	}
+
+	if assert.Equal(t, len(tests), len(program.Comments)) {
+		for i, tt := range tests {
+			c := program.Comments[i]
+			assert.Equal(t, tt.lit, c.Token.Lit)
+			assert.Equal(t, tt.lit, input[c.Pos().Offset:c.Pos().Offset+len(tt.lit)])
+			assert.Equal(t, tt.statement, c.Statement, "comment: %s", tt.lit)
+		}
+	}
+
+	assert.Nil(t, program.Tags(0))
+	assert.Equal(t, map[string]string{"controller": "users"}, program.Tags(1))
+
+	// Comments are walked along with the statements, in the order they start
+	lits := []string{}
+	for _, n := range program.Children() {
+		if c, ok := n.(*ast.Comment); ok {
+			lits = append(lits, c.Token.Lit)
+		} else {
+			lits = append(lits, n.TokenLiteral())
+		}
+	}
+	assert.Equal(t, []string{"-- first", "SELECT", "/* inline */", "-- trailing", "/* second */", "SELECT", "/*controller='users'*/", "-- the end"}, lits)
+}
+
+func TestCommentsWithoutStatements(t *testing.T) {
+	l := lexer.New("-- nothing to see here")
+	p := New(l)
+	program := p.ParseProgram()
+
+	assert.Equal(t, 0, len(program.Statements))
+	if assert.Equal(t, 1, len(program.Comments)) {
+		assert.Equal(t, -1, program.Comments[0].Statement)
+	}
+}
+
+func TestCommentsAfterDroppedStatement(t *testing.T) {
+	input := "select 1; select ) from t; /* a='b' */ select 2"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	assert.Equal(t, 1, len(p.Errors()))
+	assert.Equal(t, "(SELECT 1);(SELECT 2);", program.String(false))
+
+	// The comment comes after the dropped statement, so it doesn't trail select 1
+	if assert.Equal(t, 1, len(program.Comments)) {
+		assert.Equal(t, 1, program.Comments[0].Statement)
+	}
+	assert.Nil(t, program.Tags(0))
+	assert.Equal(t, map[string]string{"a": "b"}, program.Tags(1))
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/brianbroderick/lantern/pkg/sql/ast"
 	"github.com/brianbroderick/lantern/pkg/sql/token"
 )
@@ -51,7 +53,13 @@ func (p *Parser) parseCreateStatement() *ast.CreateStatement {
 		}
 	}
 
-	stmt.Name = p.parseExpression(LOWEST)
+	if stmt.Object.Type == token.TABLE && p.hasColumnDefinitions() {
+		stmt.Name = p.parseIdentifier()
+		p.nextToken()
+		p.parseTableElements(stmt)
+	} else {
+		stmt.Name = p.parseExpression(LOWEST)
+	}
 
 	p.nextToken()
 
@@ -83,7 +91,7 @@ func (p *Parser) parseCreateStatement() *ast.CreateStatement {
 		p.nextToken()
 	}
 
-	if !p.curTokenIs(token.SEMICOLON) {
+	if !p.curTokenIsOne([]token.TokenType{token.SEMICOLON, token.EOF}) {
 		stmt.Expression = p.parseExpression(LOWEST)
 	}
 
@@ -118,3 +126,94 @@ func (p *Parser) parseLikeExpression() ast.Expression {
 
 	return x
 }
+
+// hasColumnDefinitions is true when the table's name is followed by a list of columns, rather than (LIKE other_table)
+func (p *Parser) hasColumnDefinitions() bool {
+	if !p.curTokenIs(token.IDENT) {
+		return false
+	}
+	if p.peekTokenIs(token.LPAREN) {
+		return p.peekTwoToken.Type != token.LIKE
+	}
+	return p.peekTokenIs(token.DOT) && p.peekThreeToken.Type == token.LPAREN && p.peekFourToken.Type != token.LIKE
+}
+
+// tableConstraints start a table constraint rather than a column
+var tableConstraints = map[string]bool{"CONSTRAINT": true, "PRIMARY": true, "FOREIGN": true, "UNIQUE": true, "CHECK": true, "EXCLUDE": true}
+
+// columnConstraints end the type of a column
+var columnConstraints = map[string]bool{
+	"CONSTRAINT": true, "NOT": true, "NULL": true, "DEFAULT": true, "PRIMARY": true, "UNIQUE": true, "CHECK": true,
+	"REFERENCES": true, "COLLATE": true, "GENERATED": true, "AUTO_INCREMENT": true, "AUTOINCREMENT": true,
+}
+
+// parseTableElements parses the columns and constraints of CREATE TABLE. The current token is the opening parenthesis,
+// and it ends on the closing one. The types and constraints are copied from the source.
+func (p *Parser) parseTableElements(stmt *ast.CreateStatement) {
+	for !p.curTokenIs(token.RPAREN) {
+		p.nextToken()
+		if p.curTokenIs(token.RPAREN) {
+			break
+		}
+
+		if tableConstraints[p.curToken.Upper] {
+			start := p.curToken.Pos
+			_, end := p.skipTableElement(nil)
+			stmt.Constraints = append(stmt.Constraints, p.l.Input[start.Offset:end.Offset])
+			continue
+		}
+
+		col := &ast.ColumnDefinition{Token: p.curToken, Branch: p.clause, CommandTag: p.command}
+		col.Name = &ast.SimpleIdentifier{Token: p.curToken, Value: p.curToken.Lit, Branch: p.clause, CommandTag: p.command}
+		col.Name.SetSpan(p.curToken.Pos, p.curToken.End)
+
+		var typeEnd token.Pos
+		if !p.peekTokenIs(token.COMMA) && !p.peekTokenIs(token.RPAREN) {
+			p.nextToken()
+			typeStart := p.curToken.Pos
+			var end token.Pos
+			typeEnd, end = p.skipTableElement(columnConstraints)
+			col.DataType = strings.TrimSpace(p.l.Input[typeStart.Offset:typeEnd.Offset])
+			if end.Offset > typeEnd.Offset {
+				col.Constraints = strings.TrimSpace(p.l.Input[typeEnd.Offset:end.Offset])
+			}
+			typeEnd = end
+		} else {
+			// A column without a type, as in CREATE TABLE x (a, b) AS, ends at its name. Moving onto the comma keeps it
+			// from being read as another column.
+			typeEnd = p.curToken.End
+			p.nextToken()
+		}
+		col.SetSpan(col.Token.Pos, typeEnd)
+		stmt.Columns = append(stmt.Columns, col)
+	}
+}
+
+// skipTableElement moves to the comma or parenthesis that ends a column or constraint, leaving it as the current token.
+// It returns where the first of the stop words was found, or the end when there isn't one, along with the end.
+func (p *Parser) skipTableElement(stop map[string]bool) (stopAt, end token.Pos) {
+	depth := 0
+	for {
+		if depth == 0 && !stopAt.IsValid() && stop[p.curToken.Upper] {
+			stopAt = p.curToken.Pos
+		}
+		end = p.curToken.End
+
+		switch {
+		case p.curTokenIs(token.LPAREN):
+			depth++
+		case p.curTokenIs(token.RPAREN):
+			depth--
+		}
+		if p.peekTokenIs(token.EOF) || depth == 0 && (p.peekTokenIs(token.COMMA) || p.peekTokenIs(token.RPAREN)) {
+			break
+		}
+		p.nextToken()
+	}
+
+	p.nextToken()
+	if !stopAt.IsValid() {
+		stopAt = end
+	}
+	return stopAt, end
+}
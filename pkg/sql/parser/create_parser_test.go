@@ -26,6 +26,10 @@ func TestCreateStatements(t *testing.T) {
 		// TODO: This is a bug, the name should be temp_my_table. Instead, it's parsing as if it's a function name.
 		{"create temp table temp_my_table( like my_reports );", "CREATE TEMP TABLE temp_my_table((LIKE my_reports));"},
 		{"create index idx_temp_stuff ON my_temp USING btree( id, temp_id ) WHERE ( blah_id = 1 );", "CREATE INDEX idx_temp_stuff ON (my_temp USING btree(id, temp_id)) WHERE (blah_id = 1);"},
+		// Column definitions
+		{"create table users (id int, name text not null);", "CREATE TABLE users (id int, name text not null);"},
+		{"create table if not exists public.users (id bigint primary key, price numeric(10, 2) default 0, created_at timestamp with time zone not null default now(), primary key (id), constraint fk foreign key (a) references b (id));", "CREATE TABLE IF NOT EXISTS public.users (id bigint primary key, price numeric(10, 2) default 0, created_at timestamp with time zone not null default now(), primary key (id), constraint fk foreign key (a) references b (id));"},
+		{"create table t (id int)", "CREATE TABLE t (id int);"},
 	}
 
 	for _, tt := range tests {
@@ -76,3 +80,64 @@ func TestLikeExpressions(t *testing.T) {
 		// fmt.Printf("output: %s\n", output)
 	}
 }
+
+func TestCreateTableColumns(t *testing.T) {
+	input := "create table users (id bigint primary key, name varchar(20) not null default '', tags text[], unique (name));"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p, input)
+
+	stmt, ok := program.Statements[0].(*ast.CreateStatement)
+	if !assert.True(t, ok) || !assert.Len(t, stmt.Columns, 3) {
+		return
+	}
+
+	expected := []struct{ name, dataType, constraints string }{
+		{"id", "bigint", "primary key"},
+		{"name", "varchar(20)", "not null default ''"},
+		{"tags", "text[]", ""},
+	}
+	for i, e := range expected {
+		assert.Equal(t, e.name, stmt.Columns[i].Name.Value)
+		assert.Equal(t, e.dataType, stmt.Columns[i].DataType)
+		assert.Equal(t, e.constraints, stmt.Columns[i].Constraints)
+	}
+	assert.Equal(t, []string{"unique (name)"}, stmt.Constraints)
+}
+
+func TestCreateTableColumnNames(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		input   string
+		output  string
+		columns []string
+	}{
+		// Untyped columns print the same as when the list was parsed as a call
+		{"create table daily (u, t) as select user_id, sum(amount) from orders group by user_id;", "CREATE TABLE daily(u, t) AS (SELECT user_id, sum(amount) FROM orders GROUP BY user_id);", []string{"u", "t"}},
+		{"create table daily(u, t) as select user_id, sum(amount) from orders group by user_id;", "CREATE TABLE daily(u, t) AS (SELECT user_id, sum(amount) FROM orders GROUP BY user_id);", []string{"u", "t"}},
+		{"create table daily ( u ) as select user_id from orders;", "CREATE TABLE daily(u) AS (SELECT user_id FROM orders);", []string{"u"}},
+		{"create table daily (u int, t) as select user_id, 1 from orders;", "CREATE TABLE daily (u int, t) AS (SELECT user_id, 1 FROM orders);", []string{"u", "t"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		stmt, ok := program.Statements[0].(*ast.CreateStatement)
+		if !assert.True(t, ok, "input: %s", tt.input) {
+			continue
+		}
+
+		columns := []string{}
+		for _, c := range stmt.Columns {
+			columns = append(columns, c.Name.Value)
+		}
+		assert.Equal(t, tt.columns, columns, "input: %s", tt.input)
+		assert.Equal(t, tt.output, program.String(maskParams), "input: %s", tt.input)
+	}
+}
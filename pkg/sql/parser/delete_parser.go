@@ -41,7 +41,7 @@ func (p *Parser) parseDeleteExpression() ast.Expression {
 		x.Table = p.parseIdentifier()
 	} else {
 		msg := "expected IDENT for the table name"
-		p.errors = append(p.errors, msg)
+		p.addError(ErrUnexpectedToken, p.peekToken, []token.TokenType{token.IDENT}, msg)
 		return nil
 	}
 
@@ -65,6 +65,7 @@ func (p *Parser) parseDeleteExpression() ast.Expression {
 	}
 	if p.curTokenIs(token.WHERE) {
 		p.nextToken()
+		p.clause = token.WHERE
 		if p.curTokenIs(token.CURRENT) && p.peekTokenIs(token.OF) {
 			p.nextToken()
 			p.nextToken()
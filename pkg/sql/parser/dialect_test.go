@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectStatements(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		dialect token.Dialect
+		input   string
+		output  string
+	}{
+		// MySQL
+		{token.MySQL, "select `id`, `name` from `users` where `id` = ?;", "(SELECT `id`, `name` FROM `users` WHERE (`id` = ?));"},
+		{token.MySQL, "select id from users where name = \"brian\" and bio = 'it\\'s';", "(SELECT id FROM users WHERE ((name = 'brian') AND (bio = 'it''s')));"},
+		{token.MySQL, "select id from users order by id limit 10, 20;", "(SELECT id FROM users ORDER BY id LIMIT 20 OFFSET 10);"},
+		{token.MySQL, "select id from users limit 5 offset 10;", "(SELECT id FROM users LIMIT 5 OFFSET 10);"},
+		{token.MySQL, "insert into users (id, name) values (1, 'a') on duplicate key update name = values(name);", "(INSERT INTO users (id, name) VALUES (1, 'a') ON DUPLICATE KEY UPDATE (name = (VALUES (name))));"},
+		{token.MySQL, "insert into counts (id, n) values (?, 1) on duplicate key update n = n + 1, updated_at = now();", "(INSERT INTO counts (id, n) VALUES (?, 1) ON DUPLICATE KEY UPDATE (n = (n + 1)), (updated_at = now()));"},
+		{token.MySQL, "select id # the id\nfrom users -- the table\nwhere id = 1;", "(SELECT id FROM users WHERE (id = 1));"},
+		{token.MySQL, "select id from users /*!50001 where id = 1 */ /* not run */;", "(SELECT id FROM users WHERE (id = 1));"},
+		{token.MySQL, "select id from users where id = 5--1;", "(SELECT id FROM users WHERE (id = (5 - (-1))));"},
+
+		{token.MySQL, "select id from users where a = @a;", "(SELECT id FROM users WHERE (a = @a));"},
+
+		// SQLite
+		{token.SQLite, "select [first name], `last` from people where id = ?1 and name = :name;", "(SELECT [first name], `last` FROM people WHERE ((id = ?1) AND (name = :name)));"},
+		{token.SQLite, "select id from people where a = @a and b = $b and c = ?;", "(SELECT id FROM people WHERE (((a = @a) AND (b = $b)) AND (c = ?)));"},
+		{token.SQLite, "select id from people limit 10, 20;", "(SELECT id FROM people LIMIT 20 OFFSET 10);"},
+		{token.SQLite, "insert into people (id) values (1) on conflict (id) do nothing;", "(INSERT INTO people (id) VALUES (1) ON CONFLICT (id) DO NOTHING);"},
+
+		// Postgres
+		{token.Postgres, "select data ? 'key', data #> '{a}' from users;", "(SELECT (data ? 'key'), (data #> '{a}') FROM users);"},
+		{token.Postgres, "select id from users where name = \"Brian\";", "(SELECT id FROM users WHERE (name = \"Brian\"));"},
+		{token.Postgres, "insert into users (id) values (1) on conflict (id) do update set name = 'b';", "(INSERT INTO users (id) VALUES (1) ON CONFLICT (id) DO UPDATE SET (name = 'b'));"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input, tt.dialect)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		output := program.String(maskParams)
+		assert.Equal(t, tt.output, output, "input: %s\nprogram.String() not '%s'. got=%s", tt.input, tt.output, output)
+	}
+}
+
+func TestDialectMasked(t *testing.T) {
+	tests := []struct {
+		dialect token.Dialect
+		input   string
+		output  string
+	}{
+		{token.MySQL, "select id from users where name = \"brian\" limit 10, 20;", "(SELECT id FROM users WHERE (name = '?') LIMIT ? OFFSET ?);"},
+		{token.MySQL, "insert into users (id, name) values (1, 'a'), (2, 'b') on duplicate key update name = 'c';", "(INSERT INTO users (id, name) VALUES (?, '?') ON DUPLICATE KEY UPDATE (name = '?'));"},
+		{token.SQLite, "select id from people where id = ?1 and name = :name;", "(SELECT id FROM people WHERE ((id = ?) AND (name = ?)));"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input, tt.dialect))
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		assert.Equal(t, tt.output, program.String(true), "input: %s", tt.input)
+	}
+}
+
+// MySQL's syntax is only MySQL's, so the same input means something else in the other dialects
+func TestDialectDifferences(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		input   string
+		outputs map[token.Dialect]string // no output is a parse error
+	}{
+		{"insert into users (id, name) values (1, 'a') on duplicate key update name = 'b';", map[token.Dialect]string{
+			token.MySQL: "(INSERT INTO users (id, name) VALUES (1, 'a') ON DUPLICATE KEY UPDATE (name = 'b'));",
+		}},
+		{"select `id` from users;", map[token.Dialect]string{
+			token.MySQL:  "(SELECT `id` FROM users);",
+			token.SQLite: "(SELECT `id` FROM users);",
+		}},
+		{"select id # the id\nfrom users;", map[token.Dialect]string{
+			token.MySQL: "(SELECT id FROM users);",
+		}},
+		// /*! is a comment that MySQL runs, and only a comment elsewhere
+		{"select id from users /*!50001 where id = 1 */;", map[token.Dialect]string{
+			token.Postgres: "(SELECT id FROM users);",
+			token.MySQL:    "(SELECT id FROM users WHERE (id = 1));",
+			token.SQLite:   "(SELECT id FROM users);",
+		}},
+		{"select id from users where id = 5--1;", map[token.Dialect]string{
+			token.Postgres: "(SELECT id FROM users WHERE (id = 5));",
+			token.MySQL:    "(SELECT id FROM users WHERE (id = (5 - (-1))));",
+			token.SQLite:   "(SELECT id FROM users WHERE (id = 5));",
+		}},
+		{"select id from users limit 10, 20;", map[token.Dialect]string{
+			token.MySQL:  "(SELECT id FROM users LIMIT 20 OFFSET 10);",
+			token.SQLite: "(SELECT id FROM users LIMIT 20 OFFSET 10);",
+		}},
+		{"select [first name] from people;", map[token.Dialect]string{
+			token.SQLite: "(SELECT [first name] FROM people);",
+		}},
+		{"select id from people where a = @a;", map[token.Dialect]string{
+			token.MySQL:  "(SELECT id FROM people WHERE (a = @a));",
+			token.SQLite: "(SELECT id FROM people WHERE (a = @a));",
+		}},
+		// # starts a comment in MySQL
+		{"select data #> '{a}' from users;", map[token.Dialect]string{
+			token.Postgres: "(SELECT (data #> '{a}') FROM users);",
+			token.SQLite:   "(SELECT (data #> '{a}') FROM users);",
+			token.MySQL:    "(SELECT data);",
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, dialect := range []token.Dialect{token.Postgres, token.MySQL, token.SQLite} {
+			p := New(lexer.New(tt.input, dialect))
+			program := p.ParseProgram()
+
+			output, ok := tt.outputs[dialect]
+			if !ok {
+				assert.NotEmpty(t, p.Errors(), "%s input: %s\nshould not parse. got=%s", dialect, tt.input, program.String(maskParams))
+				continue
+			}
+			assert.Empty(t, p.Errors(), "%s input: %s", dialect, tt.input)
+			assert.Equal(t, output, program.String(maskParams), "%s input: %s", dialect, tt.input)
+		}
+	}
+}
+
+// Without a dialect, ? is still Postgres' JSON operator and backticks aren't allowed
+func TestPostgresIsTheDefaultDialect(t *testing.T) {
+	p := New(lexer.New("select data ? 'key' from users;"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p, "")
+	assert.Equal(t, "(SELECT (data ? 'key') FROM users);", program.String(false))
+
+	p = New(lexer.New("select `id` from users;"))
+	p.ParseProgram()
+	assert.NotEmpty(t, p.Errors())
+}
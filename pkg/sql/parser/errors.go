@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// ErrorCode groups parse errors by kind. Messages embed literals from the query, so they're too specific to aggregate on.
+type ErrorCode string
+
+const (
+	ErrUnexpectedToken ErrorCode = "unexpected_token"   // the next token wasn't one the grammar allows here
+	ErrNoPrefixParseFn ErrorCode = "no_prefix_parse_fn" // an expression started with a token that can't start an expression
+	ErrInvalidNumber   ErrorCode = "invalid_number"     // a numeric literal couldn't be converted, i.e. it overflows
+)
+
+// ParseError is a single error found while parsing.
+// Statement is the zero-based index of the statement in the input, counting every statement the parser attempted.
+type ParseError struct {
+	Code      ErrorCode         `json:"code"`
+	Msg       string            `json:"msg"`
+	Expected  []token.TokenType `json:"expected,omitempty"` // the token types that would have been accepted, if known
+	Got       token.TokenType   `json:"got"`                // the token type that was found
+	Pos       token.Pos         `json:"pos"`                // where the offending token starts
+	Statement int               `json:"statement"`
+}
+
+func (e *ParseError) Error() string { return e.Msg }
+
+func (p *Parser) addError(code ErrorCode, tok token.Token, expected []token.TokenType, msg string) {
+	p.errors = append(p.errors, &ParseError{
+		Code:      code,
+		Msg:       msg,
+		Expected:  expected,
+		Got:       tok.Type,
+		Pos:       tok.Pos,
+		Statement: p.statement,
+	})
+}
+
+// synchronize discards tokens until the end of the current statement, so parsing can resume with the next one.
+// This is panic-mode recovery: everything up to the next semicolon belongs to the statement that failed.
+func (p *Parser) synchronize() {
+	for !p.curTokenIsOne([]token.TokenType{token.SEMICOLON, token.EOF}) {
+		p.nextToken()
+	}
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseErrors(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		input     string
+		code      ErrorCode
+		expected  []token.TokenType
+		got       token.TokenType
+		pos       token.Pos
+		statement int
+		output    string // the statements that were recovered
+	}{
+		{"select 1; select ) from x; select 2;", ErrNoPrefixParseFn, nil, token.RPAREN, token.Pos{Offset: 17, Line: 1, Char: 18}, 1, "(SELECT 1);(SELECT 2);"},
+		{"select 99999999999999999999;\nselect 5", ErrInvalidNumber, nil, token.INT, token.Pos{Offset: 7, Line: 1, Char: 8}, 0, "(SELECT 5);"},
+		{"select 1;\ndelete from 5; select 7;", ErrUnexpectedToken, []token.TokenType{token.IDENT}, token.INT, token.Pos{Offset: 22, Line: 2, Char: 13}, 1, "(SELECT 1);(SELECT 7);"},
+		{"update 5 set a = 1; select 8;", ErrUnexpectedToken, []token.TokenType{token.IDENT}, token.INT, token.Pos{Offset: 7, Line: 1, Char: 8}, 0, "(SELECT 8);"},
+		{"reindex foo bar; select 4;", ErrUnexpectedToken, nil, token.IDENT, token.Pos{Offset: 8, Line: 1, Char: 9}, 0, "(SELECT 4);"},
+		{"select 1; declare c no hold cursor for select 1;", ErrUnexpectedToken, nil, token.IDENT, token.Pos{Offset: 23, Line: 1, Char: 24}, 1, "(SELECT 1);"},
+		{"select id from users where a between symmetric 1 and 2;", ErrNoPrefixParseFn, nil, token.SYMMETRIC, token.Pos{Offset: 37, Line: 1, Char: 38}, 0, ""},
+		// Errors in a routine body are at the position of the body
+		{"create function f() returns int language sql as $$ select ) from x $$; select 3;", ErrNoPrefixParseFn, nil, token.RPAREN, token.Pos{Offset: 48, Line: 1, Char: 49}, 0, "(SELECT 3);"},
+		{"select 1; do $$ begin delete from 5; end $$;", ErrUnexpectedToken, []token.TokenType{token.IDENT}, token.INT, token.Pos{Offset: 13, Line: 1, Char: 14}, 1, "(SELECT 1);"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errs := p.ParseErrors()
+		if assert.Equal(t, 1, len(errs), "input: %s\nerrors: %v", tt.input, p.Errors()) {
+			e := errs[0]
+			assert.Equal(t, tt.code, e.Code, "input: %s", tt.input)
+			assert.Equal(t, tt.expected, e.Expected, "input: %s", tt.input)
+			assert.Equal(t, tt.got, e.Got, "input: %s", tt.input)
+			assert.Equal(t, tt.pos, e.Pos, "input: %s", tt.input)
+			assert.Equal(t, tt.statement, e.Statement, "input: %s", tt.input)
+			assert.Equal(t, e.Msg, p.Errors()[0], "input: %s", tt.input)
+		}
+
+		assert.Equal(t, tt.output, program.String(maskParams), "input: %s", tt.input)
+	}
+}
@@ -0,0 +1,559 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// This file handles routines: CREATE FUNCTION, CREATE PROCEDURE, CREATE TRIGGER, DO, and CALL
+
+// Words that start a new option in a CREATE FUNCTION statement
+var functionOptionHeads = map[string]bool{
+	"LANGUAGE": true, "AS": true, "RETURNS": true, "IMMUTABLE": true, "STABLE": true, "VOLATILE": true,
+	"STRICT": true, "CALLED": true, "SECURITY": true, "EXTERNAL": true, "PARALLEL": true, "COST": true,
+	"ROWS": true, "SET": true, "LEAKPROOF": true, "NOT": true, "WINDOW": true, "SUPPORT": true, "TRANSFORM": true,
+}
+
+// Types that are made up of more than one word, so the first word can't be a parameter name
+var multiWordTypes = map[string]bool{
+	"DOUBLE": true, "CHARACTER": true, "BIT": true, "TIME": true, "NATIONAL": true,
+}
+
+// createObject looks ahead past CREATE [ OR REPLACE ] [ CONSTRAINT ] to find the type of object being created
+func (p *Parser) createObject() string {
+	object := p.peekToken
+	next := p.peekTwoToken
+	if p.peekTokenIs(token.OR) && p.peekTwoToken.Upper == "REPLACE" {
+		object = p.peekThreeToken
+		next = p.peekFourToken
+	}
+	if object.Type == token.CONSTRAINT {
+		return next.Upper
+	}
+	return object.Upper
+}
+
+func (p *Parser) parseCreateFunctionStatement() *ast.CreateFunctionStatement {
+	// defer p.untrace(p.trace("parseCreateFunctionStatement"))
+
+	stmt := &ast.CreateFunctionStatement{Token: p.curToken}
+	p.nextToken()
+
+	if p.curTokenIs(token.OR) {
+		stmt.OrReplace = true
+		p.nextToken()
+		p.nextToken()
+	}
+
+	stmt.Object = p.curToken.Upper
+	p.nextToken()
+	stmt.Name = p.parseIdentifier()
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	stmt.Parameters = p.parseFunctionParameters()
+
+	options := []token.Token{}
+	for !p.peekTokenIsOne([]token.TokenType{token.SEMICOLON, token.EOF}) {
+		p.nextToken()
+
+		switch {
+		case p.curToken.Upper == "RETURNS" && stmt.Returns == "":
+			p.nextToken()
+			stmt.Returns = renderTokens(p.collectTokens(func() bool { return functionOptionHeads[p.peekToken.Upper] }))
+		case p.curToken.Upper == "LANGUAGE":
+			p.nextToken()
+			stmt.Language = strings.ToLower(p.curToken.Lit)
+		case p.curTokenIs(token.AS):
+			p.nextToken()
+			stmt.Body = p.parseExpression(LOWEST)
+			if p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+				p.nextToken()
+				stmt.LinkSymbol = p.parseExpression(LOWEST)
+			}
+		default:
+			// Options such as SECURITY DEFINER or COST 100 are kept as they were written
+			notLeakproof := p.curToken.Upper == "LEAKPROOF" && len(options) > 0 && options[len(options)-1].Upper == "NOT"
+			if functionOptionHeads[p.curToken.Upper] && !notLeakproof && len(options) > 0 {
+				stmt.Options = append(stmt.Options, renderFunctionOption(options))
+				options = []token.Token{}
+			}
+			options = append(options, p.curToken)
+		}
+	}
+	if len(options) > 0 {
+		stmt.Options = append(stmt.Options, renderFunctionOption(options))
+	}
+
+	stmt.Program = p.parseRoutineBody(stmt.Language, stmt.Body)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseFunctionParameters starts on the LPAREN and leaves curToken on the RPAREN
+func (p *Parser) parseFunctionParameters() []*ast.FunctionParameter {
+	params := []*ast.FunctionParameter{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return params
+	}
+
+	for {
+		p.nextToken()
+		params = append(params, p.parseFunctionParameter())
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return params
+}
+
+// [ IN | OUT | INOUT | VARIADIC ] [ name ] type [ { DEFAULT | = } default_expr ]
+func (p *Parser) parseFunctionParameter() *ast.FunctionParameter {
+	x := &ast.FunctionParameter{}
+
+	switch p.curToken.Upper {
+	case "IN", "OUT", "INOUT", "VARIADIC":
+		x.Mode = p.curToken.Upper
+		p.nextToken()
+	}
+
+	toks := p.collectTokens(func() bool {
+		return p.peekTokenIsOne([]token.TokenType{token.COMMA, token.RPAREN, token.DEFAULT, token.ASSIGN})
+	})
+
+	if len(toks) > 1 && toks[0].Type == token.IDENT && !multiWordTypes[toks[0].Upper] {
+		switch toks[1].Type {
+		case token.LPAREN, token.LBRACKET, token.DOT:
+		default:
+			x.Name = toks[0].Lit
+			toks = toks[1:]
+		}
+	}
+	x.Type = renderTokens(toks)
+
+	if p.peekTokenIsOne([]token.TokenType{token.DEFAULT, token.ASSIGN}) {
+		p.nextToken()
+		p.nextToken()
+		x.Default = p.parseExpression(LOWEST)
+	}
+
+	return x
+}
+
+// CREATE [ OR REPLACE ] [ CONSTRAINT ] TRIGGER name { BEFORE | AFTER | INSTEAD OF } { event [ OR ... ] }
+// ON table_name [ options ] [ FOR [ EACH ] { ROW | STATEMENT } ] [ WHEN ( condition ) ]
+// EXECUTE { FUNCTION | PROCEDURE } function_name ( arguments )
+func (p *Parser) parseCreateTriggerStatement() *ast.CreateTriggerStatement {
+	// defer p.untrace(p.trace("parseCreateTriggerStatement"))
+
+	stmt := &ast.CreateTriggerStatement{Token: p.curToken}
+	p.nextToken()
+
+	if p.curTokenIs(token.OR) {
+		stmt.OrReplace = true
+		p.nextToken()
+		p.nextToken()
+	}
+
+	if p.curTokenIs(token.CONSTRAINT) {
+		stmt.Constraint = true
+		p.nextToken()
+	}
+
+	p.nextToken()
+	stmt.Name = p.parseIdentifier()
+
+	p.nextToken()
+	stmt.Timing = p.curToken.Upper
+	if stmt.Timing == "INSTEAD" && p.peekTokenIs(token.OF) {
+		p.nextToken()
+		stmt.Timing = "INSTEAD OF"
+	}
+
+	for {
+		p.nextToken()
+		event := p.curToken.Upper
+		if p.curTokenIs(token.UPDATE) && p.peekTokenIs(token.OF) {
+			p.nextToken()
+			p.nextToken()
+			columns := []string{p.curToken.Lit}
+			for p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+				p.nextToken()
+				columns = append(columns, p.curToken.Lit)
+			}
+			event = event + " OF " + strings.Join(columns, ", ")
+		}
+		stmt.Events = append(stmt.Events, event)
+
+		if !p.peekTokenIs(token.OR) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.ON) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Table = p.parseIdentifier()
+
+	if !p.peekTokenIsOne([]token.TokenType{token.FOR, token.WHEN}) && p.peekToken.Upper != "EXECUTE" {
+		p.nextToken()
+		stmt.Options = strings.ToUpper(renderTokens(p.collectTokens(func() bool {
+			return p.peekTokenIsOne([]token.TokenType{token.FOR, token.WHEN, token.SEMICOLON, token.EOF}) || p.peekToken.Upper == "EXECUTE"
+		})))
+	}
+
+	if p.peekTokenIs(token.FOR) {
+		p.nextToken()
+		if p.peekToken.Upper == "EACH" {
+			p.nextToken()
+		}
+		p.nextToken()
+		stmt.ForEach = p.curToken.Upper
+	}
+
+	if p.peekTokenIs(token.WHEN) {
+		p.nextToken()
+		p.nextToken()
+		stmt.When = p.parseExpression(LOWEST)
+	}
+
+	if p.peekToken.Upper != "EXECUTE" {
+		p.addError(ErrUnexpectedToken, p.peekToken, nil, "expected EXECUTE in CREATE TRIGGER, got "+p.peekToken.Lit)
+		return nil
+	}
+	p.nextToken()
+	p.nextToken()
+	stmt.ExecuteType = p.curToken.Upper
+
+	p.nextToken()
+	function := p.parseIdentifier()
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	stmt.Function = p.parseCallExpression(function)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// DO [ LANGUAGE lang_name ] code
+func (p *Parser) parseDoStatement() *ast.DoStatement {
+	// defer p.untrace(p.trace("parseDoStatement"))
+
+	stmt := &ast.DoStatement{Token: p.curToken}
+
+	if p.peekToken.Upper == "LANGUAGE" {
+		p.nextToken()
+		p.nextToken()
+		stmt.Language = strings.ToLower(p.curToken.Lit)
+	}
+
+	p.nextToken()
+	stmt.Body = p.parseExpression(LOWEST)
+
+	// The language can also come after the code
+	if p.peekToken.Upper == "LANGUAGE" {
+		p.nextToken()
+		p.nextToken()
+		stmt.Language = strings.ToLower(p.curToken.Lit)
+	}
+
+	language := stmt.Language
+	if language == "" {
+		language = "plpgsql"
+	}
+	stmt.Program = p.parseRoutineBody(language, stmt.Body)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// CALL name ( [ argument ] [, ...] )
+func (p *Parser) parseCallStatement() *ast.CallStatement {
+	// defer p.untrace(p.trace("parseCallStatement"))
+
+	p.clause = token.CALL_STATEMENT
+	p.command = token.CALL_STATEMENT
+
+	stmt := &ast.CallStatement{Token: token.Token{Type: token.CALL_STATEMENT, Lit: p.curToken.Lit, Upper: "CALL"}}
+
+	p.nextToken()
+	procedure := p.parseIdentifier()
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	stmt.Expression = p.parseCallExpression(procedure)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// collectTokens gathers tokens starting with curToken until done() is true or the next token
+// closes a paren that was opened before we started. Parens are balanced, so done() is only checked at depth 0.
+// It leaves curToken on the last token collected.
+func (p *Parser) collectTokens(done func() bool) []token.Token {
+	toks := []token.Token{}
+	depth := 0
+
+	for {
+		toks = append(toks, p.curToken)
+		switch p.curToken.Type {
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		}
+
+		if p.peekTokenIs(token.EOF) || (depth == 0 && (done() || p.peekTokenIs(token.SEMICOLON))) {
+			break
+		}
+		if depth == 0 && p.peekTokenIs(token.RPAREN) {
+			break
+		}
+		p.nextToken()
+	}
+
+	return toks
+}
+
+// renderFunctionOption uppercases an option such as security definer, but leaves the value of SET param = value alone
+func renderFunctionOption(toks []token.Token) string {
+	if toks[0].Type == token.SET {
+		return "SET " + renderTokens(toks[1:])
+	}
+	return strings.ToUpper(renderTokens(toks))
+}
+
+// renderTokens turns a list of tokens back into SQL
+func renderTokens(toks []token.Token) string {
+	var out strings.Builder
+
+	for i, t := range toks {
+		if i > 0 {
+			switch toks[i-1].Type {
+			case token.LPAREN, token.LBRACKET, token.DOT, token.DOUBLECOLON:
+			default:
+				switch t.Type {
+				case token.LPAREN, token.RPAREN, token.LBRACKET, token.RBRACKET, token.COMMA, token.DOT, token.DOUBLECOLON:
+				default:
+					out.WriteString(" ")
+				}
+			}
+		}
+
+		switch t.Type {
+		case token.STRING:
+			out.WriteString("'" + strings.ReplaceAll(t.Lit, "'", "''") + "'")
+		default:
+			out.WriteString(t.Lit)
+		}
+	}
+
+	return out.String()
+}
+
+// parseRoutineBody parses the body of sql and plpgsql routines so the extractor can find the tables used inside of them.
+// The body's errors are the routine's errors, and they're at the position of the body, since the positions inside
+// the returned program are relative to the body rather than the outer statement.
+func (p *Parser) parseRoutineBody(language string, body ast.Expression) *ast.Program {
+	var source string
+	var tok token.Token
+	switch b := body.(type) {
+	case *ast.StringLiteral:
+		source, tok = b.Token.Lit, b.Token
+	case *ast.DollarStringLiteral:
+		source, tok = b.Value, b.Token
+	default:
+		return nil
+	}
+
+	var statements []string
+	switch language {
+	case "sql":
+		statements = []string{source}
+	case "plpgsql":
+		statements = splitPlpgsql(source)
+	default:
+		return nil
+	}
+
+	program := &ast.Program{Statements: []ast.Statement{}}
+	for _, s := range statements {
+		sub := New(lexer.New(s))
+		prog := sub.ParseProgram()
+		for _, e := range sub.ParseErrors() {
+			p.addError(e.Code, token.Token{Type: e.Got, Lit: tok.Lit, Pos: tok.Pos}, e.Expected, "routine body: "+e.Msg)
+		}
+		program.Statements = append(program.Statements, prog.Statements...)
+	}
+
+	if len(program.Statements) == 0 {
+		return nil
+	}
+
+	return program
+}
+
+// splitPlpgsql pulls the SQL statements out of a plpgsql block.
+// Control flow such as IF ... THEN, LOOP, and BEGIN ... END is dropped, PERFORM is turned into SELECT,
+// and INTO targets are removed since they're plpgsql variables.
+func splitPlpgsql(source string) []string {
+	segments := [][]token.Token{}
+	segment := []token.Token{}
+	depth := 0
+	caseDepth := 0
+
+	l := lexer.New(source)
+	for {
+		tok, _ := l.Scan()
+		if tok.Type == token.EOF {
+			break
+		}
+
+		split := false
+		switch tok.Type {
+		case token.SQLCOMMENT:
+			continue
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		case token.CASE:
+			caseDepth++
+		case token.SEMICOLON:
+			split = true
+		case token.END:
+			if caseDepth > 0 {
+				caseDepth--
+			} else {
+				split = depth == 0
+			}
+		case token.THEN, token.ELSE:
+			split = depth == 0 && caseDepth == 0
+		case token.IDENT:
+			switch tok.Upper {
+			case "LOOP", "BEGIN", "DECLARE", "EXCEPTION", "ELSIF":
+				split = depth == 0 && caseDepth == 0
+			}
+		}
+
+		if split {
+			segments = append(segments, segment)
+			segment = []token.Token{}
+			continue
+		}
+		segment = append(segment, tok)
+	}
+	segments = append(segments, segment)
+
+	statements := []string{}
+	for _, seg := range segments {
+		if stmt := plpgsqlStatement(seg); len(stmt) > 0 {
+			statements = append(statements, renderTokens(stmt))
+		}
+	}
+
+	return statements
+}
+
+// plpgsqlStatement finds the SQL statement inside of a plpgsql segment, i.e. RETURN QUERY SELECT ...
+func plpgsqlStatement(seg []token.Token) []token.Token {
+	start := -1
+	depth := 0
+	for i, t := range seg {
+		switch t.Type {
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		case token.SELECT, token.INSERT, token.UPDATE, token.DELETE, token.WITH:
+			if depth == 0 {
+				start = i
+			}
+		case token.IDENT:
+			if depth == 0 && t.Upper == "PERFORM" {
+				start = i
+			}
+		}
+		if start >= 0 {
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	stmt := []token.Token{}
+	depth = 0
+	for i := start; i < len(seg); i++ {
+		t := seg[i]
+		switch t.Type {
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		case token.IDENT:
+			if i == start && t.Upper == "PERFORM" {
+				t = token.Token{Type: token.SELECT, Lit: "SELECT", Upper: "SELECT"}
+			}
+		case token.INTO:
+			// INSERT INTO is SQL, but SELECT ... INTO var and RETURNING ... INTO var are plpgsql
+			if depth == 0 && i > start && seg[i-1].Type != token.INSERT {
+				i = skipIntoTargets(seg, i)
+				continue
+			}
+		}
+		stmt = append(stmt, t)
+	}
+
+	return stmt
+}
+
+// skipIntoTargets returns the index of the last token in: INTO [ STRICT ] target [, ...]
+func skipIntoTargets(seg []token.Token, i int) int {
+	if i+1 < len(seg) && seg[i+1].Upper == "STRICT" {
+		i++
+	}
+	for i+1 < len(seg) && seg[i+1].Type == token.IDENT {
+		i++
+		for i+2 < len(seg) && seg[i+1].Type == token.DOT {
+			i += 2
+		}
+		if i+2 < len(seg) && seg[i+1].Type == token.COMMA && seg[i+2].Type == token.IDENT {
+			i++
+			continue
+		}
+		break
+	}
+	return i
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutineStatements(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		input   string
+		output  string
+		command token.TokenType
+	}{
+		// Functions and procedures
+		{"create function add(a integer, b integer default 1) returns integer language sql immutable strict as $$ select a + b $$;",
+			"CREATE FUNCTION add(a integer, b integer DEFAULT 1) RETURNS integer LANGUAGE sql IMMUTABLE STRICT AS $$ select a + b $$;", token.CREATE},
+		{"create or replace function f(out total numeric(10, 2), inout x text[]) returns setof record as $body$ select 1 $body$ language sql;",
+			"CREATE OR REPLACE FUNCTION f(OUT total numeric(10, 2), INOUT x text[]) RETURNS setof record LANGUAGE sql AS $body$ select 1 $body$;", token.CREATE},
+		{"create function c_func(double precision) returns double precision as 'lib', 'c_func' language c;",
+			"CREATE FUNCTION c_func(double precision) RETURNS double precision LANGUAGE c AS 'lib', 'c_func';", token.CREATE},
+		{"create function t() returns table(id int, name text) language sql stable not leakproof security definer cost 100 set search_path = public as 'select 1, ''a''';",
+			"CREATE FUNCTION t() RETURNS table(id int, name text) LANGUAGE sql STABLE NOT LEAKPROOF SECURITY DEFINER COST 100 SET search_path = public AS 'select 1, ''a''';", token.CREATE},
+		{"create procedure p(in x int) language plpgsql as $$ begin update users set a = x; end $$;",
+			"CREATE PROCEDURE p(IN x int) LANGUAGE plpgsql AS $$ begin update users set a = x; end $$;", token.CREATE},
+
+		// Triggers
+		{"create trigger t before insert or update of name, email on users for each row when (old.name is distinct from new.name) execute function f();",
+			"CREATE TRIGGER t BEFORE INSERT OR UPDATE OF name, email ON users FOR EACH ROW WHEN (old.name IS DISTINCT FROM new.name) EXECUTE FUNCTION f();", token.CREATE},
+		{"create or replace constraint trigger t after delete on public.users deferrable initially deferred for each row execute procedure f('a');",
+			"CREATE OR REPLACE CONSTRAINT TRIGGER t AFTER DELETE ON public.users DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE PROCEDURE f('a');", token.CREATE},
+		{"create trigger t instead of insert on my_view execute function f();",
+			"CREATE TRIGGER t INSTEAD OF INSERT ON my_view EXECUTE FUNCTION f();", token.CREATE},
+
+		// Do
+		{"do $$ begin perform 1; end $$;", "DO $$ begin perform 1; end $$;", token.DO},
+		{"do language plpgsql 'begin perform 1; end';", "DO LANGUAGE plpgsql 'begin perform 1; end';", token.DO},
+
+		// Call
+		{"call my_proc(1, 'a');", "CALL my_proc(1, 'a');", token.CALL_STATEMENT},
+		{"CALL public.my_proc()", "CALL public.my_proc();", token.CALL_STATEMENT},
+	}
+
+	for _, tt := range tests {
+		// fmt.Printf("\ninput:  %s\n", tt.input)
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		stmt := program.Statements[0]
+		assert.Equal(t, tt.command, stmt.Command(), "input: %s\nstmt.Command() is not %s. got=%s", tt.input, tt.command, stmt.Command())
+
+		output := program.String(maskParams)
+		assert.Equal(t, tt.output, output, "input: %s\nprogram.String() not '%s'. got=%s", tt.input, tt.output, output)
+		// fmt.Printf("output: %s\n", output)
+	}
+}
+
+func TestRoutineBodies(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		input string
+		body  string
+	}{
+		{"create function f(a int) returns bigint language sql as $$ select sum(amount) from payments where user_id = a $$;",
+			"(SELECT sum(amount) FROM payments WHERE (user_id = a));"},
+		{"create function f() returns trigger as $body$ begin if new.id is null then select id into new.id from seqs where name = 'x'; end if; insert into audit (id) values (new.id); perform notify_me(); return new; end; $body$ language plpgsql;",
+			"(SELECT id FROM seqs WHERE (name = 'x'));(INSERT INTO audit (id) VALUES (new.id));(SELECT notify_me());"},
+		{"do $$ declare n int; begin for r in select id from users loop delete from sessions where user_id = r.id; end loop; end $$;",
+			"(SELECT id FROM users);(DELETE FROM sessions WHERE (user_id = r.id));"},
+		{"do language plpgsql 'begin return query select 1; end';", "(SELECT 1);"},
+		{"create function f() returns int as 'lib', 'f' language c;", ""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		var body *ast.Program
+		switch stmt := program.Statements[0].(type) {
+		case *ast.CreateFunctionStatement:
+			body = stmt.Program
+		case *ast.DoStatement:
+			body = stmt.Program
+		}
+
+		if tt.body == "" {
+			assert.Nil(t, body, "input: %s\nbody should not be parsed", tt.input)
+			continue
+		}
+		if assert.NotNil(t, body, "input: %s\nbody was not parsed", tt.input) {
+			assert.Equal(t, tt.body, body.String(maskParams), "input: %s\nbody.String() not '%s'. got=%s", tt.input, tt.body, body.String(maskParams))
+		}
+	}
+}
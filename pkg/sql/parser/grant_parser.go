@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// parseGrantStatement handles both GRANT and REVOKE.
+// GRANT { privileges | role } [ ON [ object_type ] objects ] TO roles [ WITH { GRANT | ADMIN } OPTION ]
+// REVOKE [ GRANT OPTION FOR ] { privileges | role } [ ON [ object_type ] objects ] FROM roles [ CASCADE | RESTRICT ]
+func (p *Parser) parseGrantStatement() *ast.GrantStatement {
+	// defer p.untrace(p.trace("parseGrantStatement"))
+
+	var stmt *ast.GrantStatement
+	if p.curTokenIs(token.GRANT) {
+		stmt = &ast.GrantStatement{Token: p.curToken}
+	} else {
+		stmt = &ast.GrantStatement{Token: token.Token{Type: token.REVOKE_STATEMENT, Lit: p.curToken.Lit, Upper: "REVOKE"}}
+	}
+
+	p.clause = stmt.Token.Type
+	p.command = stmt.Token.Type
+
+	if stmt.IsRevoke() && p.peekTokenIs(token.GRANT) {
+		p.nextToken()
+		if p.peekTokenIs(token.IDENT) && p.peekToken.Upper == "OPTION" {
+			p.nextToken()
+			if p.peekTokenIs(token.FOR) {
+				p.nextToken()
+				stmt.GrantOptionFor = true
+			}
+		}
+	}
+
+	stmt.Privileges = p.parsePrivileges()
+
+	if p.peekTokenIs(token.ON) {
+		p.nextToken()
+		p.nextToken()
+		stmt.ObjectType = p.parsePrivilegeObjectType()
+		stmt.Objects = p.parsePrivilegeObjects(stmt.OnTables())
+	}
+
+	if stmt.IsRevoke() {
+		if !p.expectPeek(token.FROM) {
+			return nil
+		}
+	} else if !p.expectPeek(token.TO) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Grantees = p.parseGrantees()
+
+	if p.peekTokenIs(token.WITH) {
+		p.nextToken()
+		p.nextToken()
+		option := strings.ToUpper(p.curToken.Lit)
+		if p.peekTokenIs(token.IDENT) && p.peekToken.Upper == "OPTION" {
+			p.nextToken()
+		}
+		stmt.WithOption = "WITH " + option + " OPTION"
+	}
+
+	if p.peekTokenIs(token.IDENT) && (p.peekToken.Upper == "CASCADE" || p.peekToken.Upper == "RESTRICT") {
+		p.nextToken()
+		stmt.Options = p.curToken.Upper
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parsePrivileges collects privileges such as: SELECT, UPDATE (col_a, col_b), ALL PRIVILEGES
+// It leaves curToken on the last token of the list
+func (p *Parser) parsePrivileges() []string {
+	privileges := []string{}
+	words := []string{}
+
+	for !p.peekTokenIsOne([]token.TokenType{token.ON, token.TO, token.FROM, token.SEMICOLON, token.EOF}) {
+		p.nextToken()
+		switch p.curToken.Type {
+		case token.COMMA:
+			privileges = append(privileges, strings.Join(words, " "))
+			words = []string{}
+		case token.LPAREN:
+			p.nextToken()
+			columns := []string{}
+			for _, c := range p.parseExpressionList([]token.TokenType{token.RPAREN}) {
+				columns = append(columns, c.String(false))
+			}
+			words = append(words, "("+strings.Join(columns, ", ")+")")
+		default:
+			words = append(words, strings.ToUpper(p.curToken.Lit))
+		}
+	}
+	if len(words) > 0 {
+		privileges = append(privileges, strings.Join(words, " "))
+	}
+
+	return privileges
+}
+
+// parsePrivilegeObjectType starts on the token after ON and leaves curToken on the first object name
+func (p *Parser) parsePrivilegeObjectType() string {
+	if p.curTokenIs(token.TABLE) {
+		p.nextToken()
+		return "TABLE"
+	}
+
+	// ALL { TABLES | SEQUENCES | FUNCTIONS | PROCEDURES | ROUTINES } IN SCHEMA
+	if p.curTokenIs(token.ALL) {
+		words := []string{"ALL"}
+		for i := 0; i < 3; i++ {
+			p.nextToken()
+			words = append(words, strings.ToUpper(p.curToken.Lit))
+		}
+		p.nextToken()
+		return strings.Join(words, " ")
+	}
+
+	if p.curTokenIs(token.IDENT) && !p.peekTokenIsOne([]token.TokenType{token.TO, token.FROM, token.COMMA, token.DOT}) {
+		switch p.curToken.Upper {
+		case "SEQUENCE", "SCHEMA", "DATABASE", "FUNCTION", "PROCEDURE", "ROUTINE", "LANGUAGE", "TABLESPACE", "TYPE", "DOMAIN":
+			objectType := p.curToken.Upper
+			p.nextToken()
+			return objectType
+		}
+	}
+
+	return ""
+}
+
+// parsePrivilegeObjects starts on the first object and leaves curToken on the last one
+func (p *Parser) parsePrivilegeObjects(onTables bool) []ast.Expression {
+	objects := []ast.Expression{}
+
+	for {
+		object := p.parseIdentifier()
+		// Functions may include their argument types, i.e. my_func(integer)
+		if !onTables && p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			object = p.parseCallExpression(object)
+		}
+		objects = append(objects, object)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		p.nextToken()
+	}
+
+	return objects
+}
+
+// parseGrantees starts on the first role and leaves curToken on the last one
+func (p *Parser) parseGrantees() []ast.Expression {
+	grantees := []ast.Expression{}
+
+	for {
+		// GROUP is noise in: GRANT SELECT ON my_table TO GROUP my_group
+		if p.curTokenIs(token.IDENT) && p.curToken.Upper == "GROUP" && p.peekTokenIs(token.IDENT) {
+			p.nextToken()
+		}
+		grantees = append(grantees, p.parseIdentifier())
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		p.nextToken()
+	}
+
+	return grantees
+}
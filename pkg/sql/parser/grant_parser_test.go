@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrantStatements(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		input   string
+		output  string
+		command token.TokenType
+	}{
+		// Grant
+		{"grant select on my_table to bob;", "GRANT SELECT ON my_table TO bob;", token.GRANT},
+		{"grant select, insert, update (name, email) on table public.users, other to bob, group admins with grant option;", "GRANT SELECT, INSERT, UPDATE (name, email) ON TABLE public.users, other TO bob, admins WITH GRANT OPTION;", token.GRANT},
+		{"grant all privileges on all tables in schema public to reporting;", "GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO reporting;", token.GRANT},
+		{"grant usage on schema reports to public;", "GRANT USAGE ON SCHEMA reports TO public;", token.GRANT},
+		{"grant execute on function my_func(integer) to current_user;", "GRANT EXECUTE ON FUNCTION my_func(integer) TO current_user;", token.GRANT},
+		{"grant admin to bob with admin option;", "GRANT ADMIN TO bob WITH ADMIN OPTION;", token.GRANT},
+
+		// Revoke
+		{"revoke select on my_table from bob;", "REVOKE SELECT ON my_table FROM bob;", token.REVOKE_STATEMENT},
+		{"revoke grant option for insert on table users from bob cascade;", "REVOKE GRANT OPTION FOR INSERT ON TABLE users FROM bob CASCADE;", token.REVOKE_STATEMENT},
+		{"revoke all on sequence users_id_seq from public restrict;", "REVOKE ALL ON SEQUENCE users_id_seq FROM public RESTRICT;", token.REVOKE_STATEMENT},
+	}
+
+	for _, tt := range tests {
+		// fmt.Printf("\ninput:  %s\n", tt.input)
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		stmt := program.Statements[0]
+		_, ok := stmt.(*ast.GrantStatement)
+		assert.True(t, ok, "input: %s\nstmt is not *ast.GrantStatement. got=%T", tt.input, stmt)
+		assert.Equal(t, tt.command, stmt.Command(), "input: %s\nstmt.Command() is not %s. got=%s", tt.input, tt.command, stmt.Command())
+
+		output := program.String(maskParams)
+		assert.Equal(t, tt.output, output, "input: %s\nprogram.String() not '%s'. got=%s", tt.input, tt.output, output)
+		// fmt.Printf("output: %s\n", output)
+	}
+}
@@ -39,7 +39,7 @@ func (p *Parser) parseInsertExpression() ast.Expression {
 		x.Table = p.parseIdentifier()
 	} else {
 		msg := fmt.Sprintf("expected %q to be an IDENT", p.curToken.Lit)
-		p.errors = append(p.errors, msg)
+		p.addError(ErrUnexpectedToken, p.peekToken, []token.TokenType{token.IDENT}, msg)
 		return nil
 	}
 
@@ -98,16 +98,26 @@ func (p *Parser) parseInsertExpression() ast.Expression {
 		p.nextToken()
 	}
 
+	// MySQL's ON DUPLICATE KEY UPDATE col = value, ...
+	if p.dialect == token.MySQL && p.peekTokenIs(token.ON) && p.peekTwoToken.Upper == "DUPLICATE" && p.peekThreeToken.Upper == "KEY" && p.peekFourToken.Type == token.UPDATE {
+		for i := 0; i < 5; i++ {
+			p.nextToken()
+		}
+		p.clause = token.SET
+		x.DuplicateUpdate = p.parseExpressionList([]token.TokenType{token.SEMICOLON, token.EOF, token.RPAREN})
+	}
+
 	if p.peekTokenIs(token.ON) {
 		p.nextToken()
-		if p.peekTokenIs(token.CONFLICT) {
+		// Other than MySQL's ON DUPLICATE KEY, ON is only followed by CONFLICT
+		if !p.expectPeek(token.CONFLICT) {
+			return nil
+		}
+		if p.peekTokenIs(token.LPAREN) {
 			p.nextToken()
-			if p.peekTokenIs(token.LPAREN) {
-				p.nextToken()
-				p.nextToken()
-				p.clause = token.CONFLICT
-				x.ConflictTarget = p.parseExpressionList([]token.TokenType{token.RPAREN})
-			}
+			p.nextToken()
+			p.clause = token.CONFLICT
+			x.ConflictTarget = p.parseExpressionList([]token.TokenType{token.RPAREN})
 		}
 		if p.peekTokenIs(token.DO) {
 			p.nextToken()
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	maskParams := false
+
+	tests := []string{
+		"select distinct on (u.id) u.id, count(*) as n, sum(x) filter (where x > 0), name::text from users u join addresses a on a.user_id = u.id where u.id in (1, 2) and u.name like 'b%' group by rollup (u.id) having count(*) > 1 order by n desc nulls last limit 10 offset 5 for update;",
+		"select id, row_number() over (partition by a order by b rows between 1 preceding and unbounded following) from users window w as (partition by c) fetch first 5 rows only;",
+		"select case when a = 1 then 'one' else 'other' end, interval '1 day', array[1, 2][1], trim(both 'x' from y), substring(z from 1 for 2), created_at at time zone 'utc', e'\\n', $$dollar$$, 1.5, null, true, -x from t;",
+		"select * from users u, lateral unnest(u.tags) with ordinality as t(tag, n), rows from (generate_series(1, 3), unnest(u.ids)) as r;",
+		"with recursive cte as materialized (select 1 union all select n + 1 from cte) select * from cte;",
+		"insert into users (id, name) values (1, 'a'), (2, 'b') on conflict (id) do update set name = excluded.name where users.id > 0 returning id;",
+		"update users u set name = 'a' from accounts a where a.id = u.id returning *;",
+		"delete from users u using accounts a where a.id = u.id returning u.id;",
+		"create index concurrently if not exists idx on users (id) where active;",
+		"create function f(a int default 1) returns int language sql as $$ select a from users $$;",
+		"create trigger t before insert on users for each row when (new.id > 0) execute function f();",
+		"do $$ begin update users set a = 1; end $$;",
+		"prepare p(int) as select * from users where id = $1; execute p(1); deallocate p;",
+		"declare c cursor for select * from users; fetch 10 from c; close c; listen ch; notify ch, 'x';",
+		"vacuum (analyze) users (id, name), accounts; reindex table users; cluster users using idx; refresh materialized view v; truncate users;",
+		"grant select on users to bob; set search_path = public; show timezone; begin; savepoint a; rollback; commit; drop table users;",
+		"analyze users; call p(1); values (1, 2);",
+		"-- tagged\nselect 1 /*controller='users'*/;",
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, input)
+
+		data, err := json.Marshal(program)
+		if !assert.NoError(t, err, "input: %s", input) {
+			continue
+		}
+
+		decoded := &ast.Program{}
+		if !assert.NoError(t, json.Unmarshal(data, decoded), "input: %s", input) {
+			continue
+		}
+
+		assert.Equal(t, program.String(maskParams), decoded.String(maskParams), "input: %s", input)
+		assert.Equal(t, program.String(true), decoded.String(true), "input: %s", input)
+
+		// Encoding the decoded program gives the same JSON, so nothing was lost, including positions
+		again, err := json.Marshal(decoded)
+		assert.NoError(t, err)
+		assert.JSONEq(t, string(data), string(again), "input: %s", input)
+	}
+}
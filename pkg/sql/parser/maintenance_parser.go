@@ -0,0 +1,286 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// This file handles maintenance commands: VACUUM, REINDEX, CLUSTER, REFRESH MATERIALIZED VIEW, and TRUNCATE
+// None of these words are reserved in PG, so they come through as IDENT tokens and we swap in a context token.
+
+func (p *Parser) parseVacuumStatement() *ast.VacuumStatement {
+	// defer p.untrace(p.trace("parseVacuumStatement"))
+
+	p.clause = token.VACUUM_STATEMENT
+	p.command = token.VACUUM_STATEMENT
+
+	stmt := &ast.VacuumStatement{Token: token.Token{Type: token.VACUUM_STATEMENT, Lit: p.curToken.Lit, Upper: "VACUUM"}}
+
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+		if stmt.Options = p.parseUtilityOptions(); stmt.Options == nil {
+			return nil
+		}
+	}
+
+	for p.peekTokenIsOne([]token.TokenType{token.FULL, token.FREEZE, token.VERBOSE, token.ANALYZE}) {
+		p.nextToken()
+		switch p.curToken.Type {
+		case token.FULL:
+			stmt.Full = true
+		case token.FREEZE:
+			stmt.Freeze = true
+		case token.VERBOSE:
+			stmt.Verbose = true
+		case token.ANALYZE:
+			stmt.Analyze = true
+		}
+	}
+
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		stmt.Tables, stmt.Columns = p.parseTableNamesWithColumns()
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseReindexStatement() *ast.ReindexStatement {
+	// defer p.untrace(p.trace("parseReindexStatement"))
+
+	p.clause = token.REINDEX_STATEMENT
+	p.command = token.REINDEX_STATEMENT
+
+	stmt := &ast.ReindexStatement{Token: token.Token{Type: token.REINDEX_STATEMENT, Lit: p.curToken.Lit, Upper: "REINDEX"}}
+
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+		if stmt.Options = p.parseUtilityOptions(); stmt.Options == nil {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	switch strings.ToUpper(p.curToken.Lit) {
+	case "INDEX", "TABLE", "SCHEMA", "DATABASE", "SYSTEM":
+		stmt.Object = strings.ToUpper(p.curToken.Lit)
+	default:
+		p.addError(ErrUnexpectedToken, p.curToken, nil, "expected INDEX, TABLE, SCHEMA, DATABASE, or SYSTEM after REINDEX, got "+p.curToken.Lit)
+		return nil
+	}
+
+	if p.peekTokenIs(token.CONCURRENTLY) {
+		p.nextToken()
+		stmt.Concurrently = true
+	}
+
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		stmt.Name = p.parseIdentifier()
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseClusterStatement() *ast.ClusterStatement {
+	// defer p.untrace(p.trace("parseClusterStatement"))
+
+	p.clause = token.CLUSTER_STATEMENT
+	p.command = token.CLUSTER_STATEMENT
+
+	stmt := &ast.ClusterStatement{Token: token.Token{Type: token.CLUSTER_STATEMENT, Lit: p.curToken.Lit, Upper: "CLUSTER"}}
+
+	if p.peekTokenIs(token.VERBOSE) {
+		p.nextToken()
+		stmt.Verbose = true
+	}
+
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		stmt.Table = p.parseIdentifier()
+
+		if p.peekTokenIs(token.USING) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			stmt.Index = p.parseIdentifier()
+		} else if p.peekTokenIs(token.ON) {
+			// Pre-8.3 syntax: CLUSTER index_name ON table_name
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			stmt.Index = stmt.Table
+			stmt.Table = p.parseIdentifier()
+		}
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseRefreshStatement() *ast.RefreshStatement {
+	// defer p.untrace(p.trace("parseRefreshStatement"))
+
+	p.clause = token.REFRESH_STATEMENT
+	p.command = token.REFRESH_STATEMENT
+
+	stmt := &ast.RefreshStatement{Token: token.Token{Type: token.REFRESH_STATEMENT, Lit: p.curToken.Lit, Upper: "REFRESH"}}
+
+	if !p.expectPeek(token.MATERIALIZED) {
+		return nil
+	}
+	p.nextToken()
+	if !(p.curTokenIs(token.IDENT) && p.curToken.Upper == "VIEW") {
+		p.addError(ErrUnexpectedToken, p.curToken, nil, "expected VIEW after REFRESH MATERIALIZED, got "+p.curToken.Lit)
+		return nil
+	}
+
+	if p.peekTokenIs(token.CONCURRENTLY) {
+		p.nextToken()
+		stmt.Concurrently = true
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = p.parseIdentifier()
+
+	if p.peekTokenIs(token.WITH) {
+		p.nextToken()
+		if p.peekTokenIs(token.NO) {
+			p.nextToken()
+			stmt.WithData = "WITH NO DATA"
+		} else {
+			stmt.WithData = "WITH DATA"
+		}
+		p.nextToken() // DATA
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseTruncateStatement() *ast.TruncateStatement {
+	// defer p.untrace(p.trace("parseTruncateStatement"))
+
+	p.clause = token.TRUNCATE_STATEMENT
+	p.command = token.TRUNCATE_STATEMENT
+
+	stmt := &ast.TruncateStatement{Token: token.Token{Type: token.TRUNCATE_STATEMENT, Lit: p.curToken.Lit, Upper: "TRUNCATE"}}
+
+	if p.peekTokenIs(token.TABLE) {
+		p.nextToken()
+	}
+
+	if p.peekTokenIs(token.ONLY) {
+		p.nextToken()
+		stmt.Only = true
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	for {
+		stmt.Tables = append(stmt.Tables, p.parseIdentifier())
+		// table_name * explicitly includes descendant tables, which is already the default
+		if p.peekTokenIs(token.ASTERISK) {
+			p.nextToken()
+		}
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		p.nextToken()
+	}
+
+	if p.peekTokenIs(token.IDENT) && (p.peekToken.Upper == "RESTART" || p.peekToken.Upper == "CONTINUE") {
+		p.nextToken()
+		stmt.Identity = p.curToken.Upper + " IDENTITY"
+		if p.peekTokenIs(token.IDENT) && p.peekToken.Upper == "IDENTITY" {
+			p.nextToken()
+		}
+	}
+
+	if p.peekTokenIs(token.IDENT) && (p.peekToken.Upper == "CASCADE" || p.peekToken.Upper == "RESTRICT") {
+		p.nextToken()
+		stmt.Options = p.curToken.Upper
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseUtilityOptions parses the parenthesized option list used by VACUUM, REINDEX, etc.
+// i.e. (VERBOSE, PARALLEL 4). It starts on the LPAREN and leaves curToken on the RPAREN.
+// It returns nil if the list isn't closed.
+func (p *Parser) parseUtilityOptions() []string {
+	options := []string{}
+	words := []string{}
+
+	for !p.peekTokenIsOne([]token.TokenType{token.RPAREN, token.SEMICOLON, token.EOF}) {
+		p.nextToken()
+		if p.curTokenIs(token.COMMA) {
+			options = append(options, strings.Join(words, " "))
+			words = []string{}
+			continue
+		}
+		words = append(words, strings.ToUpper(p.curToken.Lit))
+	}
+	if len(words) > 0 {
+		options = append(options, strings.Join(words, " "))
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return options
+}
+
+// parseTableNamesWithColumns parses a list such as: table_a (col_a, col_b), table_b
+// It starts on the first table name and leaves curToken on the last token of the list
+func (p *Parser) parseTableNamesWithColumns() ([]ast.Expression, [][]ast.Expression) {
+	tables := []ast.Expression{}
+	columns := [][]ast.Expression{}
+
+	for {
+		tables = append(tables, p.parseIdentifier())
+
+		var cols []ast.Expression
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			p.nextToken()
+			cols = p.parseExpressionList([]token.TokenType{token.RPAREN})
+		}
+		columns = append(columns, cols)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		p.nextToken()
+	}
+
+	return tables, columns
+}
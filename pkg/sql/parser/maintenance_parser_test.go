@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceStatements(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		input   string
+		output  string
+		command token.TokenType
+	}{
+		// Vacuum
+		{"vacuum;", "VACUUM;", token.VACUUM_STATEMENT},
+		{"vacuum my_table;", "VACUUM my_table;", token.VACUUM_STATEMENT},
+		{"vacuum full freeze verbose analyze my_table;", "VACUUM FULL FREEZE VERBOSE ANALYZE my_table;", token.VACUUM_STATEMENT},
+		{"vacuum (verbose, analyze, parallel 4) public.my_table, other;", "VACUUM (VERBOSE, ANALYZE, PARALLEL 4) public.my_table, other;", token.VACUUM_STATEMENT},
+		{"vacuum analyze my_table (id, name), other;", "VACUUM ANALYZE my_table (id, name), other;", token.VACUUM_STATEMENT},
+
+		// Reindex
+		{"reindex table my_table;", "REINDEX TABLE my_table;", token.REINDEX_STATEMENT},
+		{"reindex index concurrently idx_my_table_id;", "REINDEX INDEX CONCURRENTLY idx_my_table_id;", token.REINDEX_STATEMENT},
+		{"reindex (verbose) database my_db;", "REINDEX (VERBOSE) DATABASE my_db;", token.REINDEX_STATEMENT},
+		{"reindex system;", "REINDEX SYSTEM;", token.REINDEX_STATEMENT},
+
+		// Cluster
+		{"cluster;", "CLUSTER;", token.CLUSTER_STATEMENT},
+		{"cluster verbose my_table using idx_my_table_id;", "CLUSTER VERBOSE my_table USING idx_my_table_id;", token.CLUSTER_STATEMENT},
+		{"cluster idx_my_table_id on my_table;", "CLUSTER my_table USING idx_my_table_id;", token.CLUSTER_STATEMENT},
+
+		// Refresh
+		{"refresh materialized view my_view;", "REFRESH MATERIALIZED VIEW my_view;", token.REFRESH_STATEMENT},
+		{"refresh materialized view concurrently public.my_view with data;", "REFRESH MATERIALIZED VIEW CONCURRENTLY public.my_view WITH DATA;", token.REFRESH_STATEMENT},
+		{"refresh materialized view my_view with no data;", "REFRESH MATERIALIZED VIEW my_view WITH NO DATA;", token.REFRESH_STATEMENT},
+
+		// Truncate
+		{"truncate my_table;", "TRUNCATE TABLE my_table;", token.TRUNCATE_STATEMENT},
+		{"truncate table only my_table, other *;", "TRUNCATE TABLE ONLY my_table, other;", token.TRUNCATE_STATEMENT},
+		{"truncate my_table restart identity cascade;", "TRUNCATE TABLE my_table RESTART IDENTITY CASCADE;", token.TRUNCATE_STATEMENT},
+		{"TRUNCATE public.my_table CONTINUE IDENTITY RESTRICT", "TRUNCATE TABLE public.my_table CONTINUE IDENTITY RESTRICT;", token.TRUNCATE_STATEMENT},
+	}
+
+	for _, tt := range tests {
+		// fmt.Printf("\ninput:  %s\n", tt.input)
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		stmt := program.Statements[0]
+		assert.Equal(t, tt.command, stmt.Command(), "input: %s\nstmt.Command() is not %s. got=%s", tt.input, tt.command, stmt.Command())
+
+		output := program.String(maskParams)
+		assert.Equal(t, tt.output, output, "input: %s\nprogram.String() not '%s'. got=%s", tt.input, tt.output, output)
+		// fmt.Printf("output: %s\n", output)
+	}
+}
+
+func TestMaintenanceErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.TokenType
+		got      token.TokenType
+	}{
+		{"cluster idx on;", []token.TokenType{token.IDENT}, token.SEMICOLON},
+		{"cluster users using;", []token.TokenType{token.IDENT}, token.SEMICOLON},
+		{"vacuum (analyze users;", []token.TokenType{token.RPAREN}, token.SEMICOLON},
+		{"vacuum (verbose", []token.TokenType{token.RPAREN}, token.EOF},
+		{"reindex (verbose table users;", []token.TokenType{token.RPAREN}, token.SEMICOLON},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errs := p.ParseErrors()
+		if assert.Equal(t, 1, len(errs), "input: %s\nerrors: %v", tt.input, p.Errors()) {
+			assert.Equal(t, ErrUnexpectedToken, errs[0].Code, "input: %s", tt.input)
+			assert.Equal(t, tt.expected, errs[0].Expected, "input: %s", tt.input)
+			assert.Equal(t, tt.got, errs[0].Got, "input: %s", tt.input)
+		}
+		assert.Empty(t, program.Statements, "input: %s", tt.input)
+	}
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/brianbroderick/lantern/pkg/sql/ast"
 	"github.com/brianbroderick/lantern/pkg/sql/lexer"
@@ -68,7 +69,7 @@ var precedences = map[token.TokenType]int{
 	token.NOTNULL:           IS,
 	token.FROM:              FROM,
 	token.OVER:              WINDOW,
-	token.BETWEEN:           BETWEEN,
+	token.BETWEEN:           COMPARE,
 	token.IN:                COMPARE,
 	token.LIKE:              COMPARE,
 	token.ILIKE:             COMPARE,
@@ -131,6 +132,7 @@ const (
 	XIN
 	XCREATE
 	XUPDATE
+	XWINDOW
 	// XSELECTCOLUMN
 )
 
@@ -139,15 +141,14 @@ const (
 // For now, we're just passing the caller as a string in certain functions
 
 type Parser struct {
-	l            *lexer.Lexer
-	errors       []string
-	paramOffset  int
-	traceLevel   int
-	pos          lexer.Pos
-	posPeek      lexer.Pos
-	posPeekTwo   lexer.Pos
-	posPeekThree lexer.Pos
-	posPeekFour  lexer.Pos
+	l           *lexer.Lexer
+	dialect     token.Dialect
+	errors      []*ParseError
+	comments    []*ast.Comment
+	starts      []int // offsets where each statement starts, including the ones left out for errors
+	paramOffset int
+	traceLevel  int
+	statement   int // index of the statement being parsed, for error reporting
 
 	curToken       token.Token
 	peekToken      token.Token
@@ -166,8 +167,9 @@ type Parser struct {
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:       l,
+		dialect: l.Dialect(),
+		errors:  []*ParseError{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
@@ -176,6 +178,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.ESCAPESTRING, p.parseEscapeStringLiteral)
+	p.registerPrefix(token.DOLLARSTRING, p.parseDollarStringLiteral)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.NULL, p.parseNull)
@@ -197,6 +200,9 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.UPDATE, p.parseUpdateExpression)
 	p.registerPrefix(token.DELETE, p.parseDeleteExpression)
 	p.registerPrefix(token.DISTINCT, p.parseDistinct)
+	p.registerPrefix(token.ROLLUP, p.parseGroupingSetExpression)
+	p.registerPrefix(token.CUBE, p.parseGroupingSetExpression)
+	p.registerPrefix(token.GROUPING, p.parseGroupingSetExpression)
 	p.registerPrefix(token.ALL, p.parseDistinct)
 	p.registerPrefix(token.CASE, p.parseCaseExpression)
 	p.registerPrefix(token.LIKE, p.parseLikeExpression)
@@ -259,12 +265,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.LIKE, p.parseInfixExpression)
 	p.registerInfix(token.ILIKE, p.parseInfixExpression)
 	p.registerInfix(token.SIMILAR, p.parseSimilarToInfixExpression)
-	p.registerInfix(token.BETWEEN, p.parseInfixExpression)
+	p.registerInfix(token.BETWEEN, p.parseBetweenExpression)
 	p.registerInfix(token.REGEXMATCH, p.parseInfixExpression)
 	p.registerInfix(token.REGEXIMATCH, p.parseInfixExpression)
 	p.registerInfix(token.REGEXNOTMATCH, p.parseInfixExpression)
 	p.registerInfix(token.REGEXNOTIMATCH, p.parseInfixExpression)
-	p.registerInfix(token.OVER, p.parseInfixExpression)
+	p.registerInfix(token.OVER, p.parseOverExpression)
 	p.registerInfix(token.JSONGETBYKEY, p.parseInfixExpression)
 	p.registerInfix(token.JSONGETBYTEXT, p.parseInfixExpression)
 	p.registerInfix(token.JSONGETBYPATH, p.parseInfixExpression)
@@ -315,16 +321,10 @@ func (p *Parser) Input() string {
 // Multi word keywords is getting annoying... we need to think of a better way to do this.
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
-	p.pos = p.posPeek
 	p.peekToken = p.peekTwoToken
-	p.pos = p.posPeekTwo
 	p.peekTwoToken = p.peekThreeToken
-	p.posPeekTwo = p.posPeekThree
 	p.peekThreeToken = p.peekFourToken
-	p.posPeekThree = p.posPeekFour
-	newToken, pos := p.advanceToken()
-	p.peekFourToken = newToken
-	p.posPeekFour = pos
+	p.peekFourToken = p.advanceToken()
 
 	// Read into the future for AT TIME ZONE since AT isn't a reserved word (it's often used as a column or table alias)
 	if p.peekTwoToken.Type == token.IDENT {
@@ -333,44 +333,38 @@ func (p *Parser) nextToken() {
 		case "AT":
 			if p.peekThreeToken.Type == token.IDENT && p.peekThreeToken.Upper == "TIME" {
 				if p.peekFourToken.Type == token.IDENT && p.peekFourToken.Upper == "ZONE" {
-					p.peekTwoToken = token.Token{Type: token.AT_TIME_ZONE, Lit: "AT TIME ZONE"}
-					newToken, pos = p.advanceToken()
-					p.posPeekThree = pos
-					p.peekThreeToken = newToken
-					newToken, pos = p.advanceToken()
-					p.peekFourToken = newToken
-					p.posPeekFour = pos
+					p.peekTwoToken = token.Token{Type: token.AT_TIME_ZONE, Lit: "AT TIME ZONE", Pos: p.peekTwoToken.Pos, End: p.peekFourToken.End}
+					p.peekThreeToken = p.advanceToken()
+					p.peekFourToken = p.advanceToken()
 				}
 			}
 		case "GROUP":
 			if p.peekThreeToken.Type == token.BY {
-				p.peekTwoToken = token.Token{Type: token.GROUP_BY, Lit: "GROUP BY"}
+				p.peekTwoToken = token.Token{Type: token.GROUP_BY, Lit: "GROUP BY", Pos: p.peekTwoToken.Pos, End: p.peekThreeToken.End}
 				p.peekThreeToken = p.peekFourToken
-				p.posPeekThree = p.posPeekFour
-				newToken, pos = p.advanceToken()
-				p.peekFourToken = newToken
-				p.posPeekFour = pos
+				p.peekFourToken = p.advanceToken()
 			}
 		}
 	}
 
 }
 
-func (p *Parser) advanceToken() (newToken token.Token, pos lexer.Pos) {
-	newToken, pos = p.l.Scan()
-	// Skip comments
+func (p *Parser) advanceToken() token.Token {
+	newToken, _ := p.l.Scan()
+	// Comments are kept on the side, since they can appear anywhere and don't affect the parse
 	iter := 0
 	for newToken.Type == token.SQLCOMMENT {
-		newToken, pos = p.l.Scan()
+		p.addComment(newToken)
+		newToken, _ = p.l.Scan()
 		iter++
 		if iter > 50000 {
-			return token.Token{Type: token.ILLEGAL, Lit: "Infinite loop in COMMENT block"}, pos
+			return token.Token{Type: token.ILLEGAL, Lit: "Infinite loop in COMMENT block", Pos: newToken.Pos, End: newToken.End}
 		}
 	}
 	// if newToken.Type == token.SQLCOMMENT {
-	// 	newToken, pos = p.l.Scan()
+	// 	newToken, _ = p.l.Scan()
 	// }
-	return newToken, pos
+	return newToken
 }
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -455,6 +449,14 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 // }
 
 func (p *Parser) Errors() []string {
+	msgs := make([]string, 0, len(p.errors))
+	for _, e := range p.errors {
+		msgs = append(msgs, e.Error())
+	}
+	return msgs
+}
+
+func (p *Parser) ParseErrors() []*ParseError {
 	return p.errors
 }
 
@@ -462,8 +464,8 @@ func (p *Parser) PrintErrors() {
 	if len(p.errors) == 0 {
 		return
 	}
-	for _, msg := range p.errors {
-		fmt.Printf("parser error: %s\n", msg)
+	for _, e := range p.errors {
+		fmt.Printf("parser error: %s\n", e.Msg)
 	}
 }
 
@@ -476,18 +478,18 @@ func (p *Parser) PrintErrors() {
 
 // 	msg := fmt.Sprintf("expected next token to be one of %s, got %s: %s instead. current token is: %s: %s",
 // 		strings.Join(toks, ", "), p.peekToken.Type, p.peekToken.Lit, p.curToken.Type, p.curToken.Lit)
-// 	p.errors = append(p.errors, msg)
+// 	p.addError(ErrUnexpectedToken, p.peekToken, tokens, msg)
 // }
 
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s: %s instead. current token is: %s: %s",
 		t, p.peekToken.Type, p.peekToken.Lit, p.curToken.Type, p.curToken.Lit)
-	p.errors = append(p.errors, msg)
+	p.addError(ErrUnexpectedToken, p.peekToken, []token.TokenType{t}, msg)
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found at line %d char %d", t, p.pos.Line, p.pos.Char)
-	p.errors = append(p.errors, msg)
+	msg := fmt.Sprintf("no prefix parse function for %s found at line %d char %d", t, p.curToken.Pos.Line, p.curToken.Pos.Char)
+	p.addError(ErrNoPrefixParseFn, p.curToken, nil, msg)
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -496,20 +498,35 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
-	for !p.curTokenIs(token.EOF) {
+	// A statement with errors is left out of the program and the rest of it is skipped,
+	// so one bad statement doesn't discard the others in a multi-statement string
+	for p.statement = 0; !p.curTokenIs(token.EOF); p.statement++ {
+		errCount := len(p.errors)
+		p.starts = append(p.starts, p.curToken.Pos.Offset)
 		stmt := p.parseStatement()
-		program.Statements = append(program.Statements, stmt)
+		if len(p.errors) > errCount {
+			p.synchronize()
+		} else {
+			program.Statements = append(program.Statements, stmt)
+		}
 		p.paramOffset = 0 // reset this to zero so the next query will start at $1
 
 		p.nextToken()
 	}
 
+	program.Comments = p.comments
+	attachComments(program, p.starts)
+	fillSpans(program)
+
 	return program
 }
 
-func (p *Parser) parseStatement() ast.Statement {
+func (p *Parser) parseStatement() (stmt ast.Statement) {
 	defer p.untrace(p.trace("parseStatement"))
 
+	start := p.curToken.Pos
+	defer func() { p.setSpan(stmt, start) }()
+
 	p.clause = p.curToken.Type
 	p.command = p.curToken.Type
 
@@ -523,7 +540,16 @@ func (p *Parser) parseStatement() ast.Statement {
 	case token.DROP:
 		return p.parseDropStatement()
 	case token.CREATE:
-		return p.parseCreateStatement()
+		switch p.createObject() {
+		case "FUNCTION", "PROCEDURE":
+			return p.parseCreateFunctionStatement()
+		case "TRIGGER":
+			return p.parseCreateTriggerStatement()
+		default:
+			return p.parseCreateStatement()
+		}
+	case token.DO:
+		return p.parseDoStatement()
 	case token.ANALYZE:
 		return p.parseAnalyzeStatement()
 	case token.INSERT:
@@ -538,6 +564,10 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseCommitStatement()
 	case token.ROLLBACK:
 		return p.parseRollbackStatement()
+	case token.GRANT:
+		return p.parseGrantStatement()
+	case token.FETCH:
+		return p.parseFetchStatement()
 	case token.IDENT:
 		switch p.curToken.Upper {
 		case "BEGIN":
@@ -546,6 +576,34 @@ func (p *Parser) parseStatement() ast.Statement {
 			return p.parseShowStatement()
 		case "SAVEPOINT":
 			return p.parseSavepointStatement()
+		case "VACUUM":
+			return p.parseVacuumStatement()
+		case "REINDEX":
+			return p.parseReindexStatement()
+		case "CLUSTER":
+			return p.parseClusterStatement()
+		case "REFRESH":
+			return p.parseRefreshStatement()
+		case "TRUNCATE":
+			return p.parseTruncateStatement()
+		case "REVOKE":
+			return p.parseGrantStatement()
+		case "CALL":
+			return p.parseCallStatement()
+		case "PREPARE":
+			return p.parsePrepareStatement()
+		case "EXECUTE":
+			return p.parseExecuteStatement()
+		case "DEALLOCATE":
+			return p.parseDeallocateStatement()
+		case "DECLARE":
+			return p.parseDeclareCursorStatement()
+		case "CLOSE":
+			return p.parseCloseStatement()
+		case "LISTEN", "UNLISTEN":
+			return p.parseListenStatement()
+		case "NOTIFY":
+			return p.parseNotifyStatement()
 		default:
 			return p.parseExpressionStatement()
 		}
@@ -584,6 +642,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		p.nextToken()
 		p.nextToken()
 		leftExp.SetCast(p.parseDoubleColonExpression())
+		p.setSpan(leftExp, leftExp.Pos())
 	}
 
 	return leftExp
@@ -600,6 +659,8 @@ func (p *Parser) determineInfix(precedence int, leftExp ast.Expression) ast.Expr
 	// 	end = []token.TokenType{token.FROM, token.WHERE, token.GROUP_BY, token.HAVING, token.ORDER, token.LIMIT, token.OFFSET, token.FETCH, token.FOR, token.SEMICOLON, token.EOF}
 	case XUPDATE:
 		end = []token.TokenType{token.SET, token.FROM, token.WHERE, token.RETURNING, token.SEMICOLON, token.EOF}
+	case XWINDOW: // ORDER BY starts the next part of the window rather than an aggregate
+		end = []token.TokenType{token.COMMA, token.ORDER, token.ROWS, token.RPAREN}
 	default:
 		end = []token.TokenType{token.COMMA, token.WHERE, token.GROUP_BY, token.HAVING, token.ORDER, token.LIMIT, token.OFFSET, token.FETCH, token.FOR, token.SEMICOLON}
 	}
@@ -620,6 +681,10 @@ func (p *Parser) determineInfix(precedence int, leftExp ast.Expression) ast.Expr
 }
 
 func (p *Parser) peekPrecedence() int {
+	// NOT IN, NOT LIKE, NOT BETWEEN, etc bind like the operator they negate
+	if p.peekToken.Type == token.NOT && negatable[p.peekTwoToken.Type] {
+		return precedences[p.peekTwoToken.Type]
+	}
 	if p, ok := precedences[p.peekToken.Type]; ok {
 		return p
 	}
@@ -627,6 +692,9 @@ func (p *Parser) peekPrecedence() int {
 	return LOWEST
 }
 
+// negatable are the operators that NOT can come before
+var negatable = map[token.TokenType]bool{token.IN: true, token.LIKE: true, token.ILIKE: true, token.SIMILAR: true, token.BETWEEN: true}
+
 func (p *Parser) curPrecedence() int {
 	if p, ok := precedences[p.curToken.Type]; ok {
 		return p
@@ -694,7 +762,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Lit, 0, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Lit)
-		p.errors = append(p.errors, msg)
+		p.addError(ErrInvalidNumber, p.curToken, nil, msg)
 		return nil
 	}
 
@@ -726,7 +794,7 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	value, err := strconv.ParseFloat(p.curToken.Lit, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Lit)
-		p.errors = append(p.errors, msg)
+		p.addError(ErrInvalidNumber, p.curToken, nil, msg)
 		return nil
 	}
 
@@ -767,6 +835,22 @@ func (p *Parser) parseEscapeStringLiteral() ast.Expression {
 	return str
 }
 
+func (p *Parser) parseDollarStringLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseDollarStringLiteral"))
+
+	// The literal includes its delimiters, i.e. $body$ ... $body$
+	tag := p.curToken.Lit[:strings.Index(p.curToken.Lit[1:], "$")+2]
+
+	p.paramOffset++
+	str := &ast.DollarStringLiteral{Token: p.curToken, Tag: tag, Value: p.curToken.Lit[len(tag) : len(p.curToken.Lit)-len(tag)], ParamOffset: p.paramOffset, Branch: p.clause, CommandTag: p.command}
+	if p.peekTokenIs(token.DOUBLECOLON) {
+		p.nextToken()
+		p.nextToken()
+		str.SetCast(p.parseDoubleColonExpression())
+	}
+	return str
+}
+
 func (p *Parser) parseSemicolonStatement() *ast.SemicolonStatement {
 	defer p.untrace(p.trace("parseSemicolonStatement"))
 
@@ -844,6 +928,48 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return x
 }
 
+// parseBetweenExpression parses x BETWEEN low AND high. The bounds bind tighter than AND, so the AND between them
+// doesn't join the predicates around it. The bounds are the right side as (low AND high).
+func (p *Parser) parseBetweenExpression(left ast.Expression) ast.Expression {
+	defer p.untrace(p.trace("parseBetweenExpression"))
+
+	x := &ast.InfixExpression{
+		Token:      p.curToken,
+		Operator:   p.curToken.Lit,
+		Left:       left,
+		Branch:     p.clause,
+		CommandTag: p.command,
+	}
+
+	if p.not {
+		x.Not = true
+		p.not = false
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	low := p.parseExpression(precedence)
+	if low == nil {
+		return nil // the error is already reported
+	}
+	// The bounds are printed as (low AND high), so that's read back as the bounds too
+	if grouped, ok := low.(*ast.GroupedExpression); ok && !p.peekTokenIs(token.AND) && len(grouped.Elements) == 1 && grouped.Cast == nil {
+		if bounds, ok := grouped.Elements[0].(*ast.InfixExpression); ok && strings.ToUpper(bounds.Operator) == "AND" {
+			x.Right = bounds
+			return x
+		}
+	}
+	if !p.expectPeek(token.AND) {
+		return nil
+	}
+	bounds := &ast.InfixExpression{Token: p.curToken, Operator: p.curToken.Lit, Left: low, Branch: p.clause, CommandTag: p.command}
+	p.nextToken()
+	bounds.Right = p.parseExpression(precedence)
+	x.Right = bounds
+
+	return x
+}
+
 func (p *Parser) parseSimilarToInfixExpression(left ast.Expression) ast.Expression {
 	defer p.untrace(p.trace("parseInfixExpression"))
 
@@ -1111,12 +1237,29 @@ func (p *Parser) parseIntervalExpression() ast.Expression {
 	return interval
 }
 
+// registerPrefix wraps each prefix function so the expression it returns
+// spans from the token it started on to the token it finished on
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
-	p.prefixParseFns[tokenType] = fn
+	p.prefixParseFns[tokenType] = func() ast.Expression {
+		start := p.curToken.Pos
+		x := fn()
+		p.setSpan(x, start)
+		return x
+	}
 }
 
+// registerInfix wraps each infix function so the expression it returns
+// spans from the start of the left expression to the token it finished on
 func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
-	p.infixParseFns[tokenType] = fn
+	p.infixParseFns[tokenType] = func(left ast.Expression) ast.Expression {
+		start := p.curToken.Pos
+		if !isNil(left) && left.Pos().IsValid() {
+			start = left.Pos()
+		}
+		x := fn(left)
+		p.setSpan(x, start)
+		return x
+	}
 }
 
 // This allows the parser to know what context it's currently in.
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"reflect"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// setSpan sets the span of a node from start to the end of the current token.
+// Parse functions leave curToken on the last token of what they parsed, so this is the end of the node.
+func (p *Parser) setSpan(node ast.Node, start token.Pos) {
+	if isNil(node) || !start.IsValid() {
+		return
+	}
+	node.SetSpan(start, p.curToken.End)
+}
+
+// isNil checks for both a nil interface and an interface holding a nil pointer,
+// since parse functions that fail usually return a typed nil.
+func isNil(node ast.Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// fillSpans gives a span to the nodes that the parser built directly rather than through a prefix, infix,
+// or statement function. These nodes span their own token along with all of their children.
+// It returns the span of the node.
+func fillSpans(node ast.Node) (start, end token.Pos) {
+	if isNil(node) {
+		return token.NoPos, token.NoPos
+	}
+
+	var childStart, childEnd token.Pos
+	for _, child := range node.Children() {
+		s, e := fillSpans(child)
+		childStart, childEnd = widen(childStart, childEnd, s, e)
+	}
+
+	start, end = node.Pos(), node.End()
+	if !start.IsValid() {
+		start, end = childStart, childEnd
+		if f := reflect.ValueOf(node).Elem().FieldByName("Token"); f.IsValid() && f.Type() == tokenType {
+			tok := f.Interface().(token.Token)
+			start, end = widen(start, end, tok.Pos, tok.End)
+		}
+		node.SetSpan(start, end)
+	}
+
+	return start, end
+}
+
+func widen(start, end, childStart, childEnd token.Pos) (token.Pos, token.Pos) {
+	if !childStart.IsValid() {
+		return start, end
+	}
+	if !start.IsValid() || childStart.Offset < start.Offset {
+		start = childStart
+	}
+	if !end.IsValid() || childEnd.Offset > end.Offset {
+		end = childEnd
+	}
+	return start, end
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodePositions(t *testing.T) {
+	input := "select u.id, count(*) as n\nfrom users u\n  join addresses a on a.user_id = u.id\nwhere created_at at time zone 'utc' > now()\ngroup by u.id having count(*) > 1;\nvacuum users;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p, input)
+
+	text := func(n ast.Node) string {
+		return input[n.Pos().Offset:n.End().Offset]
+	}
+
+	assert.Equal(t, input, text(program))
+	assert.Equal(t, 2, len(program.Statements))
+
+	stmt := program.Statements[0].(*ast.SelectStatement)
+	assert.Equal(t, token.Pos{Offset: 0, Line: 1, Char: 1}, stmt.Pos())
+	assert.Equal(t, token.Pos{Line: 5, Char: 35}, token.Pos{Line: stmt.End().Line, Char: stmt.End().Char})
+
+	x := stmt.Expressions[0].(*ast.SelectExpression)
+	assert.Equal(t, "u.id", text(x.Columns[0]))
+	assert.Equal(t, "count(*) as n", text(x.Columns[1]))
+	assert.Equal(t, "n", text(x.Columns[1].(*ast.ColumnExpression).Name))
+	assert.Equal(t, "count(*)", text(x.Columns[1].(*ast.ColumnExpression).Value))
+
+	assert.Equal(t, "users u", text(x.Tables[0]))
+	join := x.Tables[1].(*ast.TableExpression)
+	assert.Equal(t, "addresses a on a.user_id = u.id", text(join))
+	assert.Equal(t, "a.user_id = u.id", text(join.JoinCondition))
+	assert.Equal(t, token.Pos{Offset: 62, Line: 3, Char: 23}, join.JoinCondition.Pos())
+
+	// AT TIME ZONE is merged from three tokens, so the span needs to cover all of them
+	where := x.Where.(*ast.InfixExpression)
+	assert.Equal(t, "created_at at time zone 'utc' > now()", text(where))
+	assert.Equal(t, "created_at at time zone 'utc'", text(where.Left))
+
+	assert.Equal(t, "u.id", text(x.GroupBy[0]))
+	assert.Equal(t, "count(*) > 1", text(x.Having))
+
+	assert.Equal(t, "vacuum users;", text(program.Statements[1]))
+}
+
+func TestSynthesizedNodePositions(t *testing.T) {
+	input := "select id from users order by id fetch first row only"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p, input)
+
+	x := program.Statements[0].(*ast.SelectStatement).Expressions[0].(*ast.SelectExpression)
+	fetch := x.Fetch.(*ast.FetchExpression)
+
+	// The row count of 1 is implied, so it doesn't appear in the source
+	assert.False(t, fetch.Value.Pos().IsValid())
+	assert.True(t, fetch.Pos().IsValid())
+	assert.Equal(t, "first row only", input[fetch.Pos().Offset:fetch.End().Offset])
+	assert.Equal(t, "id", input[x.OrderBy[0].Pos().Offset:x.OrderBy[0].End().Offset])
+}
+
+func TestParenthesizedClausePositions(t *testing.T) {
+	input := "select distinct on (a, b) a, b from t window w as (partition by a) order by a"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p, input)
+
+	text := func(n ast.Node) string {
+		return input[n.Pos().Offset:n.End().Offset]
+	}
+
+	x := program.Statements[0].(*ast.SelectStatement).Expressions[0].(*ast.SelectExpression)
+	assert.Equal(t, "distinct on (a, b)", text(x.Distinct))
+	assert.Equal(t, "w as (partition by a)", text(x.Window[0]))
+}
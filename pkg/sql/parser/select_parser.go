@@ -90,6 +90,10 @@ func (p *Parser) parseSelectExpression() ast.Expression {
 			p.nextToken()
 			p.nextToken()
 			p.clause = token.GROUP_BY
+			if p.curTokenIsOne([]token.TokenType{token.DISTINCT, token.ALL}) {
+				x.GroupByDistinct = p.curTokenIs(token.DISTINCT)
+				p.nextToken()
+			}
 			x.GroupBy = p.parseColumnList(defaultListSeparators)
 		}
 
@@ -121,6 +125,14 @@ func (p *Parser) parseSelectExpression() ast.Expression {
 					p.nextToken()
 					p.clause = token.LIMIT
 					x.Limit = p.parseExpression(LOWEST)
+
+					// MySQL and SQLite allow LIMIT offset, count
+					if p.dialect != token.Postgres && p.peekTokenIs(token.COMMA) {
+						p.nextToken()
+						p.nextToken()
+						x.Offset = x.Limit
+						x.Limit = p.parseExpression(LOWEST)
+					}
 				}
 
 				// OFFSET CLAUSE
@@ -182,6 +194,7 @@ func (p *Parser) parseDistinct() ast.Expression {
 				p.nextToken()
 				x.Right = p.parseExpressionList([]token.TokenType{token.RPAREN})
 				if p.curTokenIs(token.RPAREN) {
+					p.setSpan(x, x.Token.Pos)
 					p.nextToken()
 				}
 			}
@@ -204,7 +217,7 @@ func (p *Parser) parseTables() ([]ast.Expression, map[string]string) {
 		aliasMap[alias] = table
 	}
 
-	for p.peekTokenIsOne([]token.TokenType{token.JOIN, token.INNER, token.LEFT, token.RIGHT, token.FULL, token.CROSS, token.LATERAL, token.COMMA}) {
+	for p.peekTokenIsOne([]token.TokenType{token.JOIN, token.NATURAL, token.INNER, token.LEFT, token.RIGHT, token.FULL, token.CROSS, token.LATERAL, token.COMMA}) {
 		nextTable, table, alias := p.parseTable()
 		x = append(x, nextTable)
 		if alias != "" && table != "" {
@@ -218,12 +231,43 @@ func (p *Parser) parseTables() ([]ast.Expression, map[string]string) {
 // parseFirstTable will leave curToken on the last token of the table (name or alias)
 func (p *Parser) parseFirstTable() (ast.Expression, string, string) {
 	defer p.untrace(p.trace("parseFirstTable"))
-	var table string
-	var alias string
 
 	x := &ast.TableExpression{Token: token.Token{Type: token.FROM}, Branch: p.clause, CommandTag: p.command}
+	start := p.curToken.Pos
+
+	if p.curTokenIs(token.LATERAL) {
+		x.Lateral = true
+		p.nextToken()
+	}
+
+	table, alias := p.parseTableSource(x)
+	p.setSpan(x, start)
 
-	x.Table = p.parseExpression(LOWEST)
+	// fmt.Printf("parseFirstTable2: %s :: %s == %+v\n", p.curToken.Lit, p.peekToken.Lit, table)
+
+	return x, table, alias
+}
+
+// parseTableSource starts on the first token of the table, subquery, or function and leaves curToken on the last token of the alias.
+// It returns the table name and alias when the source is a real relation so that the alias can be resolved.
+func (p *Parser) parseTableSource(x *ast.TableExpression) (string, string) {
+	defer p.untrace(p.trace("parseTableSource"))
+	var table string
+	var alias string
+
+	if p.curTokenIs(token.ROWS) && p.peekTokenIs(token.FROM) {
+		p.nextToken()
+		if !p.expectPeek(token.LPAREN) {
+			return "", ""
+		}
+		p.nextToken()
+		x.Kind = ast.TableKindRowsFrom
+		x.Functions = p.parseExpressionList([]token.TokenType{token.RPAREN})
+	} else {
+		// USING after the table is the join's columns rather than an operator
+		x.Table = p.parseExpression(USING)
+		x.Kind = tableKind(x.Table)
+	}
 
 	if p.peekTokenIs(token.WITH) {
 		p.nextToken()
@@ -237,11 +281,16 @@ func (p *Parser) parseFirstTable() (ast.Expression, string, string) {
 		p.nextToken()
 	}
 
-	// Do we have an alias
+	// Do we have an alias?
 	// if p.peekTokenIsOne([]token.TokenType{token.IDENT, token.AT}) {
 	if p.peekTokenIsOne([]token.TokenType{token.IDENT, token.SET, token.LAST}) {
 		p.nextToken()
-		x.Alias = p.parseExpression(LOWEST)
+		x.Alias = p.parseIdentifier()
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			x.ColumnAliases = p.parseColumnAliases()
+		}
 
 		switch tbl := x.Table.(type) {
 		case *ast.Identifier:
@@ -254,21 +303,64 @@ func (p *Parser) parseFirstTable() (ast.Expression, string, string) {
 		}
 	}
 
-	// fmt.Printf("parseFirstTable2: %s :: %s == %+v\n", p.curToken.Lit, p.peekToken.Lit, table)
+	return table, alias
+}
 
-	return x, table, alias
+// tableKind determines what kind of source is in a FROM clause
+func tableKind(table ast.Expression) string {
+	switch table.(type) {
+	case *ast.Identifier, *ast.SimpleIdentifier:
+		return ast.TableKindRelation
+	case *ast.CallExpression:
+		return ast.TableKindFunction
+	case *ast.SelectExpression, *ast.UnionExpression, *ast.CTEExpression, *ast.GroupedExpression:
+		return ast.TableKindSubquery
+	}
+	return ""
+}
+
+// parseColumnAliases starts on the LPAREN and leaves curToken on the RPAREN
+// Columns may include a type for functions that return record: AS x(a int, b text)
+func (p *Parser) parseColumnAliases() []*ast.ColumnAlias {
+	defer p.untrace(p.trace("parseColumnAliases"))
+
+	columns := []*ast.ColumnAlias{}
+
+	for !p.peekTokenIsOne([]token.TokenType{token.RPAREN, token.EOF}) {
+		p.nextToken()
+		column := &ast.ColumnAlias{Name: p.curToken.Lit}
+
+		if !p.peekTokenIsOne([]token.TokenType{token.COMMA, token.RPAREN}) {
+			p.nextToken()
+			column.Type = renderTokens(p.collectTokens(func() bool { return p.peekTokenIs(token.COMMA) }))
+		}
+		columns = append(columns, column)
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return columns
 }
 
 func (p *Parser) parseTable() (ast.Expression, string, string) {
 	defer p.untrace(p.trace("parseTable"))
-	var table string
-	var alias string
 
 	p.clause = token.FROM
 	x := &ast.TableExpression{Token: token.Token{Type: token.FROM}, Branch: p.clause, CommandTag: p.command}
 
+	if p.peekTokenIs(token.NATURAL) {
+		p.nextToken()
+		x.Natural = true
+	}
+
 	// Get the join type
-	if p.peekTokenIsOne([]token.TokenType{token.INNER, token.LEFT, token.RIGHT, token.FULL, token.CROSS, token.LATERAL}) {
+	if p.peekTokenIsOne([]token.TokenType{token.INNER, token.LEFT, token.RIGHT, token.FULL, token.CROSS}) {
 		p.nextToken()
 		x.JoinType = p.curToken.Upper
 
@@ -292,11 +384,6 @@ func (p *Parser) parseTable() (ast.Expression, string, string) {
 		}
 	}
 
-	if p.peekTokenIs(token.LATERAL) {
-		p.nextToken()
-		x.JoinType = x.JoinType + " LATERAL"
-	}
-
 	if p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		if x.JoinType == "" {
@@ -304,39 +391,20 @@ func (p *Parser) parseTable() (ast.Expression, string, string) {
 		}
 	}
 
-	p.nextToken()
-
-	// Get the table name
-	x.Table = p.parseExpression(LOWEST)
-
-	if p.peekTokenIs(token.WITH) {
+	// The span starts at the table rather than the join type
+	start := token.NoPos
+	if p.peekTokenIs(token.LATERAL) {
 		p.nextToken()
-		if p.peekTokenIs(token.ORDINALITY) {
-			p.nextToken()
-			x.Ordinality = true
-		}
+		x.Lateral = true
+		start = p.curToken.Pos
 	}
 
-	if p.peekTokenIs(token.AS) {
-		p.nextToken()
+	p.nextToken()
+	if !start.IsValid() {
+		start = p.curToken.Pos
 	}
 
-	// Do we have an alias?
-	// if p.peekTokenIsOne([]token.TokenType{token.IDENT, token.AT}) {
-	if p.peekTokenIsOne([]token.TokenType{token.IDENT, token.SET, token.LAST}) {
-		p.nextToken()
-		x.Alias = p.parseExpression(LOWEST)
-
-		switch tbl := x.Table.(type) {
-		case *ast.Identifier:
-			table = tbl.String(false)
-		}
-
-		switch a := x.Alias.(type) {
-		case *ast.Identifier:
-			alias = a.String(false)
-		}
-	}
+	table, alias := p.parseTableSource(x)
 
 	// Get the join condition, but skip past the ON keyword
 	if p.peekTokenIs(token.ON) {
@@ -344,11 +412,41 @@ func (p *Parser) parseTable() (ast.Expression, string, string) {
 		p.nextToken()
 		p.clause = token.ON
 		x.JoinCondition = p.parseExpression(LOWEST)
+	} else if p.peekTokenIs(token.USING) {
+		p.nextToken()
+		p.clause = token.ON
+		x.Using = p.parseJoinUsing()
 	}
+	p.setSpan(x, start)
 
 	return x, table, alias
 }
 
+// parseJoinUsing parses the columns of JOIN ... USING (a, b). The current token is USING, and it ends on the closing parenthesis.
+func (p *Parser) parseJoinUsing() []*ast.SimpleIdentifier {
+	defer p.untrace(p.trace("parseJoinUsing"))
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	columns := []*ast.SimpleIdentifier{}
+	for !p.peekTokenIs(token.RPAREN) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		if p.curTokenIs(token.COMMA) {
+			continue
+		}
+		column := &ast.SimpleIdentifier{Token: p.curToken, Value: p.curToken.Lit, Branch: p.clause, CommandTag: p.command}
+		column.SetSpan(p.curToken.Pos, p.curToken.End)
+		columns = append(columns, column)
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return columns
+}
+
 func (p *Parser) parseFetch() ast.Expression {
 	defer p.untrace(p.trace("parseFetch"))
 
@@ -356,6 +454,7 @@ func (p *Parser) parseFetch() ast.Expression {
 		p.nextToken()
 	}
 
+	start := p.curToken.Pos
 	x := &ast.FetchExpression{Token: p.curToken,
 		Value:  &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Lit: "1"}, Value: 1, ParamOffset: p.paramOffset, Branch: p.clause, CommandTag: p.command},
 		Option: token.Token{Type: token.NIL, Lit: ""}, Branch: p.clause, CommandTag: p.command}
@@ -374,17 +473,21 @@ func (p *Parser) parseFetch() ast.Expression {
 			ParamOffset: p.paramOffset, Branch: p.clause, CommandTag: p.command}
 	}
 
+	// parseFetch ends one token past the clause, so the span is set as each trailing token is consumed
 	if p.curTokenIsOne([]token.TokenType{token.ROW, token.ROWS}) {
+		p.setSpan(x, start)
 		p.nextToken()
 	}
 
 	if p.curTokenIs(token.ONLY) {
 		x.Option = p.curToken
+		p.setSpan(x, start)
 		p.nextToken()
 	} else if p.curTokenIs(token.WITH) {
 		if p.peekTokenIs(token.TIES) {
 			p.nextToken()
 			x.Option = p.curToken
+			p.setSpan(x, start)
 			p.nextToken()
 		}
 	}
@@ -414,6 +517,7 @@ func (p *Parser) parseSortList(end []token.TokenType) []ast.Expression {
 func (p *Parser) parseSort(precedence int) ast.Expression {
 	defer p.untrace(p.trace("parseSort"))
 
+	start := p.curToken.Pos
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
@@ -446,6 +550,7 @@ func (p *Parser) parseSort(precedence int) ast.Expression {
 			x.Nulls = p.curToken
 		}
 	}
+	p.setSpan(x, start)
 
 	return x
 }
@@ -489,12 +594,15 @@ func (p *Parser) parseWindow() ast.Expression {
 		}
 	}
 
-	window := p.parseWindowExpression()
-	x.PartitionBy = window.(*ast.WindowExpression).PartitionBy
-	x.OrderBy = window.(*ast.WindowExpression).OrderBy
+	window := p.parseWindowExpression().(*ast.WindowExpression)
+	x.ExistingWindow = window.ExistingWindow
+	x.PartitionBy = window.PartitionBy
+	x.OrderBy = window.OrderBy
+	x.Frame = window.Frame
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
+		p.setSpan(x, x.Token.Pos)
 	}
 
 	return x
@@ -525,6 +633,7 @@ func (p *Parser) parseColumn(precedence int) ast.Expression {
 
 	// fmt.Println("parseColumn: ", p.curToken.Lit)
 
+	start := p.curToken.Pos
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
@@ -558,21 +667,42 @@ func (p *Parser) parseColumn(precedence int) ast.Expression {
 		p.nextToken()
 
 		alias := &ast.SimpleIdentifier{Token: p.curToken, Value: p.curToken.Lit, Branch: p.clause, CommandTag: p.command}
+		p.setSpan(alias, p.curToken.Pos)
 		x = &ast.ColumnExpression{Token: p.curToken, Value: leftExp, Name: alias, Branch: p.clause, CommandTag: p.command}
 	}
+	p.setSpan(x, start)
 
 	return x
 }
 
+// parseWindowExpression starts on the first token of the window definition and leaves curToken on its last token.
+// [ existing_window_name ] [ PARTITION BY ... ] [ ORDER BY ... ] [ frame_clause ]
 func (p *Parser) parseWindowExpression() ast.Expression {
 	defer p.untrace(p.trace("parseWindowExpression"))
 
+	context := p.context
+	p.setContext(XWINDOW)         // sets the context for the determineInfix function
+	defer p.resetContext(context) // reset to prior context
+
 	x := &ast.WindowExpression{Token: p.curToken, Branch: p.clause, CommandTag: p.command}
 
+	if p.curTokenIs(token.IDENT) && !p.isWindowFrame() {
+		x.ExistingWindow = &ast.SimpleIdentifier{Token: p.curToken, Value: p.curToken.Lit, Branch: p.clause, CommandTag: p.command}
+		if !p.peekTokenIsOne([]token.TokenType{token.PARTITION, token.ORDER, token.ROWS, token.IDENT}) {
+			return x
+		}
+		p.nextToken()
+	}
+
 	if p.curTokenIs(token.PARTITION) {
 		if p.expectPeek(token.BY) {
 			p.nextToken()
-			x.PartitionBy = p.parseColumnList([]token.TokenType{token.COMMA, token.ORDER, token.AS, token.RPAREN})
+			x.PartitionBy = append(x.PartitionBy, p.parseExpression(LOWEST))
+			for p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+				p.nextToken()
+				x.PartitionBy = append(x.PartitionBy, p.parseExpression(LOWEST))
+			}
 		}
 	}
 	if p.peekTokenIs(token.ORDER) {
@@ -586,6 +716,151 @@ func (p *Parser) parseWindowExpression() ast.Expression {
 		}
 	}
 
+	if !p.isWindowFrame() {
+		if p.peekTokenIs(token.ROWS) || (p.peekTokenIs(token.IDENT) && (p.peekToken.Upper == "RANGE" || p.peekToken.Upper == "GROUPS")) {
+			p.nextToken()
+		}
+	}
+	if p.isWindowFrame() {
+		x.Frame = p.parseWindowFrame()
+	}
+
+	return x
+}
+
+// isWindowFrame is true when curToken starts a frame clause. RANGE and GROUPS aren't reserved words,
+// so they're only a frame when followed by the start of a frame bound
+func (p *Parser) isWindowFrame() bool {
+	if p.curTokenIs(token.ROWS) {
+		return true
+	}
+	if p.curTokenIs(token.IDENT) && (p.curToken.Upper == "RANGE" || p.curToken.Upper == "GROUPS") {
+		return !p.peekTokenIsOne([]token.TokenType{token.RPAREN, token.PARTITION, token.ORDER, token.ROWS, token.COMMA})
+	}
+	return false
+}
+
+// parseWindowFrame starts on ROWS, RANGE, or GROUPS and leaves curToken on the last token of the frame
+func (p *Parser) parseWindowFrame() *ast.WindowFrame {
+	defer p.untrace(p.trace("parseWindowFrame"))
+
+	x := &ast.WindowFrame{Mode: p.curToken.Upper}
+	p.nextToken()
+
+	if p.curTokenIs(token.BETWEEN) {
+		p.nextToken()
+		x.Start = p.parseFrameBound()
+		if !p.expectPeek(token.AND) {
+			return nil
+		}
+		p.nextToken()
+		x.End = p.parseFrameBound()
+	} else {
+		x.Start = p.parseFrameBound()
+	}
+
+	if p.peekTokenIs(token.EXCLUDE) {
+		p.nextToken()
+		p.nextToken()
+		switch {
+		case p.curTokenIs(token.CURRENT) && p.peekTokenIs(token.ROW):
+			p.nextToken()
+			x.Exclude = "CURRENT ROW"
+		case p.curTokenIs(token.NO) && p.peekTokenIs(token.OTHERS):
+			p.nextToken()
+			x.Exclude = "NO OTHERS"
+		default:
+			// GROUP or TIES
+			x.Exclude = p.curToken.Upper
+		}
+	}
+
+	return x
+}
+
+// parseFrameBound starts on the first token of the bound and leaves curToken on PRECEDING, FOLLOWING, or ROW
+func (p *Parser) parseFrameBound() *ast.FrameBound {
+	defer p.untrace(p.trace("parseFrameBound"))
+
+	x := &ast.FrameBound{}
+
+	switch {
+	case p.curTokenIs(token.UNBOUNDED):
+		p.nextToken()
+		x.Direction = "UNBOUNDED " + p.curToken.Upper
+	case p.curTokenIs(token.CURRENT) && p.peekTokenIs(token.ROW):
+		p.nextToken()
+		x.Direction = "CURRENT ROW"
+	default:
+		x.Offset = p.parseExpression(LOWEST)
+		if !p.peekTokenIsOne([]token.TokenType{token.PRECEDING, token.FOLLOWING}) {
+			p.peekError(token.PRECEDING)
+			return x
+		}
+		p.nextToken()
+		x.Direction = p.curToken.Upper
+	}
+
+	return x
+}
+
+// parseOverExpression handles the OVER in a window function call: sum(amount) OVER (PARTITION BY id ROWS UNBOUNDED PRECEDING)
+// The window can also be referenced by name: sum(amount) OVER w
+func (p *Parser) parseOverExpression(left ast.Expression) ast.Expression {
+	defer p.untrace(p.trace("parseOverExpression"))
+
+	x := &ast.InfixExpression{
+		Token:      p.curToken,
+		Operator:   p.curToken.Lit,
+		Left:       left,
+		Branch:     p.clause,
+		CommandTag: p.command,
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+
+	// A reference to a window in the WINDOW clause is a name rather than a column
+	if p.curTokenIs(token.IDENT) {
+		x.Right = &ast.SimpleIdentifier{Token: p.curToken, Value: p.curToken.Lit, Branch: p.clause, CommandTag: p.command}
+		return x
+	}
+
+	if !p.curTokenIs(token.LPAREN) {
+		x.Right = p.parseExpression(precedence)
+		return x
+	}
+
+	p.nextToken()
+	x.Right = p.parseWindowExpression()
+	if !p.curTokenIs(token.RPAREN) && !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return x
+}
+
+// parseGroupingSetExpression handles ROLLUP ( ... ), CUBE ( ... ), and GROUPING SETS ( ... )
+// GROUPING is also a function, i.e. HAVING grouping(a) = 0, so it's treated as an identifier when not followed by SETS
+func (p *Parser) parseGroupingSetExpression() ast.Expression {
+	defer p.untrace(p.trace("parseGroupingSetExpression"))
+
+	x := &ast.GroupingSetExpression{Token: p.curToken, Type: p.curToken.Upper, Branch: p.clause, CommandTag: p.command}
+
+	if p.curTokenIs(token.GROUPING) {
+		if p.peekToken.Upper != "SETS" {
+			return p.parseIdentifier()
+		}
+		p.nextToken()
+		x.Type = "GROUPING SETS"
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	x.Sets = p.parseExpressionList([]token.TokenType{token.RPAREN})
+
 	return x
 }
 
@@ -644,11 +919,16 @@ func (p *Parser) parseLock() ast.Expression {
 	return x
 }
 
-// TODO handle other NOT expressions (NOT IN, NOT LIKE, NOT ILIKE, NOT BETWEEN)
+// parseNotExpression parses NOT IN, NOT LIKE, NOT ILIKE, NOT SIMILAR TO, and NOT BETWEEN as the operator they negate.
+// Only that operator is parsed, so the predicates after it are joined by the expression around it.
 func (p *Parser) parseNotExpression(x ast.Expression) ast.Expression {
 	defer p.untrace(p.trace("parseNotExpression"))
 
 	p.not = true // sets the next expression to be a NOT expression
+	if infix := p.infixParseFns[p.peekToken.Type]; infix != nil && negatable[p.peekToken.Type] {
+		p.nextToken()
+		return infix(x)
+	}
 	return p.determineInfix(LOWEST, x)
 }
 
@@ -92,6 +92,17 @@ func TestSingleSelectStatements(t *testing.T) {
 		{"select wf1() over w, wf2() over w from table_name window w as (partition by c1 order by c2);", "(SELECT (wf1() OVER w), (wf2() OVER w) FROM table_name WINDOW w AS (PARTITION BY c1 ORDER BY c2));"},
 		{"select wf1() over w, wf2() over w from table_name window w as (partition by c1 order by c2), foo as (partition by c3 order by c4);", "(SELECT (wf1() OVER w), (wf2() OVER w) FROM table_name WINDOW w AS (PARTITION BY c1 ORDER BY c2), foo AS (PARTITION BY c3 ORDER BY c4));"},
 
+		// Select: window frames
+		{"select sum(x) over (order by y rows between unbounded preceding and current row) from t;", "(SELECT (sum(x) OVER (ORDER BY y ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)) FROM t);"},
+		{"select sum(x) over (partition by z order by y range between interval '1 day' preceding and current row exclude ties) from t;", "(SELECT (sum(x) OVER (PARTITION BY z ORDER BY y RANGE BETWEEN INTERVAL '1 day' PRECEDING AND CURRENT ROW EXCLUDE TIES)) FROM t);"},
+		{"select sum(x) over (order by y groups 2 preceding exclude no others) from t;", "(SELECT (sum(x) OVER (ORDER BY y GROUPS 2 PRECEDING EXCLUDE NO OTHERS)) FROM t);"},
+		{"select sum(x) over (rows between current row and unbounded following exclude group) from t;", "(SELECT (sum(x) OVER (ROWS BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING EXCLUDE GROUP)) FROM t);"},
+		{"select sum(x) over (w rows unbounded preceding) from t window w as (partition by z);", "(SELECT (sum(x) OVER (w ROWS UNBOUNDED PRECEDING)) FROM t WINDOW w AS (PARTITION BY z));"},
+		{"select sum(x) over w from t window w as (order by y rows between 1 preceding and 1 following exclude current row);", "(SELECT (sum(x) OVER w) FROM t WINDOW w AS (ORDER BY y ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING EXCLUDE CURRENT ROW));"},
+		{"select sum(x) over w2 from t window w1 as (partition by z), w2 as (w1 order by y);", "(SELECT (sum(x) OVER w2) FROM t WINDOW w1 AS (PARTITION BY z), w2 AS (w1 ORDER BY y));"},
+		{"select coalesce(sum(x) over (partition by z order by y), 0) from t;", "(SELECT coalesce((sum(x) OVER (PARTITION BY z ORDER BY y)), 0) FROM t);"},
+		{"select count(*) over () from t;", "(SELECT (count(*) OVER ()) FROM t);"},
+
 		// Select: joins
 		{"select c.id from customers c join addresses a on c.id = a.customer_id;", "(SELECT c.id FROM customers c INNER JOIN addresses a ON (c.id = a.customer_id));"},
 		{"select c.id from customers c join addresses a on (c.id = a.customer_id) join states s on (s.id = a.state_id);", "(SELECT c.id FROM customers c INNER JOIN addresses a ON (c.id = a.customer_id) INNER JOIN states s ON (s.id = a.state_id));"},
@@ -135,6 +146,14 @@ func TestSingleSelectStatements(t *testing.T) {
 		{"select baz from sales group by bar;", "(SELECT baz FROM sales GROUP BY bar);"},
 		{"select id from users group by id, name;", "(SELECT id FROM users GROUP BY id, name);"},
 
+		// Select: grouping sets
+		{"select a, b, sum(c) from t group by rollup(a, b);", "(SELECT a, b, sum(c) FROM t GROUP BY ROLLUP(a, b));"},
+		{"select a, b, sum(c) from t group by cube(a, (b, c));", "(SELECT a, b, sum(c) FROM t GROUP BY CUBE(a, (b, c)));"},
+		{"select a, b, sum(c) from t group by grouping sets ((a, b), (a), ());", "(SELECT a, b, sum(c) FROM t GROUP BY GROUPING SETS((a, b), (a), ()));"},
+		{"select a, sum(c) from t group by a, rollup(b, c) having grouping(a) = 0;", "(SELECT a, sum(c) FROM t GROUP BY a, ROLLUP(b, c) HAVING (grouping(a) = 0));"},
+		{"select a from t group by grouping sets (rollup(a, b), cube(c));", "(SELECT a FROM t GROUP BY GROUPING SETS(ROLLUP(a, b), CUBE(c)));"},
+		{"select a from t group by distinct rollup(a, b), rollup(a, c);", "(SELECT a FROM t GROUP BY DISTINCT ROLLUP(a, b), ROLLUP(a, c));"},
+
 		// Select: combined clauses
 		{"select id from users where id = 42 group by id, name", "(SELECT id FROM users WHERE (id = 42) GROUP BY id, name);"},
 		{"select id from customers join addresses on id = customer_id where id = 46 group by id;", "(SELECT id FROM customers INNER JOIN addresses ON (id = customer_id) WHERE (id = 46) GROUP BY id);"},
@@ -199,6 +218,10 @@ func TestSingleSelectStatements(t *testing.T) {
 		{"select id from users where rownum between 1 and sample_size", "(SELECT id FROM users WHERE (rownum BETWEEN (1 AND sample_size)));"},         // BETWEEN
 		{"select id from users where rownum not between 1 and sample_size", "(SELECT id FROM users WHERE (rownum NOT BETWEEN (1 AND sample_size)));"}, // BETWEEN
 		{"select if from users where rownum between 1 and sample_size group by property_id;", "(SELECT if FROM users WHERE (rownum BETWEEN (1 AND sample_size)) GROUP BY property_id);"},
+		{"select id from users where a = 1 and b between 2 and 3 and c = 4;", "(SELECT id FROM users WHERE (((a = 1) AND (b BETWEEN (2 AND 3))) AND (c = 4)));"},
+		{"select id from users where a = 1 and b not between 2 + 1 and 3 or c = 4;", "(SELECT id FROM users WHERE (((a = 1) AND (b NOT BETWEEN ((2 + 1) AND 3))) OR (c = 4)));"},
+		{"select id from users where a = 1 and b not in (2, 3) and c = 4;", "(SELECT id FROM users WHERE (((a = 1) AND b NOT IN (2, 3)) AND (c = 4)));"},
+		{"select id from users where a = 1 and b not like 'x%' and c = 4;", "(SELECT id FROM users WHERE (((a = 1) AND (b NOT LIKE 'x%')) AND (c = 4)));"},
 		{"select * from mytable where mycolumn ~ 'regexp';", "(SELECT * FROM mytable WHERE (mycolumn ~ 'regexp'));"},     // basic regex (case sensitive)
 		{"select * from mytable where mycolumn ~* 'regexp';", "(SELECT * FROM mytable WHERE (mycolumn ~* 'regexp'));"},   // basic regex (case insensitive)
 		{"select * from mytable where mycolumn !~ 'regexp';", "(SELECT * FROM mytable WHERE (mycolumn !~ 'regexp'));"},   // basic not regex (case sensitive)
@@ -291,6 +314,28 @@ func TestSingleSelectStatements(t *testing.T) {
 		{"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality as t(key, index);", "(SELECT * FROM unnest(array[4, 2, 1, 3, 7]) WITH ORDINALITY t(key, index));"},
 		{"select * from unnest(array [ 4, 2, 1, 3, 7 ]) with ordinality t(key, index);", "(SELECT * FROM unnest(array[4, 2, 1, 3, 7]) WITH ORDINALITY t(key, index));"},
 
+		// Select: Table functions and LATERAL
+		{"select * from jsonb_to_recordset('[{\"a\":1}]') as x(a int, b text);", "(SELECT * FROM jsonb_to_recordset('[{\"a\":1}]') x(a int, b text));"},
+		{"select * from json_to_recordset(j) as x(a numeric(10, 2), b text[]);", "(SELECT * FROM json_to_recordset(j) x(a numeric(10, 2), b text[]));"},
+		{"select * from rows from (generate_series(1,3), unnest(array['a'])) with ordinality as t(n, s, o);", "(SELECT * FROM ROWS FROM (generate_series(1, 3), unnest(array['a'])) WITH ORDINALITY t(n, s, o));"},
+		{"select * from users u, lateral (select * from orders o where o.user_id = u.id) x;", "(SELECT * FROM users u , LATERAL (SELECT * FROM orders o WHERE (o.user_id = u.id)) x);"},
+		{"select * from users u left join lateral (select id from orders) x on true;", "(SELECT * FROM users u LEFT JOIN LATERAL (SELECT id FROM orders) x ON TRUE);"},
+		{"select * from users u cross join lateral unnest(u.tags) as tag;", "(SELECT * FROM users u CROSS JOIN LATERAL unnest(u.tags) tag);"},
+		{"select * from users as u(i, n);", "(SELECT * FROM users u(i, n));"},
+		{"select * from orders o join customers c using (customer_id) where c.active;", "(SELECT * FROM orders o INNER JOIN customers (c USING customer_id) WHERE c.active);"},
+		{"select * from users u join lateral (select user_id from orders) x using (user_id);", "(SELECT * FROM users u INNER JOIN LATERAL (SELECT user_id FROM orders) (x USING user_id));"},
+
+		// Select: USING and NATURAL joins. USING prints the way it did when it was parsed as an operator on the word before it,
+		// which is the alias when the table has one.
+		{"select * from orders join order_items using (order_id);", "(SELECT * FROM orders INNER JOIN (order_items USING order_id));"},
+		{"select * from a join b using (id) join c using (id, z);", "(SELECT * FROM a INNER JOIN (b USING id) INNER JOIN (c USING (id, z)));"},
+		{"select * from orders o join customers c using (customer_id);", "(SELECT * FROM orders o INNER JOIN customers (c USING customer_id));"},
+		{"select * from orders o join customers as c using (customer_id);", "(SELECT * FROM orders o INNER JOIN customers (c USING customer_id));"},
+		{"select * from a left join b x using (id, region) where x.z = 1;", "(SELECT * FROM a LEFT JOIN b (x USING (id, region)) WHERE (x.z = 1));"},
+		{"select * from a join (select id from b) x using (id);", "(SELECT * FROM a INNER JOIN (SELECT id FROM b) (x USING id));"},
+		{"select * from a natural join b;", "(SELECT * FROM a NATURAL INNER JOIN b);"},
+		{"select * from a natural left join b natural full outer join c;", "(SELECT * FROM a NATURAL LEFT JOIN b NATURAL FULL JOIN c);"},
+
 		// Select: reserved words
 		{"select id from users where any(type_ids) = 10;", "(SELECT id FROM users WHERE (any(type_ids) = 10));"},               // any
 		{"select null::integer AS id from users;", "(SELECT NULL::INTEGER AS id FROM users);"},                                 // null
@@ -427,6 +472,52 @@ func TestSubSelects(t *testing.T) {
 	}
 }
 
+func TestTableExpressionKinds(t *testing.T) {
+	tests := []struct {
+		input   string
+		kinds   []string
+		lateral []bool
+		columns []string
+	}{
+		{"select * from users u;", []string{ast.TableKindRelation}, []bool{false}, []string{""}},
+		{"select * from (select 1) as s(a);", []string{ast.TableKindSubquery}, []bool{false}, []string{"a"}},
+		{"select * from generate_series(1, 10) g;", []string{ast.TableKindFunction}, []bool{false}, []string{""}},
+		{"select * from unnest(array[1, 2]) with ordinality as t(a, b);", []string{ast.TableKindFunction}, []bool{false}, []string{"a, b"}},
+		{"select * from jsonb_to_recordset(j) as x(a int, b text);", []string{ast.TableKindFunction}, []bool{false}, []string{"a int, b text"}},
+		{"select * from rows from (generate_series(1, 3), unnest(array['a'])) as t(n, s);", []string{ast.TableKindRowsFrom}, []bool{false}, []string{"n, s"}},
+		{"select * from users u, lateral (select * from orders o where o.user_id = u.id) x;",
+			[]string{ast.TableKindRelation, ast.TableKindSubquery}, []bool{false, true}, []string{"", ""}},
+		{"select * from users u cross join lateral unnest(u.tags) as t(tag);",
+			[]string{ast.TableKindRelation, ast.TableKindFunction}, []bool{false, true}, []string{"", "tag"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		selectStmt := program.Statements[0].(*ast.SelectStatement)
+		selectExp := selectStmt.Expressions[0].(*ast.SelectExpression)
+		assert.Equal(t, len(tt.kinds), len(selectExp.Tables), "input: %s\nnumber of tables not equal", tt.input)
+
+		for i, table := range selectExp.Tables {
+			te, ok := table.(*ast.TableExpression)
+			if !assert.True(t, ok, "input: %s\ntable is not *ast.TableExpression. got=%T", tt.input, table) {
+				continue
+			}
+			assert.Equal(t, tt.kinds[i], te.Kind, "input: %s\nkind not equal", tt.input)
+			assert.Equal(t, tt.lateral[i], te.Lateral, "input: %s\nlateral not equal", tt.input)
+
+			columns := []string{}
+			for _, c := range te.ColumnAliases {
+				columns = append(columns, c.String())
+			}
+			assert.Equal(t, tt.columns[i], strings.Join(columns, ", "), "input: %s\ncolumn aliases not equal", tt.input)
+		}
+	}
+}
+
 // TestMaskParams tests that the maskParams flag works as expected.
 // The purpose of masking parameters is to make it easier to total up the number of the same query.
 // It is not intended to be used to convert into a prepared statement.
@@ -572,3 +663,69 @@ func TestExpressionStatements(t *testing.T) {
 		assert.Equal(t, tt.output, output, "input: %s\n\noutput: %s\n\nprogram.String() not '%s'. got=%s", tt.input, tt.output, output)
 	}
 }
+
+// The BETWEEN and NOT operators were parsed with too low a precedence, which took in the predicates around them.
+// Predicates that were parsed correctly before are printed the same, so their fingerprints don't change.
+func TestBetweenFingerprints(t *testing.T) {
+	maskParams := true
+
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"select id from users where a between 1 and 2", "(SELECT id FROM users WHERE (a BETWEEN (? AND ?)));"},
+		{"select id from users where a not between 1 and 2", "(SELECT id FROM users WHERE (a NOT BETWEEN (? AND ?)));"},
+		{"select id from users where a between 1 + 1 and 2 * 3", "(SELECT id FROM users WHERE (a BETWEEN ((? + ?) AND (? * ?))));"},
+		{"select id from users where a between now() - interval '1 day' and now()", "(SELECT id FROM users WHERE (a BETWEEN ((now() - INTERVAL '?') AND now())));"},
+		{"select id from users where a between $1 and $2", "(SELECT id FROM users WHERE (a BETWEEN (? AND ?)));"},
+		{"select id from users where a + 1 between 1 and 2", "(SELECT id FROM users WHERE ((a + ?) BETWEEN (? AND ?)));"},
+		{"select id from users where not a between 1 and 2", "(SELECT id FROM users WHERE ((NOT a) BETWEEN (? AND ?)));"},
+		{"select a between 1 and 2 as x from users", "(SELECT (a BETWEEN (? AND ?)) AS x FROM users);"},
+		{"select id from users where a::date between '2020-01-01' and '2020-02-01'", "(SELECT id FROM users WHERE (a::DATE BETWEEN ('?' AND '?')));"},
+		{"select id from users where a between (select 1) and 2", "(SELECT id FROM users WHERE (a BETWEEN ((SELECT ?) AND ?)));"},
+		{"select id from users where a not in (1, 2)", "(SELECT id FROM users WHERE a NOT IN (?));"},
+		{"select id from users where a not in (select id from b)", "(SELECT id FROM users WHERE a NOT IN ((SELECT id FROM b)));"},
+		{"select id from users where a not like 'x' and b = 1", "(SELECT id FROM users WHERE ((a NOT LIKE '?') AND (b = ?)));"},
+		{"select id from users where a not ilike 'x'", "(SELECT id FROM users WHERE (a NOT ILIKE '?'));"},
+		{"select id from users where a not similar to 'x'", "(SELECT id FROM users WHERE (a NOT SIMILAR TO '?'));"},
+		{"select id from users where not exists (select 1)", "(SELECT id FROM users WHERE (NOT exists((SELECT ?))));"},
+		{"select id from users where a = 1 and not b", "(SELECT id FROM users WHERE ((a = ?) AND (NOT b)));"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		assert.Equal(t, tt.output, program.String(maskParams), "input: %s", tt.input)
+	}
+}
+
+// What's printed for BETWEEN parses back to the same statement
+func TestBetweenRoundTrip(t *testing.T) {
+	maskParams := false
+
+	tests := []string{
+		"select id from users where x between b and a;",
+		"select id from users where x not between 1 and 2;",
+		"select id from users where a = 1 and b between 2 + 1 and 3 * 4 and c = 4;",
+		"select id from users where a between now() - interval '1 day' and now() or b not between 1 and 2;",
+		"select id from users where (x between (b and a));",
+		"select a between 1 and 2 as x from users;",
+	}
+
+	for _, input := range tests {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p, input)
+		output := program.String(maskParams)
+
+		// A statement is printed in parentheses, which would be read back as an expression
+		unwrapped := strings.TrimSuffix(strings.TrimPrefix(output, "("), ");") + ";"
+		p = New(lexer.New(unwrapped))
+		reparsed := p.ParseProgram()
+		checkParserErrors(t, p, unwrapped)
+		assert.Equal(t, output, reparsed.String(maskParams), "input: %s", input)
+	}
+}
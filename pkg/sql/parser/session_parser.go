@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+)
+
+// This file handles session level commands: PREPARE, EXECUTE, DEALLOCATE, DECLARE CURSOR, FETCH, CLOSE,
+// LISTEN, UNLISTEN, and NOTIFY. Other than FETCH, none of these words are reserved in PG,
+// so they come through as IDENT tokens and we swap in a context token.
+
+func (p *Parser) parsePrepareStatement() *ast.PrepareStatement {
+	// defer p.untrace(p.trace("parsePrepareStatement"))
+
+	p.clause = token.PREPARE_STATEMENT
+	p.command = token.PREPARE_STATEMENT
+
+	stmt := &ast.PrepareStatement{Token: token.Token{Type: token.PREPARE_STATEMENT, Lit: p.curToken.Lit, Upper: "PREPARE"}}
+
+	p.nextToken()
+	stmt.Name = p.curToken.Lit
+
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+		for !p.curTokenIsOne([]token.TokenType{token.RPAREN, token.EOF}) {
+			p.nextToken()
+			toks := p.collectTokens(func() bool { return p.peekTokenIs(token.COMMA) })
+			stmt.Types = append(stmt.Types, renderTokens(toks))
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.AS) {
+		return nil
+	}
+	p.nextToken()
+
+	stmt.Query = p.parseStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseExecuteStatement() *ast.ExecuteStatement {
+	// defer p.untrace(p.trace("parseExecuteStatement"))
+
+	p.clause = token.EXECUTE_STATEMENT
+	p.command = token.EXECUTE_STATEMENT
+
+	stmt := &ast.ExecuteStatement{Token: token.Token{Type: token.EXECUTE_STATEMENT, Lit: p.curToken.Lit, Upper: "EXECUTE"}}
+
+	p.nextToken()
+	stmt.Name = p.curToken.Lit
+
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Arguments = p.parseExpressionList([]token.TokenType{token.RPAREN})
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseDeallocateStatement() *ast.DeallocateStatement {
+	// defer p.untrace(p.trace("parseDeallocateStatement"))
+
+	p.clause = token.DEALLOCATE_STATEMENT
+	p.command = token.DEALLOCATE_STATEMENT
+
+	stmt := &ast.DeallocateStatement{Token: token.Token{Type: token.DEALLOCATE_STATEMENT, Lit: p.curToken.Lit, Upper: "DEALLOCATE"}}
+
+	if p.peekToken.Upper == "PREPARE" {
+		p.nextToken()
+	}
+
+	p.nextToken()
+	if p.curTokenIs(token.ALL) {
+		stmt.All = true
+	} else {
+		stmt.Name = p.curToken.Lit
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseDeclareCursorStatement() *ast.DeclareCursorStatement {
+	// defer p.untrace(p.trace("parseDeclareCursorStatement"))
+
+	p.clause = token.DECLARE_STATEMENT
+	p.command = token.DECLARE_STATEMENT
+
+	stmt := &ast.DeclareCursorStatement{Token: token.Token{Type: token.DECLARE_STATEMENT, Lit: p.curToken.Lit, Upper: "DECLARE"}}
+
+	p.nextToken()
+	stmt.Name = p.curToken.Lit
+
+	for p.peekToken.Upper != "CURSOR" {
+		p.nextToken()
+		switch p.curToken.Upper {
+		case "BINARY", "ASENSITIVE", "INSENSITIVE", "SCROLL":
+			stmt.Options = append(stmt.Options, p.curToken.Upper)
+		case "NO":
+			if !p.expectPeek(token.IDENT) || p.curToken.Upper != "SCROLL" {
+				p.addError(ErrUnexpectedToken, p.curToken, nil, "expected SCROLL after NO in DECLARE, got "+p.curToken.Lit)
+				return nil
+			}
+			stmt.Options = append(stmt.Options, "NO SCROLL")
+		default:
+			p.addError(ErrUnexpectedToken, p.curToken, nil, "expected CURSOR in DECLARE, got "+p.curToken.Lit)
+			return nil
+		}
+	}
+	p.nextToken()
+
+	if p.peekToken.Upper == "WITH" || p.peekToken.Upper == "WITHOUT" {
+		p.nextToken()
+		stmt.Hold = p.curToken.Upper + " HOLD"
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.FOR) {
+		return nil
+	}
+	p.nextToken()
+
+	stmt.Query = p.parseStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseFetchStatement() *ast.FetchStatement {
+	// defer p.untrace(p.trace("parseFetchStatement"))
+
+	p.clause = token.FETCH
+	p.command = token.FETCH
+
+	stmt := &ast.FetchStatement{Token: p.curToken}
+
+	switch p.peekToken.Upper {
+	case "NEXT", "PRIOR", "FIRST", "LAST", "ALL":
+		p.nextToken()
+		stmt.Direction = p.curToken.Upper
+	case "ABSOLUTE", "RELATIVE", "FORWARD", "BACKWARD":
+		p.nextToken()
+		stmt.Direction = p.curToken.Upper
+		if p.peekTokenIs(token.ALL) {
+			p.nextToken()
+			stmt.Direction += " ALL"
+		}
+	}
+
+	if p.peekTokenIsOne([]token.TokenType{token.INT, token.MINUS}) {
+		p.nextToken()
+		stmt.Count = p.parseExpression(FROM)
+	}
+
+	if p.peekTokenIsOne([]token.TokenType{token.FROM, token.IN}) {
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Cursor = p.curToken.Lit
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseCloseStatement() *ast.CloseStatement {
+	// defer p.untrace(p.trace("parseCloseStatement"))
+
+	p.clause = token.CLOSE_STATEMENT
+	p.command = token.CLOSE_STATEMENT
+
+	stmt := &ast.CloseStatement{Token: token.Token{Type: token.CLOSE_STATEMENT, Lit: p.curToken.Lit, Upper: "CLOSE"}}
+
+	p.nextToken()
+	if p.curTokenIs(token.ALL) {
+		stmt.All = true
+	} else {
+		stmt.Cursor = p.curToken.Lit
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseListenStatement() *ast.ListenStatement {
+	// defer p.untrace(p.trace("parseListenStatement"))
+
+	tokenType := token.LISTEN_STATEMENT
+	if p.curToken.Upper == "UNLISTEN" {
+		tokenType = token.UNLISTEN_STATEMENT
+	}
+
+	p.clause = tokenType
+	p.command = tokenType
+
+	stmt := &ast.ListenStatement{Token: token.Token{Type: tokenType, Lit: p.curToken.Lit, Upper: p.curToken.Upper}}
+
+	p.nextToken()
+	stmt.Channel = p.curToken.Lit
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseNotifyStatement() *ast.NotifyStatement {
+	// defer p.untrace(p.trace("parseNotifyStatement"))
+
+	p.clause = token.NOTIFY_STATEMENT
+	p.command = token.NOTIFY_STATEMENT
+
+	stmt := &ast.NotifyStatement{Token: token.Token{Type: token.NOTIFY_STATEMENT, Lit: p.curToken.Lit, Upper: "NOTIFY"}}
+
+	p.nextToken()
+	stmt.Channel = p.curToken.Lit
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Payload = p.parseExpression(LOWEST)
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
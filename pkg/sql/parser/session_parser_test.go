@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/brianbroderick/lantern/pkg/sql/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionStatements(t *testing.T) {
+	maskParams := false
+
+	tests := []struct {
+		input   string
+		output  string
+		command token.TokenType
+	}{
+		// Prepare
+		{"prepare get_user as select id from users where id = $1;", "PREPARE get_user AS (SELECT id FROM users WHERE (id = $1));", token.PREPARE_STATEMENT},
+		{"prepare get_user(int, varchar(10)) as select id from users where id = $1 and name = $2", "PREPARE get_user(int, varchar(10)) AS (SELECT id FROM users WHERE ((id = $1) AND (name = $2)));", token.PREPARE_STATEMENT},
+		{"prepare ins_user (bigint) as insert into users (id) values ($1);", "PREPARE ins_user(bigint) AS (INSERT INTO users (id) VALUES ($1));", token.PREPARE_STATEMENT},
+
+		// Execute
+		{"execute get_user(42);", "EXECUTE get_user(42);", token.EXECUTE_STATEMENT},
+		{"execute get_user(42, 'bob');", "EXECUTE get_user(42, 'bob');", token.EXECUTE_STATEMENT},
+		{"execute refresh_all;", "EXECUTE refresh_all;", token.EXECUTE_STATEMENT},
+
+		// Deallocate
+		{"deallocate get_user;", "DEALLOCATE get_user;", token.DEALLOCATE_STATEMENT},
+		{"deallocate prepare get_user;", "DEALLOCATE get_user;", token.DEALLOCATE_STATEMENT},
+		{"deallocate all;", "DEALLOCATE ALL;", token.DEALLOCATE_STATEMENT},
+
+		// Declare
+		{"declare c cursor for select id from users;", "DECLARE c CURSOR FOR (SELECT id FROM users);", token.DECLARE_STATEMENT},
+		{"declare c binary no scroll cursor with hold for select id from users;", "DECLARE c BINARY NO SCROLL CURSOR WITH HOLD FOR (SELECT id FROM users);", token.DECLARE_STATEMENT},
+		{"declare c insensitive scroll cursor without hold for select id from users", "DECLARE c INSENSITIVE SCROLL CURSOR WITHOUT HOLD FOR (SELECT id FROM users);", token.DECLARE_STATEMENT},
+
+		// Fetch
+		{"fetch 100 from c;", "FETCH 100 FROM c;", token.FETCH},
+		{"fetch c;", "FETCH FROM c;", token.FETCH},
+		{"fetch next in c;", "FETCH NEXT FROM c;", token.FETCH},
+		{"fetch absolute -1 from c;", "FETCH ABSOLUTE (-1) FROM c;", token.FETCH},
+		{"fetch forward all from c;", "FETCH FORWARD ALL FROM c;", token.FETCH},
+		{"fetch backward 5 from c;", "FETCH BACKWARD 5 FROM c;", token.FETCH},
+
+		// Close
+		{"close c;", "CLOSE c;", token.CLOSE_STATEMENT},
+		{"close all;", "CLOSE ALL;", token.CLOSE_STATEMENT},
+
+		// Listen / Notify
+		{"listen my_channel;", "LISTEN my_channel;", token.LISTEN_STATEMENT},
+		{"unlisten my_channel;", "UNLISTEN my_channel;", token.UNLISTEN_STATEMENT},
+		{"unlisten *;", "UNLISTEN *;", token.UNLISTEN_STATEMENT},
+		{"notify my_channel;", "NOTIFY my_channel;", token.NOTIFY_STATEMENT},
+		{"notify my_channel, 'hello';", "NOTIFY my_channel, 'hello';", token.NOTIFY_STATEMENT},
+	}
+
+	for _, tt := range tests {
+		// fmt.Printf("\ninput:  %s\n", tt.input)
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		assert.Equal(t, 1, len(program.Statements), "input: %s\nprogram.Statements does not contain 1 statement. got=%d", tt.input, len(program.Statements))
+
+		stmt := program.Statements[0]
+		assert.Equal(t, tt.command, stmt.Command(), "input: %s\nstmt.Command() is not %s. got=%s", tt.input, tt.command, stmt.Command())
+
+		output := program.String(maskParams)
+		assert.Equal(t, tt.output, output, "input: %s\nprogram.String() not '%s'. got=%s", tt.input, tt.output, output)
+		// fmt.Printf("output: %s\n", output)
+	}
+}
+
+func TestSessionInnerQuery(t *testing.T) {
+	tests := []struct {
+		input   string
+		masked  string
+		command token.TokenType
+	}{
+		{"prepare get_user(int) as select id from users where id = $1 and active = true;", "(SELECT id FROM users WHERE ((id = ?) AND (active = ?)));", token.SELECT},
+		{"declare c cursor for select id from users where name = 'bob';", "(SELECT id FROM users WHERE (name = '?'));", token.SELECT},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, tt.input)
+
+		var inner ast.Statement
+		switch stmt := program.Statements[0].(type) {
+		case *ast.PrepareStatement:
+			inner = stmt.Query
+		case *ast.DeclareCursorStatement:
+			inner = stmt.Query
+		}
+
+		assert.Equal(t, tt.command, inner.Command(), "input: %s", tt.input)
+		assert.Equal(t, tt.masked, inner.String(true), "input: %s", tt.input)
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		code  ErrorCode
+		got   token.TokenType
+	}{
+		{"fetch;", ErrUnexpectedToken, token.SEMICOLON},
+		{"fetch next from;", ErrUnexpectedToken, token.SEMICOLON},
+		{"fetch 10", ErrUnexpectedToken, token.EOF},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errs := p.ParseErrors()
+		if assert.Equal(t, 1, len(errs), "input: %s\nerrors: %v", tt.input, p.Errors()) {
+			assert.Equal(t, tt.code, errs[0].Code, "input: %s", tt.input)
+			assert.Equal(t, []token.TokenType{token.IDENT}, errs[0].Expected, "input: %s", tt.input)
+			assert.Equal(t, tt.got, errs[0].Got, "input: %s", tt.input)
+		}
+		assert.Empty(t, program.Statements, "input: %s", tt.input)
+	}
+}
@@ -114,6 +114,17 @@ func (p *Parser) parseSetStatement() *ast.SetStatement {
 
 	stmt.Expression = p.parseExpression(LOWEST)
 
+	// A list of values, such as SET search_path = app, public
+	if infix, ok := stmt.Expression.(*ast.InfixExpression); ok && p.peekTokenIs(token.COMMA) {
+		list := &ast.GroupedExpression{Token: token.Token{Type: token.LPAREN, Lit: "("}, Elements: []ast.Expression{infix.Right}}
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			list.Elements = append(list.Elements, p.parseExpression(LOWEST))
+		}
+		infix.Right = list
+	}
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -33,6 +33,10 @@ func TestSetStatements(t *testing.T) {
 		{"set transaction isolation level repeatable read;", 1, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ;"},
 		{"set constraints my_id deferred;", 1, "SET CONSTRAINTS my_id DEFERRED;"},
 		{"set constraints all immediate;", 1, "SET CONSTRAINTS ALL IMMEDIATE;"},
+		{"set search_path = app;", 1, "SET (search_path = app);"},
+		{"set search_path to app, \"$user\", public;", 1, "SET (search_path TO (app, \"$user\", public));"},
+		{"set session search_path = 'app', public;", 1, "SET SESSION (search_path = ('app', public));"},
+		{"set local search_path to default;", 1, "SET LOCAL (search_path TO default);"},
 	}
 
 	for _, tt := range tests {
@@ -39,7 +39,7 @@ func (p *Parser) parseUpdateExpression() ast.Expression {
 		x.Table = p.parseIdentifier()
 	} else {
 		msg := "expected IDENT for the table name"
-		p.errors = append(p.errors, msg)
+		p.addError(ErrUnexpectedToken, p.peekToken, []token.TokenType{token.IDENT}, msg)
 		return nil
 	}
 	if p.peekTokenIs(token.ASTERISK) {
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/brianbroderick/lantern/pkg/sql/ast"
+	"github.com/brianbroderick/lantern/pkg/sql/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+// Every node that can be reached through a node's fields should also be reached through Children,
+// otherwise ast.Walk and ast.Inspect would silently skip part of the tree.
+func TestChildrenCoverAllNodes(t *testing.T) {
+	tests := []string{
+		"select distinct on (u.id) u.id, count(*) as n, sum(x) filter (where x > 0), name::text from users u join addresses a on a.user_id = u.id where u.id in (1, 2) and u.name like 'b%' group by rollup (u.id) having count(*) > 1 order by n desc nulls last limit 10 offset 5 for update;",
+		"select id, row_number() over (partition by a order by b rows between 1 preceding and unbounded following) from users fetch first 5 rows only;",
+		"select case when a = 1 then 'one' else 'other' end, interval '1 day', array[1, 2][1], trim(both 'x' from y), substring(z from 1 for 2), created_at at time zone 'utc' from t;",
+		"select * from users u, lateral unnest(u.tags) with ordinality as t(tag, n), rows from (generate_series(1, 3), unnest(u.ids)) as r;",
+		"with recursive cte as materialized (select 1 union all select n + 1 from cte) select * from cte;",
+		"insert into users (id, name) values (1, 'a'), (2, 'b') on conflict (id) do update set name = excluded.name where users.id > 0 returning id;",
+		"insert into users select * from old_users;",
+		"update users u set name = 'a' from accounts a where a.id = u.id returning *;",
+		"delete from users u using accounts a where a.id = u.id returning u.id;",
+		"create index concurrently if not exists idx on users (id) where active;",
+		"create function f(a int default 1) returns int language sql as $$ select a from users $$;",
+		"create trigger t before insert on users for each row when (new.id > 0) execute function f();",
+		"do $$ begin update users set a = 1; end $$;",
+		"prepare p(int) as select * from users where id = $1; execute p(1); deallocate p;",
+		"declare c cursor for select * from users; fetch 10 from c; close c; notify ch, 'x';",
+		"vacuum (analyze) users (id, name), accounts; reindex table users; cluster users using idx; refresh materialized view v; truncate users;",
+		"grant select on users to bob; set search_path = public; show timezone; begin; savepoint a; rollback; commit; drop table users;",
+		"analyze users; call p(1); values (1, 2);",
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p, input)
+
+		walked := map[ast.Node]bool{}
+		ast.Inspect(program, func(n ast.Node) bool {
+			if n != nil {
+				walked[n] = true
+			}
+			return true
+		})
+
+		reachable := map[ast.Node]bool{}
+		reachableNodes(reflect.ValueOf(program), reachable)
+
+		for n := range reachable {
+			assert.True(t, walked[n], "input: %s\n%T not reached by Children: %s", input, n, n.String(false))
+		}
+	}
+}
+
+func reachableNodes(v reflect.Value, found map[ast.Node]bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			reachableNodes(v.Elem(), found)
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if n, ok := v.Interface().(ast.Node); ok {
+			if found[n] {
+				return
+			}
+			found[n] = true
+		}
+		reachableNodes(v.Elem(), found)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			reachableNodes(v.Index(i), found)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				reachableNodes(v.Field(i), found)
+			}
+		}
+	}
+}
@@ -110,7 +110,7 @@ func StartParser(in io.Reader, out io.Writer) {
 				io.WriteString(out, "Functions:\n")
 
 				for _, function := range r.FunctionsInQueries {
-					io.WriteString(out, fmt.Sprintf("  %s\n", function.Name))
+					io.WriteString(out, fmt.Sprintf("  %s.%s\n", function.Schema, function.Name))
 				}
 				io.WriteString(out, "\n")
 			}
@@ -35,6 +35,18 @@ func (r *Resolver) Resolve(node ast.Node, env *object.Environment) {
 		r.Resolve(node.Expression, env)
 	case *ast.DeleteStatement:
 		r.Resolve(node.Expression, env)
+	case *ast.CreateFunctionStatement:
+		if node.Program != nil {
+			r.Resolve(node.Program, env)
+		}
+	case *ast.DoStatement:
+		if node.Program != nil {
+			r.Resolve(node.Program, env)
+		}
+	case *ast.PrepareStatement:
+		r.Resolve(node.Query, env)
+	case *ast.DeclareCursorStatement:
+		r.Resolve(node.Query, env)
 
 	// Expressions
 	case *ast.CTEExpression:
@@ -111,6 +123,16 @@ func (r *Resolver) Resolve(node ast.Node, env *object.Environment) {
 		for _, o := range node.OrderBy {
 			r.Resolve(o, env)
 		}
+		if node.Frame != nil {
+			r.Resolve(node.Frame.Start.Offset, env)
+			if node.Frame.End != nil {
+				r.Resolve(node.Frame.End.Offset, env)
+			}
+		}
+	case *ast.GroupingSetExpression:
+		for _, s := range node.Sets {
+			r.Resolve(s, env)
+		}
 	case *ast.TableExpression:
 		// remove the table alias from the environment
 		switch node.Table.(type) {
@@ -119,6 +141,9 @@ func (r *Resolver) Resolve(node ast.Node, env *object.Environment) {
 		}
 		r.Resolve(node.JoinCondition, env)
 		r.Resolve(node.Table, env)
+		for _, f := range node.Functions {
+			r.Resolve(f, env)
+		}
 	case *ast.LockExpression:
 		for _, t := range node.Tables {
 			r.Resolve(t, env)
@@ -150,20 +175,28 @@ func (r *Resolver) Resolve(node ast.Node, env *object.Environment) {
 		r.resolveIdentifier(node, env)
 
 	// Noops
-	case *ast.UpdateExpression:
+	case *ast.UpdateExpression, *ast.DeleteExpression:
 		// Currently do nothing till we verify that we don't have aliases to resolve
 
 	case nil, *ast.AnalyzeStatement, *ast.DropStatement, *ast.SetStatement,
+		*ast.VacuumStatement, *ast.ReindexStatement, *ast.ClusterStatement,
+		*ast.RefreshStatement, *ast.TruncateStatement, *ast.GrantStatement,
+		*ast.CreateTriggerStatement, *ast.CallStatement,
+		*ast.ExecuteStatement, *ast.DeallocateStatement, *ast.FetchStatement, *ast.CloseStatement,
+		*ast.ListenStatement, *ast.NotifyStatement,
 		*ast.ValuesExpression,
 		*ast.WildcardLiteral, *ast.Boolean, *ast.Null,
 		*ast.Unknown, *ast.Infinity, *ast.IllegalExpression,
-		*ast.SimpleIdentifier, *ast.IntegerLiteral, *ast.FloatLiteral,
-		*ast.StringLiteral, *ast.EscapeStringLiteral,
+		*ast.SimpleIdentifier, *ast.IntegerLiteral, *ast.FloatLiteral, *ast.ParamLiteral,
+		*ast.StringLiteral, *ast.EscapeStringLiteral, *ast.DollarStringLiteral,
 		*ast.TimestampExpression, *ast.KeywordExpression:
 		// Do nothing
 
+	// Anything without special handling is walked generically, so new node types don't need to be added here
 	default:
-		r.newError("unknown node type: %T", node)
+		for _, child := range node.Children() {
+			r.Resolve(child, env)
+		}
 	}
 
 }
@@ -0,0 +1,41 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect is the flavor of SQL to lex and parse. The zero value is Postgres.
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	MySQL
+	SQLite
+)
+
+var dialects = [...]string{
+	Postgres: "postgres",
+	MySQL:    "mysql",
+	SQLite:   "sqlite",
+}
+
+func (d Dialect) String() string {
+	if d >= 0 && int(d) < len(dialects) {
+		return dialects[d]
+	}
+	return fmt.Sprintf("dialect(%d)", int(d))
+}
+
+// ParseDialect returns the dialect with the given name, i.e. postgres, mysql, or sqlite
+func ParseDialect(name string) (Dialect, error) {
+	for d, n := range dialects {
+		if strings.EqualFold(n, name) {
+			return Dialect(d), nil
+		}
+	}
+	if strings.EqualFold(name, "postgresql") {
+		return Postgres, nil
+	}
+	return Postgres, fmt.Errorf("unknown dialect %q", name)
+}
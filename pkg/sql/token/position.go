@@ -0,0 +1,24 @@
+package token
+
+import "fmt"
+
+// Pos is a position in the source query.
+// Offset is a zero-based byte offset. Line and Char are one-based, the way editors count them.
+type Pos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Char   int `json:"char"`
+}
+
+// NoPos is the zero value for Pos. It's used for nodes that the parser synthesized and that don't appear in the source.
+var NoPos = Pos{}
+
+// IsValid returns true if the position came from the source
+func (p Pos) IsValid() bool { return p.Line > 0 }
+
+func (p Pos) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Char)
+}
@@ -11,6 +11,8 @@ type Token struct {
 	Type  TokenType `json:"type,omitempty"`
 	Lit   string    `json:"literal,omitempty"`
 	Upper string    `json:"-"`
+	Pos   Pos       `json:"-"` // where the token starts in the source
+	End   Pos       `json:"-"` // the position just after the token
 }
 
 func (t *Token) MarshalJSON() ([]byte, error) {
@@ -38,6 +40,21 @@ const (
 	FUNCTION_CALL
 	SHOW_STATEMENT
 	SAVEPOINT_STATEMENT
+	VACUUM_STATEMENT
+	REINDEX_STATEMENT
+	CLUSTER_STATEMENT
+	REFRESH_STATEMENT
+	TRUNCATE_STATEMENT
+	REVOKE_STATEMENT
+	CALL_STATEMENT
+	PREPARE_STATEMENT
+	EXECUTE_STATEMENT
+	DEALLOCATE_STATEMENT
+	DECLARE_STATEMENT
+	CLOSE_STATEMENT
+	LISTEN_STATEMENT
+	UNLISTEN_STATEMENT
+	NOTIFY_STATEMENT
 
 	literalBeg   // Literals
 	IDENT        // identity: add, foobar, x, y, my_var, ...
@@ -47,6 +64,7 @@ const (
 	ESCAPESTRING // E'foobar'
 	INFINITY     // Infinity: this is used as a placeholder for array ranges with no right value, i.e. array[1:]
 	PARAM        // $1, $2, $3, etc.
+	DOLLARSTRING // $$foobar$$ or $tag$foobar$tag$
 	literalEnd
 
 	// Operators
@@ -272,13 +290,31 @@ var Tokens = [...]string{
 	SHOW_STATEMENT:      "SHOW_STATEMENT",
 	SAVEPOINT_STATEMENT: "SAVEPOINT_STATEMENT",
 
+	// Statements whose word isn't a keyword are named by the word, like the commands that are keywords
+	VACUUM_STATEMENT:     "VACUUM",
+	REINDEX_STATEMENT:    "REINDEX",
+	CLUSTER_STATEMENT:    "CLUSTER",
+	REFRESH_STATEMENT:    "REFRESH",
+	TRUNCATE_STATEMENT:   "TRUNCATE",
+	REVOKE_STATEMENT:     "REVOKE",
+	CALL_STATEMENT:       "CALL",
+	PREPARE_STATEMENT:    "PREPARE",
+	EXECUTE_STATEMENT:    "EXECUTE",
+	DEALLOCATE_STATEMENT: "DEALLOCATE",
+	DECLARE_STATEMENT:    "DECLARE",
+	CLOSE_STATEMENT:      "CLOSE",
+	LISTEN_STATEMENT:     "LISTEN",
+	UNLISTEN_STATEMENT:   "UNLISTEN",
+	NOTIFY_STATEMENT:     "NOTIFY",
+
 	IDENT:        "IDENT",
 	INT:          "INTEGER",
 	FLOAT:        "FLOAT", // 0.12345 or 12345.12345
 	STRING:       "STRING",
 	ESCAPESTRING: "ESCAPESTRING", // E'foobar'
 	INFINITY:     "INFINITY",
-	PARAM:        "PARAM", // $1, $2, $3, etc.
+	PARAM:        "PARAM",        // $1, $2, $3, etc.
+	DOLLARSTRING: "DOLLARSTRING", // $$foobar$$ or $tag$foobar$tag$
 
 	ASSIGN:   "ASSIGN",   // =
 	PLUS:     "PLUS",     // +
@@ -486,12 +522,20 @@ var Tokens = [...]string{
 }
 
 var keywords map[string]TokenType
+var names map[string]TokenType
 
 func init() {
 	keywords = make(map[string]TokenType)
 	for tok := keywordBeg + 1; tok < keywordEnd; tok++ {
 		keywords[strings.ToUpper(Tokens[tok])] = tok
 	}
+
+	names = make(map[string]TokenType)
+	for tok, name := range Tokens {
+		if name != "" {
+			names[name] = TokenType(tok)
+		}
+	}
 }
 
 // String returns the string representation of the token.
@@ -502,6 +546,13 @@ func (tok TokenType) String() string {
 	return ""
 }
 
+// FromString is the inverse of String. It returns the token type with the given name, or false if there isn't one.
+// Unlike Lookup, it finds every token type, not just keywords.
+func FromString(name string) (TokenType, bool) {
+	tok, ok := names[name]
+	return tok, ok
+}
+
 // Lookup returns the token associated with a given string.
 func Lookup(ident string) TokenType {
 	if tok, ok := keywords[strings.ToUpper(ident)]; ok {
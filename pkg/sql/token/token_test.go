@@ -16,6 +16,46 @@ func TestLookup(t *testing.T) {
 	assert.Equal(t, IDENT, tok)
 }
 
+func TestFromString(t *testing.T) {
+	// Every token type can be found by its name
+	for tok, name := range Tokens {
+		if name == "" {
+			continue
+		}
+		found, ok := FromString(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, TokenType(tok), found, name)
+	}
+
+	_, ok := FromString("scooby")
+	assert.False(t, ok)
+}
+
+func TestStatementNames(t *testing.T) {
+	// Statements are named by their command, whether or not their word is a keyword
+	for tok, name := range map[TokenType]string{
+		GRANT: "GRANT", REVOKE_STATEMENT: "REVOKE", VACUUM_STATEMENT: "VACUUM", TRUNCATE_STATEMENT: "TRUNCATE",
+		CALL_STATEMENT: "CALL", LISTEN_STATEMENT: "LISTEN", NOTIFY_STATEMENT: "NOTIFY", CLOSE_STATEMENT: "CLOSE",
+	} {
+		assert.Equal(t, name, tok.String())
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	for _, d := range []Dialect{Postgres, MySQL, SQLite} {
+		found, err := ParseDialect(d.String())
+		assert.NoError(t, err)
+		assert.Equal(t, d, found)
+	}
+
+	found, err := ParseDialect("MySQL")
+	assert.NoError(t, err)
+	assert.Equal(t, MySQL, found)
+
+	_, err = ParseDialect("oracle")
+	assert.EqualError(t, err, `unknown dialect "oracle"`)
+}
+
 // func TestSort(t *testing.T) {
 // 	list := []string{
 // 		"AND",